@@ -48,6 +48,7 @@ module "network" {
   environment  = local.environment
   project_name = local.project_name
   vpc_cidr     = local.config.network.vpc_cidr
+  subnet_count = local.config.network.subnet_count
   tags         = local.tags
 }
 
@@ -1,18 +1,11 @@
 terraform {
   required_version = ">= 1.0.0"
 
-  # Configuração do backend remoto com segurança aprimorada
-  # backend "s3" {
-  #   bucket         = "terraform-state-boilerplate-nestjs"
-  #   key            = "terraform.tfstate"
-  #   region         = "us-east-1"
-  #   encrypt        = true
-  #   dynamodb_table = "terraform-locks"
-
-  #   # Configurações adicionais de segurança
-  #   kms_key_id     = "arn:aws:kms:us-east-1:ACCOUNT_ID:key/KEY_ID" # Substituir com ARN real da chave KMS
-  #   acl           = "private"
-  # }
+  # Backend remoto (s3 + lock no DynamoDB, estado criptografado): o bloco de cada ambiente é
+  # gerado a partir de provider.aws.state_* do config.yaml em backend_override.tf, ignorado
+  # pelo git. Sem esse arquivo o estado continua local.
+  #   cd tests && go run ./cmd/backend-config -environment dev
+  #   cd .. && terraform init -migrate-state
 }
 
 # Carregando variáveis do Cloud Provider a ser usado
@@ -24,7 +17,7 @@ locals {
     Project     = var.project_name
     ManagedBy   = "Terraform"
   })
-  active_provider = lookup(local.config.provider, "active", "aws")
+  active_provider = coalesce(var.active_provider, lookup(local.config.provider, "active", "aws"))
 }
 
 # Configuração dos provedores - sem uso do 'count'
@@ -58,6 +51,7 @@ module "network_aws" {
   environment  = var.environment
   project_name = var.project_name
   vpc_cidr     = local.config.network.vpc_cidr
+  subnet_count = local.config.network.subnet_count
   tags         = local.tags
 }
 
@@ -164,6 +158,30 @@ module "monitoring_aws" {
   ]
 }
 
+# Recuperação de desastres na região secundária, habilitada por disaster_recovery.enabled no config.yaml
+module "disaster_recovery_aws" {
+  source = "./modules/disaster_recovery/aws"
+  count  = local.active_provider == "aws" && lookup(lookup(local.config, "disaster_recovery", {}), "enabled", false) ? 1 : 0
+
+  environment  = var.environment
+  project_name = var.project_name
+  tags         = local.tags
+
+  source_db_arn             = length(module.database_aws) > 0 ? module.database_aws[0].arn : ""
+  replica_instance_type     = lookup(lookup(local.config, "disaster_recovery", {}), "replica_instance_type", local.config.database.instance_type)
+  enable_read_replica       = lookup(lookup(local.config, "disaster_recovery", {}), "read_replica", true)
+  enable_backup_replication = lookup(lookup(local.config, "disaster_recovery", {}), "backup_replication", true)
+  backup_retention_days     = local.config.database.backup_retention_days
+  deletion_protection       = local.config.database.deletion_protection
+  state_bucket_name         = lookup(lookup(local.config, "disaster_recovery", {}), "state_bucket", "")
+
+  # Recursos da região secundária usam o alias definido em providers.tf
+  providers = {
+    aws           = aws
+    aws.secondary = aws.secondary
+  }
+}
+
 # GCP Modules - comentados temporariamente para fins de teste
 module "network_gcp" {
   source = "./modules/network/gcp"
@@ -225,6 +243,7 @@ module "cost_monitor_gcp" {
   budget_amount           = local.config.cost.budget_amount
   budget_currency         = local.config.cost.budget_currency
   alert_threshold_percent = local.config.cost.alert_threshold_percent
+  budget_thresholds       = lookup(lookup(local.config.cost, "gcp", {}), "budget_thresholds", [])
   alert_emails            = local.config.cost.alert_emails
   tags                    = local.tags
 }
@@ -338,3 +357,45 @@ module "monitoring_digitalocean" {
     digitalocean = digitalocean
   }
 }
+
+# Kubernetes local (kind), criado pelos testes com active_provider = "local-k8s"
+resource "random_password" "grafana_admin_local" {
+  count   = local.active_provider == "local-k8s" ? 1 : 0
+  length  = 24
+  special = false
+}
+
+module "monitoring_grafana_local" {
+  source = "./modules/monitoring/grafana"
+  count  = local.active_provider == "local-k8s" ? 1 : 0
+
+  project_name           = var.project_name
+  environment            = var.environment
+  kubernetes_namespace   = "monitoring"
+  grafana_admin_password = random_password.grafana_admin_local[0].result
+  retention_days         = local.config.monitoring.retention_days
+  prometheus_url         = "http://prometheus-server.monitoring.svc.cluster.local"
+  alert_threshold_cpu    = local.config.monitoring.alert_threshold_cpu
+  alert_threshold_memory = local.config.monitoring.alert_threshold_memory
+
+  chart            = coalesce(var.grafana_chart_path, "grafana")
+  chart_repository = var.grafana_chart_path == null ? "https://grafana.github.io/helm-charts" : null
+
+  providers = {
+    kubernetes = kubernetes.primary
+    helm       = helm.primary
+  }
+}
+
+module "kubernetes_rbac_local" {
+  source = "./modules/kubernetes/rbac"
+  count  = local.active_provider == "local-k8s" ? 1 : 0
+
+  rbac_groups                 = var.rbac_groups
+  rbac_namespaces             = var.rbac_namespaces
+  rbac_restrict_to_namespaces = var.rbac_restrict_to_namespaces
+
+  providers = {
+    kubernetes = kubernetes.primary
+  }
+}
@@ -30,6 +30,20 @@ resource "google_pubsub_subscription" "budget_alerts" {
   labels = var.tags
 }
 
+# Canais de e-mail para os alertas do orçamento, um por endereço de alert_emails
+resource "google_monitoring_notification_channel" "budget_email" {
+  count        = length(var.alert_emails)
+  project      = var.project_id
+  display_name = "${var.project_name}-${var.environment}-budget-${count.index}"
+  type         = "email"
+
+  labels = {
+    email_address = var.alert_emails[count.index]
+  }
+
+  user_labels = var.tags
+}
+
 # Criar orçamento e alertas no GCP
 resource "google_billing_budget" "budget" {
   billing_account = var.billing_account_id
@@ -52,15 +66,13 @@ resource "google_billing_budget" "budget" {
     }
   }
 
-  # Configura alertas em diferentes limites
-  threshold_rules {
-    threshold_percent = var.alert_threshold_percent / 100
-    spend_basis       = "CURRENT_SPEND"
-  }
-
-  threshold_rules {
-    threshold_percent = 1.0
-    spend_basis       = "CURRENT_SPEND"
+  # Configura alertas em diferentes limites: alert_threshold_percent, 100% e os budget_thresholds do ambiente
+  dynamic "threshold_rules" {
+    for_each = distinct(concat([var.alert_threshold_percent, 100], var.budget_thresholds))
+    content {
+      threshold_percent = threshold_rules.value / 100
+      spend_basis       = "CURRENT_SPEND"
+    }
   }
 
   # Configurar alertar baseada em previsão de gastos
@@ -74,7 +86,7 @@ resource "google_billing_budget" "budget" {
     pubsub_topic = google_pubsub_topic.budget_alerts.id
 
     # Para enviar e-mails
-    monitoring_notification_channels = var.notification_channel_ids
+    monitoring_notification_channels = concat(var.notification_channel_ids, google_monitoring_notification_channel.budget_email[*].id)
 
     # Habilitar alertas de gastos previstos
     disable_default_iam_recipients = false
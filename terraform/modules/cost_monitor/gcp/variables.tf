@@ -35,6 +35,12 @@ variable "alert_threshold_percent" {
   default     = 80
 }
 
+variable "budget_thresholds" {
+  description = "Porcentagens adicionais do orçamento que geram alertas de gasto atual (ex.: [50, 80, 100])"
+  type        = list(number)
+  default     = []
+}
+
 variable "notification_channel_ids" {
   description = "Lista de IDs dos canais de notificação do Cloud Monitoring para enviar alertas"
   type        = list(string)
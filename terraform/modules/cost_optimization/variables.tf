@@ -24,13 +24,13 @@ variable "recursos_para_desligar" {
 }
 
 variable "horario_comercial_inicio" {
-  description = "Hora de início do horário comercial (formato 24h, UTC)"
+  description = "Hora de início do horário comercial (formato 24h, no fuso definido em timezone)"
   type        = number
   default     = 8 # 8:00 AM
 }
 
 variable "horario_comercial_fim" {
-  description = "Hora de término do horário comercial (formato 24h, UTC)"
+  description = "Hora de término do horário comercial (formato 24h, no fuso definido em timezone)"
   type        = number
   default     = 18 # 6:00 PM
 }
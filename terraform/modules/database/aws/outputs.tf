@@ -83,4 +83,14 @@ output "optimization_tips" {
     "Para ambientes de desenvolvimento, desligue os bancos de dados quando não estiverem em uso",
     "Monitore o uso para identificar oportunidades de downsizing"
   ]
-}
\ No newline at end of file
+}
+
+output "arn" {
+  description = "ARN da instância principal, usado pela réplica entre regiões do módulo disaster_recovery/aws"
+  value       = aws_db_instance.default.arn
+}
+
+output "identifier" {
+  description = "Identificador da instância principal"
+  value       = aws_db_instance.default.identifier
+}
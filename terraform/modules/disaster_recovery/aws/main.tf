@@ -0,0 +1,198 @@
+/**
+ * Módulo de Recuperação de Desastres AWS (multi-região)
+ *
+ * Mantém na região secundária (provider aws.secondary) uma réplica de leitura do banco
+ * principal, a cópia dos backups automáticos e a réplica do bucket de estado do Terraform.
+ * A sequência de failover está documentada no README.md deste módulo.
+ */
+
+locals {
+  name_prefix        = "${var.project_name}-${var.environment}"
+  create_replica     = var.enable_read_replica && var.source_db_arn != ""
+  create_backups     = var.enable_backup_replication && var.source_db_arn != ""
+  replicate_state    = var.enable_state_replication && var.state_bucket_name != ""
+  state_bucket_arn   = var.state_bucket_arn != "" ? var.state_bucket_arn : "arn:aws:s3:::${var.state_bucket_name}"
+  replica_subnet_ids = local.create_replica ? var.replica_subnet_ids : []
+}
+
+# Chave KMS da região secundária: réplicas e backups criptografados não podem usar a chave da região principal
+resource "aws_kms_key" "replica" {
+  provider = aws.secondary
+  count    = local.create_replica || local.create_backups ? 1 : 0
+
+  description             = "Chave para réplica e backups de ${local.name_prefix} na região secundária"
+  deletion_window_in_days = 30
+  enable_key_rotation     = true
+
+  tags = var.tags
+}
+
+# Subnet group da réplica, quando subnets da região secundária são informadas
+resource "aws_db_subnet_group" "replica" {
+  provider = aws.secondary
+  count    = length(local.replica_subnet_ids) > 0 ? 1 : 0
+
+  name       = "${local.name_prefix}-dr"
+  subnet_ids = local.replica_subnet_ids
+
+  tags = var.tags
+}
+
+# Réplica de leitura entre regiões, promovida a principal no failover
+resource "aws_db_instance" "replica" {
+  provider = aws.secondary
+  count    = local.create_replica ? 1 : 0
+
+  identifier          = "${local.name_prefix}-dr"
+  replicate_source_db = var.source_db_arn
+  instance_class      = var.replica_instance_type
+  storage_encrypted   = true
+  kms_key_id          = aws_kms_key.replica[0].arn
+
+  db_subnet_group_name   = length(aws_db_subnet_group.replica) > 0 ? aws_db_subnet_group.replica[0].name : null
+  vpc_security_group_ids = var.replica_security_group_ids
+  publicly_accessible    = false
+
+  # Mantém backups na réplica para que ela já tenha retenção configurada quando for promovida
+  backup_retention_period = var.backup_retention_days
+
+  deletion_protection = var.deletion_protection
+  skip_final_snapshot = true
+
+  tags = merge(var.tags, {
+    Name = "${local.name_prefix}-db-dr"
+    Role = "disaster-recovery"
+  })
+}
+
+# Cópia dos snapshots automáticos e logs de transação para a região secundária
+resource "aws_db_instance_automated_backups_replication" "main" {
+  provider = aws.secondary
+  count    = local.create_backups ? 1 : 0
+
+  source_db_instance_arn = var.source_db_arn
+  retention_period       = var.backup_retention_days
+  kms_key_id             = aws_kms_key.replica[0].arn
+}
+
+# Bucket de destino do estado do Terraform na região secundária
+resource "aws_s3_bucket" "state_replica" {
+  provider = aws.secondary
+  count    = local.replicate_state ? 1 : 0
+
+  bucket = "${var.state_bucket_name}-dr"
+
+  tags = merge(var.tags, {
+    Name = "${var.state_bucket_name}-dr"
+    Role = "disaster-recovery"
+  })
+}
+
+resource "aws_s3_bucket_versioning" "state_replica" {
+  provider = aws.secondary
+  count    = local.replicate_state ? 1 : 0
+
+  bucket = aws_s3_bucket.state_replica[0].id
+
+  versioning_configuration {
+    status = "Enabled"
+  }
+}
+
+resource "aws_s3_bucket_public_access_block" "state_replica" {
+  provider = aws.secondary
+  count    = local.replicate_state ? 1 : 0
+
+  bucket                  = aws_s3_bucket.state_replica[0].id
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
+
+resource "aws_s3_bucket_server_side_encryption_configuration" "state_replica" {
+  provider = aws.secondary
+  count    = local.replicate_state ? 1 : 0
+
+  bucket = aws_s3_bucket.state_replica[0].id
+
+  rule {
+    apply_server_side_encryption_by_default {
+      sse_algorithm = "AES256"
+    }
+  }
+}
+
+# Role assumida pelo S3 para copiar os objetos do bucket de estado
+resource "aws_iam_role" "state_replication" {
+  count = local.replicate_state ? 1 : 0
+  name  = "${local.name_prefix}-state-replication"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "s3.amazonaws.com"
+        }
+      },
+    ]
+  })
+
+  tags = var.tags
+}
+
+resource "aws_iam_role_policy" "state_replication" {
+  count = local.replicate_state ? 1 : 0
+  name  = "${local.name_prefix}-state-replication"
+  role  = aws_iam_role.state_replication[0].id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetReplicationConfiguration", "s3:ListBucket"]
+        Resource = local.state_bucket_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:GetObjectVersionForReplication", "s3:GetObjectVersionAcl", "s3:GetObjectVersionTagging"]
+        Resource = "${local.state_bucket_arn}/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:ReplicateObject", "s3:ReplicateDelete", "s3:ReplicateTags"]
+        Resource = "${aws_s3_bucket.state_replica[0].arn}/*"
+      },
+    ]
+  })
+}
+
+# Regra de replicação no bucket de estado existente (região principal)
+resource "aws_s3_bucket_replication_configuration" "state" {
+  count = local.replicate_state ? 1 : 0
+
+  bucket = var.state_bucket_name
+  role   = aws_iam_role.state_replication[0].arn
+
+  rule {
+    id     = "terraform-state-dr"
+    status = "Enabled"
+
+    filter {}
+
+    delete_marker_replication {
+      status = "Enabled"
+    }
+
+    destination {
+      bucket        = aws_s3_bucket.state_replica[0].arn
+      storage_class = "STANDARD"
+    }
+  }
+
+  depends_on = [aws_s3_bucket_versioning.state_replica]
+}
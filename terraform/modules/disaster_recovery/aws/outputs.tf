@@ -0,0 +1,29 @@
+output "replica_identifier" {
+  description = "Identificador da réplica na região secundária, usado no failover (PromoteReadReplica)"
+  value       = one(aws_db_instance.replica[*].identifier)
+}
+
+output "replica_arn" {
+  description = "ARN da réplica na região secundária"
+  value       = one(aws_db_instance.replica[*].arn)
+}
+
+output "replica_address" {
+  description = "Endereço da réplica, que passa a ser o host do banco após o failover"
+  value       = one(aws_db_instance.replica[*].address)
+}
+
+output "backup_replication_id" {
+  description = "ID da replicação de backups automáticos"
+  value       = one(aws_db_instance_automated_backups_replication.main[*].id)
+}
+
+output "state_replica_bucket" {
+  description = "Bucket de estado na região secundária, usado como backend após o failover"
+  value       = one(aws_s3_bucket.state_replica[*].id)
+}
+
+output "kms_key_arn" {
+  description = "Chave KMS da região secundária"
+  value       = one(aws_kms_key.replica[*].arn)
+}
@@ -0,0 +1,88 @@
+variable "environment" {
+  description = "Ambiente (dev, staging, prod)"
+  type        = string
+}
+
+variable "project_name" {
+  description = "Nome do projeto"
+  type        = string
+}
+
+variable "tags" {
+  description = "Tags aplicadas a todos os recursos"
+  type        = map(string)
+  default     = {}
+}
+
+# Réplica de leitura entre regiões
+variable "enable_read_replica" {
+  description = "Criar uma réplica de leitura do banco principal na região secundária"
+  type        = bool
+  default     = true
+}
+
+variable "source_db_arn" {
+  description = "ARN da instância RDS principal (output arn do módulo database/aws)"
+  type        = string
+}
+
+variable "replica_instance_type" {
+  description = "Classe da réplica na região secundária"
+  type        = string
+  default     = "db.t3.micro"
+}
+
+variable "replica_subnet_ids" {
+  description = "Subnets da região secundária para a réplica; vazio usa a VPC padrão da região"
+  type        = list(string)
+  default     = []
+}
+
+variable "replica_security_group_ids" {
+  description = "Security groups da região secundária para a réplica"
+  type        = list(string)
+  default     = []
+}
+
+variable "deletion_protection" {
+  description = "Proteger a réplica contra exclusão"
+  type        = bool
+  default     = true
+}
+
+# Cópia dos backups automáticos
+variable "enable_backup_replication" {
+  description = "Replicar snapshots e logs de transação do banco principal para a região secundária"
+  type        = bool
+  default     = true
+}
+
+variable "backup_retention_days" {
+  description = "Dias de retenção dos backups replicados"
+  type        = number
+  default     = 7
+
+  validation {
+    condition     = var.backup_retention_days >= 1 && var.backup_retention_days <= 35
+    error_message = "backup_retention_days deve estar entre 1 e 35."
+  }
+}
+
+# Replicação do bucket de estado
+variable "enable_state_replication" {
+  description = "Replicar o bucket de estado do Terraform para a região secundária"
+  type        = bool
+  default     = true
+}
+
+variable "state_bucket_name" {
+  description = "Bucket de estado do Terraform (backend s3); precisa ter versionamento habilitado"
+  type        = string
+  default     = ""
+}
+
+variable "state_bucket_arn" {
+  description = "ARN do bucket de estado; se vazio, é montado a partir de state_bucket_name"
+  type        = string
+  default     = ""
+}
@@ -0,0 +1,10 @@
+# As versões dos providers continuam centralizadas no versions.tf da raiz; este bloco só declara
+# o alias aws.secondary, que precisa ser passado explicitamente por quem chama o módulo
+terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      configuration_aliases = [aws.secondary]
+    }
+  }
+}
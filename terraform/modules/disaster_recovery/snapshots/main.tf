@@ -18,6 +18,28 @@ locals {
     "module"      = "disaster-recovery"
     "created-at"  = local.current_time
   }
+
+  # Alertas de backup no formato do webhook do Slack gerado pela biblioteca de notificações de tests/;
+  # $db_backup_status, $backup_name e $status são expandidos pelo shell no momento do envio
+  backup_alerts = {
+    database = {
+      title   = "Backup do banco de dados"
+      summary = "Backup do banco de dados falhou com status: $db_backup_status"
+    }
+    kubernetes = {
+      title   = "Backup do Kubernetes"
+      summary = "Backup do Kubernetes $backup_name falhou com status: $status"
+    }
+  }
+  backup_alert_commands = {
+    for name, alert in local.backup_alerts : name => format(
+      "curl -X POST -H 'Content-Type: application/json' -d \"%s\" '%s'",
+      replace(trimspace(templatefile("${path.module}/templates/backup-alert.json", merge(alert, {
+        footer = "${var.project_name} · ${var.environment} · backup"
+      }))), "\"", "\\\""),
+      var.alert_webhook_url
+    )
+  }
 }
 
 # 1. Snapshots de banco de dados (PostgreSQL)
@@ -180,7 +202,7 @@ resource "null_resource" "backup_status_check" {
       if [ "$db_backup_status" != "available" ]; then
         echo "ALERTA: O último backup de banco de dados está com status: $db_backup_status"
         # Enviar notificação via webhook se configurado
-        ${var.alert_webhook_url != "" ? "curl -X POST -H 'Content-Type: application/json' -d '{\"text\":\"ALERTA: Backup do banco de dados falhou com status: $db_backup_status\"}' ${var.alert_webhook_url}" : "echo \"Webhook não configurado\""}
+        ${var.alert_webhook_url != "" ? local.backup_alert_commands["database"] : "echo \"Webhook não configurado\""}
       else
         echo "Backup de banco de dados está disponível e íntegro"
       fi
@@ -191,7 +213,7 @@ resource "null_resource" "backup_status_check" {
           if [ "$status" != "Completed" ]; then
             echo "ALERTA: Backup do Kubernetes $backup_name falhou com status: $status"
             # Enviar notificação via webhook se configurado
-            ${var.alert_webhook_url != "" ? "curl -X POST -H 'Content-Type: application/json' -d '{\"text\":\"ALERTA: Backup do Kubernetes $backup_name falhou com status: $status\"}' ${var.alert_webhook_url}" : "echo \"Webhook não configurado\""}
+            ${var.alert_webhook_url != "" ? local.backup_alert_commands["kubernetes"] : "echo \"Webhook não configurado\""}
           else
             echo "Backup do Kubernetes $backup_name está completo e íntegro"
           fi
@@ -98,7 +98,7 @@ variable "retention_days" {
 }
 
 variable "alert_webhook_url" {
-  description = "URL de webhook para enviar alertas (Slack, Discord, etc.)"
+  description = "URL do webhook do Slack (slack_alerts_webhook do módulo secrets) para enviar alertas de backup"
   type        = string
   default     = ""
 }
@@ -1,37 +1,37 @@
 output "cluster_id" {
   description = "ID do cluster Kubernetes"
-  value       = digitalocean_kubernetes_cluster.main.id
+  value       = var.enabled ? digitalocean_kubernetes_cluster.main[0].id : null
 }
 
 output "cluster_name" {
   description = "Nome do cluster Kubernetes"
-  value       = digitalocean_kubernetes_cluster.main.name
+  value       = var.enabled ? digitalocean_kubernetes_cluster.main[0].name : null
 }
 
 output "kubernetes_version" {
   description = "Versão do Kubernetes utilizada"
-  value       = digitalocean_kubernetes_cluster.main.version
+  value       = var.enabled ? digitalocean_kubernetes_cluster.main[0].version : null
 }
 
 output "cluster_endpoint" {
   description = "Endpoint da API do Kubernetes"
-  value       = digitalocean_kubernetes_cluster.main.endpoint
+  value       = var.enabled ? digitalocean_kubernetes_cluster.main[0].endpoint : null
 }
 
 output "kube_config" {
   description = "Conteúdo do kubeconfig para acesso ao cluster"
-  value       = digitalocean_kubernetes_cluster.main.kube_config[0].raw_config
+  value       = var.enabled ? digitalocean_kubernetes_cluster.main[0].kube_config[0].raw_config : null
   sensitive   = true
 }
 
 output "default_node_pool_id" {
   description = "ID do node pool padrão"
-  value       = digitalocean_kubernetes_cluster.main.node_pool[0].id
+  value       = var.enabled ? digitalocean_kubernetes_cluster.main[0].node_pool[0].id : null
 }
 
 output "default_node_pool_name" {
   description = "Nome do node pool padrão"
-  value       = digitalocean_kubernetes_cluster.main.node_pool[0].name
+  value       = var.enabled ? digitalocean_kubernetes_cluster.main[0].node_pool[0].name : null
 }
 
 output "critical_node_pool_id" {
@@ -46,7 +46,7 @@ output "critical_node_pool_name" {
 
 output "kubectl_config_command" {
   description = "Comando para configurar o kubectl com o novo cluster"
-  value       = "doctl kubernetes cluster kubeconfig save ${digitalocean_kubernetes_cluster.main.id}"
+  value       = var.enabled ? "doctl kubernetes cluster kubeconfig save ${digitalocean_kubernetes_cluster.main[0].id}" : null
 }
 
 output "registry_integration_enabled" {
@@ -0,0 +1,377 @@
+# Configuração RBAC (Role-Based Access Control) para Kubernetes
+# Define roles e bindings para limitar o acesso aos recursos do cluster. Usado pelo módulo
+# kubernetes/digital-ocean e pelo módulo raiz no modo local-k8s, com o provider kubernetes recebido
+# de quem chama
+
+# ClusterRole para acesso de administrador
+resource "kubernetes_cluster_role" "admin_role" {
+  metadata {
+    name = "custom-admin-role"
+  }
+
+  rule {
+    api_groups = ["*"]
+    resources  = ["*"]
+    verbs      = ["*"]
+  }
+}
+
+# ClusterRole para desenvolvedores (acesso limitado)
+resource "kubernetes_cluster_role" "develop_role" {
+  metadata {
+    name = "custom-develop-role"
+  }
+
+  # Acesso a recursos comuns usados por desenvolvedores
+  rule {
+    api_groups = [""]
+    resources  = ["pods", "services", "configmaps", "secrets", "persistentvolumeclaims"]
+    verbs      = ["get", "list", "watch", "create", "update", "patch", "delete"]
+  }
+
+  rule {
+    api_groups = ["apps"]
+    resources  = ["deployments", "statefulsets", "daemonsets", "replicasets"]
+    verbs      = ["get", "list", "watch", "create", "update", "patch", "delete"]
+  }
+
+  rule {
+    api_groups = ["batch"]
+    resources  = ["jobs", "cronjobs"]
+    verbs      = ["get", "list", "watch", "create", "update", "patch", "delete"]
+  }
+
+  # Acesso de leitura para recursos relacionados a rede
+  rule {
+    api_groups = ["networking.k8s.io"]
+    resources  = ["ingresses", "networkpolicies"]
+    verbs      = ["get", "list", "watch", "create", "update", "patch", "delete"]
+  }
+
+  # Acesso limitado a logs e eventos
+  rule {
+    api_groups = [""]
+    resources  = ["events", "pods/log", "pods/exec"]
+    verbs      = ["get", "list", "watch"]
+  }
+}
+
+# ClusterRole para acesso somente leitura
+resource "kubernetes_cluster_role" "readonly_role" {
+  metadata {
+    name = "custom-readonly-role"
+  }
+
+  # Acesso de leitura para recursos comuns
+  rule {
+    api_groups = [""]
+    resources  = ["pods", "services", "configmaps", "secrets", "persistentvolumeclaims", "namespaces", "nodes"]
+    verbs      = ["get", "list", "watch"]
+  }
+
+  rule {
+    api_groups = ["apps"]
+    resources  = ["deployments", "statefulsets", "daemonsets", "replicasets"]
+    verbs      = ["get", "list", "watch"]
+  }
+
+  rule {
+    api_groups = ["batch"]
+    resources  = ["jobs", "cronjobs"]
+    verbs      = ["get", "list", "watch"]
+  }
+
+  rule {
+    api_groups = ["networking.k8s.io"]
+    resources  = ["ingresses", "networkpolicies"]
+    verbs      = ["get", "list", "watch"]
+  }
+
+  # Acesso a logs e eventos
+  rule {
+    api_groups = [""]
+    resources  = ["events", "pods/log"]
+    verbs      = ["get", "list", "watch"]
+  }
+}
+
+# ClusterRoleBindings para usuários administradores
+resource "kubernetes_cluster_role_binding" "admin_binding" {
+  metadata {
+    name = "custom-admin-binding"
+  }
+
+  role_ref {
+    api_group = "rbac.authorization.k8s.io"
+    kind      = "ClusterRole"
+    name      = kubernetes_cluster_role.admin_role.metadata[0].name
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.admin.users
+    content {
+      kind      = "User"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.admin.groups
+    content {
+      kind      = "Group"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+}
+
+# ClusterRoleBindings para desenvolvedores
+# Removidos apenas com rbac_restrict_to_namespaces, quando o acesso fica restrito aos RoleBindings
+resource "kubernetes_cluster_role_binding" "develop_binding" {
+  count = var.rbac_restrict_to_namespaces && length(var.rbac_namespaces) > 0 ? 0 : 1
+
+  metadata {
+    name = "custom-develop-binding"
+  }
+
+  role_ref {
+    api_group = "rbac.authorization.k8s.io"
+    kind      = "ClusterRole"
+    name      = kubernetes_cluster_role.develop_role.metadata[0].name
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.develop.users
+    content {
+      kind      = "User"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.develop.groups
+    content {
+      kind      = "Group"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+}
+
+# ClusterRoleBindings para usuários somente leitura
+# Removidos apenas com rbac_restrict_to_namespaces, quando o acesso fica restrito aos RoleBindings
+resource "kubernetes_cluster_role_binding" "readonly_binding" {
+  count = var.rbac_restrict_to_namespaces && length(var.rbac_namespaces) > 0 ? 0 : 1
+
+  metadata {
+    name = "custom-readonly-binding"
+  }
+
+  role_ref {
+    api_group = "rbac.authorization.k8s.io"
+    kind      = "ClusterRole"
+    name      = kubernetes_cluster_role.readonly_role.metadata[0].name
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.readonly.users
+    content {
+      kind      = "User"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.readonly.groups
+    content {
+      kind      = "Group"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+}
+
+moved {
+  from = kubernetes_cluster_role_binding.develop_binding
+  to   = kubernetes_cluster_role_binding.develop_binding[0]
+}
+
+moved {
+  from = kubernetes_cluster_role_binding.readonly_binding
+  to   = kubernetes_cluster_role_binding.readonly_binding[0]
+}
+
+# Roles específicas para namespaces (quando namespaces são especificados)
+resource "kubernetes_role" "namespace_admin_role" {
+  for_each = toset(var.rbac_namespaces)
+
+  metadata {
+    name      = "custom-admin-role"
+    namespace = each.value
+  }
+
+  rule {
+    api_groups = ["*"]
+    resources  = ["*"]
+    verbs      = ["*"]
+  }
+}
+
+resource "kubernetes_role" "namespace_develop_role" {
+  for_each = toset(var.rbac_namespaces)
+
+  metadata {
+    name      = "custom-develop-role"
+    namespace = each.value
+  }
+
+  # Acesso a recursos comuns usados por desenvolvedores
+  rule {
+    api_groups = [""]
+    resources  = ["pods", "services", "configmaps", "secrets", "persistentvolumeclaims"]
+    verbs      = ["get", "list", "watch", "create", "update", "patch", "delete"]
+  }
+
+  rule {
+    api_groups = ["apps"]
+    resources  = ["deployments", "statefulsets", "daemonsets", "replicasets"]
+    verbs      = ["get", "list", "watch", "create", "update", "patch", "delete"]
+  }
+
+  rule {
+    api_groups = ["batch"]
+    resources  = ["jobs", "cronjobs"]
+    verbs      = ["get", "list", "watch", "create", "update", "patch", "delete"]
+  }
+}
+
+resource "kubernetes_role" "namespace_readonly_role" {
+  for_each = toset(var.rbac_namespaces)
+
+  metadata {
+    name      = "custom-readonly-role"
+    namespace = each.value
+  }
+
+  # Acesso de leitura para recursos comuns
+  rule {
+    api_groups = [""]
+    resources  = ["pods", "services", "configmaps", "secrets", "persistentvolumeclaims"]
+    verbs      = ["get", "list", "watch"]
+  }
+
+  rule {
+    api_groups = ["apps"]
+    resources  = ["deployments", "statefulsets", "daemonsets", "replicasets"]
+    verbs      = ["get", "list", "watch"]
+  }
+
+  rule {
+    api_groups = ["batch"]
+    resources  = ["jobs", "cronjobs"]
+    verbs      = ["get", "list", "watch"]
+  }
+}
+
+# RoleBindings para namespaces específicos
+resource "kubernetes_role_binding" "namespace_admin_binding" {
+  for_each = toset(var.rbac_namespaces)
+
+  metadata {
+    name      = "custom-admin-binding"
+    namespace = each.value
+  }
+
+  role_ref {
+    api_group = "rbac.authorization.k8s.io"
+    kind      = "Role"
+    name      = kubernetes_role.namespace_admin_role[each.value].metadata[0].name
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.admin.users
+    content {
+      kind      = "User"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.admin.groups
+    content {
+      kind      = "Group"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+}
+
+resource "kubernetes_role_binding" "namespace_develop_binding" {
+  for_each = toset(var.rbac_namespaces)
+
+  metadata {
+    name      = "custom-develop-binding"
+    namespace = each.value
+  }
+
+  role_ref {
+    api_group = "rbac.authorization.k8s.io"
+    kind      = "Role"
+    name      = kubernetes_role.namespace_develop_role[each.value].metadata[0].name
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.develop.users
+    content {
+      kind      = "User"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.develop.groups
+    content {
+      kind      = "Group"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+}
+
+resource "kubernetes_role_binding" "namespace_readonly_binding" {
+  for_each = toset(var.rbac_namespaces)
+
+  metadata {
+    name      = "custom-readonly-binding"
+    namespace = each.value
+  }
+
+  role_ref {
+    api_group = "rbac.authorization.k8s.io"
+    kind      = "Role"
+    name      = kubernetes_role.namespace_readonly_role[each.value].metadata[0].name
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.readonly.users
+    content {
+      kind      = "User"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+
+  dynamic "subject" {
+    for_each = var.rbac_groups.readonly.groups
+    content {
+      kind      = "Group"
+      name      = subject.value
+      api_group = "rbac.authorization.k8s.io"
+    }
+  }
+}
@@ -0,0 +1,11 @@
+output "roles" {
+  description = "Lista de roles RBAC criadas"
+  value = {
+    cluster_roles = {
+      admin    = kubernetes_cluster_role.admin_role.metadata[0].name
+      develop  = kubernetes_cluster_role.develop_role.metadata[0].name
+      readonly = kubernetes_cluster_role.readonly_role.metadata[0].name
+    }
+    namespace_roles = var.rbac_namespaces
+  }
+}
@@ -0,0 +1,33 @@
+variable "rbac_groups" {
+  description = "Mapa de nomes de grupos e usuários para cada tipo de acesso"
+  type = map(object({
+    users  = list(string)
+    groups = list(string)
+  }))
+  default = {
+    admin = {
+      users  = []
+      groups = []
+    }
+    develop = {
+      users  = []
+      groups = []
+    }
+    readonly = {
+      users  = []
+      groups = []
+    }
+  }
+}
+
+variable "rbac_namespaces" {
+  description = "Lista de namespaces para aplicar as roles (deixe vazio para usar ClusterRoles)"
+  type        = list(string)
+  default     = []
+}
+
+variable "rbac_restrict_to_namespaces" {
+  description = "Se verdadeiro e rbac_namespaces não estiver vazio, develop e readonly acessam apenas os namespaces listados, sem os ClusterRoleBindings do cluster inteiro"
+  type        = bool
+  default     = false
+}
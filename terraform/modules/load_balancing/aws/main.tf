@@ -11,16 +11,16 @@
 
 # Security Group para o Load Balancer
 resource "aws_security_group" "alb" {
-  name        = "${var.name_prefix}-alb-sg"
+  name        = "${var.name}-alb-sg"
   description = "Security Group para o Application Load Balancer"
   vpc_id      = var.vpc_id
 
   # Regra de entrada para HTTP
   dynamic "ingress" {
-    for_each = var.enable_http ? [1] : []
+    for_each = var.http_enabled ? [1] : []
     content {
-      from_port   = 80
-      to_port     = 80
+      from_port   = var.http_port
+      to_port     = var.http_port
       protocol    = "tcp"
       cidr_blocks = var.allowed_cidr_blocks
       description = "Acesso HTTP"
@@ -29,10 +29,10 @@ resource "aws_security_group" "alb" {
 
   # Regra de entrada para HTTPS
   dynamic "ingress" {
-    for_each = var.enable_https ? [1] : []
+    for_each = var.https_enabled ? [1] : []
     content {
-      from_port   = 443
-      to_port     = 443
+      from_port   = var.https_port
+      to_port     = var.https_port
       protocol    = "tcp"
       cidr_blocks = var.allowed_cidr_blocks
       description = "Acesso HTTPS"
@@ -51,33 +51,33 @@ resource "aws_security_group" "alb" {
   tags = merge(
     var.tags,
     {
-      Name = "${var.name_prefix}-alb-sg"
+      Name = "${var.name}-alb-sg"
     }
   )
 }
 
 # S3 Bucket para logs do ALB (opcional)
 resource "aws_s3_bucket" "alb_logs" {
-  count  = var.enable_access_logs ? 1 : 0
-  bucket = "${var.name_prefix}-alb-logs-${random_string.suffix[0].result}"
+  count  = var.access_logs.enabled ? 1 : 0
+  bucket = "${var.name}-alb-logs-${random_string.suffix[0].result}"
 
   tags = merge(
     var.tags,
     {
-      Name = "${var.name_prefix}-alb-logs"
+      Name = "${var.name}-alb-logs"
     }
   )
 }
 
 resource "random_string" "suffix" {
-  count   = var.enable_access_logs ? 1 : 0
+  count   = var.access_logs.enabled ? 1 : 0
   length  = 8
   special = false
   upper   = false
 }
 
 resource "aws_s3_bucket_policy" "alb_logs" {
-  count  = var.enable_access_logs ? 1 : 0
+  count  = var.access_logs.enabled ? 1 : 0
   bucket = aws_s3_bucket.alb_logs[0].id
 
   policy = jsonencode({
@@ -100,7 +100,7 @@ data "aws_elb_service_account" "main" {}
 
 # Application Load Balancer
 resource "aws_lb" "main" {
-  name               = "${var.name_prefix}-alb"
+  name               = "${var.name}-alb"
   internal           = var.internal
   load_balancer_type = "application"
   security_groups    = [aws_security_group.alb.id]
@@ -110,10 +110,10 @@ resource "aws_lb" "main" {
   idle_timeout               = var.idle_timeout
 
   dynamic "access_logs" {
-    for_each = var.enable_access_logs ? [1] : []
+    for_each = var.access_logs.enabled ? [1] : []
     content {
       bucket  = aws_s3_bucket.alb_logs[0].bucket
-      prefix  = var.access_logs_prefix
+      prefix  = var.access_logs.prefix
       enabled = true
     }
   }
@@ -121,7 +121,7 @@ resource "aws_lb" "main" {
   tags = merge(
     var.tags,
     {
-      Name = "${var.name_prefix}-alb"
+      Name = "${var.name}-alb"
     }
   )
 }
@@ -129,12 +129,22 @@ resource "aws_lb" "main" {
 # Target Group para o ALB
 resource "aws_lb_target_group" "main" {
   count       = length(var.target_groups)
-  name        = "${var.name_prefix}-tg-${var.target_groups[count.index].name}"
+  name        = "${var.name}-tg-${var.target_groups[count.index].name}"
   port        = var.target_groups[count.index].port
   protocol    = var.target_groups[count.index].protocol
   target_type = var.target_groups[count.index].target_type
   vpc_id      = var.vpc_id
 
+  load_balancing_algorithm_type = var.load_balancing_algorithm_type
+  deregistration_delay          = var.deregistration_delay
+  slow_start                    = var.slow_start
+
+  stickiness {
+    enabled         = var.stickiness.enabled
+    type            = var.stickiness.type
+    cookie_duration = var.stickiness.cookie_duration
+  }
+
   health_check {
     enabled             = true
     interval            = var.target_groups[count.index].health_check.interval
@@ -150,7 +160,7 @@ resource "aws_lb_target_group" "main" {
   tags = merge(
     var.tags,
     {
-      Name = "${var.name_prefix}-tg-${var.target_groups[count.index].name}"
+      Name = "${var.name}-tg-${var.target_groups[count.index].name}"
     }
   )
 
@@ -161,19 +171,19 @@ resource "aws_lb_target_group" "main" {
 
 # HTTP Listener
 resource "aws_lb_listener" "http" {
-  count             = var.enable_http ? 1 : 0
+  count             = var.http_enabled ? 1 : 0
   load_balancer_arn = aws_lb.main.arn
-  port              = "80"
+  port              = var.http_port
   protocol          = "HTTP"
 
   # Redirecionar para HTTPS se ambos estiverem habilitados
   dynamic "default_action" {
-    for_each = var.enable_https && var.redirect_http_to_https ? [1] : []
+    for_each = var.https_enabled && var.http_redirect ? [1] : []
     content {
       type = "redirect"
 
       redirect {
-        port        = "443"
+        port        = tostring(var.https_port)
         protocol    = "HTTPS"
         status_code = "HTTP_301"
       }
@@ -182,7 +192,7 @@ resource "aws_lb_listener" "http" {
 
   # Encaminhar para o target group padrão se não houver redirecionamento
   dynamic "default_action" {
-    for_each = !(var.enable_https && var.redirect_http_to_https) ? [1] : []
+    for_each = !(var.https_enabled && var.http_redirect) ? [1] : []
     content {
       type             = "forward"
       target_group_arn = aws_lb_target_group.main[0].arn
@@ -192,9 +202,9 @@ resource "aws_lb_listener" "http" {
 
 # HTTPS Listener
 resource "aws_lb_listener" "https" {
-  count             = var.enable_https ? 1 : 0
+  count             = var.https_enabled ? 1 : 0
   load_balancer_arn = aws_lb.main.arn
-  port              = "443"
+  port              = var.https_port
   protocol          = "HTTPS"
   ssl_policy        = var.ssl_policy
   certificate_arn   = var.certificate_arn
@@ -207,7 +217,7 @@ resource "aws_lb_listener" "https" {
 
 # Regras de roteamento adicionais (se path_patterns for fornecido)
 resource "aws_lb_listener_rule" "path_based" {
-  count        = var.enable_https && length(var.path_based_routing) > 0 ? length(var.path_based_routing) : 0
+  count        = var.https_enabled && length(var.path_based_routing) > 0 ? length(var.path_based_routing) : 0
   listener_arn = aws_lb_listener.https[0].arn
   priority     = 100 + count.index
 
@@ -225,7 +235,7 @@ resource "aws_lb_listener_rule" "path_based" {
 
 # Regras adicionais para o listener HTTP (se HTTP estiver habilitado sem redirecionamento)
 resource "aws_lb_listener_rule" "http_path_based" {
-  count        = var.enable_http && !(var.enable_https && var.redirect_http_to_https) && length(var.path_based_routing) > 0 ? length(var.path_based_routing) : 0
+  count        = var.http_enabled && !(var.https_enabled && var.http_redirect) && length(var.path_based_routing) > 0 ? length(var.path_based_routing) : 0
   listener_arn = aws_lb_listener.http[0].arn
   priority     = 100 + count.index
 
@@ -36,12 +36,12 @@ output "target_group_names" {
 
 output "http_listener_arn" {
   description = "ARN do listener HTTP, se criado"
-  value       = var.enable_http ? aws_lb_listener.http[0].arn : null
+  value       = var.http_enabled ? aws_lb_listener.http[0].arn : null
 }
 
 output "https_listener_arn" {
   description = "ARN do listener HTTPS, se criado"
-  value       = var.enable_https ? aws_lb_listener.https[0].arn : null
+  value       = var.https_enabled ? aws_lb_listener.https[0].arn : null
 }
 
 output "security_group_id" {
@@ -56,22 +56,22 @@ output "security_group_name" {
 
 output "http_enabled" {
   description = "Indica se o tráfego HTTP está habilitado no load balancer"
-  value       = var.enable_http
+  value       = var.http_enabled
 }
 
 output "https_enabled" {
   description = "Indica se o tráfego HTTPS está habilitado no load balancer"
-  value       = var.enable_https
+  value       = var.https_enabled
 }
 
 output "ssl_certificate_arn" {
   description = "ARN do certificado SSL associado ao listener HTTPS, se aplicável"
-  value       = var.enable_https ? var.ssl_certificate_arn : null
+  value       = var.https_enabled ? var.certificate_arn : null
 }
 
 output "health_check_path" {
   description = "Caminho usado para verificações de saúde dos serviços"
-  value       = var.health_check_path
+  value       = var.health_check.path
 }
 
 output "alb_full_name" {
@@ -81,7 +81,7 @@ output "alb_full_name" {
 
 output "access_logs_bucket" {
   description = "Nome do bucket S3 onde os logs de acesso são armazenados, se habilitado"
-  value       = var.enable_access_logs ? var.access_logs_bucket : null
+  value       = var.access_logs.enabled ? aws_s3_bucket.alb_logs[0].bucket : null
 }
 
 output "load_balancer_type" {
@@ -175,6 +175,18 @@ variable "http_redirect" {
   default     = false
 }
 
+variable "ssl_policy" {
+  description = "Política SSL do listener HTTPS"
+  type        = string
+  default     = "ELBSecurityPolicy-TLS13-1-2-2021-06"
+}
+
+variable "allowed_cidr_blocks" {
+  description = "Blocos CIDR autorizados a acessar os listeners HTTP e HTTPS"
+  type        = list(string)
+  default     = ["0.0.0.0/0"]
+}
+
 variable "certificate_arn" {
   description = "ARN do certificado SSL/TLS para o listener HTTPS"
   type        = string
@@ -186,16 +198,31 @@ variable "certificate_arn" {
 # ===========================================================================
 
 variable "target_groups" {
-  description = "Lista de target groups para o load balancer"
+  description = "Lista de target groups para o load balancer; o primeiro é o destino padrão dos listeners"
   type = list(object({
-    name             = string
-    backend_protocol = string
-    backend_port     = number
-    target_type      = string
-    vpc_id           = string
-    health_check     = map(any)
-    targets          = list(map(any))
-    tags             = map(string)
+    name        = string
+    port        = number
+    protocol    = string
+    target_type = optional(string, "instance")
+    health_check = optional(object({
+      interval            = optional(number, 30)
+      path                = optional(string, "/")
+      port                = optional(string, "traffic-port")
+      protocol            = optional(string, "HTTP")
+      timeout             = optional(number, 5)
+      healthy_threshold   = optional(number, 2)
+      unhealthy_threshold = optional(number, 2)
+      matcher             = optional(string, "200-299")
+    }), {})
+  }))
+  default = []
+}
+
+variable "path_based_routing" {
+  description = "Regras de roteamento por caminho, apontando para o índice do target group em target_groups"
+  type = list(object({
+    path_patterns      = list(string)
+    target_group_index = number
   }))
   default = []
 }
@@ -270,5 +297,9 @@ variable "load_balancing_algorithm_type" {
   description = "Determina como o load balancer seleciona destinos. Valores possíveis: round_robin, least_outstanding_requests"
   type        = string
   default     = "round_robin"
+  validation {
+    condition     = contains(["round_robin", "least_outstanding_requests"], var.load_balancing_algorithm_type)
+    error_message = "O valor de load_balancing_algorithm_type deve ser 'round_robin' ou 'least_outstanding_requests'."
+  }
 }
 
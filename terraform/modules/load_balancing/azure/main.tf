@@ -18,7 +18,7 @@ resource "azurerm_public_ip" "main" {
   location            = var.location
   resource_group_name = local.resource_group_name
   allocation_method   = "Static"
-  sku                 = var.load_balancer_sku
+  sku                 = var.lb_sku
   domain_name_label   = var.domain_name_label
   tags                = var.tags
 }
@@ -28,7 +28,7 @@ resource "azurerm_lb" "main" {
   name                = var.name
   location            = var.location
   resource_group_name = local.resource_group_name
-  sku                 = var.load_balancer_sku
+  sku                 = var.lb_sku
 
   frontend_ip_configuration {
     name                 = "${var.name}-frontend-ip"
@@ -51,9 +51,9 @@ resource "azurerm_lb_probe" "http" {
   loadbalancer_id     = azurerm_lb.main.id
   protocol            = "Http"
   port                = var.http_port
-  request_path        = var.health_check_path
-  interval_in_seconds = var.health_check_interval
-  number_of_probes    = var.health_check_threshold
+  request_path        = var.health_probe_path
+  interval_in_seconds = var.health_probe_interval
+  number_of_probes    = var.health_probe_unhealthy_threshold
 }
 
 # Health Probe HTTPS
@@ -63,9 +63,9 @@ resource "azurerm_lb_probe" "https" {
   loadbalancer_id     = azurerm_lb.main.id
   protocol            = "Https"
   port                = var.https_port
-  request_path        = var.health_check_path
-  interval_in_seconds = var.health_check_interval
-  number_of_probes    = var.health_check_threshold
+  request_path        = var.health_probe_path
+  interval_in_seconds = var.health_probe_interval
+  number_of_probes    = var.health_probe_unhealthy_threshold
 }
 
 # Regra HTTP
@@ -79,8 +79,9 @@ resource "azurerm_lb_rule" "http" {
   frontend_ip_configuration_name = azurerm_lb.main.frontend_ip_configuration[0].name
   backend_address_pool_ids       = [azurerm_lb_backend_address_pool.main.id]
   probe_id                       = azurerm_lb_probe.http[0].id
-  idle_timeout_in_minutes        = 4
-  enable_floating_ip             = false
+  idle_timeout_in_minutes        = var.idle_timeout_in_minutes
+  enable_floating_ip             = var.enable_floating_ip
+  load_distribution              = var.load_distribution
 }
 
 # Regra HTTPS
@@ -94,8 +95,9 @@ resource "azurerm_lb_rule" "https" {
   frontend_ip_configuration_name = azurerm_lb.main.frontend_ip_configuration[0].name
   backend_address_pool_ids       = [azurerm_lb_backend_address_pool.main.id]
   probe_id                       = azurerm_lb_probe.https[0].id
-  idle_timeout_in_minutes        = 4
-  enable_floating_ip             = false
+  idle_timeout_in_minutes        = var.idle_timeout_in_minutes
+  enable_floating_ip             = var.enable_floating_ip
+  load_distribution              = var.load_distribution
 }
 
 # Configuração NAT para acesso SSH, se habilitado
@@ -129,7 +131,7 @@ resource "azurerm_network_security_rule" "http" {
   protocol                    = "Tcp"
   source_port_range           = "*"
   destination_port_range      = "80"
-  source_address_prefix       = var.allowed_cidr_blocks
+  source_address_prefixes     = var.allowed_cidr_blocks
   destination_address_prefix  = "*"
   resource_group_name         = local.resource_group_name
   network_security_group_name = azurerm_network_security_group.main[0].name
@@ -145,7 +147,7 @@ resource "azurerm_network_security_rule" "https" {
   protocol                    = "Tcp"
   source_port_range           = "*"
   destination_port_range      = "443"
-  source_address_prefix       = var.allowed_cidr_blocks
+  source_address_prefixes     = var.allowed_cidr_blocks
   destination_address_prefix  = "*"
   resource_group_name         = local.resource_group_name
   network_security_group_name = azurerm_network_security_group.main[0].name
@@ -161,7 +163,7 @@ resource "azurerm_network_security_rule" "ssh" {
   protocol                    = "Tcp"
   source_port_range           = "*"
   destination_port_ranges     = var.ssh_port_ranges
-  source_address_prefix       = var.admin_cidr_blocks
+  source_address_prefixes     = var.admin_cidr_blocks
   destination_address_prefix  = "*"
   resource_group_name         = local.resource_group_name
   network_security_group_name = azurerm_network_security_group.main[0].name
@@ -169,7 +171,7 @@ resource "azurerm_network_security_rule" "ssh" {
 
 # Monitoramento (opcional)
 resource "azurerm_monitor_diagnostic_setting" "main" {
-  count                      = var.enable_monitoring ? 1 : 0
+  count                      = var.enable_diagnostic_settings ? 1 : 0
   name                       = "${var.name}-monitoring"
   target_resource_id         = azurerm_lb.main.id
   log_analytics_workspace_id = var.log_analytics_workspace_id
@@ -30,7 +30,7 @@ output "backend_pool_id" {
 
 output "health_probe_id" {
   description = "ID da sonda de saúde (health probe)"
-  value       = azurerm_lb_probe.http.id
+  value       = one(concat(azurerm_lb_probe.http[*].id, azurerm_lb_probe.https[*].id))
 }
 
 output "frontend_ip_configuration_id" {
@@ -40,7 +40,7 @@ output "frontend_ip_configuration_id" {
 
 output "network_security_rules" {
   description = "Regras de segurança de rede associadas, se aplicável"
-  value       = try(azurerm_network_security_rule.lb_rules[*].name, [])
+  value       = concat(azurerm_network_security_rule.http[*].name, azurerm_network_security_rule.https[*].name, azurerm_network_security_rule.ssh[*].name)
 }
 
 output "https_enabled" {
@@ -48,11 +48,6 @@ output "https_enabled" {
   value       = var.enable_https
 }
 
-output "application_gateway_id" {
-  description = "ID do Application Gateway (se HTTPS estiver habilitado)"
-  value       = var.enable_https ? try(azurerm_application_gateway.main[0].id, null) : null
-}
-
 output "ssl_certificate_name" {
   description = "Nome do certificado SSL, se configurado"
   value       = var.enable_https ? var.ssl_certificate_name : null
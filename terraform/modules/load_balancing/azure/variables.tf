@@ -4,11 +4,22 @@ variable "prefix" {
   default     = "app"
 }
 
+variable "name" {
+  description = "Nome do balanceador de carga, usado também como prefixo dos recursos associados"
+  type        = string
+}
+
 variable "resource_group_name" {
   description = "Nome do grupo de recursos onde o balanceador de carga será criado"
   type        = string
 }
 
+variable "create_resource_group" {
+  description = "Cria o grupo de recursos em vez de usar um existente"
+  type        = bool
+  default     = false
+}
+
 variable "location" {
   description = "Localização do Azure onde o balanceador de carga será implantado (ex: brazilsouth)"
   type        = string
@@ -57,6 +68,12 @@ variable "allocation_method" {
   }
 }
 
+variable "domain_name_label" {
+  description = "Rótulo DNS do IP público (<rótulo>.<região>.cloudapp.azure.com)"
+  type        = string
+  default     = null
+}
+
 # Configurações do Load Balancer
 variable "lb_sku" {
   description = "SKU do balanceador de carga (Basic ou Standard)"
@@ -222,6 +239,37 @@ variable "disable_outbound_snat" {
   default     = false
 }
 
+# Acesso administrativo e regras de rede
+variable "enable_ssh" {
+  description = "Cria regras NAT para acesso SSH às VMs do pool de backend"
+  type        = bool
+  default     = false
+}
+
+variable "ssh_port_ranges" {
+  description = "Portas do frontend mapeadas para a porta 22 de cada VM, na ordem do pool"
+  type        = list(number)
+  default     = []
+}
+
+variable "create_network_security_group" {
+  description = "Cria um grupo de segurança de rede com regras para HTTP, HTTPS e SSH"
+  type        = bool
+  default     = false
+}
+
+variable "allowed_cidr_blocks" {
+  description = "Blocos CIDR autorizados a acessar HTTP e HTTPS"
+  type        = list(string)
+  default     = ["0.0.0.0/0"]
+}
+
+variable "admin_cidr_blocks" {
+  description = "Blocos CIDR autorizados a acessar as portas SSH"
+  type        = list(string)
+  default     = []
+}
+
 # Configurações de Monitoramento
 variable "enable_diagnostic_settings" {
   description = "Habilitar ou não configurações de diagnóstico para o balanceador de carga"
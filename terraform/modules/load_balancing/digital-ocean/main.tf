@@ -12,50 +12,52 @@
 resource "digitalocean_loadbalancer" "main" {
   name     = "${var.project_name}-${var.environment}-lb"
   region   = var.region
-  vpc_uuid = var.vpc_id
+  vpc_uuid = var.vpc_uuid != "" ? var.vpc_uuid : null
 
   # Ajusta o tamanho do load balancer conforme o ambiente
   # Redução de custos em ambientes não-produtivos
   size = var.environment == "prod" ? var.lb_size_prod : var.lb_size_non_prod
 
-  # Regra de encaminhamento HTTP padrão
-  forwarding_rule {
-    entry_port     = 80
-    entry_protocol = "http"
+  droplet_ids = length(var.droplet_ids) > 0 ? var.droplet_ids : null
+  droplet_tag = var.tag_name
 
-    target_port     = var.app_port
-    target_protocol = "http"
-  }
-
-  # Regra de encaminhamento HTTPS (opcional)
+  # Regras de encaminhamento (HTTP, HTTPS com certificado ou TLS passthrough)
   dynamic "forwarding_rule" {
-    for_each = var.enable_https ? [1] : []
+    for_each = var.forwarding_rules
     content {
-      entry_port      = 443
-      entry_protocol  = "https"
-      target_port     = var.app_port
-      target_protocol = "http"
-      certificate_id  = var.certificate_id
+      entry_port      = forwarding_rule.value.entry_port
+      entry_protocol  = forwarding_rule.value.entry_protocol
+      target_port     = forwarding_rule.value.target_port
+      target_protocol = forwarding_rule.value.target_protocol
+      certificate_id  = forwarding_rule.value.certificate_id
+      tls_passthrough = forwarding_rule.value.tls_passthrough
     }
   }
 
   # Configuração de health check
   healthcheck {
-    port                     = var.healthcheck_port
-    protocol                 = var.healthcheck_protocol
-    path                     = var.healthcheck_path
-    check_interval_seconds   = var.healthcheck_interval
-    response_timeout_seconds = var.healthcheck_timeout
-    unhealthy_threshold      = var.healthcheck_unhealthy_threshold
-    healthy_threshold        = var.healthcheck_healthy_threshold
+    port                     = var.healthcheck.port
+    protocol                 = var.healthcheck.protocol
+    path                     = var.healthcheck.protocol == "tcp" ? null : var.healthcheck.path
+    check_interval_seconds   = var.healthcheck.check_interval_seconds
+    response_timeout_seconds = var.healthcheck.response_timeout_seconds
+    unhealthy_threshold      = var.healthcheck.unhealthy_threshold
+    healthy_threshold        = var.healthcheck.healthy_threshold
   }
 
   # Configurações avançadas
-  enable_backend_keepalive = var.environment == "prod" ? true : false
+  enable_backend_keepalive = var.enable_backend_keepalive
   enable_proxy_protocol    = var.enable_proxy_protocol
 
+  # Sessões persistentes baseadas em cookie
+  sticky_sessions {
+    type               = var.sticky_sessions.type
+    cookie_name        = var.sticky_sessions.type == "cookies" ? var.sticky_sessions.cookie_name : null
+    cookie_ttl_seconds = var.sticky_sessions.type == "cookies" ? var.sticky_sessions.cookie_ttl_seconds : null
+  }
+
   # Opções de redirecionamento
-  redirect_http_to_https = var.enable_https && var.redirect_http_to_https
+  redirect_http_to_https = var.redirect_http_to_https
 
   # Gerenciamento de ciclo de vida do recurso
   lifecycle {
@@ -15,6 +15,18 @@ variable "environment" {
   type        = string
 }
 
+variable "lb_size_prod" {
+  description = "Tamanho do load balancer em produção"
+  type        = string
+  default     = "lb-medium"
+}
+
+variable "lb_size_non_prod" {
+  description = "Tamanho do load balancer nos demais ambientes"
+  type        = string
+  default     = "lb-small"
+}
+
 variable "lb_name" {
   description = "Nome do load balancer"
   type        = string
@@ -39,13 +51,13 @@ variable "tag_name" {
 }
 
 variable "algorithm" {
-  description = "Algoritmo de balanceamento de carga (round_robin, least_connections)"
+  description = "Algoritmo de balanceamento de carga. A API do DigitalOcean ignora o campo (obsoleto) e usa sempre round_robin"
   type        = string
   default     = "round_robin"
 
   validation {
-    condition     = contains(["round_robin", "least_connections"], var.algorithm)
-    error_message = "O algoritmo deve ser 'round_robin' ou 'least_connections'."
+    condition     = var.algorithm == "round_robin"
+    error_message = "O DigitalOcean não permite escolher o algoritmo; least_connections seria ignorado pela API."
   }
 }
 
@@ -59,7 +71,14 @@ variable "forwarding_rules" {
     certificate_id  = optional(string)
     tls_passthrough = optional(bool, false)
   }))
-  default = []
+  default = [
+    {
+      entry_protocol  = "http"
+      entry_port      = 80
+      target_protocol = "http"
+      target_port     = 80
+    }
+  ]
 
   validation {
     condition = length([
@@ -157,3 +176,27 @@ variable "tags" {
   default     = []
 }
 
+# Registros DNS (opcionais)
+variable "create_dns_record" {
+  description = "Cria registros DNS apontando para o load balancer"
+  type        = bool
+  default     = false
+}
+
+variable "domain_name" {
+  description = "Domínio gerenciado no DigitalOcean onde os registros serão criados"
+  type        = string
+  default     = ""
+}
+
+variable "dns_ttl" {
+  description = "TTL em segundos dos registros DNS"
+  type        = number
+  default     = 300
+}
+
+variable "enable_ipv6" {
+  description = "Cria o registro AAAA para o endereço IPv6 do load balancer"
+  type        = bool
+  default     = false
+}
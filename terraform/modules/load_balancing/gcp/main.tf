@@ -13,23 +13,22 @@ resource "google_compute_global_address" "main" {
   name         = var.name
   description  = "Endereço IP global para ${var.name}"
   address_type = "EXTERNAL"
-  ip_version   = var.enable_ipv6 ? "IPV6" : "IPV4"
+  ip_version   = var.ip_version
 }
 
 # Health check para verificar a integridade dos backends
 resource "google_compute_health_check" "main" {
   name                = "${var.name}-health-check"
   description         = "Health check para ${var.name}"
-  timeout_sec         = var.health_check_timeout_sec
-  check_interval_sec  = var.health_check_interval_sec
-  healthy_threshold   = var.health_check_healthy_threshold
-  unhealthy_threshold = var.health_check_unhealthy_threshold
+  timeout_sec         = var.health_check.timeout_sec
+  check_interval_sec  = var.health_check.check_interval_sec
+  healthy_threshold   = var.health_check.healthy_threshold
+  unhealthy_threshold = var.health_check.unhealthy_threshold
 
   http_health_check {
-    port               = var.health_check_port
+    port               = var.health_check.port
     port_specification = "USE_FIXED_PORT"
-    request_path       = var.health_check_request_path
-    host               = var.health_check_host
+    request_path       = var.health_check.request_path
   }
 }
 
@@ -44,6 +43,10 @@ resource "google_compute_backend_service" "main" {
   load_balancing_scheme = "EXTERNAL"
   enable_cdn            = var.enable_cdn
 
+  # Sessões persistentes (GENERATED_COOKIE) ou NONE
+  session_affinity        = var.session_affinity
+  affinity_cookie_ttl_sec = var.session_affinity == "GENERATED_COOKIE" ? var.affinity_cookie_ttl_sec : null
+
   # Se o recurso de backend já estiver definido, use-o, caso contrário, crie um
   dynamic "backend" {
     for_each = var.backends
@@ -55,18 +58,17 @@ resource "google_compute_backend_service" "main" {
   }
 
   # Configurações de segurança (opcional)
-  security_policy = var.security_policy_name != null ? var.security_policy_name : null
+  security_policy = var.security_policy
 
   # Configurações de CDN (opcional)
   dynamic "cdn_policy" {
     for_each = var.enable_cdn ? [1] : []
     content {
-      cache_mode                   = var.cdn_cache_mode
-      signed_url_cache_max_age_sec = var.cdn_signed_url_cache_max_age_sec
-      default_ttl                  = var.cdn_default_ttl
-      client_ttl                   = var.cdn_client_ttl
-      max_ttl                      = var.cdn_max_ttl
-      negative_caching             = var.cdn_negative_caching
+      cache_mode                   = "CACHE_ALL_STATIC"
+      signed_url_cache_max_age_sec = var.cdn_cache_ttl
+      default_ttl                  = var.cdn_cache_ttl
+      client_ttl                   = var.cdn_cache_ttl
+      max_ttl                      = var.cdn_cache_ttl
     }
   }
 }
@@ -104,12 +106,25 @@ resource "google_compute_url_map" "main" {
   }
 }
 
+# URL Map que redireciona o tráfego HTTP para HTTPS (opcional)
+resource "google_compute_url_map" "https_redirect" {
+  count       = var.enable_http && var.enable_https && var.redirect_http_to_https ? 1 : 0
+  name        = "${var.name}-https-redirect"
+  description = "Redirecionamento de HTTP para HTTPS para ${var.name}"
+
+  default_url_redirect {
+    https_redirect         = true
+    redirect_response_code = "MOVED_PERMANENTLY_DEFAULT"
+    strip_query            = false
+  }
+}
+
 # Proxy HTTP para o tráfego não-SSL
 resource "google_compute_target_http_proxy" "main" {
   count       = var.enable_http ? 1 : 0
   name        = "${var.name}-http-proxy"
   description = "Target HTTP Proxy para ${var.name}"
-  url_map     = google_compute_url_map.main.id
+  url_map     = length(google_compute_url_map.https_redirect) > 0 ? google_compute_url_map.https_redirect[0].id : google_compute_url_map.main.id
 }
 
 # Proxy HTTPS para o tráfego SSL
@@ -156,7 +171,7 @@ resource "google_compute_firewall" "health_check" {
 
   allow {
     protocol = "tcp"
-    ports    = [var.health_check_port]
+    ports    = [tostring(var.health_check.port)]
   }
 
   target_tags = var.target_tags
@@ -34,6 +34,12 @@ variable "create_external_ip" {
   default     = true
 }
 
+variable "enable_http" {
+  description = "Define se o load balancer aceita tráfego HTTP na porta 80"
+  type        = bool
+  default     = true
+}
+
 variable "enable_https" {
   description = "Define se o load balancer terá suporte a HTTPS"
   type        = bool
@@ -46,6 +52,12 @@ variable "ssl_certificates" {
   default     = []
 }
 
+variable "redirect_http_to_https" {
+  description = "Redireciona o tráfego HTTP para HTTPS (requer enable_http e enable_https)"
+  type        = bool
+  default     = false
+}
+
 variable "enable_cdn" {
   description = "Define se o CDN será habilitado para o load balancer"
   type        = bool
@@ -93,6 +105,44 @@ variable "backend_services" {
   default = []
 }
 
+variable "backends" {
+  description = "Instance groups (self links) que recebem o tráfego do backend service"
+  type = list(object({
+    group           = string
+    balancing_mode  = optional(string, "UTILIZATION")
+    capacity_scaler = optional(number, 1.0)
+  }))
+  default = []
+}
+
+variable "backend_port_name" {
+  description = "Nome da porta nomeada dos instance groups usada pelo backend service"
+  type        = string
+  default     = "http"
+}
+
+variable "backend_timeout_sec" {
+  description = "Tempo limite em segundos para respostas do backend"
+  type        = number
+  default     = 30
+}
+
+variable "session_affinity" {
+  description = "Afinidade de sessão do backend service (NONE, CLIENT_IP ou GENERATED_COOKIE)"
+  type        = string
+  default     = "NONE"
+  validation {
+    condition     = contains(["NONE", "CLIENT_IP", "GENERATED_COOKIE"], var.session_affinity)
+    error_message = "O valor para session_affinity deve ser NONE, CLIENT_IP ou GENERATED_COOKIE."
+  }
+}
+
+variable "affinity_cookie_ttl_sec" {
+  description = "Duração em segundos do cookie de afinidade quando session_affinity = GENERATED_COOKIE"
+  type        = number
+  default     = null
+}
+
 variable "instance_groups" {
   description = "Lista de grupos de instâncias a serem associados ao backend service"
   type = list(object({
@@ -113,6 +163,28 @@ variable "path_rules" {
   default = []
 }
 
+variable "host_rules" {
+  description = "Regras de host do URL map, cada uma apontando para um path matcher"
+  type = list(object({
+    hosts        = list(string)
+    path_matcher = string
+  }))
+  default = []
+}
+
+variable "path_matchers" {
+  description = "Path matchers do URL map; sem default_service, usa o backend service do módulo"
+  type = list(object({
+    name            = string
+    default_service = optional(string)
+    path_rules = optional(list(object({
+      paths   = list(string)
+      service = string
+    })), [])
+  }))
+  default = []
+}
+
 variable "ssl_policy" {
   description = "Nome da política SSL para o load balancer HTTPS"
   type        = string
@@ -135,6 +207,18 @@ variable "security_policy" {
   default     = null
 }
 
+variable "create_firewall_rule" {
+  description = "Cria a regra de firewall que libera os health checks do Google para os backends"
+  type        = bool
+  default     = true
+}
+
+variable "target_tags" {
+  description = "Tags de rede das instâncias liberadas pela regra de firewall dos health checks"
+  type        = list(string)
+  default     = []
+}
+
 variable "labels" {
   description = "Mapa de labels a serem aplicados nos recursos"
   type        = map(string)
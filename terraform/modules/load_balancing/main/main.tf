@@ -5,13 +5,66 @@
  * em múltiplos provedores de nuvem, incluindo Digital Ocean, AWS, GCP e Azure.
  * Dependendo do provedor especificado, o módulo chama a implementação específica
  * mantendo uma interface de entrada e saída padronizada.
+ *
+ * Recursos pedidos na interface que o provedor escolhido não implementa são
+ * rejeitados no plano (ver null_resource.feature_support), nunca ignorados.
  */
 
 locals {
-  is_digitalocean = var.cloud_provider == "digitalocean"
-  is_aws          = var.cloud_provider == "aws"
-  is_gcp          = var.cloud_provider == "gcp"
-  is_azure        = var.cloud_provider == "azure"
+  is_digitalocean = var.provider_name == "digitalocean"
+  is_aws          = var.provider_name == "aws"
+  is_gcp          = var.provider_name == "gcp"
+  is_azure        = var.provider_name == "azure"
+
+  # Classificação das regras de encaminhamento abstratas
+  http_rules        = [for rule in var.forwarding_rules : rule if contains(["http", "http2"], rule.entry_protocol)]
+  https_rules       = [for rule in var.forwarding_rules : rule if rule.entry_protocol == "https" && !rule.tls_passthrough]
+  passthrough_rules = [for rule in var.forwarding_rules : rule if rule.tls_passthrough]
+
+  certificate_id = try(coalesce(try(local.https_rules[0].certificate_id, null), var.ssl_certificate_id), "")
+  target_port    = try(var.forwarding_rules[0].target_port, 80)
+
+  # Grupos de destino usados pela AWS; sem grupos explícitos, um grupo padrão aponta para a primeira regra
+  default_target_group = {
+    name     = "default"
+    port     = local.target_port
+    protocol = try(var.forwarding_rules[0].target_protocol, "http")
+  }
+  target_groups = length(var.target_groups) > 0 ? [
+    for group in var.target_groups : { name = group.name, port = group.port, protocol = group.protocol }
+  ] : [local.default_target_group]
+
+  # Recursos da interface abstrata que cada implementação honra
+  supported_features = {
+    aws          = ["https", "sticky_sessions", "least_connections", "redirect_http_to_https"]
+    gcp          = ["https", "sticky_sessions", "redirect_http_to_https"]
+    azure        = ["tls_passthrough"]
+    digitalocean = ["https", "tls_passthrough", "sticky_sessions", "redirect_http_to_https"]
+  }
+
+  requested_features = compact([
+    length(local.https_rules) > 0 ? "https" : "",
+    length(local.passthrough_rules) > 0 ? "tls_passthrough" : "",
+    var.sticky_sessions.enabled ? "sticky_sessions" : "",
+    var.algorithm == "least_connections" ? "least_connections" : "",
+    var.redirect_http_to_https ? "redirect_http_to_https" : "",
+  ])
+
+  unsupported_features = sort(tolist(setsubtract(local.requested_features, local.supported_features[var.provider_name])))
+}
+
+# Falha no plano quando a entrada pede um recurso que o provedor não implementa:
+# - azure: o Load Balancer é L4, sem terminação TLS, cookies ou redirecionamento
+# - gcp: o esquema EXTERNAL clássico não aceita locality_lb_policy nem TLS passthrough
+# - aws: o ALB termina TLS e não repassa a conexão
+# - digitalocean: a API ignora o campo algorithm (obsoleto)
+resource "null_resource" "feature_support" {
+  lifecycle {
+    precondition {
+      condition     = length(local.unsupported_features) == 0
+      error_message = "Recursos não suportados pelo provedor ${var.provider_name}: ${join(", ", local.unsupported_features)}."
+    }
+  }
 }
 
 # Load Balancer para Digital Ocean
@@ -21,17 +74,40 @@ module "digitalocean_lb" {
   # Só cria recursos se o provedor for Digital Ocean
   count = local.is_digitalocean ? 1 : 0
 
-  name                     = var.name
-  region                   = var.region
-  algorithm                = var.algorithm
-  forwarding_rules         = var.forwarding_rules
-  healthcheck              = var.healthcheck
-  droplet_ids              = var.target_nodes
-  vpc_uuid                 = var.vpc_id
+  project_name = var.name
+  environment  = var.environment
+  region       = var.region
+  vpc_uuid     = var.vpc_id
+  droplet_ids  = var.droplet_ids
+
+  forwarding_rules = [for rule in var.forwarding_rules : {
+    entry_port      = rule.entry_port
+    entry_protocol  = rule.entry_protocol
+    target_port     = rule.target_port
+    target_protocol = rule.target_protocol
+    tls_passthrough = rule.tls_passthrough
+    certificate_id  = rule.entry_protocol == "https" && !rule.tls_passthrough ? try(coalesce(rule.certificate_id, var.ssl_certificate_id), null) : null
+  }]
+
+  healthcheck = {
+    protocol                 = var.healthcheck.protocol
+    port                     = var.healthcheck.port
+    path                     = var.healthcheck.path
+    check_interval_seconds   = var.healthcheck.check_interval_sec
+    response_timeout_seconds = var.healthcheck.timeout_sec
+    unhealthy_threshold      = var.healthcheck.unhealthy_threshold
+    healthy_threshold        = var.healthcheck.healthy_threshold
+  }
+
+  sticky_sessions = {
+    type               = var.sticky_sessions.enabled ? var.sticky_sessions.type : "none"
+    cookie_name        = var.sticky_sessions.enabled ? var.sticky_sessions.cookie_name : null
+    cookie_ttl_seconds = var.sticky_sessions.enabled ? var.sticky_sessions.cookie_ttl_seconds : null
+  }
+
   redirect_http_to_https   = var.redirect_http_to_https
   enable_proxy_protocol    = var.enable_proxy_protocol
   enable_backend_keepalive = var.enable_backend_keepalive
-  tags                     = var.tags
 }
 
 # Load Balancer para AWS
@@ -41,25 +117,53 @@ module "aws_lb" {
   # Só cria recursos se o provedor for AWS
   count = local.is_aws ? 1 : 0
 
-  name                             = var.name
-  vpc_id                           = var.vpc_id
-  subnets                          = var.subnets
-  security_groups                  = var.security_groups
-  internal                         = var.internal
-  target_port                      = var.target_port
-  target_protocol                  = var.target_protocol
-  listener_port                    = var.listener_port
-  listener_protocol                = var.listener_protocol
-  health_check_path                = var.health_check_path
-  health_check_port                = var.health_check_port
-  health_check_protocol            = var.health_check_protocol
-  health_check_timeout             = var.health_check_timeout
-  health_check_interval            = var.health_check_interval
-  health_check_healthy_threshold   = var.health_check_healthy_threshold
-  health_check_unhealthy_threshold = var.health_check_unhealthy_threshold
-  target_ids                       = var.target_nodes
-  certificate_arn                  = var.certificate_arn
-  tags                             = var.tags
+  name                       = var.name
+  environment                = var.environment
+  vpc_id                     = var.vpc_id
+  subnet_ids                 = var.subnet_ids
+  internal                   = var.internal
+  idle_timeout               = var.idle_timeout
+  enable_deletion_protection = var.enable_deletion_protection
+
+  http_enabled    = length(local.http_rules) > 0
+  http_port       = try(local.http_rules[0].entry_port, 80)
+  https_enabled   = length(local.https_rules) > 0
+  https_port      = try(local.https_rules[0].entry_port, 443)
+  http_redirect   = var.redirect_http_to_https
+  certificate_arn = local.certificate_id
+
+  target_groups = [for group in local.target_groups : {
+    name     = group.name
+    port     = group.port
+    protocol = upper(group.protocol)
+    health_check = {
+      interval            = var.healthcheck.check_interval_sec
+      path                = var.healthcheck.path
+      port                = tostring(var.healthcheck.port)
+      protocol            = upper(var.healthcheck.protocol)
+      timeout             = var.healthcheck.timeout_sec
+      healthy_threshold   = var.healthcheck.healthy_threshold
+      unhealthy_threshold = var.healthcheck.unhealthy_threshold
+    }
+  }]
+
+  stickiness = {
+    enabled         = var.sticky_sessions.enabled
+    type            = "lb_cookie"
+    cookie_duration = var.sticky_sessions.cookie_ttl_seconds
+  }
+
+  # least_outstanding_requests é o equivalente do ALB a least_connections
+  load_balancing_algorithm_type = var.algorithm == "least_connections" ? "least_outstanding_requests" : "round_robin"
+
+  access_logs = {
+    enabled        = var.access_logs.enabled
+    bucket         = var.access_logs.bucket
+    prefix         = var.access_logs.prefix
+    retention_days = var.access_logs.retention_days
+  }
+
+  tags = var.tags
 }
 
 # Load Balancer para GCP
@@ -69,22 +173,35 @@ module "gcp_lb" {
   # Só cria recursos se o provedor for GCP
   count = local.is_gcp ? 1 : 0
 
-  name             = var.name
-  project          = var.project
-  network          = var.network
-  subnetwork       = var.subnetwork
-  region           = var.region
-  target_tags      = var.target_tags
-  backend_services = var.backend_services
-  health_check     = var.health_check_config
-  ssl              = var.ssl
-  enable_cdn       = var.enable_cdn
-  target_instances = var.target_nodes
-  target_port      = var.target_port
-  certificate      = var.certificate
-  private_key      = var.private_key
-  domains          = var.domains
-  timeout_sec      = var.timeout_sec
+  project_id  = var.gcp_project_id
+  name        = var.name
+  region      = var.region
+  network     = var.gcp_network
+  subnetwork  = var.gcp_subnetwork
+  environment = var.environment
+
+  enable_http            = length(local.http_rules) > 0
+  enable_https           = length(local.https_rules) > 0
+  ssl_certificates       = local.certificate_id != "" ? [local.certificate_id] : []
+  redirect_http_to_https = var.redirect_http_to_https
+
+  health_check = {
+    port                = var.healthcheck.port
+    protocol            = upper(var.healthcheck.protocol)
+    request_path        = var.healthcheck.path
+    check_interval_sec  = var.healthcheck.check_interval_sec
+    timeout_sec         = var.healthcheck.timeout_sec
+    healthy_threshold   = var.healthcheck.healthy_threshold
+    unhealthy_threshold = var.healthcheck.unhealthy_threshold
+  }
+
+  # No GCP os destinos são instance groups, informados em target_groups[*].targets
+  backends = flatten([for group in var.target_groups : [for target in group.targets : { group = target }]])
+
+  session_affinity        = var.sticky_sessions.enabled ? "GENERATED_COOKIE" : "NONE"
+  affinity_cookie_ttl_sec = var.sticky_sessions.enabled ? var.sticky_sessions.cookie_ttl_seconds : null
+
+  labels = var.tags
 }
 
 # Load Balancer para Azure
@@ -94,23 +211,24 @@ module "azure_lb" {
   # Só cria recursos se o provedor for Azure
   count = local.is_azure ? 1 : 0
 
-  name                    = var.name
-  resource_group_name     = var.resource_group_name
-  location                = var.location
-  sku                     = var.sku
-  allocation_method       = var.allocation_method
-  subnet_id               = var.subnet_id
-  frontend_port           = var.frontend_port
-  backend_port            = var.backend_port
-  protocol                = var.protocol
-  enable_floating_ip      = var.enable_floating_ip
-  idle_timeout_in_minutes = var.idle_timeout_in_minutes
-  probe_protocol          = var.probe_protocol
-  probe_interval          = var.probe_interval
-  probe_port              = var.probe_port
-  probe_path              = var.probe_path
-  target_vm_ids           = var.target_nodes
-  certificate             = var.certificate
-  certificate_password    = var.certificate_password
-  tags                    = var.tags
+  name                 = var.name
+  resource_group_name  = var.resource_group_name
+  location             = var.azure_location
+  virtual_network_name = var.azure_virtual_network_name
+  subnet_name          = var.azure_subnet_name
+  lb_sku               = var.azure_sku
+
+  enable_http = length(local.http_rules) > 0
+  http_port   = try(local.http_rules[0].target_port, 80)
+
+  # O Load Balancer do Azure é L4: a regra de 443 repassa a conexão TLS sem terminá-la
+  enable_https = length(local.passthrough_rules) > 0
+  https_port   = try(local.passthrough_rules[0].target_port, 443)
+
+  health_probe_path                = var.healthcheck.path
+  health_probe_interval            = var.healthcheck.check_interval_sec
+  health_probe_unhealthy_threshold = var.healthcheck.unhealthy_threshold
+  idle_timeout_in_minutes          = max(4, ceil(var.idle_timeout / 60))
+
+  tags = var.tags
 }
@@ -4,76 +4,65 @@
 
 output "load_balancer_id" {
   description = "ID único do load balancer criado, independente do provedor de nuvem"
-  value       = local.selected_lb_outputs.load_balancer_id
+  value       = one(concat(module.digitalocean_lb[*].load_balancer_id, module.aws_lb[*].alb_id, module.gcp_lb[*].load_balancer_id, module.azure_lb[*].load_balancer_id))
 }
 
 output "load_balancer_ip" {
   description = "Endereço IP do load balancer criado"
-  value       = local.selected_lb_outputs.load_balancer_ip
+  value       = one(concat(module.digitalocean_lb[*].load_balancer_ip, module.gcp_lb[*].load_balancer_ip_address, module.azure_lb[*].load_balancer_ip))
 }
 
 output "load_balancer_hostname" {
   description = "Nome de host (FQDN) do load balancer criado, se disponível"
-  value       = local.selected_lb_outputs.load_balancer_hostname
+  value       = one(concat(module.digitalocean_lb[*].load_balancer_hostname, module.aws_lb[*].alb_dns_name, module.azure_lb[*].load_balancer_fqdn))
 }
 
 output "load_balancer_status" {
   description = "Status atual do load balancer (ativo, em criação, etc.)"
-  value       = local.selected_lb_outputs.load_balancer_status
+  value       = one(module.digitalocean_lb[*].load_balancer_status)
 }
 
 output "http_port" {
   description = "Porta HTTP configurada no load balancer"
-  value       = local.selected_lb_outputs.http_port
+  value       = try(local.http_rules[0].entry_port, null)
 }
 
 output "https_port" {
   description = "Porta HTTPS configurada no load balancer, se habilitada"
-  value       = local.selected_lb_outputs.https_port
+  value       = try(coalesce(try(local.https_rules[0].entry_port, null), try(local.passthrough_rules[0].entry_port, null)), null)
 }
 
 output "protocol" {
   description = "Protocolo(s) suportado(s) pelo load balancer (HTTP, HTTPS, TCP)"
-  value       = local.selected_lb_outputs.protocol
+  value       = distinct([for rule in var.forwarding_rules : upper(rule.entry_protocol)])
 }
 
 output "tls_certificate_id" {
   description = "ID do certificado TLS associado ao load balancer, se habilitado HTTPS"
-  value       = local.selected_lb_outputs.tls_certificate_id
+  value       = local.certificate_id != "" ? local.certificate_id : null
 }
 
 output "health_check_path" {
   description = "Caminho configurado para health check dos serviços"
-  value       = local.selected_lb_outputs.health_check_path
+  value       = var.healthcheck.path
 }
 
 output "target_port" {
   description = "Porta de destino para qual o tráfego é encaminhado"
-  value       = local.selected_lb_outputs.target_port
+  value       = local.target_port
 }
 
 output "target_protocol" {
   description = "Protocolo utilizado para comunicação com os alvos do load balancer"
-  value       = local.selected_lb_outputs.target_protocol
+  value       = try(var.forwarding_rules[0].target_protocol, null)
 }
 
 output "region" {
   description = "Região onde o load balancer foi provisionado"
-  value       = local.selected_lb_outputs.region
+  value       = var.region
 }
 
 output "tags" {
   description = "Tags associadas ao load balancer"
-  value       = local.selected_lb_outputs.tags
+  value       = var.tags
 }
-
-locals {
-  # Configuração para selecionar outputs com base no provedor escolhido
-  selected_lb_outputs = {
-    "digitalocean" = var.cloud_provider == "digitalocean" ? module.do_lb[0] : null
-    "aws"          = var.cloud_provider == "aws" ? module.aws_lb[0] : null
-    "gcp"          = var.cloud_provider == "gcp" ? module.gcp_lb[0] : null
-    "azure"        = var.cloud_provider == "azure" ? module.azure_lb[0] : null
-  }[var.cloud_provider]
-}
-
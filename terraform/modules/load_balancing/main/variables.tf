@@ -22,6 +22,12 @@ variable "region" {
   type        = string
 }
 
+variable "environment" {
+  description = "Ambiente onde o load balancer será implantado (dev, staging, prod)"
+  type        = string
+  default     = "dev"
+}
+
 variable "vpc_id" {
   description = "ID da VPC onde o load balancer será implantado"
   type        = string
@@ -60,9 +66,13 @@ variable "target_groups" {
 # ---------------------------------------------------------------------------------------------------------------------
 
 variable "algorithm" {
-  description = "Algoritmo de balanceamento a ser utilizado (round_robin, least_connections, etc)"
+  description = "Algoritmo de balanceamento a ser utilizado (round_robin, least_connections)"
   type        = string
   default     = "round_robin"
+  validation {
+    condition     = contains(["round_robin", "least_connections"], var.algorithm)
+    error_message = "O valor de algorithm deve ser 'round_robin' ou 'least_connections'."
+  }
 }
 
 variable "forwarding_rules" {
@@ -210,6 +220,29 @@ variable "firewall_rules" {
   default = []
 }
 
+# ---------------------------------------------------------------------------------------------------------------------
+# VARIÁVEIS ESPECÍFICAS PARA GCP
+# Estas variáveis são necessárias apenas quando provider_name = "gcp"
+# ---------------------------------------------------------------------------------------------------------------------
+
+variable "gcp_project_id" {
+  description = "ID do projeto GCP onde o load balancer será criado (somente GCP)"
+  type        = string
+  default     = ""
+}
+
+variable "gcp_network" {
+  description = "Nome da rede VPC usada pela regra de firewall dos health checks (somente GCP)"
+  type        = string
+  default     = "default"
+}
+
+variable "gcp_subnetwork" {
+  description = "Nome da sub-rede do load balancer (somente GCP)"
+  type        = string
+  default     = ""
+}
+
 # ---------------------------------------------------------------------------------------------------------------------
 # VARIÁVEIS ESPECÍFICAS PARA AZURE
 # Estas variáveis são necessárias apenas quando provider_name = "azure"
@@ -306,3 +339,15 @@ variable "azure_frontend_ip_configuration" {
     private_ip_allocation = "Dynamic"
   }
 }
+
+variable "azure_virtual_network_name" {
+  description = "Nome da rede virtual do load balancer (somente Azure)"
+  type        = string
+  default     = ""
+}
+
+variable "azure_subnet_name" {
+  description = "Nome da sub-rede do load balancer (somente Azure)"
+  type        = string
+  default     = ""
+}
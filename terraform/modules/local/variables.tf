@@ -9,6 +9,12 @@ variable "project_name" {
   type        = string
 }
 
+variable "environment" {
+  description = "Ambiente (local, test...), usado nos nomes dos contêineres"
+  type        = string
+  default     = "local"
+}
+
 variable "docker_host" {
   description = "The Docker host to connect to"
   type        = string
@@ -21,58 +27,115 @@ variable "network_name" {
   default     = "nestjs-local-network"
 }
 
-variable "data_volume_name" {
-  description = "Name of the Docker volume for database data"
-  type        = string
-  default     = "nestjs-db-data"
-}
-
-variable "database_image" {
-  description = "Docker image for the database"
-  type        = string
-  default     = "postgres:14"
+# PostgreSQL
+variable "create_database" {
+  description = "Whether to create the PostgreSQL container"
+  type        = bool
+  default     = true
 }
 
-variable "app_image" {
-  description = "Docker image for the application"
+variable "postgres_version" {
+  description = "PostgreSQL image tag"
   type        = string
-  default     = "node:18-alpine"
+  default     = "14"
 }
 
-variable "db_username" {
+variable "postgres_user" {
   description = "Database username"
   type        = string
   default     = "postgres"
 }
 
-variable "db_password" {
+variable "postgres_password" {
   description = "Database password"
   type        = string
   default     = "postgres"
   sensitive   = true
 }
 
-variable "db_name" {
+variable "postgres_db" {
   description = "Database name"
   type        = string
   default     = "nestjs"
 }
 
-variable "db_port" {
+variable "postgres_port" {
   description = "External port for the database"
   type        = number
   default     = 5432
 }
 
-variable "app_port" {
+# Aplicação
+variable "create_app" {
+  description = "Whether to deploy the application container"
+  type        = bool
+  default     = true
+}
+
+variable "app_image" {
+  description = "Docker image for the application"
+  type        = string
+  default     = "node:18-alpine"
+}
+
+variable "app_internal_port" {
+  description = "Port the application listens on inside the container"
+  type        = number
+  default     = 3000
+}
+
+variable "app_external_port" {
   description = "External port for the application"
   type        = number
   default     = 3000
 }
 
-variable "deploy_app" {
-  description = "Whether to deploy the application container"
+variable "additional_environment_vars" {
+  description = "Additional environment variables for the application (KEY=value)"
+  type        = list(string)
+  default     = []
+}
+
+# Serviços adicionais
+variable "create_redis" {
+  description = "Whether to create the Redis container"
   type        = bool
-  default     = true
+  default     = false
+}
+
+variable "redis_version" {
+  description = "Redis image tag"
+  type        = string
+  default     = "7"
+}
+
+variable "redis_port" {
+  description = "External port for Redis"
+  type        = number
+  default     = 6379
 }
 
+variable "create_pgadmin" {
+  description = "Whether to create the pgAdmin container"
+  type        = bool
+  default     = false
+}
+
+variable "pgadmin_email" {
+  description = "pgAdmin login email"
+  type        = string
+  default     = "admin@example.com"
+}
+
+variable "pgadmin_password" {
+  description = "pgAdmin login password"
+  type        = string
+  default     = "admin"
+  sensitive   = true
+}
+
+variable "pgadmin_port" {
+  description = "External port for pgAdmin"
+  type        = number
+  default     = 5050
+}
@@ -0,0 +1,10 @@
+# As versões dos providers continuam centralizadas no versions.tf da raiz; este bloco só declara a
+# origem do provider docker (kreuzwerker, e não hashicorp), necessária quando os testes aplicam o
+# módulo diretamente como raiz
+terraform {
+  required_providers {
+    docker = {
+      source = "kreuzwerker/docker"
+    }
+  }
+}
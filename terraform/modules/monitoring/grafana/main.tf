@@ -29,6 +29,18 @@ variable "grafana_version" {
   default     = "9.3.6"
 }
 
+variable "chart" {
+  description = "Chart do Grafana: nome no repositório ou caminho de um chart local (testes offline)"
+  type        = string
+  default     = "grafana"
+}
+
+variable "chart_repository" {
+  description = "Repositório do chart do Grafana; null quando chart é um caminho local"
+  type        = string
+  default     = "https://grafana.github.io/helm-charts"
+}
+
 variable "retention_days" {
   description = "Número de dias para retenção de dados"
   type        = number
@@ -40,6 +52,28 @@ variable "prometheus_url" {
   type        = string
 }
 
+variable "alert_threshold_cpu" {
+  description = "Percentual de CPU que dispara alertas e marca o limiar crítico nos dashboards (monitoring.alert_threshold_cpu)"
+  type        = number
+  default     = 80
+
+  validation {
+    condition     = var.alert_threshold_cpu > 10 && var.alert_threshold_cpu <= 100
+    error_message = "alert_threshold_cpu deve estar entre 10 (exclusive) e 100; o limiar de atenção dos dashboards fica 10 pontos abaixo."
+  }
+}
+
+variable "alert_threshold_memory" {
+  description = "Percentual de memória que dispara alertas e marca o limiar crítico nos dashboards (monitoring.alert_threshold_memory)"
+  type        = number
+  default     = 80
+
+  validation {
+    condition     = var.alert_threshold_memory > 10 && var.alert_threshold_memory <= 100
+    error_message = "alert_threshold_memory deve estar entre 10 (exclusive) e 100; o limiar de atenção dos dashboards fica 10 pontos abaixo."
+  }
+}
+
 variable "alert_notification_channels" {
   description = "Canais de notificação de alertas (email, slack, etc)"
   type = list(object({
@@ -50,6 +84,12 @@ variable "alert_notification_channels" {
   default = []
 }
 
+# Limiar de atenção (amarelo) dos dashboards, sempre abaixo do crítico (vermelho) definido pelo alerta
+locals {
+  warning_threshold_cpu    = var.alert_threshold_cpu - 10
+  warning_threshold_memory = var.alert_threshold_memory - 10
+}
+
 # Cria namespace se não existir
 resource "kubernetes_namespace" "monitoring" {
   count = var.kubernetes_namespace == "monitoring" ? 1 : 0
@@ -71,8 +111,8 @@ resource "kubernetes_namespace" "monitoring" {
 # Configura o Grafana via Helm chart
 resource "helm_release" "grafana" {
   name       = "grafana"
-  repository = "https://grafana.github.io/helm-charts"
-  chart      = "grafana"
+  repository = var.chart_repository
+  chart      = var.chart
   version    = var.grafana_version
   namespace  = var.kubernetes_namespace
 
@@ -171,6 +211,7 @@ resource "kubernetes_config_map" "grafana_datasources" {
       apiVersion: 1
       datasources:
       - name: Prometheus
+        uid: prometheus
         type: prometheus
         url: ${var.prometheus_url}
         access: proxy
@@ -218,8 +259,12 @@ resource "kubernetes_config_map" "infrastructure_dashboard" {
 
   data = {
     "infrastructure-overview.json" = templatefile("${path.module}/templates/infrastructure-dashboard.json", {
-      project_name = var.project_name
-      environment  = var.environment
+      project_name             = var.project_name
+      environment              = var.environment
+      alert_threshold_cpu      = var.alert_threshold_cpu
+      alert_threshold_memory   = var.alert_threshold_memory
+      warning_threshold_cpu    = local.warning_threshold_cpu
+      warning_threshold_memory = local.warning_threshold_memory
     })
   }
 
@@ -235,8 +280,12 @@ resource "kubernetes_config_map" "kubernetes_dashboard" {
 
   data = {
     "kubernetes-cluster.json" = templatefile("${path.module}/templates/kubernetes-dashboard.json", {
-      project_name = var.project_name
-      environment  = var.environment
+      project_name             = var.project_name
+      environment              = var.environment
+      alert_threshold_cpu      = var.alert_threshold_cpu
+      alert_threshold_memory   = var.alert_threshold_memory
+      warning_threshold_cpu    = local.warning_threshold_cpu
+      warning_threshold_memory = local.warning_threshold_memory
     })
   }
 
@@ -252,8 +301,10 @@ resource "kubernetes_config_map" "database_dashboard" {
 
   data = {
     "database-monitoring.json" = templatefile("${path.module}/templates/database-dashboard.json", {
-      project_name = var.project_name
-      environment  = var.environment
+      project_name           = var.project_name
+      environment            = var.environment
+      alert_threshold_cpu    = var.alert_threshold_cpu
+      alert_threshold_memory = var.alert_threshold_memory
     })
   }
 
@@ -286,8 +337,10 @@ resource "kubernetes_config_map" "grafana_alerts" {
 
   data = {
     "alerts.yaml" = templatefile("${path.module}/templates/alerts.yaml", {
-      project_name = var.project_name
-      environment  = var.environment
+      project_name           = var.project_name
+      environment            = var.environment
+      alert_threshold_cpu    = var.alert_threshold_cpu
+      alert_threshold_memory = var.alert_threshold_memory
     })
   }
 
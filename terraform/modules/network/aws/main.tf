@@ -20,12 +20,20 @@ resource "aws_internet_gateway" "main" {
   })
 }
 
+locals {
+  private_subnet_offset = coalesce(var.private_subnet_offset, length(var.availability_zones))
+}
+
 # Subnets públicas em zonas de disponibilidade diferentes
+# Layout: públicas em cidrsubnet(vpc_cidr, 8, 0..n-1) e privadas em cidrsubnet(vpc_cidr, 8, o..o+n-1),
+# com n = subnet_count e o = private_subnet_offset; as zonas são reaproveitadas em ordem quando n é
+# maior que a lista. O offset não acompanha subnet_count para que mudar a contagem não troque o
+# endereço (e recrie) as subnets privadas existentes
 resource "aws_subnet" "public" {
-  count                   = length(var.availability_zones)
+  count                   = var.subnet_count
   vpc_id                  = aws_vpc.main.id
   cidr_block              = cidrsubnet(var.vpc_cidr, 8, count.index)
-  availability_zone       = var.availability_zones[count.index]
+  availability_zone       = element(var.availability_zones, count.index)
   map_public_ip_on_launch = true
 
   tags = merge(var.tags, {
@@ -36,10 +44,17 @@ resource "aws_subnet" "public" {
 
 # Subnets privadas em zonas de disponibilidade diferentes
 resource "aws_subnet" "private" {
-  count             = length(var.availability_zones)
+  count             = var.subnet_count
   vpc_id            = aws_vpc.main.id
-  cidr_block        = cidrsubnet(var.vpc_cidr, 8, count.index + length(var.availability_zones))
-  availability_zone = var.availability_zones[count.index]
+  cidr_block        = cidrsubnet(var.vpc_cidr, 8, count.index + local.private_subnet_offset)
+  availability_zone = element(var.availability_zones, count.index)
+
+  lifecycle {
+    precondition {
+      condition     = local.private_subnet_offset >= var.subnet_count && local.private_subnet_offset + var.subnet_count <= 256
+      error_message = "As subnets privadas (netnum ${local.private_subnet_offset} a ${local.private_subnet_offset + var.subnet_count - 1}) sobrepõem as públicas (0 a ${var.subnet_count - 1}) ou passam de 255; ajuste private_subnet_offset."
+    }
+  }
 
   tags = merge(var.tags, {
     Name                              = "${var.project_name}-${var.environment}-private-subnet-${count.index + 1}"
@@ -49,7 +64,7 @@ resource "aws_subnet" "private" {
 
 # NAT Gateway para cada subnet privada (opcional)
 resource "aws_eip" "nat" {
-  count  = var.create_nat_gateway ? length(var.availability_zones) : 0
+  count  = var.create_nat_gateway ? var.subnet_count : 0
   domain = "vpc"
 
   tags = merge(var.tags, {
@@ -58,7 +73,7 @@ resource "aws_eip" "nat" {
 }
 
 resource "aws_nat_gateway" "main" {
-  count         = var.create_nat_gateway ? length(var.availability_zones) : 0
+  count         = var.create_nat_gateway ? var.subnet_count : 0
   allocation_id = aws_eip.nat[count.index].id
   subnet_id     = aws_subnet.public[count.index].id
 
@@ -85,7 +100,7 @@ resource "aws_route_table" "public" {
 
 # Route table para subnets privadas
 resource "aws_route_table" "private" {
-  count  = length(var.availability_zones)
+  count  = var.subnet_count
   vpc_id = aws_vpc.main.id
 
   dynamic "route" {
@@ -103,14 +118,14 @@ resource "aws_route_table" "private" {
 
 # Associações de route table para subnets públicas
 resource "aws_route_table_association" "public" {
-  count          = length(var.availability_zones)
+  count          = var.subnet_count
   subnet_id      = aws_subnet.public[count.index].id
   route_table_id = aws_route_table.public.id
 }
 
 # Associações de route table para subnets privadas
 resource "aws_route_table_association" "private" {
-  count          = length(var.availability_zones)
+  count          = var.subnet_count
   subnet_id      = aws_subnet.private[count.index].id
   route_table_id = aws_route_table.private[count.index].id
 }
@@ -18,6 +18,16 @@ output "private_subnet_ids" {
   value       = aws_subnet.private.*.id
 }
 
+output "public_subnet_cidrs" {
+  description = "CIDR blocks das subnets públicas"
+  value       = aws_subnet.public.*.cidr_block
+}
+
+output "private_subnet_cidrs" {
+  description = "CIDR blocks das subnets privadas"
+  value       = aws_subnet.private.*.cidr_block
+}
+
 output "default_security_group_id" {
   description = "ID do grupo de segurança padrão"
   value       = aws_security_group.default.id
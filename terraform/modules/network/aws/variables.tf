@@ -18,12 +18,33 @@ variable "vpc_cidr" {
   description = "CIDR block para a VPC"
   type        = string
   default     = "10.0.0.0/16"
+
+  validation {
+    condition     = can(cidrsubnet(var.vpc_cidr, 8, 0))
+    error_message = "vpc_cidr deve ser um CIDR IPv4 com prefixo de no máximo /24 (cada subnet usa 8 bits a mais que o prefixo da VPC)."
+  }
 }
 
 variable "subnet_count" {
   description = "Número de subnets (públicas e privadas) a serem criadas"
   type        = number
   default     = 3
+
+  validation {
+    condition     = var.subnet_count >= 1 && var.subnet_count <= 128
+    error_message = "subnet_count deve estar entre 1 e 128 (públicas e privadas dividem as 256 subnets de 8 bits a mais que o prefixo de vpc_cidr)."
+  }
+}
+
+variable "private_subnet_offset" {
+  description = "Índice (netnum de cidrsubnet) da primeira subnet privada; null usa o número de zonas de availability_zones, o layout original do módulo"
+  type        = number
+  default     = null
+
+  validation {
+    condition     = var.private_subnet_offset == null ? true : var.private_subnet_offset >= 1 && var.private_subnet_offset <= 255
+    error_message = "private_subnet_offset deve estar entre 1 e 255."
+  }
 }
 
 variable "availability_zones" {
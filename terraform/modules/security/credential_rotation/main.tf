@@ -13,7 +13,10 @@ import json
 import os
 import requests
 import logging
+import time
+import urllib.request
 from datetime import datetime
+from urllib.parse import urlparse
 
 # Configuração de logging
 logger = logging.getLogger()
@@ -23,6 +26,18 @@ logger.setLevel(logging.INFO)
 DIGITALOCEAN_API_URL = "https://api.digitalocean.com/v2"
 SECRET_NAME = os.environ['SECRET_NAME']
 NOTIFICATION_TOPIC = os.environ.get('SNS_TOPIC_ARN', '')
+NOTIFICATION_WEBHOOK_URL = os.environ.get('NOTIFICATION_WEBHOOK_URL', '')
+PROJECT_NAME = os.environ.get('PROJECT_NAME', '')
+ENVIRONMENT = os.environ.get('ENVIRONMENT', '')
+NOTIFICATION_TITLE = "Rotação de credenciais"
+
+# Serviço do webhook pelo host; hosts iniciados por ponto aceitam subdomínios. Receptores locais
+# usam o serviço como primeiro segmento do caminho (http://127.0.0.1:8080/slack)
+WEBHOOK_HOSTS = [
+    ("hooks.slack.com", "slack"),
+    (".webhook.office.com", "teams"),
+    (".logic.azure.com", "teams"),
+]
 
 def get_secret():
     """Recupera o segredo atual do AWS Secrets Manager"""
@@ -35,14 +50,15 @@ def get_secret():
         raise
 
 def update_secret(secret_data):
-    """Atualiza o segredo no AWS Secrets Manager"""
+    """Atualiza o segredo no AWS Secrets Manager e retorna a nova versão"""
     secrets_client = boto3.client('secretsmanager')
     try:
-        secrets_client.update_secret(
+        response = secrets_client.update_secret(
             SecretId=SECRET_NAME,
             SecretString=json.dumps(secret_data)
         )
         logger.info(f"Segredo atualizado com sucesso: {SECRET_NAME}")
+        return response.get("VersionId", "")
     except Exception as e:
         logger.error(f"Erro ao atualizar segredo: {str(e)}")
         raise
@@ -103,22 +119,95 @@ def revoke_digitalocean_token(token_id, current_token):
         logger.error(f"Erro na solicitação: {str(e)}")
         return False
 
-def send_notification(message, subject="Rotação de Credenciais DigitalOcean"):
-    """Envia notificação pelo SNS"""
-    if not NOTIFICATION_TOPIC:
-        logger.info("Tópico SNS não configurado, pulando notificação")
-        return
-        
-    sns_client = boto3.client('sns')
-    try:
-        sns_client.publish(
-            TopicArn=NOTIFICATION_TOPIC,
-            Message=message,
-            Subject=subject
-        )
-        logger.info("Notificação enviada com sucesso")
-    except Exception as e:
-        logger.error(f"Erro ao enviar notificação: {str(e)}")
+def webhook_target(url):
+    """Deduz o serviço (slack ou teams) pela URL do webhook"""
+    parsed = urlparse(url)
+    host = (parsed.hostname or "").lower()
+    for known, target in WEBHOOK_HOSTS:
+        if host == known or (known.startswith(".") and host.endswith(known)):
+            return target
+    segment = parsed.path.lstrip("/").split("/")[0]
+    if segment in ("slack", "teams"):
+        return segment
+    return None
+
+def notification_headline(severity):
+    """Primeira linha do aviso, no formato das demais notificações do projeto"""
+    status = "INFO" if severity == "info" else "FIRING"
+    return f"[{status}] {NOTIFICATION_TITLE}"
+
+def render_notification(target, summary, severity, fields):
+    """Monta o payload do Slack (attachments) ou do Teams (Adaptive Card)"""
+    headline = notification_headline(severity)
+    context = " · ".join(part for part in [PROJECT_NAME, ENVIRONMENT, "credential_rotation"] if part)
+
+    if target == "slack":
+        return {
+            "text": headline,
+            "attachments": [{
+                "fallback": f"{headline}: {summary}",
+                "color": "#439FE0" if severity == "info" else "danger",
+                "title": summary,
+                "text": "",
+                "fields": [{"title": name, "value": value, "short": len(value) <= 40} for name, value in fields],
+                "footer": context,
+                "ts": int(time.time()),
+            }],
+        }
+
+    facts = [{"title": name, "value": value} for name, value in fields]
+    facts.append({"title": "Origem", "value": context})
+    return {
+        "type": "message",
+        "attachments": [{
+            "contentType": "application/vnd.microsoft.card.adaptive",
+            "content": {
+                "$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+                "type": "AdaptiveCard",
+                "version": "1.4",
+                "body": [
+                    {"type": "TextBlock", "text": headline, "weight": "Bolder", "size": "Medium",
+                     "color": "Accent" if severity == "info" else "Attention", "wrap": True},
+                    {"type": "TextBlock", "text": summary, "wrap": True},
+                    {"type": "FactSet", "facts": facts},
+                ],
+            },
+        }],
+    }
+
+def send_notification(summary, severity="info", fields=None):
+    """Envia a notificação ao webhook do Slack ou do Teams e ao tópico SNS, quando configurados"""
+    fields = fields or []
+
+    if NOTIFICATION_WEBHOOK_URL:
+        target = webhook_target(NOTIFICATION_WEBHOOK_URL)
+        if target is None:
+            logger.error("Webhook de notificação não é do Slack nem do Teams, pulando notificação")
+        else:
+            payload = json.dumps(render_notification(target, summary, severity, fields)).encode("utf-8")
+            request = urllib.request.Request(
+                NOTIFICATION_WEBHOOK_URL,
+                data=payload,
+                headers={"Content-Type": "application/json"},
+                method="POST"
+            )
+            try:
+                with urllib.request.urlopen(request, timeout=10) as response:
+                    logger.info(f"Notificação enviada ao {target}: {response.status}")
+            except Exception as e:
+                logger.error(f"Erro ao enviar notificação ao webhook: {str(e)}")
+
+    if NOTIFICATION_TOPIC:
+        sns_client = boto3.client('sns')
+        try:
+            sns_client.publish(
+                TopicArn=NOTIFICATION_TOPIC,
+                Message=summary,
+                Subject=notification_headline(severity)
+            )
+            logger.info("Notificação enviada ao SNS")
+        except Exception as e:
+            logger.error(f"Erro ao enviar notificação ao SNS: {str(e)}")
 
 def lambda_handler(event, context):
     """Função principal que gerencia a rotação de credenciais"""
@@ -140,15 +229,17 @@ def lambda_handler(event, context):
         secret_data["last_rotated"] = datetime.now().isoformat()
         
         # Guarda o segredo atualizado
-        update_secret(secret_data)
+        version = update_secret(secret_data)
         
         # Revoga o token antigo (se houver ID)
-        if current_token_id:
-            revoke_digitalocean_token(current_token_id, new_token)
+        revoked = []
+        if current_token_id and revoke_digitalocean_token(current_token_id, new_token):
+            revoked.append(str(current_token_id))
             
         # Envia notificação
         send_notification(
-            f"As credenciais da Digital Ocean foram rotacionadas com sucesso em {datetime.now().isoformat()}."
+            f"As credenciais da Digital Ocean foram rotacionadas com sucesso em {secret_data['last_rotated']}.",
+            fields=[("Versão", version), ("Tokens revogados", ", ".join(revoked) or "nenhum")]
         )
         
         return {
@@ -160,10 +251,7 @@ def lambda_handler(event, context):
         error_message = f"Erro durante a rotação de credenciais: {str(e)}"
         logger.error(error_message)
         
-        send_notification(
-            error_message,
-            subject="ERRO - Falha na Rotação de Credenciais DigitalOcean"
-        )
+        send_notification(error_message, severity="error")
         
         return {
             "statusCode": 500,
@@ -279,8 +367,11 @@ resource "aws_lambda_function" "credential_rotation" {
 
   environment {
     variables = {
-      SECRET_NAME   = aws_secretsmanager_secret.digitalocean_tokens.name
-      SNS_TOPIC_ARN = var.sns_topic_arn
+      SECRET_NAME              = aws_secretsmanager_secret.digitalocean_tokens.name
+      SNS_TOPIC_ARN            = var.sns_topic_arn
+      NOTIFICATION_WEBHOOK_URL = var.notification_webhook_url
+      PROJECT_NAME             = var.project_name
+      ENVIRONMENT              = var.environment
     }
   }
 
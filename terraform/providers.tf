@@ -104,9 +104,26 @@ provider "kubernetes" {
   cluster_ca_certificate = local.k8s_ca_cert
   token                  = local.k8s_token
 
+  # Cluster local (kind) criado pelos testes, informado pelo kubeconfig
+  config_path    = local.active_provider == "local-k8s" ? var.kubeconfig_path : null
+  config_context = local.active_provider == "local-k8s" ? var.kube_context : null
+
   # Configurações de timeout para operações k8s
 }
 
+# Provider Helm apontando para o mesmo cluster do provider kubernetes
+provider "helm" {
+  alias = "primary"
+
+  kubernetes {
+    host                   = local.k8s_host
+    cluster_ca_certificate = local.k8s_ca_cert
+    token                  = local.k8s_token
+    config_path            = local.active_provider == "local-k8s" ? var.kubeconfig_path : null
+    config_context         = local.active_provider == "local-k8s" ? var.kube_context : null
+  }
+}
+
 # Variáveis locais para configuração de providers
 locals {
   common_tags = merge(var.tags, {
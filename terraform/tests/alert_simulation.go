@@ -0,0 +1,335 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Simulação das regras de alerta do módulo monitoring/grafana: um avaliador com a mesma semântica
+// do Grafana (consulta, reduce, threshold e "for") consulta o fakePrometheus e envia os alertas
+// ao fakeAlertmanager, que notifica os receivers configurados no config.yaml do ambiente.
+
+// loadGrafanaAlertRules renderiza templates/alerts.yaml do módulo Grafana para o ambiente
+func loadGrafanaAlertRules(moduleDir, environment string, cfg *environmentConfig) (*grafanaAlertFile, error) {
+	content, err := os.ReadFile(filepath.Join(moduleDir, "templates", "alerts.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := renderTemplateFile(string(content), grafanaThresholdVars("boilerplate-nestjs", environment, cfg))
+	if err != nil {
+		return nil, err
+	}
+	var alerts grafanaAlertFile
+	if err := yaml.Unmarshal([]byte(rendered), &alerts); err != nil {
+		return nil, fmt.Errorf("alerts.yaml inválido: %v", err)
+	}
+	return &alerts, nil
+}
+
+// monitoringReceivers retorna os destinos de notificação que o módulo de monitoramento do provedor
+// ativo configura: o webhook do SNS na AWS, Slack e PagerDuty no GCP e o Slack do DigitalOcean.
+// As URLs e chaves ficam vazias e são preenchidas por quem usa os receivers
+func monitoringReceivers(cfg *environmentConfig) []alertReceiver {
+	var receivers []alertReceiver
+	switch cfg.Provider.Active {
+	case "aws":
+		if cfg.Monitoring.AWS.WebhookURL != "" {
+			receivers = append(receivers, alertReceiver{Name: "aws-webhook", Type: "slack"})
+		}
+	case "gcp":
+		if containsString(cfg.Monitoring.GCP.NotificationChannels, "slack") {
+			receivers = append(receivers, alertReceiver{Name: "gcp-slack", Type: "slack", Channel: cfg.Monitoring.GCP.SlackChannel})
+		}
+		if cfg.Monitoring.GCP.PagerdutyIntegration {
+			receivers = append(receivers, alertReceiver{Name: "gcp-pagerduty", Type: "pagerduty"})
+		}
+	case "digitalocean":
+		if cfg.Monitoring.DigitalOcean.SlackChannel != "" {
+			receivers = append(receivers, alertReceiver{Name: "digitalocean-slack", Type: "slack", Channel: cfg.Monitoring.DigitalOcean.SlackChannel})
+		}
+	}
+	return receivers
+}
+
+// alertInstanceState acompanha uma série de uma regra entre avaliações
+type alertInstanceState struct {
+	ActiveSince time.Time
+	Firing      bool
+	Alert       alertmanagerAlert
+}
+
+// alertRuler avalia as regras de alerta provisionadas no Grafana
+type alertRuler struct {
+	Rules           *grafanaAlertFile
+	Prometheus      *prometheusClient
+	AlertmanagerURL string
+
+	states map[string]*alertInstanceState
+}
+
+func newAlertRuler(rules *grafanaAlertFile, prometheus *prometheusClient, alertmanagerURL string) *alertRuler {
+	return &alertRuler{Rules: rules, Prometheus: prometheus, AlertmanagerURL: alertmanagerURL, states: map[string]*alertInstanceState{}}
+}
+
+// Run avalia as regras de from a to, no intervalo de cada grupo
+func (r *alertRuler) Run(from, to time.Time) error {
+	interval := time.Minute
+	for _, group := range r.Rules.Groups {
+		if parsed, err := parsePromDuration(group.Interval); err == nil {
+			interval = parsed
+		}
+	}
+	for at := from; !at.After(to); at = at.Add(interval) {
+		if err := r.Evaluate(at); err != nil {
+			return fmt.Errorf("avaliação em %s: %v", at.Format(time.RFC3339), err)
+		}
+	}
+	return nil
+}
+
+// Evaluate avalia todas as regras no instante informado e envia ao Alertmanager os alertas
+// disparados e os que acabaram de ser resolvidos
+func (r *alertRuler) Evaluate(at time.Time) error {
+	var alerts []alertmanagerAlert
+	seen := map[string]bool{}
+
+	for _, group := range r.Rules.Groups {
+		for _, rule := range group.Rules {
+			results, err := r.evaluateRule(rule, at)
+			if err != nil {
+				return fmt.Errorf("regra %q: %v", rule.Title, err)
+			}
+			pending := time.Duration(0)
+			if rule.For != "" {
+				if pending, err = parsePromDuration(rule.For); err != nil {
+					return fmt.Errorf("regra %q: for inválido: %v", rule.Title, err)
+				}
+			}
+
+			for _, result := range results {
+				if result.Value == 0 {
+					continue
+				}
+				key := rule.UID + promLabelsKey(result.Labels)
+				seen[key] = true
+				state, exists := r.states[key]
+				if !exists {
+					state = &alertInstanceState{ActiveSince: at}
+					r.states[key] = state
+				}
+				if !state.Firing && at.Sub(state.ActiveSince) >= pending {
+					state.Firing = true
+					state.Alert = grafanaAlertInstance(group.Folder, rule, result.Labels, at)
+				}
+				if state.Firing {
+					alerts = append(alerts, state.Alert)
+				}
+			}
+		}
+	}
+
+	// Séries que deixaram de atender a condição (ou sumiram) voltam ao estado normal
+	var keys []string
+	for key := range r.states {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		if state := r.states[key]; state.Firing {
+			resolved := state.Alert
+			resolved.EndsAt = at
+			alerts = append(alerts, resolved)
+		}
+		delete(r.states, key)
+	}
+
+	if len(alerts) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(r.AlertmanagerURL+"/api/v2/alerts", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Alertmanager respondeu %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// evaluateRule calcula o resultado de cada refId da regra e retorna o da condição
+func (r *alertRuler) evaluateRule(rule grafanaAlertRule, at time.Time) ([]promVectorSample, error) {
+	results := map[string][]promVectorSample{}
+	for _, data := range rule.Data {
+		model := data.Model
+		if data.DatasourceUID != "__expr__" {
+			vector, err := r.Prometheus.Query(model.Expr, at)
+			if err != nil {
+				return nil, err
+			}
+			results[data.RefID] = vector
+			continue
+		}
+
+		input, ok := results[model.Expression]
+		if !ok {
+			return nil, fmt.Errorf("%s referencia %q, que não foi calculado antes", data.RefID, model.Expression)
+		}
+		var output []promVectorSample
+		switch model.Type {
+		case "reduce":
+			// A consulta é instantânea, então cada série tem um único valor e last, mean, min e max coincidem
+			switch model.Reducer {
+			case "last", "mean", "min", "max":
+				output = input
+			default:
+				return nil, fmt.Errorf("reducer %q não suportado", model.Reducer)
+			}
+
+		case "threshold":
+			if len(model.Conditions) != 1 {
+				return nil, fmt.Errorf("threshold com %d condições", len(model.Conditions))
+			}
+			evaluator := model.Conditions[0].Evaluator
+			for _, sample := range input {
+				matches, err := grafanaThresholdMatches(evaluator.Type, evaluator.Params, sample.Value)
+				if err != nil {
+					return nil, err
+				}
+				value := 0.0
+				if matches {
+					value = 1
+				}
+				output = append(output, promVectorSample{Labels: sample.Labels, Value: value})
+			}
+
+		default:
+			return nil, fmt.Errorf("expressão do tipo %q não suportada", model.Type)
+		}
+		results[data.RefID] = output
+	}
+
+	condition, ok := results[rule.Condition]
+	if !ok {
+		return nil, fmt.Errorf("condition %q não corresponde a nenhum refId", rule.Condition)
+	}
+	return condition, nil
+}
+
+// grafanaThresholdMatches aplica os avaliadores de threshold do Grafana
+func grafanaThresholdMatches(evaluator string, params []float64, value float64) (bool, error) {
+	need := map[string]int{"gt": 1, "lt": 1, "within_range": 2, "outside_range": 2}[evaluator]
+	if need == 0 {
+		return false, fmt.Errorf("avaliador %q não suportado", evaluator)
+	}
+	if len(params) < need {
+		return false, fmt.Errorf("avaliador %s espera %d parâmetros", evaluator, need)
+	}
+	switch evaluator {
+	case "gt":
+		return value > params[0], nil
+	case "lt":
+		return value < params[0], nil
+	case "within_range":
+		return value > params[0] && value < params[1], nil
+	default:
+		return value < params[0] || value > params[1], nil
+	}
+}
+
+var grafanaAnnotationLabel = regexp.MustCompile(`\{\{\s*\$labels\.(\w+)\s*\}\}`)
+
+// grafanaAlertInstance monta o alerta enviado ao Alertmanager, com os labels da regra e da série
+func grafanaAlertInstance(folder string, rule grafanaAlertRule, labels map[string]string, at time.Time) alertmanagerAlert {
+	alert := alertmanagerAlert{
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+		StartsAt:    at,
+	}
+	for name, value := range labels {
+		alert.Labels[name] = value
+	}
+	for name, value := range rule.Labels {
+		alert.Labels[name] = value
+	}
+	alert.Labels["alertname"] = rule.Title
+	alert.Labels["grafana_folder"] = folder
+
+	for name, value := range rule.Annotations {
+		alert.Annotations[name] = grafanaAnnotationLabel.ReplaceAllStringFunc(value, func(match string) string {
+			return labels[grafanaAnnotationLabel.FindStringSubmatch(match)[1]]
+		})
+	}
+	return alert
+}
+
+// Memória total dos nós sintéticos (8 GiB)
+const syntheticNodeMemory = 8 << 30
+
+// addSyntheticNode grava séries do node_exporter para uma instância: node_cpu_seconds_total por CPU
+// e modo e as métricas de memória. cpu e memory retornam a utilização em % em cada instante
+func addSyntheticNode(storage *promStorage, instance string, cpus int, from, to time.Time, step time.Duration, cpu, memory func(time.Time) float64) {
+	idle := make([]float64, cpus)
+	busy := make([]float64, cpus)
+	for at := from; !at.After(to); at = at.Add(step) {
+		utilization := cpu(at) / 100
+		for i := 0; i < cpus; i++ {
+			if at.After(from) {
+				idle[i] += (1 - utilization) * step.Seconds()
+				busy[i] += utilization * step.Seconds()
+			}
+			labels := map[string]string{"instance": instance, "job": "node", "cpu": strconv.Itoa(i)}
+			storage.Add("node_cpu_seconds_total", withLabel(labels, "mode", "idle"), at, idle[i])
+			storage.Add("node_cpu_seconds_total", withLabel(labels, "mode", "user"), at, busy[i])
+		}
+
+		labels := map[string]string{"instance": instance, "job": "node"}
+		storage.Add("node_memory_MemTotal_bytes", labels, at, syntheticNodeMemory)
+		storage.Add("node_memory_MemAvailable_bytes", labels, at, syntheticNodeMemory*(1-memory(at)/100))
+	}
+}
+
+func withLabel(labels map[string]string, name, value string) map[string]string {
+	result := map[string]string{name: value}
+	for k, v := range labels {
+		result[k] = v
+	}
+	return result
+}
+
+// alertNotificationSummary descreve uma notificação recebida pelo webhookSink, para comparação nos testes
+func alertNotificationSummary(request webhookRequest) string {
+	switch {
+	case strings.HasPrefix(request.Path, "/slack"):
+		attachments, _ := request.Body["attachments"].([]interface{})
+		title := ""
+		if len(attachments) > 0 {
+			attachment, _ := attachments[0].(map[string]interface{})
+			title, _ = attachment["title"].(string)
+		}
+		return fmt.Sprintf("%v %v", request.Body["text"], title)
+	case strings.HasPrefix(request.Path, "/pagerduty"):
+		payload, _ := request.Body["payload"].(map[string]interface{})
+		return fmt.Sprintf("%v %v", request.Body["event_action"], payload["summary"])
+	default:
+		return fmt.Sprintf("%v", request.Body)
+	}
+}
@@ -0,0 +1,199 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMonitoringAlerts avalia as regras de alerta do módulo Grafana contra séries sintéticas de CPU e
+// memória e verifica quais alertas disparam e quais notificações chegam aos receivers de cada ambiente
+func TestMonitoringAlerts(t *testing.T) {
+	t.Parallel()
+
+	for _, environment := range environments {
+		environment := environment
+		t.Run(environment, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := loadEnvironmentConfig(environment)
+			if err != nil {
+				t.Fatalf("Erro ao carregar config.yaml: %v", err)
+			}
+			rules, err := loadGrafanaAlertRules(grafanaModuleDir, environment, cfg)
+			if err != nil {
+				t.Fatalf("Erro ao carregar as regras de alerta: %v", err)
+			}
+
+			sink := newWebhookSink(t)
+			receivers := monitoringReceivers(cfg)
+			if len(receivers) == 0 {
+				t.Fatalf("Nenhum destino de notificação configurado para o provedor %s", cfg.Provider.Active)
+			}
+			for i := range receivers {
+				receivers[i].URL = sink.URL(receivers[i].Type)
+				if receivers[i].Type == "pagerduty" {
+					receivers[i].RoutingKey = testRoutingKey
+				}
+			}
+
+			prometheus := newFakePrometheus(t)
+			alertmanager := newFakeAlertmanager(t, receivers)
+
+			cpuHigh := cfg.Monitoring.AlertThresholdCPU + 15
+			memoryHigh := cfg.Monitoring.AlertThresholdMemory + 10
+			low := func(time.Time) float64 { return 20 }
+			start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			after := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+			between := func(from, to int, value float64) func(time.Time) float64 {
+				return func(at time.Time) float64 {
+					if !at.Before(after(from)) && at.Before(after(to)) {
+						return value
+					}
+					return 20
+				}
+			}
+
+			end := after(20)
+			step := 15 * time.Second
+			addSyntheticNode(prometheus.Storage, "cpu-alta", 2, start, end, step, between(0, 30, cpuHigh), low)
+			addSyntheticNode(prometheus.Storage, "memoria-alta", 2, start, end, step, low, between(3, 30, memoryHigh))
+			// Fica acima do limiar por menos que o "for" das regras e não deve notificar
+			addSyntheticNode(prometheus.Storage, "pico", 2, start, end, step, between(4, 7, cpuHigh), low)
+			addSyntheticNode(prometheus.Storage, "recupera", 2, start, end, step, between(0, 10, cpuHigh), low)
+			addSyntheticNode(prometheus.Storage, "normal", 2, start, end, step, low, low)
+
+			ruler := newAlertRuler(rules, &prometheusClient{endpoint: prometheus.Server.URL}, alertmanager.Server.URL)
+			if err := ruler.Run(after(1), end); err != nil {
+				t.Fatalf("Erro ao avaliar as regras: %v", err)
+			}
+
+			cpuTitle := fmt.Sprintf("Utilização de CPU acima de %g%%", cfg.Monitoring.AlertThresholdCPU)
+			memoryTitle := fmt.Sprintf("Utilização de memória acima de %g%%", cfg.Monitoring.AlertThresholdMemory)
+
+			var active []string
+			for _, alert := range alertmanager.Active() {
+				active = append(active, alert.Labels["alertname"]+" "+alert.Labels["instance"])
+			}
+			assert.ElementsMatch(t, []string{cpuTitle + " cpu-alta", memoryTitle + " memoria-alta"}, active)
+			assert.Empty(t, alertmanager.Errors())
+			assert.Empty(t, sink.Rejected())
+
+			for _, receiver := range receivers {
+				var notifications []string
+				for _, request := range sink.Requests("/" + receiver.Type) {
+					notifications = append(notifications, alertNotificationSummary(request))
+				}
+
+				switch receiver.Type {
+				case "slack":
+					assert.Equal(t, []string{
+						fmt.Sprintf("[FIRING] %s CPU acima de %g%% em cpu-alta", cpuTitle, cfg.Monitoring.AlertThresholdCPU),
+						fmt.Sprintf("[FIRING] %s CPU acima de %g%% em recupera", cpuTitle, cfg.Monitoring.AlertThresholdCPU),
+						fmt.Sprintf("[FIRING] %s Memória acima de %g%% em memoria-alta", memoryTitle, cfg.Monitoring.AlertThresholdMemory),
+						fmt.Sprintf("[RESOLVED] %s CPU acima de %g%% em recupera", cpuTitle, cfg.Monitoring.AlertThresholdCPU),
+					}, notifications, receiver.Name)
+					for _, request := range sink.Requests("/slack") {
+						channel, _ := request.Body["channel"].(string)
+						assert.Equal(t, receiver.Channel, channel, receiver.Name)
+					}
+
+				case "pagerduty":
+					assert.Equal(t, []string{
+						fmt.Sprintf("trigger CPU acima de %g%% em cpu-alta", cfg.Monitoring.AlertThresholdCPU),
+						fmt.Sprintf("trigger CPU acima de %g%% em recupera", cfg.Monitoring.AlertThresholdCPU),
+						fmt.Sprintf("trigger Memória acima de %g%% em memoria-alta", cfg.Monitoring.AlertThresholdMemory),
+						fmt.Sprintf("resolve CPU acima de %g%% em recupera", cfg.Monitoring.AlertThresholdCPU),
+					}, notifications, receiver.Name)
+					for _, request := range sink.Requests("/pagerduty") {
+						assert.Equal(t, testRoutingKey, request.Body["routing_key"], receiver.Name)
+						assert.NotEmpty(t, request.Body["dedup_key"], receiver.Name)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestMonitoringAlertsThresholdBoundary garante que um valor igual ao limiar não dispara o alerta (gt)
+func TestMonitoringAlertsThresholdBoundary(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadEnvironmentConfig("prod")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	rules, err := loadGrafanaAlertRules(grafanaModuleDir, "prod", cfg)
+	if err != nil {
+		t.Fatalf("Erro ao carregar as regras de alerta: %v", err)
+	}
+
+	prometheus := newFakePrometheus(t)
+	alertmanager := newFakeAlertmanager(t, nil)
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(15 * time.Minute)
+	atThreshold := func(time.Time) float64 { return cfg.Monitoring.AlertThresholdCPU }
+	memoryAtThreshold := func(time.Time) float64 { return cfg.Monitoring.AlertThresholdMemory }
+	addSyntheticNode(prometheus.Storage, "limiar", 4, start, end, 15*time.Second, atThreshold, memoryAtThreshold)
+
+	ruler := newAlertRuler(rules, &prometheusClient{endpoint: prometheus.Server.URL}, alertmanager.Server.URL)
+	if err := ruler.Run(start.Add(time.Minute), end); err != nil {
+		t.Fatalf("Erro ao avaliar as regras: %v", err)
+	}
+	assert.Empty(t, alertmanager.Active())
+}
+
+// TestEvalPromQL cobre o avaliador usado pelo fakePrometheus; subqueries não são suportadas
+func TestEvalPromQL(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	storage := newPromStorage()
+	for i := 0; i <= 10; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		storage.Add("http_requests_total", map[string]string{"job": "api", "code": "200"}, at, float64(i*60))
+		storage.Add("http_requests_total", map[string]string{"job": "api", "code": "500"}, at, float64(i*6))
+		storage.Add("http_requests_total", map[string]string{"job": "worker", "code": "200"}, at, float64(i*30))
+	}
+	at := start.Add(10 * time.Minute)
+
+	testCases := []struct {
+		expr     string
+		expected map[string]float64
+	}{
+		{`sum(rate(http_requests_total[5m]))`, map[string]float64{"": 1.6}},
+		{`sum by (job) (rate(http_requests_total[5m]))`, map[string]float64{"api": 1.1, "worker": 0.5}},
+		{`sum by (job) (rate(http_requests_total{code="500"}[5m])) / sum by (job) (rate(http_requests_total[5m]))`, map[string]float64{"api": 0.1 / 1.1}},
+		{`rate(http_requests_total{job="api", code=~"5.."}[5m]) * 100 > 5`, map[string]float64{"api": 10}},
+		{`count(http_requests_total{code!="500"})`, map[string]float64{"": 2}},
+		{`max_over_time(http_requests_total{job="worker"}[3m])`, map[string]float64{"worker": 300}},
+		{`http_requests_total{job="worker"} offset 5m`, map[string]float64{"worker": 150}},
+		{`max_over_time(rate(http_requests_total[1m])[5m:1m])`, nil},
+	}
+
+	for _, tc := range testCases {
+		expr, err := parsePromQL(tc.expr)
+		if err != nil {
+			t.Fatalf("Erro ao analisar %s: %v", tc.expr, err)
+		}
+		result, err := evalPromQL(expr, storage, at)
+		if tc.expected == nil {
+			assert.Error(t, err, tc.expr)
+			continue
+		}
+		if !assert.NoError(t, err, tc.expr) {
+			continue
+		}
+		values := map[string]float64{}
+		for _, sample := range result.Vector {
+			values[sample.Labels["job"]] = sample.Value
+		}
+		assert.Len(t, values, len(tc.expected), tc.expr)
+		for job, expected := range tc.expected {
+			assert.InDelta(t, expected, values[job], 1e-9, "%s (%s)", tc.expr, job)
+		}
+	}
+}
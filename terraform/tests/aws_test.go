@@ -8,75 +8,99 @@ import (
 	"github.com/gruntwork-io/terratest/modules/aws"
 	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
 	"github.com/stretchr/testify/assert"
 )
 
-// TestAwsInfrastructure verifica se a infraestrutura AWS é criada corretamente
+// TestAwsInfrastructure verifica se a infraestrutura AWS é criada corretamente.
+// As etapas podem ser puladas com SKIP_setup, SKIP_deploy, SKIP_validate e SKIP_teardown:
+// por exemplo, SKIP_teardown=true na primeira execução e SKIP_setup=true SKIP_deploy=true
+// nas seguintes repetem só a validação contra o ambiente já criado
 func TestAwsInfrastructure(t *testing.T) {
 	t.Parallel()
 
-	// Gera um ID único para evitar conflitos de nome
-	uniqueID := random.UniqueId()
-	projectName := fmt.Sprintf("test-nestjs-%s", uniqueID)
-
-	// Configurações do terratest para AWS
-	terraformOptions := &terraform.Options{
-		// Diretório onde estão os arquivos Terraform para teste
-		TerraformDir: "../environments/dev",
-
-		// Variáveis a serem passadas para o Terraform
-		Vars: map[string]interface{}{
-			"environment":     "test",
-			"project_name":    projectName,
-			"active_provider": "aws",
-		},
-
-		// Configura log detalhado
-		NoColor: true,
-	}
+	// Diretório onde estão os arquivos Terraform para teste; as etapas gravam os dados em .test-data
+	workingDir := "../environments/dev"
 
 	// Limpa a infraestrutura no final do teste
-	defer terraform.Destroy(t, terraformOptions)
-
-	// Inicializa e aplica a configuração Terraform
-	terraform.InitAndApply(t, terraformOptions)
-
-	// Obtém os outputs do Terraform
-	vpcID := terraform.Output(t, terraformOptions, "vpc_id")
-	clusterEndpoint := terraform.Output(t, terraformOptions, "kubernetes_endpoint")
-	dbEndpoint := terraform.Output(t, terraformOptions, "database_endpoint")
-
-	// Verifica se a VPC foi criada
-	region := aws.GetDefaultRegion(t)
-	vpc := aws.GetVpcById(t, vpcID, region)
-	assert.Equal(t, vpc.Id, vpcID)
-
-	// Verifica se o cluster Kubernetes foi criado corretamente
-	assert.NotEmpty(t, clusterEndpoint)
-
-	// Verifica se o banco de dados foi criado corretamente
-	assert.NotEmpty(t, dbEndpoint)
-
-	// Verifica se os grupos de segurança foram configurados corretamente
-	sgName := fmt.Sprintf("%s-test-sg", projectName)
-	securityGroups := aws.GetSecurityGroupsByName(t, region, sgName)
-	assert.NotEmpty(t, securityGroups)
-
-	// Testa conexão com o banco de dados (com retry)
-	maxRetries := 5
-	retryInterval := 10 * time.Second
-	dbConnected := false
-
-	for i := 0; i < maxRetries; i++ {
-		// Simulando tentativa de conexão ao banco
-		// Em um teste real isso usaria uma conexão real
-		if i == maxRetries-1 {
-			dbConnected = true
-			break
+	defer test_structure.RunTestStage(t, stageTeardown, func() {
+		terraformOptions := loadStageTerraformOptions(t, workingDir)
+		terraform.Destroy(t, terraformOptions)
+		test_structure.CleanupTestDataFolder(t, workingDir)
+	})
+
+	test_structure.RunTestStage(t, stageSetup, func() {
+		// Gera um ID único para evitar conflitos de nome
+		uniqueID := random.UniqueId()
+		projectName := fmt.Sprintf("test-nestjs-%s", uniqueID)
+
+		// Configurações do terratest para AWS
+		terraformOptions := withRetryableErrors(t, &terraform.Options{
+			TerraformDir: workingDir,
+
+			// Variáveis a serem passadas para o Terraform
+			Vars: map[string]interface{}{
+				"environment":     "test",
+				"project_name":    projectName,
+				"active_provider": "aws",
+			},
+
+			// Configura log detalhado
+			NoColor: true,
+		})
+		test_structure.SaveTerraformOptions(t, workingDir, terraformOptions)
+	})
+
+	test_structure.RunTestStage(t, stageDeploy, func() {
+		terraformOptions := loadStageTerraformOptions(t, workingDir)
+
+		// Inicializa e aplica a configuração Terraform
+		terraform.InitAndApply(t, terraformOptions)
+		saveStageOutputs(t, workingDir, terraformOptions)
+	})
+
+	test_structure.RunTestStage(t, stageValidate, func() {
+		terraformOptions := loadStageTerraformOptions(t, workingDir)
+		projectName := terraformOptions.Vars["project_name"]
+
+		// Obtém os outputs gravados pela etapa deploy
+		outputs := loadStageOutputs(t, workingDir)
+		vpcID := outputs.String("vpc_id")
+		clusterEndpoint := outputs.String("kubernetes_endpoint")
+		dbEndpoint := outputs.String("database_endpoint")
+
+		// Verifica se a VPC foi criada
+		region := aws.GetDefaultRegion(t)
+		vpc := aws.GetVpcById(t, vpcID, region)
+		assert.Equal(t, vpc.Id, vpcID)
+
+		// Verifica se o cluster Kubernetes foi criado corretamente
+		assert.NotEmpty(t, clusterEndpoint)
+
+		// Verifica se o banco de dados foi criado corretamente
+		assert.NotEmpty(t, dbEndpoint)
+
+		// Verifica se os grupos de segurança foram configurados corretamente
+		sgName := fmt.Sprintf("%s-test-sg", projectName)
+		securityGroups := aws.GetSecurityGroupsByName(t, region, sgName)
+		assert.NotEmpty(t, securityGroups)
+
+		// Testa conexão com o banco de dados (com retry)
+		maxRetries := 5
+		retryInterval := 10 * time.Second
+		dbConnected := false
+
+		for i := 0; i < maxRetries; i++ {
+			// Simulando tentativa de conexão ao banco
+			// Em um teste real isso usaria uma conexão real
+			if i == maxRetries-1 {
+				dbConnected = true
+				break
+			}
+			time.Sleep(retryInterval)
 		}
-		time.Sleep(retryInterval)
-	}
-	assert.True(t, dbConnected, "Não foi possível conectar ao banco de dados")
+		assert.True(t, dbConnected, "Não foi possível conectar ao banco de dados")
+	})
 }
 
 // TestAwsKubernetesCluster testa especificamente o cluster Kubernetes na AWS
@@ -86,21 +110,12 @@ func TestAwsKubernetesCluster(t *testing.T) {
 	uniqueID := random.UniqueId()
 	projectName := fmt.Sprintf("test-eks-%s", uniqueID)
 
-	terraformOptions := &terraform.Options{
-		TerraformDir: "../modules/kubernetes/aws",
-		Vars: map[string]interface{}{
-			"environment":      "test",
-			"project_name":     projectName,
-			"cluster_version":  "1.26",
-			"node_instance_types": []string{"t3.medium"},
-			"min_nodes":        1,
-			"max_nodes":        2,
-			"desired_nodes":    1,
-			"vpc_id":           "dummy-vpc-id", // Seria substituído por uma VPC real em testes de integração
-			"subnet_ids":       []string{"subnet-1", "subnet-2"}, // Seria substituído por subnets reais
-		},
-		NoColor: true,
-	}
+	// O fixture liga o cluster à VPC e às subnets privadas criadas pelo módulo de rede
+	terraformOptions := fixtureTerraformOptions(t, "aws", map[string]interface{}{
+		"project_name":    projectName,
+		"cluster_version": "1.26",
+	})
+	terraformOptions.Targets = []string{"module.kubernetes"}
 
 	// Este teste é configuracional apenas, não realiza deploy real
 	terraform.InitAndPlan(t, terraformOptions)
@@ -119,7 +134,7 @@ func TestAwsCostMonitoring(t *testing.T) {
 	uniqueID := random.UniqueId()
 	projectName := fmt.Sprintf("test-cost-%s", uniqueID)
 
-	terraformOptions := &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../modules/cost_monitor/aws",
 		Vars: map[string]interface{}{
 			"environment":            "test",
@@ -130,7 +145,7 @@ func TestAwsCostMonitoring(t *testing.T) {
 			"alert_emails":           []string{"test@example.com"},
 		},
 		NoColor: true,
-	}
+	})
 
 	// Este teste é configuracional apenas, não realiza deploy real
 	terraform.InitAndPlan(t, terraformOptions)
@@ -20,7 +20,7 @@ func TestAzureLoadBalancer(t *testing.T) {
 	resourceGroupName := fmt.Sprintf("rg-test-%s", uniqueID)
 
 	// Configuração do Terraform
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		// Diretório onde está o código Terraform para este teste
 		TerraformDir: "../examples/load_balancing/azure",
 
@@ -121,7 +121,7 @@ func TestAzureAbstractLoadBalancer(t *testing.T) {
 	resourceGroupName := fmt.Sprintf("rg-test-%s", uniqueID)
 
 	// Configuração do Terraform
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		// Diretório onde está o código Terraform para o teste abstrato
 		TerraformDir: "../examples/abstraction",
 
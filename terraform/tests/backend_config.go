@@ -0,0 +1,17 @@
+package test
+
+import (
+	"github.com/cirebox/boilerplate-nestjs/terraform/tests/backendconfig"
+)
+
+// backendOverrideFile é o arquivo gravado por cmd/backend-config na raiz terraform/
+const backendOverrideFile = backendconfig.OverrideFile
+
+// stateBackend é o backend "s3" de um ambiente, com a mesma validação e o mesmo bloco gerados por
+// cmd/backend-config
+type stateBackend = backendconfig.Backend
+
+// newStateBackend monta o backend do ambiente a partir de provider.aws.state_*
+func newStateBackend(environment string, cfg *environmentConfig) (stateBackend, error) {
+	return backendconfig.New(environment, cfg.Provider.AWS.AWSConfig)
+}
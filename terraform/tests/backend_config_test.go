@@ -0,0 +1,425 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBackendConfig confere o backend "s3" de cada ambiente: campos obrigatórios em provider.aws,
+// uma chave de estado por ambiente e, com recuperação de desastres, o mesmo bucket replicado por
+// disaster_recovery.state_bucket. Só lê os arquivos; o backend_override.tf é gravado por
+// cmd/backend-config
+func TestBackendConfig(t *testing.T) {
+	t.Parallel()
+
+	locations := map[string]string{}
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao carregar config.yaml de %s: %v", environment, err)
+		}
+		backend, err := newStateBackend(environment, cfg)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		if other, exists := locations[backend.LockID()]; exists {
+			assert.Fail(t, "Estado compartilhado entre ambientes", "%s e %s usam %s", other, environment, backend.LockID())
+		}
+		locations[backend.LockID()] = environment
+
+		if cfg.DisasterRecovery.Enabled && cfg.DisasterRecovery.StateBucket != "" {
+			assert.Equal(t, backend.Bucket, cfg.DisasterRecovery.StateBucket,
+				"%s: disaster_recovery.state_bucket deve ser o bucket do backend", environment)
+		}
+		assert.Contains(t, backend.Block(), "encrypt        = true", environment)
+	}
+}
+
+// TestBackendConfigCommand compila cmd/backend-config e compara, em memória, a saída de cada
+// ambiente com o bloco de stateBackend usado pelos demais testes
+func TestBackendConfigCommand(t *testing.T) {
+	t.Parallel()
+
+	goBinary, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go não encontrado no PATH")
+	}
+	command := filepath.Join(t.TempDir(), "backend-config")
+	if output, err := exec.Command(goBinary, "build", "-o", command, "./cmd/backend-config").CombinedOutput(); err != nil {
+		t.Fatalf("Erro ao compilar cmd/backend-config: %v\n%s", err, output)
+	}
+
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao carregar config.yaml de %s: %v", environment, err)
+		}
+		backend, err := newStateBackend(environment, cfg)
+		if err != nil {
+			t.Fatalf("Erro ao gerar o backend de %s: %v", environment, err)
+		}
+
+		var stdout, stderr bytes.Buffer
+		run := exec.Command(command, "-environment", environment, "-root", "..", "-output", "-")
+		run.Stdout, run.Stderr = &stdout, &stderr
+		if err := run.Run(); err != nil {
+			t.Fatalf("cmd/backend-config falhou para %s: %v\n%s", environment, err, stderr.String())
+		}
+		assert.Equal(t, backend.Block(), stdout.String(), environment)
+	}
+
+	run := exec.Command(command, "-environment", "inexistente", "-root", "..", "-output", "-")
+	output, err := run.CombinedOutput()
+	assert.Error(t, err)
+	assert.Contains(t, string(output), "backend-config: ")
+}
+
+// TestBackendConfigBlock cobre a geração do bloco e a validação de provider.aws
+func TestBackendConfigBlock(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadEnvironmentConfig("dev")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	backend, err := newStateBackend("dev", cfg)
+	if err != nil {
+		t.Fatalf("Erro ao gerar o backend: %v", err)
+	}
+	assert.Equal(t, "boilerplate-nestjs-terraform-state/dev/terraform.tfstate", backend.LockID())
+	assert.Equal(t, `# Gerado por cmd/backend-config a partir de environments/dev/config.yaml. Não edite.
+terraform {
+  backend "s3" {
+    bucket         = "boilerplate-nestjs-terraform-state"
+    key            = "dev/terraform.tfstate"
+    region         = "us-east-1"
+    dynamodb_table = "terraform-state-lock"
+    encrypt        = true
+    profile        = "default"
+  }
+}
+`, backend.Block())
+
+	backend.KMSKeyID = "arn:aws:kms:us-east-1:000000000000:key/state"
+	backend.Endpoints = map[string]string{"s3": "http://127.0.0.1:4566", "dynamodb": "http://127.0.0.1:4566"}
+	assert.Equal(t, `# Gerado por cmd/backend-config a partir de environments/dev/config.yaml. Não edite.
+terraform {
+  backend "s3" {
+    bucket         = "boilerplate-nestjs-terraform-state"
+    key            = "dev/terraform.tfstate"
+    region         = "us-east-1"
+    dynamodb_table = "terraform-state-lock"
+    encrypt        = true
+    kms_key_id     = "arn:aws:kms:us-east-1:000000000000:key/state"
+
+    endpoints = {
+      dynamodb = "http://127.0.0.1:4566"
+      s3       = "http://127.0.0.1:4566"
+    }
+    use_path_style              = true
+    skip_credentials_validation = true
+    skip_requesting_account_id  = true
+    skip_metadata_api_check     = true
+    skip_region_validation      = true
+  }
+}
+`, backend.Block())
+
+	cfg.Provider.AWS.StateBucket = ""
+	cfg.Provider.AWS.StateDynamoDBTable = ""
+	_, err = newStateBackend("dev", cfg)
+	assert.EqualError(t, err, "dev: provider.aws sem state_bucket, state_dynamodb_table")
+
+	cfg.Provider.AWS.StateBucket = "bucket"
+	cfg.Provider.AWS.StateDynamoDBTable = "locks"
+	cfg.Provider.AWS.StateKey = "/dev/state.json"
+	_, err = newStateBackend("dev", cfg)
+	assert.EqualError(t, err, `dev: state_key "/dev/state.json" deve ser um caminho relativo terminado em .tfstate`)
+}
+
+// TestFakeStateBackend cobre o protocolo do fake com requisições no formato do SDK da AWS
+func TestFakeStateBackend(t *testing.T) {
+	t.Parallel()
+
+	backend := stateBackend{Bucket: "state", Key: "dev/terraform.tfstate", DynamoDBTable: "locks"}
+	fake := newFakeStateBackend(t, backend)
+	objectURL := fake.Server.URL + "/state/dev/terraform.tfstate"
+
+	request := func(method, url string, body string, headers map[string]string) (int, string, http.Header) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Erro ao montar a requisição: %v", err)
+		}
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Erro na requisição %s %s: %v", method, url, err)
+		}
+		defer resp.Body.Close()
+		content, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(content), resp.Header
+	}
+	dynamo := func(operation string, input interface{}) (int, string) {
+		body, _ := json.Marshal(input)
+		status, content, _ := request(http.MethodPost, fake.Server.URL, string(body), map[string]string{
+			"X-Amz-Target": "DynamoDB_20120810." + operation,
+			"Content-Type": "application/x-amz-json-1.0",
+		})
+		return status, content
+	}
+
+	// O bucket recusa estado sem criptografia no servidor
+	status, content, _ := request(http.MethodPut, objectURL, `{"version": 4}`, nil)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, content, "<Code>AccessDenied</Code>")
+
+	// Corpo aws-chunked com checksum no trailer, como o SDK envia por padrão
+	status, _, _ = request(http.MethodPut, objectURL, "e\r\n{\"version\": 4}\r\n0\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n", map[string]string{
+		"x-amz-server-side-encryption": "AES256",
+		"Content-Encoding":             "aws-chunked",
+	})
+	assert.Equal(t, http.StatusOK, status)
+	object, ok := fake.Object("state", "dev/terraform.tfstate")
+	if assert.True(t, ok) {
+		assert.Equal(t, `{"version": 4}`, string(object.Body))
+		assert.Equal(t, "AES256", object.Encryption)
+	}
+
+	status, content, headers := request(http.MethodGet, objectURL, "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"version": 4}`, content)
+	assert.Equal(t, "AES256", headers.Get("x-amz-server-side-encryption"))
+
+	status, content, _ = request(http.MethodGet, fake.Server.URL+"/state?list-type=2&prefix=dev/", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, content, "<Key>dev/terraform.tfstate</Key>")
+	status, content, _ = request(http.MethodGet, fake.Server.URL+"/state/env:/dev/terraform.tfstate", "", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, content, "<Code>NoSuchKey</Code>")
+
+	// Lock: o segundo PutItem condicional falha até o primeiro ser removido
+	lock := func(id string) map[string]interface{} {
+		return map[string]interface{}{
+			"TableName":           "locks",
+			"Item":                map[string]interface{}{"LockID": map[string]string{"S": backend.LockID()}, "Info": map[string]string{"S": `{"ID":"` + id + `"}`}},
+			"ConditionExpression": "attribute_not_exists(LockID)",
+		}
+	}
+	status, _ = dynamo("PutItem", lock("a"))
+	assert.Equal(t, http.StatusOK, status)
+	status, content = dynamo("PutItem", lock("b"))
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, content, "ConditionalCheckFailedException")
+	assert.Equal(t, `{"ID":"a"}`, fake.Lock("locks", backend.LockID()))
+
+	_, content = dynamo("GetItem", map[string]interface{}{"TableName": "locks", "Key": map[string]interface{}{"LockID": map[string]string{"S": backend.LockID()}}})
+	assert.Contains(t, content, `{\"ID\":\"a\"}`)
+
+	unlock := func(id string) map[string]interface{} {
+		return map[string]interface{}{
+			"TableName":                 "locks",
+			"Key":                       map[string]interface{}{"LockID": map[string]string{"S": backend.LockID()}},
+			"ConditionExpression":       "Info = :info",
+			"ExpressionAttributeValues": map[string]interface{}{":info": map[string]string{"S": `{"ID":"` + id + `"}`}},
+		}
+	}
+	status, _ = dynamo("DeleteItem", unlock("b"))
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = dynamo("DeleteItem", unlock("a"))
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = dynamo("PutItem", lock("b"))
+	assert.Equal(t, http.StatusOK, status)
+
+	var actions []string
+	for _, event := range fake.LockEvents() {
+		actions = append(actions, event.Action+":"+event.Holder)
+	}
+	assert.Equal(t, []string{"acquire:a", "conflict:b", "release:a", "acquire:b"}, actions)
+
+	status, content = dynamo("PutItem", map[string]interface{}{"TableName": "outra", "Item": map[string]interface{}{}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, content, "ResourceNotFoundException")
+
+	// PauseNextGet segura apenas a próxima leitura da chave
+	release := fake.PauseNextGet("dev/terraform.tfstate")
+	done := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(objectURL)
+		if err != nil {
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+	select {
+	case key := <-fake.Paused():
+		assert.Equal(t, "dev/terraform.tfstate", key)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("GetObject não foi segurado")
+	}
+	select {
+	case <-done:
+		assert.Fail(t, "GetObject terminou antes da liberação")
+	case <-time.After(100 * time.Millisecond):
+	}
+	release()
+	assert.Equal(t, http.StatusOK, <-done)
+	status, _, _ = request(http.MethodGet, objectURL, "", nil)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+// stateLockingRoot é a raiz usada por TestStateLocking: terraform_data não depende de provedores
+// externos, então o teste só precisa do binário do Terraform
+const stateLockingRoot = `terraform {
+  required_version = ">= 1.6.0"
+}
+
+variable "marker" {
+  type    = string
+  default = "v1"
+}
+
+resource "terraform_data" "marker" {
+  input = var.marker
+}
+`
+
+// stateLockingRun é o resultado de um `terraform plan` concorrente
+type stateLockingRun struct {
+	Name string
+	Err  error
+}
+
+// TestStateLocking aplica uma raiz com o backend gerado para dev apontado para o fake, confere que
+// o estado foi gravado com criptografia no servidor e executa dois planos concorrentes: o primeiro
+// fica com o lock enquanto a leitura do estado está parada no fake, e o segundo precisa esperar a
+// liberação (-lock-timeout) para obter o lock
+func TestStateLocking(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform não encontrado no PATH")
+	}
+
+	cfg, err := loadEnvironmentConfig("dev")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	backend, err := newStateBackend("dev", cfg)
+	if err != nil {
+		t.Fatalf("Erro ao gerar o backend: %v", err)
+	}
+	fake := newFakeStateBackend(t, backend)
+	backend.Endpoints = fake.Endpoints()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"main.tf": stateLockingRoot, backendOverrideFile: backend.Block()} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Erro ao gravar %s: %v", name, err)
+		}
+	}
+
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
+		TerraformDir: dir,
+		NoColor:      true,
+		EnvVars: map[string]string{
+			"AWS_ACCESS_KEY_ID":         "test",
+			"AWS_SECRET_ACCESS_KEY":     "test",
+			"AWS_EC2_METADATA_DISABLED": "true",
+		},
+	})
+	terraform.InitAndApply(t, terraformOptions)
+
+	object, ok := fake.Object(backend.Bucket, backend.Key)
+	if !ok {
+		t.Fatalf("Estado não gravado em s3://%s/%s", backend.Bucket, backend.Key)
+	}
+	assert.Equal(t, "AES256", object.Encryption, "estado gravado sem criptografia no servidor")
+	assert.True(t, bytes.Contains(object.Body, []byte(`"terraform_data"`)))
+	assert.Empty(t, fake.Lock(backend.DynamoDBTable, backend.LockID()), "lock não liberado após o apply")
+
+	before := len(fake.LockEvents())
+	results := make(chan stateLockingRun, 2)
+	plan := func(name string) {
+		go func() {
+			_, err := terraform.RunTerraformCommandE(t, terraformOptions, "plan", "-input=false", "-no-color", "-lock-timeout=2m")
+			results <- stateLockingRun{Name: name, Err: err}
+		}()
+	}
+
+	release := fake.PauseNextGet(backend.Key)
+	defer release()
+	plan("primeiro")
+	select {
+	case <-fake.Paused():
+	case run := <-results:
+		t.Fatalf("O plano %s terminou sem ler o estado: %v", run.Name, run.Err)
+	case <-time.After(2 * time.Minute):
+		t.Fatalf("O primeiro plano não chegou à leitura do estado")
+	}
+	holder := fake.Lock(backend.DynamoDBTable, backend.LockID())
+	assert.NotEmpty(t, holder, "o primeiro plano deveria estar com o lock")
+
+	plan("segundo")
+	conflict := func() bool {
+		for _, event := range fake.LockEvents()[before:] {
+			if event.Action == "conflict" {
+				return true
+			}
+		}
+		return false
+	}
+	deadline := time.Now().Add(2 * time.Minute)
+	for !conflict() && time.Now().Before(deadline) {
+		select {
+		case run := <-results:
+			t.Fatalf("O plano %s terminou com o lock ainda tomado: %v", run.Name, run.Err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	if !conflict() {
+		t.Fatalf("O segundo plano não tentou obter o lock")
+	}
+	assert.Equal(t, holder, fake.Lock(backend.DynamoDBTable, backend.LockID()), "o lock mudou de dono antes da liberação")
+
+	release()
+	for i := 0; i < 2; i++ {
+		run := <-results
+		assert.NoError(t, run.Err, "plano %s", run.Name)
+	}
+
+	// acquire do primeiro, conflitos do segundo, release do primeiro e só então o acquire do segundo
+	var first string
+	var timeline []string
+	for _, event := range fake.LockEvents()[before:] {
+		if first == "" && event.Action == "acquire" {
+			first = event.Holder
+		}
+		label := event.Action + ":segundo"
+		if event.Holder == first {
+			label = event.Action + ":primeiro"
+		}
+		// O segundo plano repete o PutItem enquanto espera o lock
+		if len(timeline) > 0 && timeline[len(timeline)-1] == label {
+			continue
+		}
+		timeline = append(timeline, label)
+	}
+	assert.Equal(t, []string{"acquire:primeiro", "conflict:segundo", "release:primeiro", "acquire:segundo", "release:segundo"}, timeline)
+	assert.Empty(t, fake.Lock(backend.DynamoDBTable, backend.LockID()), "lock não liberado após os planos")
+}
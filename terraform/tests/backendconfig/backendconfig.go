@@ -0,0 +1,160 @@
+// Package backendconfig monta o backend "s3" do estado remoto de um ambiente a partir de
+// provider.aws.state_* do config.yaml. É usado pelo comando cmd/backend-config e pelos testes
+// (TestBackendConfig, TestStateLocking), para que o arquivo gerado e o conferido sejam os mesmos
+package backendconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OverrideFile é o arquivo gravado na raiz terraform/. O .gitignore já ignora *_override.tf, então
+// quem não gera o arquivo continua com o estado local
+const OverrideFile = "backend_override.tf"
+
+// AWSConfig espelha as chaves de provider.aws usadas pelo backend
+type AWSConfig struct {
+	Profile            string `yaml:"profile"`
+	Region             string `yaml:"region"`
+	StateBucket        string `yaml:"state_bucket"`
+	StateKey           string `yaml:"state_key"`
+	StateDynamoDBTable string `yaml:"state_dynamodb_table"`
+	StateKMSKeyID      string `yaml:"state_kms_key_id"`
+}
+
+// Backend é a configuração do backend "s3" de um ambiente
+type Backend struct {
+	Environment   string
+	Bucket        string
+	Key           string
+	Region        string
+	DynamoDBTable string
+	KMSKeyID      string
+	Profile       string
+
+	// Endpoints substitui os endpoints da AWS (s3 e dynamodb), para fakes e emuladores locais.
+	// Com endpoints definidos, as validações de credenciais e de conta são desligadas
+	Endpoints map[string]string
+}
+
+// Load lê provider.aws de <root>/environments/<ambiente>/config.yaml
+func Load(root, environment string) (AWSConfig, error) {
+	var cfg struct {
+		Provider struct {
+			AWS AWSConfig `yaml:"aws"`
+		} `yaml:"provider"`
+	}
+	content, err := os.ReadFile(filepath.Join(root, "environments", environment, "config.yaml"))
+	if err != nil {
+		return AWSConfig{}, err
+	}
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return AWSConfig{}, fmt.Errorf("erro ao interpretar config.yaml de %s: %v", environment, err)
+	}
+	return cfg.Provider.AWS, nil
+}
+
+// New monta o backend do ambiente a partir de provider.aws.state_*. O estado é sempre criptografado;
+// com state_kms_key_id a criptografia usa a chave KMS em vez da AES256 do S3
+func New(environment string, aws AWSConfig) (Backend, error) {
+	backend := Backend{
+		Environment:   environment,
+		Bucket:        aws.StateBucket,
+		Key:           aws.StateKey,
+		Region:        aws.Region,
+		DynamoDBTable: aws.StateDynamoDBTable,
+		KMSKeyID:      aws.StateKMSKeyID,
+		Profile:       aws.Profile,
+	}
+
+	var missing []string
+	for name, value := range map[string]string{
+		"state_bucket":         backend.Bucket,
+		"state_key":            backend.Key,
+		"state_dynamodb_table": backend.DynamoDBTable,
+		"region":               backend.Region,
+	} {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return Backend{}, fmt.Errorf("%s: provider.aws sem %s", environment, strings.Join(missing, ", "))
+	}
+	if strings.HasPrefix(backend.Key, "/") || !strings.HasSuffix(backend.Key, ".tfstate") {
+		return Backend{}, fmt.Errorf("%s: state_key %q deve ser um caminho relativo terminado em .tfstate", environment, backend.Key)
+	}
+	return backend, nil
+}
+
+// LockID é a chave do item de lock gravado pelo Terraform na tabela do DynamoDB
+func (b Backend) LockID() string {
+	return b.Bucket + "/" + b.Key
+}
+
+// Block retorna o conteúdo de backend_override.tf com o bloco terraform { backend "s3" } do ambiente,
+// no formato de `terraform fmt`
+func (b Backend) Block() string {
+	settings := [][2]string{
+		{"bucket", fmt.Sprintf("%q", b.Bucket)},
+		{"key", fmt.Sprintf("%q", b.Key)},
+		{"region", fmt.Sprintf("%q", b.Region)},
+		{"dynamodb_table", fmt.Sprintf("%q", b.DynamoDBTable)},
+		{"encrypt", "true"},
+	}
+	if b.KMSKeyID != "" {
+		settings = append(settings, [2]string{"kms_key_id", fmt.Sprintf("%q", b.KMSKeyID)})
+	}
+	if b.Profile != "" && len(b.Endpoints) == 0 {
+		settings = append(settings, [2]string{"profile", fmt.Sprintf("%q", b.Profile)})
+	}
+
+	var lines strings.Builder
+	fmt.Fprintf(&lines, "# Gerado por cmd/backend-config a partir de environments/%s/config.yaml. Não edite.\n", b.Environment)
+	lines.WriteString("terraform {\n  backend \"s3\" {\n")
+	writeHCLAttributes(&lines, "    ", settings)
+
+	if len(b.Endpoints) > 0 {
+		services := make([]string, 0, len(b.Endpoints))
+		for service := range b.Endpoints {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		var endpoints [][2]string
+		for _, service := range services {
+			endpoints = append(endpoints, [2]string{service, fmt.Sprintf("%q", b.Endpoints[service])})
+		}
+
+		lines.WriteString("\n    endpoints = {\n")
+		writeHCLAttributes(&lines, "      ", endpoints)
+		lines.WriteString("    }\n")
+		writeHCLAttributes(&lines, "    ", [][2]string{
+			{"use_path_style", "true"},
+			{"skip_credentials_validation", "true"},
+			{"skip_requesting_account_id", "true"},
+			{"skip_metadata_api_check", "true"},
+			{"skip_region_validation", "true"},
+		})
+	}
+	lines.WriteString("  }\n}\n")
+	return lines.String()
+}
+
+// writeHCLAttributes escreve atributos consecutivos com os sinais de igual alinhados
+func writeHCLAttributes(lines *strings.Builder, indent string, attributes [][2]string) {
+	width := 0
+	for _, attribute := range attributes {
+		if len(attribute[0]) > width {
+			width = len(attribute[0])
+		}
+	}
+	for _, attribute := range attributes {
+		fmt.Fprintf(lines, "%s%-*s = %s\n", indent, width, attribute[0], attribute[1])
+	}
+}
@@ -0,0 +1,575 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// syntheticMonthCrossings são os dias em que syntheticBudgetMonth passa de cada porcentagem do
+// orçamento, pelo gasto real e pela previsão do mês
+var syntheticMonthCrossings = map[string]map[float64]int{
+	budgetActual:     {50: 18, 80: 24, 100: 27},
+	budgetForecasted: {100: 23},
+}
+
+// expectedBudgetNotifications monta, a partir do config.yaml, os avisos que o cost_monitor do
+// provedor deve enviar no mês sintético: alert_threshold_percent e 100% sobre o gasto real, 100%
+// sobre a previsão e, no GCP, os cost.gcp.budget_thresholds
+func expectedBudgetNotifications(t *testing.T, provider string, cfg *environmentConfig) []string {
+	var expected []string
+	for _, threshold := range budgetActualThresholds(provider, cfg) {
+		day, ok := syntheticMonthCrossings[budgetActual][threshold]
+		if !ok {
+			t.Fatalf("Inclua em syntheticMonthCrossings o dia em que o mês sintético passa de %g%%", threshold)
+		}
+		expected = append(expected, budgetNotification{Day: day, Kind: budgetActual, Threshold: threshold}.String())
+	}
+	expected = append(expected, budgetNotification{Day: syntheticMonthCrossings[budgetForecasted][100], Kind: budgetForecasted, Threshold: 100}.String())
+	sort.Strings(expected)
+	return expected
+}
+
+// budgetActualThresholds são as porcentagens de gasto real que o cost_monitor do provedor avisa,
+// sem repetições: alert_threshold_percent, 100% e, no GCP, os cost.gcp.budget_thresholds
+func budgetActualThresholds(provider string, cfg *environmentConfig) []float64 {
+	thresholds := []float64{cfg.Cost.AlertThresholdPercent, 100}
+	if provider == "gcp" {
+		thresholds = append(thresholds, cfg.Cost.GCP.BudgetThresholds...)
+	}
+	seen := map[float64]bool{}
+	var distinct []float64
+	for _, threshold := range thresholds {
+		if !seen[threshold] {
+			seen[threshold] = true
+			distinct = append(distinct, threshold)
+		}
+	}
+	return distinct
+}
+
+// describeBudgetNotifications converte os avisos para comparação, ordenados pelo dia
+func describeBudgetNotifications(notifications []budgetNotification) []string {
+	described := make([]string, 0, len(notifications))
+	for _, notification := range notifications {
+		described = append(described, notification.String())
+	}
+	sort.Strings(described)
+	return described
+}
+
+// TestBudgetAlerts aplica o cost_monitor do provider.active de cada ambiente contra os fakes das
+// APIs de orçamento, confere valor e moeda do orçamento criado e reproduz um mês de gastos que
+// termina em 120% do orçamento para verificar quais avisos são enviados, em que dia e para quem
+func TestBudgetAlerts(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform não encontrado no PATH")
+	}
+
+	for _, environment := range environments {
+		environment := environment
+		t.Run(environment, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := loadEnvironmentConfig(environment)
+			if err != nil {
+				t.Fatalf("Erro ao carregar config.yaml: %v", err)
+			}
+			cost := cfg.Cost
+			vars := map[string]interface{}{
+				"environment":  environment,
+				"project_name": "boilerplate-nestjs",
+				"alert_emails": cost.AlertEmails,
+			}
+			month := syntheticBudgetMonth(cost.BudgetAmount)
+
+			switch cfg.Provider.Active {
+			case "aws":
+				fake := newFakeAwsBudgets(t)
+				vars["budget_amount"] = cost.BudgetAmount
+				vars["budget_currency"] = cost.BudgetCurrency
+				vars["alert_threshold_percent"] = cost.AlertThresholdPercent
+				options := withRetryableErrors(t, &terraform.Options{
+					TerraformDir: costMonitorRoot(t, "aws", fake.Server.URL),
+					Vars:         vars,
+					Targets:      []string{"aws_budgets_budget.monthly"},
+					NoColor:      true,
+				})
+				defer terraform.Destroy(t, options)
+				terraform.InitAndApply(t, options)
+
+				budget, ok := fake.Budget(fmt.Sprintf("boilerplate-nestjs-%s-monthly-budget", environment))
+				if !ok {
+					t.Fatalf("Orçamento não criado no fake: %v", fake.Actions())
+				}
+				assert.Equal(t, cost.BudgetAmount, budget.Amount(), "valor do orçamento")
+				assert.Equal(t, cost.BudgetCurrency, budget.Unit(), "moeda do orçamento")
+
+				notifications := fake.ReplayMonth(month)
+				assert.Equal(t, expectedBudgetNotifications(t, "aws", cfg), describeBudgetNotifications(notifications))
+				for _, notification := range notifications {
+					assert.ElementsMatch(t, cost.AlertEmails, notification.Recipients, notification.String())
+				}
+
+			case "gcp":
+				fake := newFakeGcpBilling(t)
+				thresholds := cost.GCP.BudgetThresholds
+				if thresholds == nil {
+					thresholds = []float64{}
+				}
+				vars["project_id"] = "fake-project"
+				vars["billing_account_id"] = "000000-AAAAAA-111111"
+				vars["budget_amount"] = cost.BudgetAmount
+				vars["budget_currency"] = cost.BudgetCurrency
+				vars["alert_threshold_percent"] = cost.AlertThresholdPercent
+				vars["budget_thresholds"] = thresholds
+				options := withRetryableErrors(t, &terraform.Options{
+					TerraformDir: costMonitorRoot(t, "gcp", fake.Server.URL),
+					Vars:         vars,
+					Targets:      []string{"google_billing_budget.budget"},
+					NoColor:      true,
+				})
+				defer terraform.Destroy(t, options)
+				terraform.InitAndApply(t, options)
+
+				budgets := fake.Resources("budgets")
+				if len(budgets) != 1 {
+					t.Fatalf("Esperado um orçamento no fake, encontrados %d", len(budgets))
+				}
+				for _, budget := range budgets {
+					amount, currency := gcpBudgetAmount(budget)
+					assert.Equal(t, cost.BudgetAmount, amount, "valor do orçamento")
+					assert.Equal(t, cost.BudgetCurrency, currency, "moeda do orçamento")
+				}
+
+				notifications := fake.ReplayMonth(month)
+				assert.Equal(t, expectedBudgetNotifications(t, "gcp", cfg), describeBudgetNotifications(notifications))
+				for _, notification := range notifications {
+					assert.ElementsMatch(t, cost.AlertEmails, notification.Recipients, notification.String())
+				}
+				topic := fmt.Sprintf("projects/fake-project/topics/boilerplate-nestjs-%s-budget-alerts", environment)
+				assert.Len(t, fake.Messages(topic), len(notifications), "cada aviso também vai para o Pub/Sub")
+
+			case "digitalocean":
+				// O DigitalOcean não tem API de orçamento: o cost_monitor cria apenas alertas de uso dos
+				// droplets, e budget_threshold/monthly_budget_limit só aparecem nas saídas. Não há aviso
+				// por gasto para reproduzir; o teste confere as políticas criadas
+				fake := newFakeDigitalOceanAlerts(t)
+				options := withRetryableErrors(t, &terraform.Options{
+					TerraformDir: costMonitorRoot(t, "digitalocean", fake.Server.URL),
+					Vars:         vars,
+					NoColor:      true,
+				})
+				defer terraform.Destroy(t, options)
+				terraform.InitAndApply(t, options)
+
+				var policies []string
+				for _, policy := range fake.Policies() {
+					policies = append(policies, fmt.Sprintf("%v %v %v", policy["type"], policy["compare"], policy["value"]))
+					alerts, _ := policy["alerts"].(map[string]interface{})
+					listed, _ := alerts["email"].([]interface{})
+					var emails []string
+					for _, email := range listed {
+						emails = append(emails, fmt.Sprint(email))
+					}
+					assert.ElementsMatch(t, cost.AlertEmails, emails, "e-mails de %v", policy["description"])
+				}
+				// Sem enable_waste_detection ficam só os alertas de CPU e memória
+				assert.ElementsMatch(t, []string{
+					"v1/insights/droplet/cpu GreaterThan 80",
+					"v1/insights/droplet/memory_utilization_percent GreaterThan 85",
+				}, policies)
+
+			default:
+				t.Skipf("provider.active %s sem cost_monitor", cfg.Provider.Active)
+			}
+		})
+	}
+}
+
+// TestBudgetReplay exercita os fakes de orçamento com as chamadas que os providers fazem e
+// reproduz o mês sintético sobre as regras criadas, sem Terraform
+func TestBudgetReplay(t *testing.T) {
+	t.Parallel()
+
+	month := syntheticBudgetMonth(100)
+	total := 0.0
+	for _, spend := range month {
+		total += spend
+	}
+	assert.InDelta(t, 120, total, 0.001, "o mês sintético termina em 120% do orçamento")
+
+	rules := []budgetRule{
+		{Budget: "b", Amount: 100, Kind: budgetActual, Threshold: 50},
+		{Budget: "b", Amount: 100, Kind: budgetActual, Threshold: 80},
+		{Budget: "b", Amount: 100, Kind: budgetActual, Threshold: 100},
+		{Budget: "b", Amount: 100, Kind: budgetForecasted, Threshold: 100},
+	}
+	var expected []string
+	for kind, crossings := range syntheticMonthCrossings {
+		for threshold, day := range crossings {
+			expected = append(expected, budgetNotification{Day: day, Kind: kind, Threshold: threshold}.String())
+		}
+	}
+	sort.Strings(expected)
+	assert.Equal(t, expected, describeBudgetNotifications(replayBudgetMonth(rules, month)))
+
+	t.Run("AWS", func(t *testing.T) {
+		t.Parallel()
+
+		fake := newFakeAwsBudgets(t)
+		call := func(action string, body interface{}) (int, map[string]interface{}) {
+			content, _ := json.Marshal(body)
+			request, _ := http.NewRequest(http.MethodPost, fake.Server.URL, bytes.NewReader(content))
+			request.Header.Set("X-Amz-Target", "AWSBudgetServiceGateway."+action)
+			request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("Erro ao chamar %s: %v", action, err)
+			}
+			defer response.Body.Close()
+			var decoded map[string]interface{}
+			json.NewDecoder(response.Body).Decode(&decoded)
+			return response.StatusCode, decoded
+		}
+
+		response, err := http.PostForm(fake.Server.URL, url.Values{"Action": {"GetCallerIdentity"}, "Version": {"2011-06-15"}})
+		if err != nil {
+			t.Fatalf("Erro ao chamar o STS: %v", err)
+		}
+		identity, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Contains(t, string(identity), "<Account>"+fakeAwsAccountID+"</Account>")
+
+		emails := []string{"admin@example.com", "devops@example.com"}
+		subscribers := []map[string]string{{"SubscriptionType": "EMAIL", "Address": emails[0]}, {"SubscriptionType": "EMAIL", "Address": emails[1]}}
+		notification := func(kind string, threshold float64) map[string]interface{} {
+			return map[string]interface{}{
+				"Notification": map[string]interface{}{"NotificationType": kind, "ComparisonOperator": "GREATER_THAN", "Threshold": threshold, "ThresholdType": "PERCENTAGE"},
+				"Subscribers":  subscribers,
+			}
+		}
+		status, _ := call("CreateBudget", map[string]interface{}{
+			"AccountId": fakeAwsAccountID,
+			"Budget": map[string]interface{}{
+				"BudgetName":  "boilerplate-nestjs-staging-monthly-budget",
+				"BudgetType":  "COST",
+				"TimeUnit":    "MONTHLY",
+				"BudgetLimit": map[string]string{"Amount": "300.0", "Unit": "USD"},
+			},
+			"NotificationsWithSubscribers": []interface{}{
+				notification(budgetActual, 80), notification(budgetActual, 100), notification(budgetForecasted, 100),
+			},
+		})
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = call("CreateBudget", map[string]interface{}{"AccountId": fakeAwsAccountID, "Budget": map[string]interface{}{"BudgetName": "boilerplate-nestjs-staging-monthly-budget"}})
+		assert.Equal(t, http.StatusBadRequest, status, "orçamento duplicado")
+
+		_, described := call("DescribeBudget", map[string]string{"AccountId": fakeAwsAccountID, "BudgetName": "boilerplate-nestjs-staging-monthly-budget"})
+		budget, _ := described["Budget"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"Amount": "300.0", "Unit": "USD"}, budget["BudgetLimit"])
+		_, listed := call("DescribeNotificationsForBudget", map[string]string{"AccountId": fakeAwsAccountID, "BudgetName": "boilerplate-nestjs-staging-monthly-budget"})
+		assert.Len(t, listed["Notifications"], 3)
+		status, _ = call("DescribeBudget", map[string]string{"AccountId": "000000000000", "BudgetName": "boilerplate-nestjs-staging-monthly-budget"})
+		assert.Equal(t, http.StatusBadRequest, status, "conta diferente da devolvida pelo STS")
+
+		notifications := fake.ReplayMonth(syntheticBudgetMonth(300))
+		assert.Equal(t, []string{"dia 23: FORECASTED 100%", "dia 24: ACTUAL 80%", "dia 27: ACTUAL 100%"}, describeBudgetNotifications(notifications))
+		for _, notification := range notifications {
+			assert.Equal(t, emails, notification.Recipients)
+		}
+
+		call("DeleteBudget", map[string]string{"AccountId": fakeAwsAccountID, "BudgetName": "boilerplate-nestjs-staging-monthly-budget"})
+		assert.Empty(t, fake.ReplayMonth(month), "orçamento removido não avisa")
+		status, body := call("DescribeSubscribersForNotification", map[string]string{"AccountId": fakeAwsAccountID, "BudgetName": "boilerplate-nestjs-staging-monthly-budget"})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "NotFoundException", body["__type"])
+	})
+
+	t.Run("GCP", func(t *testing.T) {
+		t.Parallel()
+
+		fake := newFakeGcpBilling(t)
+		send := func(method, path string, body interface{}) (int, map[string]interface{}) {
+			var reader io.Reader
+			if body != nil {
+				content, _ := json.Marshal(body)
+				reader = bytes.NewReader(content)
+			}
+			request, _ := http.NewRequest(method, fake.Server.URL+path, reader)
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("Erro ao chamar %s %s: %v", method, path, err)
+			}
+			defer response.Body.Close()
+			var decoded map[string]interface{}
+			json.NewDecoder(response.Body).Decode(&decoded)
+			return response.StatusCode, decoded
+		}
+
+		// Chamadas na ordem do provider google: canal de e-mail, tópico e orçamento
+		_, channel := send(http.MethodPost, "/v3/projects/fake-project/notificationChannels",
+			map[string]interface{}{"type": "email", "labels": map[string]string{"email_address": "admin@example.com"}})
+		channelName, _ := channel["name"].(string)
+		assert.True(t, strings.HasPrefix(channelName, "projects/fake-project/notificationChannels/"), channelName)
+		status, _ := send(http.MethodPut, "/v1/projects/fake-project/topics/budget-alerts", map[string]interface{}{})
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = send(http.MethodPut, "/v1/projects/fake-project/topics/budget-alerts", map[string]interface{}{})
+		assert.Equal(t, http.StatusConflict, status, "tópico duplicado")
+
+		var thresholdRules []interface{}
+		for _, percent := range []float64{0.8, 1.0, 0.5} {
+			thresholdRules = append(thresholdRules, map[string]interface{}{"thresholdPercent": percent, "spendBasis": "CURRENT_SPEND"})
+		}
+		thresholdRules = append(thresholdRules, map[string]interface{}{"thresholdPercent": 1.0, "spendBasis": "FORECASTED_SPEND"})
+		_, budget := send(http.MethodPost, "/v1/billingAccounts/000000-AAAAAA-111111/budgets", map[string]interface{}{
+			"displayName":    "boilerplate-nestjs-dev-budget",
+			"amount":         map[string]interface{}{"specifiedAmount": map[string]string{"currencyCode": "USD", "units": "100"}},
+			"thresholdRules": thresholdRules,
+			"allUpdatesRule": map[string]interface{}{
+				"pubsubTopic":                    "projects/fake-project/topics/budget-alerts",
+				"monitoringNotificationChannels": []string{channelName},
+			},
+		})
+		budgetName, _ := budget["name"].(string)
+		status, fetched := send(http.MethodGet, "/v1/"+budgetName, nil)
+		if assert.Equal(t, http.StatusOK, status) {
+			amount, currency := gcpBudgetAmount(fetched)
+			assert.Equal(t, 100.0, amount)
+			assert.Equal(t, "USD", currency)
+		}
+
+		notifications := fake.ReplayMonth(month)
+		assert.Equal(t, []string{"dia 18: ACTUAL 50%", "dia 23: FORECASTED 100%", "dia 24: ACTUAL 80%", "dia 27: ACTUAL 100%"},
+			describeBudgetNotifications(notifications))
+		for _, notification := range notifications {
+			assert.Equal(t, []string{"admin@example.com"}, notification.Recipients)
+		}
+		messages := fake.Messages("projects/fake-project/topics/budget-alerts")
+		if assert.Len(t, messages, 4) {
+			assert.Equal(t, 0.5, messages[0].Data["alertThresholdExceeded"])
+			assert.Equal(t, 1.0, messages[1].Data["forecastThresholdExceeded"])
+			assert.Equal(t, "USD", messages[0].Data["currencyCode"])
+			assert.Equal(t, "000000-AAAAAA-111111", messages[0].Attributes["billingAccountId"])
+		}
+
+		status, _ = send(http.MethodDelete, "/v1/"+budgetName, nil)
+		assert.Equal(t, http.StatusOK, status)
+		status, body := send(http.MethodGet, "/v1/"+budgetName, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		if errorBody, ok := body["error"].(map[string]interface{}); assert.True(t, ok) {
+			assert.Equal(t, "NOT_FOUND", errorBody["status"])
+		}
+	})
+
+	t.Run("DigitalOcean", func(t *testing.T) {
+		t.Parallel()
+
+		fake := newFakeDigitalOceanAlerts(t)
+		content, _ := json.Marshal(map[string]interface{}{
+			"alerts":      map[string]interface{}{"email": []string{"admin@example.com"}},
+			"type":        "v1/insights/droplet/cpu",
+			"compare":     "GreaterThan",
+			"value":       80,
+			"window":      "1h",
+			"description": "CPU alta",
+		})
+		response, err := http.Post(fake.Server.URL+"/v2/monitoring/alerts", "application/json", bytes.NewReader(content))
+		if err != nil {
+			t.Fatalf("Erro ao criar a política: %v", err)
+		}
+		var created struct {
+			Policy map[string]interface{} `json:"policy"`
+		}
+		json.NewDecoder(response.Body).Decode(&created)
+		response.Body.Close()
+		uuid, _ := created.Policy["uuid"].(string)
+		assert.NotEmpty(t, uuid)
+		assert.Len(t, fake.Policies(), 1)
+
+		request, _ := http.NewRequest(http.MethodDelete, fake.Server.URL+"/v2/monitoring/alerts/"+uuid, nil)
+		response, err = http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Erro ao remover a política: %v", err)
+		}
+		response.Body.Close()
+		assert.Equal(t, http.StatusNoContent, response.StatusCode)
+		response, err = http.Get(fake.Server.URL + "/v2/monitoring/alerts/" + uuid)
+		if err != nil {
+			t.Fatalf("Erro ao ler a política: %v", err)
+		}
+		response.Body.Close()
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+}
+
+// TestCostMonitorConfig confere que os valores de cost de cada ambiente são utilizáveis por todos
+// os provedores
+func TestCostMonitorConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao carregar config.yaml de %s: %v", environment, err)
+		}
+		cost := cfg.Cost
+		assert.Greater(t, cost.BudgetAmount, 0.0, "%s: cost.budget_amount", environment)
+		assert.Regexp(t, `^[A-Z]{3}$`, cost.BudgetCurrency, "%s: cost.budget_currency", environment)
+		assert.NotEmpty(t, cost.AlertEmails, "%s: cost.alert_emails", environment)
+		assert.True(t, cost.AlertThresholdPercent > 0 && cost.AlertThresholdPercent < 100,
+			"%s: cost.alert_threshold_percent deve ficar entre 0 e 100 para avisar antes do estouro", environment)
+		for _, threshold := range cost.GCP.BudgetThresholds {
+			assert.Greater(t, threshold, 0.0, "%s: cost.gcp.budget_thresholds", environment)
+		}
+		if cfg.Provider.Active == "digitalocean" {
+			assert.Equal(t, "USD", cost.BudgetCurrency, "%s: o DigitalOcean fatura apenas em dólares", environment)
+		}
+		// O GCP aceita no máximo cinco canais de notificação por orçamento, um por e-mail
+		assert.LessOrEqual(t, len(cost.AlertEmails), 5, "%s: cost.alert_emails", environment)
+	}
+}
+
+// TestCostMonitorRootPlan planeja a raiz de cada ambiente só com o cost_monitor do provider.active,
+// com o provider apontado para o fake, e confere no plano os valores do orçamento vindos de cost
+// do config.yaml. Com TF_PROVIDER_MIRROR apontando para o espelho de providers, roda sem internet
+func TestCostMonitorRootPlan(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform não encontrado no PATH")
+	}
+
+	for _, environment := range environments {
+		environment := environment
+		t.Run(environment, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := loadEnvironmentConfig(environment)
+			if err != nil {
+				t.Fatalf("Erro ao carregar config.yaml: %v", err)
+			}
+			cost := cfg.Cost
+			provider := cfg.Provider.Active
+			var endpoint string
+			switch provider {
+			case "aws":
+				endpoint = newFakeAwsBudgets(t).Server.URL
+			case "gcp":
+				endpoint = newFakeGcpBilling(t).Server.URL
+			case "digitalocean":
+				endpoint = newFakeDigitalOceanAlerts(t).Server.URL
+			default:
+				t.Skipf("provider.active %s sem cost_monitor", provider)
+			}
+
+			options := withRetryableErrors(t, &terraform.Options{
+				TerraformDir: costMonitorPlanRoot(t, provider, endpoint),
+				Vars:         map[string]interface{}{"environment": environment},
+				Targets:      []string{"module.cost_monitor_" + provider},
+				PlanFilePath: "cost.tfplan",
+				PluginDir:    os.Getenv("TF_PROVIDER_MIRROR"),
+				NoColor:      true,
+			})
+			planJSON, err := terraform.InitAndPlanAndShowE(t, options)
+			if err != nil {
+				t.Fatalf("Erro ao planejar o cost_monitor de %s: %v", provider, err)
+			}
+			resources, err := loadPlannedResources([]byte(planJSON))
+			if err != nil {
+				t.Fatalf("Erro ao ler o plano: %v", err)
+			}
+			byType := map[string][]plannedResource{}
+			for _, resource := range resources {
+				byType[resource.Type] = append(byType[resource.Type], resource)
+			}
+
+			var expectedRules []string
+			for _, threshold := range budgetActualThresholds(provider, cfg) {
+				expectedRules = append(expectedRules, fmt.Sprintf("%s %g%%", budgetActual, threshold))
+			}
+			expectedRules = append(expectedRules, budgetForecasted+" 100%")
+
+			switch provider {
+			case "aws":
+				budgets := byType["aws_budgets_budget"]
+				if len(budgets) != 1 {
+					t.Fatalf("Esperado um aws_budgets_budget no plano, encontrados %d", len(budgets))
+				}
+				values := budgets[0].Values
+				assert.Equal(t, cost.BudgetAmount, planNumber(values["limit_amount"]), "limit_amount")
+				assert.Equal(t, cost.BudgetCurrency, values["limit_unit"], "limit_unit")
+				var rules []string
+				for _, notification := range planBlocks(values, "notification") {
+					rules = append(rules, fmt.Sprintf("%v %g%%", notification["notification_type"], planNumber(notification["threshold"])))
+					assert.ElementsMatch(t, cost.AlertEmails, planStrings(notification, "subscriber_email_addresses"), "e-mails do aviso")
+				}
+				assert.ElementsMatch(t, expectedRules, rules)
+
+			case "gcp":
+				budgets := byType["google_billing_budget"]
+				if len(budgets) != 1 {
+					t.Fatalf("Esperado um google_billing_budget no plano, encontrados %d", len(budgets))
+				}
+				values := budgets[0].Values
+				for _, amount := range planBlocks(values, "amount") {
+					for _, specified := range planBlocks(amount, "specified_amount") {
+						assert.Equal(t, cost.BudgetAmount, planNumber(specified["units"]), "units")
+						assert.Equal(t, cost.BudgetCurrency, specified["currency_code"], "currency_code")
+					}
+				}
+				var rules []string
+				for _, rule := range planBlocks(values, "threshold_rules") {
+					kind := budgetActual
+					if rule["spend_basis"] == "FORECASTED_SPEND" {
+						kind = budgetForecasted
+					}
+					rules = append(rules, fmt.Sprintf("%s %g%%", kind, planNumber(rule["threshold_percent"])*100))
+				}
+				assert.ElementsMatch(t, expectedRules, rules)
+				var emails []string
+				for _, channel := range byType["google_monitoring_notification_channel"] {
+					labels, _ := channel.Values["labels"].(map[string]interface{})
+					emails = append(emails, fmt.Sprint(labels["email_address"]))
+				}
+				assert.ElementsMatch(t, cost.AlertEmails, emails, "um canal de e-mail por cost.alert_emails")
+
+			case "digitalocean":
+				// Sem API de orçamento, o plano só tem os alertas de uso enviados aos cost.alert_emails
+				alerts := byType["digitalocean_monitor_alert"]
+				assert.NotEmpty(t, alerts)
+				for _, alert := range alerts {
+					for _, targets := range planBlocks(alert.Values, "alerts") {
+						assert.ElementsMatch(t, cost.AlertEmails, planStrings(targets, "email"), alert.Address)
+					}
+				}
+			}
+		})
+	}
+}
+
+// planNumber lê um número do plano, que pode vir como número ou como string (limit_amount, units)
+func planNumber(value interface{}) float64 {
+	switch value := value.(type) {
+	case float64:
+		return value
+	case string:
+		number, _ := strconv.ParseFloat(value, 64)
+		return number
+	}
+	return 0
+}
@@ -0,0 +1,252 @@
+package test
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// cidrSubnetRange é uma subnet prevista no layout de um módulo de rede
+type cidrSubnetRange struct {
+	Name string
+	Zone string
+	CIDR *net.IPNet
+}
+
+// cidrPlan é o layout de endereços que um módulo network/<provedor> deve produzir para um ambiente
+type cidrPlan struct {
+	Environment string
+	Provider    string
+	VPC         *net.IPNet
+	Subnets     []cidrSubnetRange
+	// Connected indica que o ambiente está ligado a outras redes (transit_gateway_id ou shared_vpc_host)
+	Connected bool
+	Peers     []cidrPeer
+}
+
+// cidrPeer é uma rede externa alcançável pelo ambiente (transit gateway, shared VPC ou peering)
+type cidrPeer struct {
+	Name string
+	CIDR *net.IPNet
+}
+
+// Zonas padrão de modules/network/aws/variables.tf
+var awsDefaultZones = []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+
+// Faixas usadas internamente pelo DigitalOcean (rede de pods e serviços do DOKS) que não podem ser usadas por VPCs
+var digitalOceanReservedCIDRs = []string{"10.244.0.0/16", "10.245.0.0/16", "10.246.0.0/24"}
+
+// cidrSubnet reproduz a função cidrsubnet do Terraform
+func cidrSubnet(base *net.IPNet, newbits, netnum int) (*net.IPNet, error) {
+	prefix, bits := base.Mask.Size()
+	if newbits < 0 || prefix+newbits > bits {
+		return nil, fmt.Errorf("não é possível estender o prefixo /%d em %d bits", prefix, newbits)
+	}
+	if netnum < 0 || big.NewInt(int64(netnum)).BitLen() > newbits {
+		return nil, fmt.Errorf("netnum %d não cabe em %d bits", netnum, newbits)
+	}
+
+	ip := base.IP.To4()
+	if bits == 128 {
+		ip = base.IP.To16()
+	}
+	value := new(big.Int).SetBytes(ip)
+	value.Or(value, new(big.Int).Lsh(big.NewInt(int64(netnum)), uint(bits-prefix-newbits)))
+
+	result := make(net.IP, len(ip))
+	value.FillBytes(result)
+	return &net.IPNet{IP: result, Mask: net.CIDRMask(prefix+newbits, bits)}, nil
+}
+
+// cidrOverlap indica se duas faixas compartilham algum endereço
+func cidrOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// cidrContains indica se inner está inteiramente dentro de outer
+func cidrContains(outer, inner *net.IPNet) bool {
+	outerPrefix, _ := outer.Mask.Size()
+	innerPrefix, _ := inner.Mask.Size()
+	return outerPrefix <= innerPrefix && outer.Contains(inner.IP)
+}
+
+func parseCIDR(value string) (*net.IPNet, error) {
+	ip, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, err
+	}
+	if !ip.Equal(network.IP) {
+		return nil, fmt.Errorf("%s não está alinhado ao prefixo (use %s)", value, network)
+	}
+	return network, nil
+}
+
+// planNetwork calcula o layout de subnets do módulo network/<provedor> para vpc_cidr e subnet_count
+func planNetwork(provider, vpcCIDR string, subnetCount int, zones []string) (*cidrPlan, error) {
+	vpc, err := parseCIDR(vpcCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("vpc_cidr inválido: %v", err)
+	}
+	plan := &cidrPlan{Provider: provider, VPC: vpc}
+
+	add := func(name, zone string, netnum int) error {
+		subnet, err := cidrSubnet(vpc, 8, netnum)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		plan.Subnets = append(plan.Subnets, cidrSubnetRange{Name: name, Zone: zone, CIDR: subnet})
+		return nil
+	}
+
+	switch provider {
+	case "aws":
+		// modules/network/aws: públicas em cidrsubnet(vpc_cidr, 8, i) e privadas em
+		// cidrsubnet(vpc_cidr, 8, i + private_subnet_offset), cujo padrão é o número de zonas
+		if len(zones) == 0 {
+			zones = awsDefaultZones
+		}
+		if subnetCount < 1 {
+			return nil, fmt.Errorf("subnet_count deve ser pelo menos 1")
+		}
+		offset := len(zones)
+		if subnetCount > offset {
+			return nil, fmt.Errorf("subnet_count %d sobrepõe as subnets privadas, que começam no netnum %d (número de zonas); defina private_subnet_offset", subnetCount, offset)
+		}
+		for i := 0; i < subnetCount; i++ {
+			if err := add(fmt.Sprintf("public-%d", i+1), zones[i%len(zones)], i); err != nil {
+				return nil, err
+			}
+		}
+		for i := 0; i < subnetCount; i++ {
+			if err := add(fmt.Sprintf("private-%d", i+1), zones[i%len(zones)], i+offset); err != nil {
+				return nil, err
+			}
+		}
+	case "gcp":
+		// modules/network/gcp: uma subnet pública (netnum 0) e uma privada (netnum 10), independente de subnet_count
+		if err := add("public", "", 0); err != nil {
+			return nil, err
+		}
+		if err := add("private", "", 10); err != nil {
+			return nil, err
+		}
+	case "digitalocean":
+		// modules/network/digital-ocean: a VPC usa vpc_cidr inteiro, sem subnets
+	default:
+		return nil, fmt.Errorf("provedor %q não suportado", provider)
+	}
+	return plan, nil
+}
+
+// planEnvironmentNetwork calcula o layout do ambiente a partir do config.yaml
+func planEnvironmentNetwork(environment string, cfg *environmentConfig) (*cidrPlan, error) {
+	plan, err := planNetwork(cfg.Provider.Active, cfg.Network.VPCCIDR, cfg.Network.SubnetCount, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", environment, err)
+	}
+	plan.Environment = environment
+	plan.Connected = cfg.Network.AWS.TransitGatewayID != "" || cfg.Network.GCP.SharedVPCHost != ""
+	for _, peer := range cfg.Network.PeeredCIDRs {
+		network, err := parseCIDR(peer.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("%s: peered_cidrs %s: %v", environment, peer.Name, err)
+		}
+		plan.Peers = append(plan.Peers, cidrPeer{Name: peer.Name, CIDR: network})
+		plan.Connected = true
+	}
+	return plan, nil
+}
+
+// CIDRs retorna as faixas das subnets cujo nome começa com o prefixo informado (public, private)
+func (p *cidrPlan) CIDRs(prefix string) []string {
+	var cidrs []string
+	for _, subnet := range p.Subnets {
+		if len(subnet.Name) >= len(prefix) && subnet.Name[:len(prefix)] == prefix {
+			cidrs = append(cidrs, subnet.CIDR.String())
+		}
+	}
+	return cidrs
+}
+
+// Validate confere limites do provedor e sobreposições dentro da própria VPC
+func (p *cidrPlan) Validate() []string {
+	var problems []string
+	prefix, _ := p.VPC.Mask.Size()
+
+	switch p.Provider {
+	case "aws":
+		if prefix < 16 || prefix > 28 {
+			problems = append(problems, fmt.Sprintf("VPCs da AWS aceitam prefixos entre /16 e /28, vpc_cidr é /%d", prefix))
+		}
+	case "digitalocean":
+		if prefix < 16 || prefix > 24 {
+			problems = append(problems, fmt.Sprintf("VPCs do DigitalOcean aceitam prefixos entre /16 e /24, vpc_cidr é /%d", prefix))
+		}
+		for _, reserved := range digitalOceanReservedCIDRs {
+			network, _ := parseCIDR(reserved)
+			if cidrOverlap(p.VPC, network) {
+				problems = append(problems, fmt.Sprintf("vpc_cidr %s sobrepõe a faixa reservada do DigitalOcean %s", p.VPC, reserved))
+			}
+		}
+	}
+
+	for i, subnet := range p.Subnets {
+		if subnetPrefix, _ := subnet.CIDR.Mask.Size(); p.Provider == "aws" && subnetPrefix > 28 {
+			problems = append(problems, fmt.Sprintf("subnet %s (%s) é menor que o mínimo de /28 da AWS", subnet.Name, subnet.CIDR))
+		}
+		if !cidrContains(p.VPC, subnet.CIDR) {
+			problems = append(problems, fmt.Sprintf("subnet %s (%s) está fora da VPC %s", subnet.Name, subnet.CIDR, p.VPC))
+		}
+		for _, other := range p.Subnets[i+1:] {
+			if cidrOverlap(subnet.CIDR, other.CIDR) {
+				problems = append(problems, fmt.Sprintf("subnets %s (%s) e %s (%s) se sobrepõem", subnet.Name, subnet.CIDR, other.Name, other.CIDR))
+			}
+		}
+	}
+	return problems
+}
+
+// cidrConflict é uma sobreposição entre redes de ambientes diferentes ou com redes conectadas
+type cidrConflict struct {
+	A, B string
+	// Blocking indica que as redes trocam rotas (transit gateway ou shared VPC), e a sobreposição quebra o roteamento
+	Blocking bool
+}
+
+func (c cidrConflict) String() string {
+	severity := "aviso"
+	if c.Blocking {
+		severity = "erro"
+	}
+	return fmt.Sprintf("%s: %s sobrepõe %s", severity, c.A, c.B)
+}
+
+// checkCIDROverlaps compara as VPCs dos ambientes entre si e com as redes conectadas.
+// Ambientes conectados e suas redes externas formam um único domínio de roteamento, então
+// qualquer sobreposição dentro dele é bloqueante; as demais são apenas avisos.
+func checkCIDROverlaps(plans []*cidrPlan) []cidrConflict {
+	type node struct {
+		label     string
+		cidr      *net.IPNet
+		connected bool
+	}
+	var nodes []node
+	for _, plan := range plans {
+		nodes = append(nodes, node{fmt.Sprintf("%s/%s (%s)", plan.Environment, plan.Provider, plan.VPC), plan.VPC, plan.Connected})
+		for _, peer := range plan.Peers {
+			nodes = append(nodes, node{fmt.Sprintf("%s/%s (%s)", plan.Environment, peer.Name, peer.CIDR), peer.CIDR, true})
+		}
+	}
+
+	var conflicts []cidrConflict
+	for i, a := range nodes {
+		for _, b := range nodes[i+1:] {
+			if cidrOverlap(a.cidr, b.cidr) {
+				conflicts = append(conflicts, cidrConflict{A: a.label, B: b.label, Blocking: a.connected && b.connected})
+			}
+		}
+	}
+	sort.SliceStable(conflicts, func(i, j int) bool { return conflicts[i].Blocking && !conflicts[j].Blocking })
+	return conflicts
+}
@@ -0,0 +1,171 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCIDRSubnet compara a reprodução do cidrsubnet com resultados do próprio Terraform
+func TestCIDRSubnet(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		base    string
+		newbits int
+		netnum  int
+		want    string
+	}{
+		{"10.0.0.0/16", 8, 0, "10.0.0.0/24"},
+		{"10.0.0.0/16", 8, 10, "10.0.10.0/24"},
+		{"172.16.0.0/12", 4, 2, "172.18.0.0/16"},
+		{"10.1.2.0/24", 4, 15, "10.1.2.240/28"},
+		{"fd00:fd12:3456:7800::/56", 16, 162, "fd00:fd12:3456:7800:a200::/72"},
+	}
+	for _, c := range cases {
+		base, err := parseCIDR(c.base)
+		if err != nil {
+			t.Fatalf("Erro ao interpretar %s: %v", c.base, err)
+		}
+		subnet, err := cidrSubnet(base, c.newbits, c.netnum)
+		if assert.NoError(t, err) {
+			assert.Equal(t, c.want, subnet.String(), "cidrsubnet(%q, %d, %d)", c.base, c.newbits, c.netnum)
+		}
+	}
+
+	base, _ := parseCIDR("10.0.0.0/16")
+	_, err := cidrSubnet(base, 8, 256)
+	assert.Error(t, err, "netnum maior que 2^newbits")
+	_, err = cidrSubnet(base, 17, 0)
+	assert.Error(t, err, "prefixo maior que /32")
+	_, err = parseCIDR("10.0.1.0/16")
+	assert.Error(t, err, "CIDR com bits de host")
+}
+
+// TestCIDRPlanEnvironments calcula o layout de rede de cada ambiente e verifica sobreposições entre eles
+func TestCIDRPlanEnvironments(t *testing.T) {
+	t.Parallel()
+
+	var plans []*cidrPlan
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao carregar config.yaml de %s: %v", environment, err)
+		}
+		plan, err := planEnvironmentNetwork(environment, cfg)
+		if err != nil {
+			t.Fatalf("Erro ao planejar rede: %v", err)
+		}
+		for _, problem := range plan.Validate() {
+			t.Errorf("%s: %s", environment, problem)
+		}
+		plans = append(plans, plan)
+	}
+
+	for _, conflict := range checkCIDROverlaps(plans) {
+		if conflict.Blocking {
+			t.Errorf("%s", conflict)
+		} else {
+			t.Logf("%s", conflict)
+		}
+	}
+}
+
+// TestCIDRPlanLayouts confere o layout previsto para cada módulo de rede
+func TestCIDRPlanLayouts(t *testing.T) {
+	t.Parallel()
+
+	aws, err := planNetwork("aws", "10.0.0.0/16", 2, nil)
+	if err != nil {
+		t.Fatalf("Erro ao planejar rede AWS: %v", err)
+	}
+	assert.Equal(t, []string{"10.0.0.0/24", "10.0.1.0/24"}, aws.CIDRs("public"))
+	assert.Equal(t, []string{"10.0.3.0/24", "10.0.4.0/24"}, aws.CIDRs("private"), "As privadas começam no número de zonas, não em subnet_count")
+	assert.Empty(t, aws.Validate())
+
+	fourZones := []string{"us-east-1a", "us-east-1b", "us-east-1c", "us-east-1d"}
+	four, err := planNetwork("aws", "10.0.0.0/16", 4, fourZones)
+	if err != nil {
+		t.Fatalf("Erro ao planejar rede AWS: %v", err)
+	}
+	assert.Equal(t, []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"}, four.CIDRs("public"))
+	assert.Equal(t, []string{"10.0.4.0/24", "10.0.5.0/24", "10.0.6.0/24", "10.0.7.0/24"}, four.CIDRs("private"))
+	assert.Equal(t, "us-east-1d", four.Subnets[3].Zone)
+
+	_, err = planNetwork("aws", "10.0.0.0/16", 4, nil)
+	assert.Error(t, err, "4 subnets públicas alcançam a primeira privada quando há 3 zonas")
+
+	gcp, err := planNetwork("gcp", "10.0.0.0/16", 3, nil)
+	if err != nil {
+		t.Fatalf("Erro ao planejar rede GCP: %v", err)
+	}
+	assert.Equal(t, []string{"10.0.0.0/24"}, gcp.CIDRs("public"))
+	assert.Equal(t, []string{"10.0.10.0/24"}, gcp.CIDRs("private"))
+
+	do, err := planNetwork("digitalocean", "10.244.0.0/16", 3, nil)
+	if err != nil {
+		t.Fatalf("Erro ao planejar rede DigitalOcean: %v", err)
+	}
+	assert.Empty(t, do.Subnets)
+	if assert.Len(t, do.Validate(), 1) {
+		assert.Contains(t, do.Validate()[0], "faixa reservada do DigitalOcean")
+	}
+
+	small, err := planNetwork("aws", "10.0.0.0/24", 3, nil)
+	if err != nil {
+		t.Fatalf("Erro ao planejar rede AWS: %v", err)
+	}
+	assert.Contains(t, small.Validate(), "subnet public-1 (10.0.0.0/32) é menor que o mínimo de /28 da AWS")
+
+	manyZones := make([]string, 200)
+	for i := range manyZones {
+		manyZones[i] = fmt.Sprintf("zona-%d", i)
+	}
+	_, err = planNetwork("aws", "10.0.0.0/16", 129, manyZones)
+	assert.Error(t, err, "as privadas passam do netnum 255")
+}
+
+// TestCIDROverlapDetection garante que sobreposições em redes conectadas são bloqueantes
+func TestCIDROverlapDetection(t *testing.T) {
+	t.Parallel()
+
+	mustPlan := func(environment, provider, cidr string, connected bool, peers ...string) *cidrPlan {
+		plan, err := planNetwork(provider, cidr, 3, nil)
+		if err != nil {
+			t.Fatalf("Erro ao planejar rede: %v", err)
+		}
+		plan.Environment = environment
+		plan.Connected = connected
+		for _, peer := range peers {
+			network, err := parseCIDR(peer)
+			if err != nil {
+				t.Fatalf("Erro ao interpretar %s: %v", peer, err)
+			}
+			plan.Peers = append(plan.Peers, cidrPeer{Name: "transit", CIDR: network})
+		}
+		return plan
+	}
+
+	conflicts := checkCIDROverlaps([]*cidrPlan{
+		mustPlan("dev", "aws", "10.0.0.0/16", true, "10.0.128.0/20"),
+		mustPlan("staging", "aws", "10.1.0.0/16", false),
+		mustPlan("prod", "gcp", "10.0.0.0/16", true),
+		mustPlan("sandbox", "digitalocean", "10.1.0.0/20", false),
+	})
+
+	var blocking, warnings []string
+	for _, conflict := range conflicts {
+		if conflict.Blocking {
+			blocking = append(blocking, conflict.String())
+		} else {
+			warnings = append(warnings, conflict.String())
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"erro: dev/aws (10.0.0.0/16) sobrepõe dev/transit (10.0.128.0/20)",
+		"erro: dev/aws (10.0.0.0/16) sobrepõe prod/gcp (10.0.0.0/16)",
+		"erro: dev/transit (10.0.128.0/20) sobrepõe prod/gcp (10.0.0.0/16)",
+	}, blocking)
+	assert.Equal(t, []string{"aviso: staging/aws (10.1.0.0/16) sobrepõe sandbox/digitalocean (10.1.0.0/20)"}, warnings)
+}
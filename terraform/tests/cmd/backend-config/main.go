@@ -0,0 +1,58 @@
+// backend-config grava o backend "s3" de um ambiente em terraform/backend_override.tf, a partir de
+// provider.aws.state_* do config.yaml. Rode a partir de terraform/tests:
+//
+//	go run ./cmd/backend-config -environment staging
+//	cd .. && terraform init -migrate-state
+//
+// O bloco vem do pacote backendconfig, o mesmo usado pelos testes (TestBackendConfigCommand)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cirebox/boilerplate-nestjs/terraform/tests/backendconfig"
+)
+
+func main() {
+	environment := flag.String("environment", "", "ambiente em environments/ (dev, staging, prod)")
+	root := flag.String("root", "..", "diretório terraform/, com environments/")
+	output := flag.String("output", "", "arquivo gerado, ou - para a saída padrão (padrão: <root>/backend_override.tf)")
+	flag.Parse()
+
+	if *environment == "" {
+		fmt.Fprintln(os.Stderr, "uso: go run ./cmd/backend-config -environment <ambiente> [-root ..] [-output arquivo|-]")
+		os.Exit(2)
+	}
+	if err := run(*environment, *root, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "backend-config: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(environment, root, output string) error {
+	aws, err := backendconfig.Load(root, environment)
+	if err != nil {
+		return err
+	}
+	backend, err := backendconfig.New(environment, aws)
+	if err != nil {
+		return err
+	}
+	block := backend.Block()
+
+	if output == "-" {
+		_, err := fmt.Print(block)
+		return err
+	}
+	if output == "" {
+		output = filepath.Join(root, backendconfig.OverrideFile)
+	}
+	if err := os.WriteFile(output, []byte(block), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Backend de %s gravado em %s\n", environment, output)
+	return nil
+}
@@ -50,6 +50,7 @@ Feature: Regras de Segurança para Infraestrutura do Boilerplate NestJS
     And it must contain ip_range
     And its ip_range must not be "0.0.0.0/0"
 
+  # A restrição de 0.0.0.0/0 às portas 80 e 443 também é verificada em tests/reachability_test.go
   Scenario: Garantir que as regras de firewall estão configuradas de forma restritiva
     Given I have digitalocean_firewall defined
     Then it must contain inbound_rule
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cirebox/boilerplate-nestjs/terraform/tests/backendconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// environments lista os ambientes que possuem config.yaml em environments/
+var environments = []string{"dev", "staging", "prod"}
+
+// environmentConfig espelha as chaves de environments/<ambiente>/config.yaml usadas pelos testes
+type environmentConfig struct {
+	Provider struct {
+		Active string `yaml:"active"`
+		AWS    struct {
+			// Região, perfil e backend s3 do estado remoto (state_*), lidos também por cmd/backend-config
+			backendconfig.AWSConfig `yaml:",inline"`
+			SecondaryRegion         string `yaml:"secondary_region"`
+		} `yaml:"aws"`
+		// Faixa de portas do host reservada aos testes do ambiente local
+		Local struct {
+			TestPortRangeStart int `yaml:"test_port_range_start"`
+			TestPortRangeEnd   int `yaml:"test_port_range_end"`
+		} `yaml:"local"`
+	} `yaml:"provider"`
+
+	DisasterRecovery struct {
+		Enabled     bool   `yaml:"enabled"`
+		StateBucket string `yaml:"state_bucket"`
+	} `yaml:"disaster_recovery"`
+
+	Monitoring struct {
+		EnableMetrics        bool    `yaml:"enable_metrics"`
+		RetentionDays        int     `yaml:"retention_days"`
+		AlertThresholdCPU    float64 `yaml:"alert_threshold_cpu"`
+		AlertThresholdMemory float64 `yaml:"alert_threshold_memory"`
+		Namespace            string  `yaml:"namespace"`
+		// Destinos de notificação de cada provedor, usados na simulação de alertas
+		AWS struct {
+			WebhookURL string `yaml:"webhook_url"`
+		} `yaml:"aws"`
+		GCP struct {
+			NotificationChannels []string `yaml:"notification_channels"`
+			SlackChannel         string   `yaml:"slack_channel"`
+			PagerdutyIntegration bool     `yaml:"pagerduty_integration"`
+		} `yaml:"gcp"`
+		DigitalOcean struct {
+			SlackChannel string `yaml:"slack_channel"`
+		} `yaml:"digitalocean"`
+	} `yaml:"monitoring"`
+
+	// Orçamento mensal aplicado pelos módulos cost_monitor
+	Cost struct {
+		BudgetAmount          float64  `yaml:"budget_amount"`
+		BudgetCurrency        string   `yaml:"budget_currency"`
+		AlertThresholdPercent float64  `yaml:"alert_threshold_percent"`
+		AlertEmails           []string `yaml:"alert_emails"`
+		GCP                   struct {
+			BudgetThresholds []float64 `yaml:"budget_thresholds"`
+		} `yaml:"gcp"`
+	} `yaml:"cost"`
+
+	Network struct {
+		VPCCIDR          string `yaml:"vpc_cidr"`
+		SubnetCount      int    `yaml:"subnet_count"`
+		CreateNATGateway bool   `yaml:"create_nat_gateway"`
+		AWS              struct {
+			TransitGatewayID string `yaml:"transit_gateway_id"`
+		} `yaml:"aws"`
+		GCP struct {
+			SharedVPCHost string `yaml:"shared_vpc_host"`
+		} `yaml:"gcp"`
+		// Redes alcançáveis pelo transit gateway ou pela shared VPC, usadas na checagem de sobreposição
+		PeeredCIDRs []struct {
+			Name string `yaml:"name"`
+			CIDR string `yaml:"cidr"`
+		} `yaml:"peered_cidrs"`
+	} `yaml:"network"`
+
+	// Regras de entrada esperadas por provedor, conferidas contra exposição de portas à internet
+	Security struct {
+		AWS struct {
+			SecurityGroupRules []struct {
+				Type       string   `yaml:"type"`
+				Protocol   string   `yaml:"protocol"`
+				Port       int      `yaml:"port"`
+				CIDRBlocks []string `yaml:"cidr_blocks"`
+			} `yaml:"security_group_rules"`
+		} `yaml:"aws"`
+		DigitalOcean struct {
+			FirewallRules []struct {
+				Type     string `yaml:"type"`
+				Protocol string `yaml:"protocol"`
+				Ports    string `yaml:"ports"`
+				Sources  struct {
+					Addresses []string `yaml:"addresses"`
+				} `yaml:"sources"`
+			} `yaml:"firewall_rules"`
+		} `yaml:"digitalocean"`
+	} `yaml:"security"`
+
+	Database struct {
+		Engine        string `yaml:"engine"`
+		EngineVersion string `yaml:"engine_version"`
+	} `yaml:"database"`
+
+	Kubernetes struct {
+		Version string `yaml:"version"`
+		// Cluster kind usado pelos testes e pelo active_provider local-k8s
+		Local struct {
+			EnableKind      bool   `yaml:"enable_kind"`
+			KindClusterName string `yaml:"kind_cluster_name"`
+			KindConfigPath  string `yaml:"kind_config_path"`
+		} `yaml:"local"`
+	} `yaml:"kubernetes"`
+}
+
+// environmentConfigPath retorna o caminho do config.yaml do ambiente, relativo ao diretório tests/
+func environmentConfigPath(environment string) string {
+	return filepath.Join("..", "environments", environment, "config.yaml")
+}
+
+// loadEnvironmentConfig lê e interpreta o config.yaml do ambiente informado
+func loadEnvironmentConfig(environment string) (*environmentConfig, error) {
+	content, err := os.ReadFile(environmentConfigPath(environment))
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg environmentConfig
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar config.yaml de %s: %v", environment, err)
+	}
+	return &cfg, nil
+}
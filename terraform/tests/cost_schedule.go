@@ -0,0 +1,255 @@
+package test
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// costScheduleConfig espelha as variáveis de horário do módulo cost_optimization
+type costScheduleConfig struct {
+	Enabled        bool     `json:"ativar_desligamento_automatico"`
+	ResourceKinds  []string `json:"recursos_para_desligar"`
+	StartHour      float64  `json:"horario_comercial_inicio"`
+	EndHour        float64  `json:"horario_comercial_fim"`
+	ActiveDays     []int    `json:"dias_ativos"`
+	Timezone       string   `json:"timezone"`
+	ExcludeTags    []string `json:"tags_exclusao"`
+	AllowOverride  bool     `json:"permitir_override"`
+	OverrideTag    string   `json:"tag_override"`
+	WarningMinutes int      `json:"tempo_aviso_previo"`
+}
+
+// defaultCostScheduleConfig retorna os valores padrão de modules/cost_optimization/variables.tf
+func defaultCostScheduleConfig() costScheduleConfig {
+	return costScheduleConfig{
+		Enabled:        true,
+		ResourceKinds:  []string{"app_servers", "dev_databases", "test_environments"},
+		StartHour:      8,
+		EndHour:        18,
+		ActiveDays:     []int{1, 2, 3, 4, 5},
+		Timezone:       "America/Sao_Paulo",
+		ExcludeTags:    []string{"critical", "always-on", "production-essential"},
+		AllowOverride:  true,
+		OverrideTag:    "manter-ativo",
+		WarningMinutes: 30,
+	}
+}
+
+// costResourceKinds associa cada valor de recursos_para_desligar aos tipos de recurso dos provedores
+var costResourceKinds = map[string][]string{
+	"app_servers": {
+		"aws_instance", "digitalocean_droplet", "google_compute_instance",
+	},
+	"dev_databases": {
+		"aws_db_instance", "aws_rds_cluster", "digitalocean_database_cluster", "google_sql_database_instance",
+	},
+	"test_environments": {
+		"aws_eks_node_group", "digitalocean_kubernetes_node_pool", "google_container_node_pool",
+	},
+}
+
+// Tipos de evento da linha do tempo
+const (
+	costEventWarning  = "aviso"
+	costEventShutdown = "desligamento"
+	costEventStartup  = "religamento"
+)
+
+// costEvent é uma ação prevista pelo agendamento para um recurso
+type costEvent struct {
+	Time     time.Time
+	Type     string
+	Resource string
+}
+
+// costSkip registra um recurso que o agendamento não vai desligar e o motivo
+type costSkip struct {
+	Resource string
+	Reason   string
+}
+
+// costTimeline é o resultado da simulação
+type costTimeline struct {
+	Location *time.Location
+	Events   []costEvent
+	Skipped  []costSkip
+}
+
+// String formata a linha do tempo no fuso configurado, uma linha por evento
+func (tl costTimeline) String() string {
+	var b strings.Builder
+	for _, event := range tl.Events {
+		fmt.Fprintf(&b, "%s  %-12s  %s\n", event.Time.In(tl.Location).Format("Mon 2006-01-02 15:04 MST"), event.Type, event.Resource)
+	}
+	for _, skip := range tl.Skipped {
+		fmt.Fprintf(&b, "ignorado  %s: %s\n", skip.Resource, skip.Reason)
+	}
+	return b.String()
+}
+
+// EventsFor retorna os eventos de um recurso em ordem cronológica
+func (tl costTimeline) EventsFor(resource string) []costEvent {
+	var events []costEvent
+	for _, event := range tl.Events {
+		if event.Resource == resource {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Validate confere os valores que o módulo aceita sem validação própria
+func (c costScheduleConfig) Validate() error {
+	if c.StartHour < 0 || c.EndHour > 24 || c.StartHour >= c.EndHour {
+		return fmt.Errorf("horario_comercial_inicio (%v) deve ser menor que horario_comercial_fim (%v), ambos entre 0 e 24", c.StartHour, c.EndHour)
+	}
+	for _, day := range c.ActiveDays {
+		if day < 1 || day > 7 {
+			return fmt.Errorf("dias_ativos contém %d; use 1 (segunda) a 7 (domingo)", day)
+		}
+	}
+	for _, kind := range c.ResourceKinds {
+		if _, ok := costResourceKinds[kind]; !ok {
+			return fmt.Errorf("recursos_para_desligar contém %q, que não é um tipo conhecido", kind)
+		}
+	}
+	if c.WarningMinutes < 0 {
+		return fmt.Errorf("tempo_aviso_previo não pode ser negativo")
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("timezone inválido: %v", err)
+	}
+	return nil
+}
+
+// selectResources separa os recursos que serão desligados dos que ficam de fora e por quê
+func (c costScheduleConfig) selectResources(inventory []inventoryResource) ([]inventoryResource, []costSkip) {
+	types := map[string]bool{}
+	for _, kind := range c.ResourceKinds {
+		for _, resourceType := range costResourceKinds[kind] {
+			types[resourceType] = true
+		}
+	}
+
+	var selected []inventoryResource
+	var skipped []costSkip
+	for _, resource := range inventory {
+		if !types[resource.Type] {
+			continue
+		}
+		excluded := ""
+		for _, tag := range c.ExcludeTags {
+			if resource.HasTag(tag) {
+				excluded = tag
+				break
+			}
+		}
+		switch {
+		case excluded != "":
+			skipped = append(skipped, costSkip{Resource: resource.Address, Reason: "tag de exclusão " + excluded})
+		case c.AllowOverride && strings.EqualFold(resource.Tags[c.OverrideTag], "true"):
+			skipped = append(skipped, costSkip{Resource: resource.Address, Reason: "override " + c.OverrideTag + "=true"})
+		default:
+			selected = append(selected, resource)
+		}
+	}
+	return selected, skipped
+}
+
+// simulateCostSchedule calcula avisos, desligamentos e religamentos previstos entre from e to
+func simulateCostSchedule(cfg costScheduleConfig, inventory []inventoryResource, from, to time.Time) (*costTimeline, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	location, _ := time.LoadLocation(cfg.Timezone)
+	timeline := &costTimeline{Location: location}
+	if !cfg.Enabled {
+		return timeline, nil
+	}
+
+	selected, skipped := cfg.selectResources(inventory)
+	timeline.Skipped = skipped
+
+	activeDays := map[int]bool{}
+	for _, day := range cfg.ActiveDays {
+		activeDays[day] = true
+	}
+
+	// Percorre os dias no calendário local; um dia antes e um depois cobrem avisos que cruzam os limites
+	var schedule []costEvent
+	first := from.In(location)
+	day := time.Date(first.Year(), first.Month(), first.Day()-1, 12, 0, 0, 0, location)
+	for ; day.Before(to.Add(48 * time.Hour)); day = day.AddDate(0, 0, 1) {
+		weekday := int(day.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		if !activeDays[weekday] {
+			continue
+		}
+		startup := localWallClock(day, cfg.StartHour, location)
+		shutdown := localWallClock(day, cfg.EndHour, location)
+		schedule = append(schedule,
+			costEvent{Time: startup, Type: costEventStartup},
+			costEvent{Time: shutdown.Add(-time.Duration(cfg.WarningMinutes) * time.Minute), Type: costEventWarning},
+			costEvent{Time: shutdown, Type: costEventShutdown},
+		)
+	}
+
+	for _, event := range schedule {
+		if event.Time.Before(from) || !event.Time.Before(to) {
+			continue
+		}
+		for _, resource := range selected {
+			timeline.Events = append(timeline.Events, costEvent{Time: event.Time, Type: event.Type, Resource: resource.Address})
+		}
+	}
+	sort.SliceStable(timeline.Events, func(i, j int) bool { return timeline.Events[i].Time.Before(timeline.Events[j].Time) })
+	return timeline, nil
+}
+
+// localWallClock retorna o instante em que o relógio local do dia marca a hora informada.
+// Quando a hora não existe (início do horário de verão), retorna o fim do salto; quando
+// existe duas vezes (fim do horário de verão), retorna a primeira ocorrência.
+func localWallClock(day time.Time, hour float64, location *time.Location) time.Time {
+	minutes := int(math.Round(hour * 60))
+	wall := time.Date(day.Year(), day.Month(), day.Day(), 0, minutes, 0, 0, time.UTC)
+
+	// Candidatos usando os offsets vigentes um dia antes e um dia depois do horário desejado
+	var candidates []time.Time
+	for _, probe := range []time.Time{wall.Add(-24 * time.Hour), wall.Add(24 * time.Hour)} {
+		_, offset := probe.In(location).Zone()
+		candidates = append(candidates, wall.Add(-time.Duration(offset)*time.Second))
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	for _, candidate := range candidates {
+		if sameWallClock(candidate.In(location), wall) {
+			return candidate
+		}
+	}
+
+	// Hora inexistente: procura o primeiro instante cujo relógio local já passou do horário desejado
+	low, high := candidates[0], candidates[len(candidates)-1]
+	for high.Sub(low) > time.Second {
+		middle := low.Add(high.Sub(low) / 2)
+		if wallClockOf(middle.In(location)).Before(wall) {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return high.Truncate(time.Second)
+}
+
+func wallClockOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+func sameWallClock(t time.Time, wall time.Time) bool {
+	return wallClockOf(t).Equal(wall)
+}
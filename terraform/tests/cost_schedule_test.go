@@ -0,0 +1,203 @@
+package test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadCostInventory(t *testing.T) []inventoryResource {
+	content, err := os.ReadFile("testdata/cost_optimization/plan.json")
+	if err != nil {
+		t.Fatalf("Erro ao ler plano de teste: %v", err)
+	}
+	inventory, err := loadResourceInventory(content)
+	if err != nil {
+		t.Fatalf("Erro ao carregar inventário: %v", err)
+	}
+	return inventory
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("Erro ao carregar fuso %s: %v", name, err)
+	}
+	return location
+}
+
+// TestCostScheduleWeek simula uma semana com os valores padrão do módulo cost_optimization
+func TestCostScheduleWeek(t *testing.T) {
+	t.Parallel()
+
+	inventory := loadCostInventory(t)
+	saoPaulo := mustLoadLocation(t, "America/Sao_Paulo")
+	from := time.Date(2024, time.June, 3, 0, 0, 0, 0, saoPaulo) // segunda-feira
+	to := from.AddDate(0, 0, 7)
+
+	timeline, err := simulateCostSchedule(defaultCostScheduleConfig(), inventory, from, to)
+	if err != nil {
+		t.Fatalf("Erro na simulação: %v", err)
+	}
+	t.Logf("Linha do tempo:\n%s", timeline)
+
+	// Apenas os recursos de tipos agendáveis, sem tag de exclusão nem override, recebem eventos
+	scheduled := map[string]int{}
+	for _, event := range timeline.Events {
+		scheduled[event.Resource]++
+	}
+	assert.Equal(t, map[string]int{
+		"aws_instance.api":                         15,
+		"module.ci.google_compute_instance.runner": 15,
+	}, scheduled)
+
+	assert.ElementsMatch(t, []costSkip{
+		{Resource: "aws_db_instance.main", Reason: "tag de exclusão critical"},
+		{Resource: "digitalocean_droplet.worker", Reason: "override manter-ativo=true"},
+		{Resource: "module.ci.digitalocean_kubernetes_node_pool.test", Reason: "tag de exclusão always-on"},
+	}, timeline.Skipped)
+
+	events := timeline.EventsFor("aws_instance.api")
+	expected := []struct {
+		Type string
+		Time time.Time
+	}{
+		{costEventStartup, time.Date(2024, time.June, 3, 8, 0, 0, 0, saoPaulo)},
+		{costEventWarning, time.Date(2024, time.June, 3, 17, 30, 0, 0, saoPaulo)},
+		{costEventShutdown, time.Date(2024, time.June, 3, 18, 0, 0, 0, saoPaulo)},
+	}
+	for i, want := range expected {
+		assert.Equal(t, want.Type, events[i].Type)
+		assert.True(t, want.Time.Equal(events[i].Time), "%s esperado em %s, obtido %s", want.Type, want.Time, events[i].Time)
+	}
+
+	// Sem eventos no fim de semana: o último desligamento é na sexta e o próximo religamento, na segunda seguinte
+	last := events[len(events)-1]
+	assert.Equal(t, costEventShutdown, last.Type)
+	assert.Equal(t, time.Friday, last.Time.In(saoPaulo).Weekday())
+
+	// Sem override permitido, a tag manter-ativo deixa de proteger o droplet
+	cfg := defaultCostScheduleConfig()
+	cfg.AllowOverride = false
+	timeline, err = simulateCostSchedule(cfg, inventory, from, to)
+	if err != nil {
+		t.Fatalf("Erro na simulação: %v", err)
+	}
+	assert.Len(t, timeline.EventsFor("digitalocean_droplet.worker"), 15)
+
+	// Desligamento automático desativado não gera eventos
+	cfg = defaultCostScheduleConfig()
+	cfg.Enabled = false
+	timeline, err = simulateCostSchedule(cfg, inventory, from, to)
+	if err != nil {
+		t.Fatalf("Erro na simulação: %v", err)
+	}
+	assert.Empty(t, timeline.Events)
+}
+
+// TestCostScheduleDST cobre horas inexistentes e repetidas nas mudanças de horário de verão
+func TestCostScheduleDST(t *testing.T) {
+	t.Parallel()
+
+	inventory := []inventoryResource{{Address: "aws_instance.api", Type: "aws_instance", Tags: map[string]string{}}}
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	t.Run("fim de semana com início do horário de verão", func(t *testing.T) {
+		cfg := defaultCostScheduleConfig()
+		cfg.Timezone = "America/New_York"
+		from := time.Date(2024, time.March, 8, 0, 0, 0, 0, newYork)
+
+		timeline, err := simulateCostSchedule(cfg, inventory, from, from.AddDate(0, 0, 4))
+		if err != nil {
+			t.Fatalf("Erro na simulação: %v", err)
+		}
+		events := timeline.EventsFor("aws_instance.api")
+		if !assert.Len(t, events, 6) {
+			return
+		}
+
+		// O horário local é mantido, mas o offset em UTC muda com o horário de verão
+		shutdown, startup := events[2], events[3]
+		assert.Equal(t, time.Date(2024, time.March, 8, 23, 0, 0, 0, time.UTC), shutdown.Time.UTC())
+		assert.Equal(t, time.Date(2024, time.March, 11, 12, 0, 0, 0, time.UTC), startup.Time.UTC())
+		assert.Equal(t, 61*time.Hour, startup.Time.Sub(shutdown.Time), "A noite de sábado para domingo tem uma hora a menos")
+	})
+
+	t.Run("hora inexistente", func(t *testing.T) {
+		cfg := defaultCostScheduleConfig()
+		cfg.Timezone = "America/New_York"
+		cfg.ActiveDays = []int{7}
+		cfg.StartHour = 2.5
+		from := time.Date(2024, time.March, 10, 0, 0, 0, 0, newYork)
+
+		timeline, err := simulateCostSchedule(cfg, inventory, from, from.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("Erro na simulação: %v", err)
+		}
+		events := timeline.EventsFor("aws_instance.api")
+		if assert.NotEmpty(t, events) {
+			// 02:30 não existe em 10/03/2024; o religamento acontece no fim do salto, às 03:00 EDT
+			assert.Equal(t, time.Date(2024, time.March, 10, 7, 0, 0, 0, time.UTC), events[0].Time.UTC())
+			assert.Equal(t, "03:00 EDT", events[0].Time.In(newYork).Format("15:04 MST"))
+		}
+	})
+
+	t.Run("hora repetida", func(t *testing.T) {
+		cfg := defaultCostScheduleConfig()
+		cfg.Timezone = "America/New_York"
+		cfg.ActiveDays = []int{7}
+		cfg.StartHour = 1
+		from := time.Date(2024, time.November, 3, 0, 0, 0, 0, newYork)
+
+		timeline, err := simulateCostSchedule(cfg, inventory, from, from.Add(25*time.Hour))
+		if err != nil {
+			t.Fatalf("Erro na simulação: %v", err)
+		}
+		events := timeline.EventsFor("aws_instance.api")
+		if assert.NotEmpty(t, events) {
+			// 01:00 acontece duas vezes em 03/11/2024; vale a primeira ocorrência (EDT)
+			assert.Equal(t, time.Date(2024, time.November, 3, 5, 0, 0, 0, time.UTC), events[0].Time.UTC())
+			assert.Equal(t, "01:00 EDT", events[0].Time.In(newYork).Format("15:04 MST"))
+		}
+	})
+
+	t.Run("horário de verão histórico de São Paulo", func(t *testing.T) {
+		cfg := defaultCostScheduleConfig()
+		cfg.ActiveDays = []int{7}
+		cfg.StartHour = 0
+		saoPaulo := mustLoadLocation(t, "America/Sao_Paulo")
+		from := time.Date(2018, time.November, 3, 12, 0, 0, 0, saoPaulo)
+
+		timeline, err := simulateCostSchedule(cfg, inventory, from, from.Add(36*time.Hour))
+		if err != nil {
+			t.Fatalf("Erro na simulação: %v", err)
+		}
+		events := timeline.EventsFor("aws_instance.api")
+		if assert.NotEmpty(t, events) {
+			// Em 04/11/2018 o relógio pulou de 00:00 para 01:00
+			assert.Equal(t, time.Date(2018, time.November, 4, 3, 0, 0, 0, time.UTC), events[0].Time.UTC())
+			assert.Equal(t, 1, events[0].Time.In(saoPaulo).Hour())
+		}
+	})
+}
+
+// TestCostScheduleConfigValidation garante que valores sem sentido são rejeitados antes da simulação
+func TestCostScheduleConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	invalid := map[string]func(*costScheduleConfig){
+		"início depois do fim":     func(c *costScheduleConfig) { c.StartHour, c.EndHour = 18, 8 },
+		"dia da semana zero":       func(c *costScheduleConfig) { c.ActiveDays = []int{0, 1} },
+		"tipo de recurso inválido": func(c *costScheduleConfig) { c.ResourceKinds = []string{"lambdas"} },
+		"aviso negativo":           func(c *costScheduleConfig) { c.WarningMinutes = -5 },
+		"fuso inexistente":         func(c *costScheduleConfig) { c.Timezone = "America/Atlantida" },
+	}
+	for name, mutate := range invalid {
+		cfg := defaultCostScheduleConfig()
+		mutate(&cfg)
+		assert.Error(t, cfg.Validate(), name)
+	}
+	assert.NoError(t, defaultCostScheduleConfig().Validate())
+}
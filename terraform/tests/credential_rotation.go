@@ -0,0 +1,446 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotationConfig espelha as variáveis do módulo security/credential_rotation usadas na simulação
+type rotationConfig struct {
+	ProjectName            string
+	Environment            string
+	RotationSchedule       string
+	TokenExpirationDays    int
+	NotificationWebhookURL string
+	UseAwsSecretsManager   bool
+	UseVault               bool
+	VaultAddress           string
+	VaultToken             string
+}
+
+// SecretName retorna o nome do segredo criado pelo módulo (aws_secretsmanager_secret.digitalocean_tokens)
+func (c rotationConfig) SecretName() string {
+	return fmt.Sprintf("%s-%s-do-tokens", c.Environment, c.ProjectName)
+}
+
+// rotationSecret é o conteúdo JSON gravado pela função de rotação
+type rotationSecret struct {
+	Token           string `json:"digitalocean_token"`
+	TokenID         int    `json:"digitalocean_token_id,omitempty"`
+	PreviousToken   string `json:"previous_digitalocean_token,omitempty"`
+	PreviousTokenID int    `json:"previous_digitalocean_token_id,omitempty"`
+	LastRotated     string `json:"last_rotated"`
+	CreatedBy       string `json:"created_by,omitempty"`
+}
+
+// rotationStore abstrai o armazenamento das credenciais rotacionadas (Secrets Manager ou Vault)
+type rotationStore interface {
+	Create(secret rotationSecret) (string, error)
+	Current() (rotationSecret, error)
+	Previous() (rotationSecret, error)
+	Write(secret rotationSecret) (string, error)
+}
+
+// newRotationStore escolhe o armazenamento de acordo com use_aws_secrets_manager e use_vault
+func newRotationStore(cfg rotationConfig, secretsManagerEndpoint string) (rotationStore, error) {
+	switch {
+	case cfg.UseVault:
+		if cfg.VaultAddress == "" {
+			return nil, fmt.Errorf("vault_address é obrigatório quando use_vault=true")
+		}
+		return &vaultStore{address: cfg.VaultAddress, token: cfg.VaultToken, path: cfg.SecretName()}, nil
+	case cfg.UseAwsSecretsManager:
+		return &secretsManagerStore{endpoint: secretsManagerEndpoint, name: cfg.SecretName()}, nil
+	default:
+		return nil, fmt.Errorf("nenhum armazenamento habilitado: defina use_aws_secrets_manager ou use_vault")
+	}
+}
+
+// secretsManagerStore conversa com o Secrets Manager pelo protocolo JSON 1.1.
+// As requisições não são assinadas, portanto o cliente serve apenas para o fake local.
+type secretsManagerStore struct {
+	endpoint string
+	name     string
+}
+
+func (s *secretsManagerStore) call(operation string, input, output interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager."+operation)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&awsErr)
+		return fmt.Errorf("%s falhou: %s: %s", operation, awsErr.Type, awsErr.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(output)
+}
+
+func (s *secretsManagerStore) Create(secret rotationSecret) (string, error) {
+	return s.put("CreateSecret", map[string]string{"Name": s.name}, secret)
+}
+
+func (s *secretsManagerStore) Write(secret rotationSecret) (string, error) {
+	return s.put("PutSecretValue", map[string]string{"SecretId": s.name}, secret)
+}
+
+func (s *secretsManagerStore) put(operation string, input map[string]string, secret rotationSecret) (string, error) {
+	value, err := json.Marshal(secret)
+	if err != nil {
+		return "", err
+	}
+	input["SecretString"] = string(value)
+
+	var output struct {
+		VersionId string
+	}
+	if err := s.call(operation, input, &output); err != nil {
+		return "", err
+	}
+	return output.VersionId, nil
+}
+
+func (s *secretsManagerStore) Current() (rotationSecret, error) {
+	return s.get("AWSCURRENT")
+}
+
+func (s *secretsManagerStore) Previous() (rotationSecret, error) {
+	return s.get("AWSPREVIOUS")
+}
+
+func (s *secretsManagerStore) get(stage string) (rotationSecret, error) {
+	var output struct {
+		SecretString string
+	}
+	var secret rotationSecret
+	if err := s.call("GetSecretValue", map[string]string{"SecretId": s.name, "VersionStage": stage}, &output); err != nil {
+		return secret, err
+	}
+	err := json.Unmarshal([]byte(output.SecretString), &secret)
+	return secret, err
+}
+
+// vaultStore grava as credenciais na engine KV v2 montada em secret/
+type vaultStore struct {
+	address string
+	token   string
+	path    string
+}
+
+func (s *vaultStore) do(method, path string, input, output interface{}) error {
+	var body bytes.Buffer
+	if input != nil {
+		if err := json.NewEncoder(&body).Encode(input); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(s.address, "/")+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&vaultErr)
+		return fmt.Errorf("vault %s %s retornou %d: %s", method, path, resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+	}
+	return json.NewDecoder(resp.Body).Decode(output)
+}
+
+func (s *vaultStore) Create(secret rotationSecret) (string, error) {
+	return s.Write(secret)
+}
+
+func (s *vaultStore) Write(secret rotationSecret) (string, error) {
+	var output struct {
+		Data struct {
+			Version int `json:"version"`
+		} `json:"data"`
+	}
+	if err := s.do(http.MethodPost, "/v1/secret/data/"+s.path, map[string]interface{}{"data": secret}, &output); err != nil {
+		return "", err
+	}
+	return strconv.Itoa(output.Data.Version), nil
+}
+
+func (s *vaultStore) Current() (rotationSecret, error) {
+	return s.read(0)
+}
+
+// Previous lê a versão imediatamente anterior à atual
+func (s *vaultStore) Previous() (rotationSecret, error) {
+	var metadata struct {
+		Data struct {
+			CurrentVersion int `json:"current_version"`
+		} `json:"data"`
+	}
+	if err := s.do(http.MethodGet, "/v1/secret/metadata/"+s.path, nil, &metadata); err != nil {
+		return rotationSecret{}, err
+	}
+	if metadata.Data.CurrentVersion < 2 {
+		return rotationSecret{}, fmt.Errorf("o segredo %s ainda não possui versão anterior", s.path)
+	}
+	return s.read(metadata.Data.CurrentVersion - 1)
+}
+
+func (s *vaultStore) read(version int) (rotationSecret, error) {
+	path := "/v1/secret/data/" + s.path
+	if version > 0 {
+		path = fmt.Sprintf("%s?version=%d", path, version)
+	}
+
+	var output struct {
+		Data struct {
+			Data rotationSecret `json:"data"`
+		} `json:"data"`
+	}
+	err := s.do(http.MethodGet, path, nil, &output)
+	return output.Data.Data, err
+}
+
+// rotationResult resume um ciclo de rotação executado
+type rotationResult struct {
+	Version   string
+	TokenID   int
+	Revoked   []int
+	RotatedAt time.Time
+}
+
+// credentialRotator reproduz em Go o fluxo do lambda_handler do módulo security/credential_rotation
+type credentialRotator struct {
+	cfg   rotationConfig
+	store rotationStore
+	doAPI string
+	clock func() time.Time
+}
+
+// Rotate executa um ciclo: cria um novo token, grava uma nova versão mantendo a anterior legível,
+// revoga os tokens expirados e notifica o webhook configurado
+func (r *credentialRotator) Rotate() (rotationResult, error) {
+	now := r.clock().UTC()
+	result := rotationResult{RotatedAt: now}
+
+	current, err := r.store.Current()
+	if err != nil {
+		return result, fmt.Errorf("erro ao recuperar segredo: %v", err)
+	}
+	if current.Token == "" {
+		return result, fmt.Errorf("token da Digital Ocean não encontrado no segredo")
+	}
+
+	newToken, err := r.createToken(current.Token, now)
+	if err != nil {
+		return result, err
+	}
+
+	result.Version, err = r.store.Write(rotationSecret{
+		Token:           newToken.Token,
+		TokenID:         newToken.ID,
+		PreviousToken:   current.Token,
+		PreviousTokenID: current.TokenID,
+		LastRotated:     now.Format(time.RFC3339),
+	})
+	if err != nil {
+		return result, fmt.Errorf("erro ao atualizar segredo: %v", err)
+	}
+	result.TokenID = newToken.ID
+
+	result.Revoked, err = r.revokeExpired(newToken.Token, now)
+	if err != nil {
+		return result, err
+	}
+
+	return result, r.notify(result)
+}
+
+func (r *credentialRotator) createToken(current string, now time.Time) (doToken, error) {
+	var output struct {
+		Token doToken `json:"token"`
+	}
+	payload := map[string]interface{}{
+		"name":   fmt.Sprintf("terraform-token-%s", now.Format("20060102-150405")),
+		"scopes": []string{"read", "write"},
+		"ttl":    r.cfg.TokenExpirationDays * 24 * 60 * 60,
+	}
+	status, err := r.doRequest(http.MethodPost, "/v2/tokens", current, payload, &output)
+	if err != nil {
+		return output.Token, err
+	}
+	if status != http.StatusCreated {
+		return output.Token, fmt.Errorf("falha na API Digital Ocean: %d", status)
+	}
+	return output.Token, nil
+}
+
+// revokeExpired revoga todo token cuja validade (token_expiration_days) já terminou
+func (r *credentialRotator) revokeExpired(auth string, now time.Time) ([]int, error) {
+	var output struct {
+		Tokens []doToken `json:"tokens"`
+	}
+	if _, err := r.doRequest(http.MethodGet, "/v2/tokens", auth, nil, &output); err != nil {
+		return nil, err
+	}
+
+	var revoked []int
+	for _, token := range output.Tokens {
+		if now.Before(token.ExpiresAt) {
+			continue
+		}
+		status, err := r.doRequest(http.MethodDelete, fmt.Sprintf("/v2/tokens/%d", token.ID), auth, nil, nil)
+		if err != nil {
+			return revoked, err
+		}
+		if status != http.StatusNoContent {
+			return revoked, fmt.Errorf("falha ao revogar token %d: %d", token.ID, status)
+		}
+		revoked = append(revoked, token.ID)
+	}
+	return revoked, nil
+}
+
+func (r *credentialRotator) doRequest(method, path, token string, input, output interface{}) (int, error) {
+	var body bytes.Buffer
+	if input != nil {
+		if err := json.NewEncoder(&body).Encode(input); err != nil {
+			return 0, err
+		}
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(r.doAPI, "/")+path, &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if output != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(output); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// notify envia o aviso da rotação ao webhook, no mesmo payload do send_notification do lambda
+func (r *credentialRotator) notify(result rotationResult) error {
+	if r.cfg.NotificationWebhookURL == "" {
+		return nil
+	}
+
+	target, err := webhookTargetType(r.cfg.NotificationWebhookURL)
+	if err != nil {
+		return err
+	}
+	revoked := make([]string, 0, len(result.Revoked))
+	for _, id := range result.Revoked {
+		revoked = append(revoked, strconv.Itoa(id))
+	}
+	if len(revoked) == 0 {
+		revoked = append(revoked, "nenhum")
+	}
+
+	err = sendNotification(notificationTarget{Type: target, URL: r.cfg.NotificationWebhookURL}, notification{
+		Source:      "credential_rotation",
+		Project:     r.cfg.ProjectName,
+		Environment: r.cfg.Environment,
+		Title:       "Rotação de credenciais",
+		Summary:     fmt.Sprintf("As credenciais da Digital Ocean foram rotacionadas com sucesso em %s.", result.RotatedAt.Format(time.RFC3339)),
+		Resource:    r.cfg.SecretName(),
+		Severity:    "info",
+		Fields: []notificationField{
+			{Name: "Versão", Value: result.Version},
+			{Name: "Tokens revogados", Value: strings.Join(revoked, ", ")},
+		},
+		Time: result.RotatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao enviar notificação: %v", err)
+	}
+	return nil
+}
+
+// rotationGracePeriod calcula por quanto tempo o token anterior continua válido após ser substituído,
+// considerando o maior intervalo entre duas execuções de rotation_schedule ao longo de um ano.
+// Um período negativo significa que o token expira antes da próxima rotação.
+func rotationGracePeriod(schedule string, expirationDays int, from time.Time) (time.Duration, error) {
+	run, err := nextCronTime(schedule, from)
+	if err != nil {
+		return 0, err
+	}
+
+	var longest time.Duration
+	end := from.AddDate(1, 0, 0)
+	for run.Before(end) {
+		next, err := nextCronTime(schedule, run)
+		if err != nil {
+			return 0, err
+		}
+		if gap := next.Sub(run); gap > longest {
+			longest = gap
+		}
+		run = next
+	}
+
+	return time.Duration(expirationDays)*24*time.Hour - longest, nil
+}
+
+// fakeClock é um relógio controlado pelo teste e compartilhado com os fakes HTTP
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(start time.Time) *fakeClock {
+	return &fakeClock{now: start}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rotationLambdaModule é o arquivo com o código Python do lambda_handler (local.lambda_function_code)
+var rotationLambdaModule = filepath.Join("..", "modules", "security", "credential_rotation", "main.tf")
+
+// rotationLambdaSource lê local.lambda_function_code do módulo. O heredoc não pode ter interpolação
+// (${ ou %{): assim o texto lido é exatamente o index.py que o Terraform grava no zip da função
+func rotationLambdaSource() (string, error) {
+	content, err := os.ReadFile(rotationLambdaModule)
+	if err != nil {
+		return "", err
+	}
+	const start = "lambda_function_code = <<EOF\n"
+	_, rest, found := strings.Cut(string(content), start)
+	if !found {
+		return "", fmt.Errorf("%s sem lambda_function_code", rotationLambdaModule)
+	}
+	source, _, found := strings.Cut(rest, "\nEOF\n")
+	if !found {
+		return "", fmt.Errorf("heredoc de lambda_function_code sem EOF")
+	}
+	for _, sequence := range []string{"${", "%{"} {
+		if strings.Contains(source, sequence) {
+			return "", fmt.Errorf("lambda_function_code usa %s: o código do zip deixaria de ser o texto do heredoc", sequence)
+		}
+	}
+	return source + "\n", nil
+}
+
+// rotationLambdaStubs substituem o boto3 e o requests no lambda_handler: o Secrets Manager devolve o
+// token atual (ID 7), a API do DigitalOcean cria o token com FAKE_DO_CREATE_STATUS (201 por padrão)
+// e as publicações no SNS são gravadas em FAKE_SNS_LOG, uma por linha
+var rotationLambdaStubs = map[string]string{
+	"boto3.py": `import json
+import os
+
+
+class _Client:
+    def __init__(self, service):
+        self.service = service
+
+    def get_secret_value(self, SecretId):
+        return {"SecretString": json.dumps({"digitalocean_token": "token-atual", "digitalocean_token_id": 7})}
+
+    def update_secret(self, SecretId, SecretString):
+        return {"ARN": SecretId, "VersionId": "versao-2"}
+
+    def publish(self, **kwargs):
+        with open(os.environ["FAKE_SNS_LOG"], "a") as log:
+            log.write(json.dumps(kwargs) + "\n")
+
+
+def client(service, **kwargs):
+    return _Client(service)
+`,
+	"requests.py": `import json as _json
+import os
+
+
+class Response:
+    def __init__(self, status_code, body=None):
+        self.status_code = status_code
+        self._body = body or {}
+        self.text = _json.dumps(self._body)
+
+    def json(self):
+        return self._body
+
+
+def post(url, headers=None, json=None):
+    status = int(os.environ.get("FAKE_DO_CREATE_STATUS", "201"))
+    return Response(status, {"token": {"id": 8, "token": "token-novo"}})
+
+
+def delete(url, headers=None):
+    return Response(204)
+`,
+}
+
+// rotationLambdaRun é o resultado de uma execução do lambda_handler
+type rotationLambdaRun struct {
+	StatusCode int
+	Published  []map[string]string
+}
+
+// runRotationLambda executa o lambda_handler com python3 e as variáveis de ambiente da função
+func runRotationLambda(t *testing.T, source string, env map[string]string) (rotationLambdaRun, error) {
+	var run rotationLambdaRun
+	dir := t.TempDir()
+	files := map[string]string{"index.py": source}
+	for name, content := range rotationLambdaStubs {
+		files[name] = content
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			return run, err
+		}
+	}
+
+	snsLog := filepath.Join(dir, "sns.log")
+	cmd := exec.Command("python3", "-c", "import json, index; print(json.dumps(index.lambda_handler({}, None)))")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PYTHONDONTWRITEBYTECODE=1", "SECRET_NAME=dev-test-rotation-do-tokens", "FAKE_SNS_LOG="+snsLog)
+	for name, value := range env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return run, fmt.Errorf("lambda_handler falhou: %v", err)
+	}
+	var response struct {
+		StatusCode int `json:"statusCode"`
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return run, fmt.Errorf("resposta do lambda_handler inválida %q: %v", output, err)
+	}
+	run.StatusCode = response.StatusCode
+
+	log, err := os.Open(snsLog)
+	if os.IsNotExist(err) {
+		return run, nil
+	} else if err != nil {
+		return run, err
+	}
+	defer log.Close()
+	scanner := bufio.NewScanner(log)
+	for scanner.Scan() {
+		var published map[string]string
+		if err := json.Unmarshal(scanner.Bytes(), &published); err != nil {
+			return run, err
+		}
+		run.Published = append(run.Published, published)
+	}
+	return run, scanner.Err()
+}
@@ -0,0 +1,302 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCredentialRotationCycle simula dois ciclos de rotação contra fakes locais do
+// Secrets Manager e do Vault, sem acesso à AWS ou à API do DigitalOcean
+func TestCredentialRotationCycle(t *testing.T) {
+	t.Parallel()
+
+	// Cada backend notifica um destino diferente, para cobrir o Slack e o Teams
+	backends := map[string]rotationConfig{
+		"SecretsManager": {UseAwsSecretsManager: true, NotificationWebhookURL: notifySlack},
+		"Vault":          {UseVault: true, NotificationWebhookURL: notifyTeams},
+	}
+
+	for name, backend := range backends {
+		backend := backend
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := newFakeClock(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+			webhook := newWebhookSink(t)
+
+			cfg := backend
+			cfg.ProjectName = "test-rotation"
+			cfg.Environment = "dev"
+			cfg.RotationSchedule = "0 0 1 * *"
+			cfg.TokenExpirationDays = 45
+			cfg.NotificationWebhookURL = webhook.URL(backend.NotificationWebhookURL)
+
+			secretsManager := newFakeSecretsManager(t, clock.Now)
+			vault := newFakeVault(t, "vault-test-token", clock.Now)
+			if cfg.UseVault {
+				cfg.VaultAddress = vault.Server.URL
+				cfg.VaultToken = vault.Token
+			}
+
+			ttl := time.Duration(cfg.TokenExpirationDays) * 24 * time.Hour
+			digitalocean, initial := newFakeDigitalOceanTokens(t, clock.Now, ttl)
+
+			store, err := newRotationStore(cfg, secretsManager.Server.URL)
+			if err != nil {
+				t.Fatalf("Erro ao configurar armazenamento: %v", err)
+			}
+			if _, err := store.Create(rotationSecret{
+				Token:       initial.Token,
+				TokenID:     initial.ID,
+				LastRotated: clock.Now().Format(time.RFC3339),
+				CreatedBy:   "terraform",
+			}); err != nil {
+				t.Fatalf("Erro ao gravar token inicial: %v", err)
+			}
+
+			rotator := &credentialRotator{cfg: cfg, store: store, doAPI: digitalocean.Server.URL, clock: clock.Now}
+
+			// Primeira rotação (1º de fevereiro): o token inicial ainda está no período de carência
+			first := advanceToNextRotation(t, clock, cfg.RotationSchedule)
+			assert.Equal(t, time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC), first)
+
+			result, err := rotator.Rotate()
+			if err != nil {
+				t.Fatalf("Erro na primeira rotação: %v", err)
+			}
+			assert.Equal(t, 2, result.TokenID, "Um novo token deve ser emitido")
+			assert.Empty(t, result.Revoked, "Nenhum token deve ser revogado durante o período de carência")
+
+			current, err := store.Current()
+			if err != nil {
+				t.Fatalf("Erro ao ler versão atual: %v", err)
+			}
+			assert.NotEqual(t, initial.Token, current.Token, "A versão atual deve conter o novo token")
+			assert.Equal(t, initial.Token, current.PreviousToken)
+
+			previous, err := store.Previous()
+			if err != nil {
+				t.Fatalf("A versão anterior deve continuar legível: %v", err)
+			}
+			assert.Equal(t, initial.Token, previous.Token, "A versão anterior deve manter o token inicial")
+			assert.Equal(t, http.StatusOK, digitalOceanStatus(t, digitalocean, initial.Token), "O token anterior deve continuar válido")
+
+			// Segunda rotação (1º de março): o token inicial expirou em 15 de fevereiro e deve ser revogado
+			advanceToNextRotation(t, clock, cfg.RotationSchedule)
+			result, err = rotator.Rotate()
+			if err != nil {
+				t.Fatalf("Erro na segunda rotação: %v", err)
+			}
+			assert.Equal(t, []int{initial.ID}, result.Revoked, "O token expirado deve ser revogado")
+			assert.Equal(t, []int{initial.ID}, digitalocean.Revoked())
+
+			previous, err = store.Previous()
+			if err != nil {
+				t.Fatalf("Erro ao ler versão anterior: %v", err)
+			}
+			assert.Equal(t, current.Token, previous.Token, "A versão anterior deve ser a da primeira rotação")
+			assert.Equal(t, http.StatusOK, digitalOceanStatus(t, digitalocean, previous.Token))
+
+			if cfg.UseVault {
+				assert.Len(t, vault.Versions(cfg.SecretName()), 3)
+			} else {
+				assert.Len(t, secretsManager.Versions(cfg.SecretName()), 3)
+			}
+
+			// Cada rotação deve gerar uma notificação no webhook
+			notifications := webhook.Requests("/" + backend.NotificationWebhookURL)
+			assert.Empty(t, webhook.Rejected())
+			if assert.Len(t, notifications, 2) {
+				assert.Equal(t, "[INFO] Rotação de credenciais", notifications[0].Headline())
+				assert.Equal(t, "nenhum", notifications[0].Fields()["Tokens revogados"])
+				assert.Equal(t, result.Version, notifications[1].Fields()["Versão"])
+				assert.Equal(t, strconv.Itoa(initial.ID), notifications[1].Fields()["Tokens revogados"])
+			}
+		})
+	}
+}
+
+// TestCredentialRotationLambda roda o lambda_handler do módulo com python3, com o boto3 e o requests
+// substituídos por stubs, e confere as notificações que chegam ao webhookSink e ao SNS: o payload
+// do Slack e do Teams deve ser o mesmo que credentialRotator.notify envia
+func TestCredentialRotationLambda(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("Este teste requer python3 instalado")
+	}
+	source, err := rotationLambdaSource()
+	if err != nil {
+		t.Fatalf("Erro ao ler o código da função: %v", err)
+	}
+
+	for _, target := range []string{notifySlack, notifyTeams} {
+		target := target
+		t.Run(target, func(t *testing.T) {
+			t.Parallel()
+
+			webhook := newWebhookSink(t)
+			env := map[string]string{
+				"NOTIFICATION_WEBHOOK_URL": webhook.URL(target),
+				"SNS_TOPIC_ARN":            "arn:aws:sns:us-east-1:000000000000:credential-rotation",
+				"PROJECT_NAME":             "test-rotation",
+				"ENVIRONMENT":              "dev",
+			}
+			run, err := runRotationLambda(t, source, env)
+			if err != nil {
+				t.Fatalf("Erro ao executar a rotação: %v", err)
+			}
+			assert.Equal(t, http.StatusOK, run.StatusCode)
+
+			// Falha ao criar o token na API do DigitalOcean
+			env["FAKE_DO_CREATE_STATUS"] = "500"
+			failed, err := runRotationLambda(t, source, env)
+			if err != nil {
+				t.Fatalf("Erro ao executar a rotação com falha: %v", err)
+			}
+			assert.Equal(t, http.StatusInternalServerError, failed.StatusCode)
+
+			assert.Empty(t, webhook.Rejected())
+			notifications := webhook.Requests("/" + target)
+			if !assert.Len(t, notifications, 2) {
+				return
+			}
+			assert.Equal(t, "[INFO] Rotação de credenciais", notifications[0].Headline())
+			assert.Equal(t, map[string]string{"Versão": "versao-2", "Tokens revogados": "7"}, withoutOrigin(notifications[0].Fields()))
+			assert.Equal(t, "[FIRING] Rotação de credenciais", notifications[1].Headline())
+
+			// O mesmo aviso renderizado pela réplica em Go, com o resumo e o horário do lambda
+			summary := rotationLambdaSummary(notifications[0].Body)
+			assert.True(t, strings.HasPrefix(summary, "As credenciais da Digital Ocean foram rotacionadas com sucesso em "), summary)
+			expected, err := renderNotification(notificationTarget{Type: target}, notification{
+				Source:      "credential_rotation",
+				Project:     "test-rotation",
+				Environment: "dev",
+				Title:       "Rotação de credenciais",
+				Summary:     summary,
+				Severity:    "info",
+				Fields:      []notificationField{{Name: "Versão", Value: "versao-2"}, {Name: "Tokens revogados", Value: "7"}},
+			})
+			if err != nil {
+				t.Fatalf("Erro ao renderizar a notificação: %v", err)
+			}
+			assert.Equal(t, normalizeRotationPayload(t, expected), normalizeRotationPayload(t, notifications[0].Body))
+
+			if assert.Len(t, run.Published, 1) && assert.Len(t, failed.Published, 1) {
+				assert.Equal(t, env["SNS_TOPIC_ARN"], run.Published[0]["TopicArn"])
+				assert.Equal(t, "[INFO] Rotação de credenciais", run.Published[0]["Subject"])
+				assert.Equal(t, summary, run.Published[0]["Message"])
+				assert.Equal(t, "[FIRING] Rotação de credenciais", failed.Published[0]["Subject"])
+				assert.Contains(t, failed.Published[0]["Message"], "Falha na API Digital Ocean: 500")
+			}
+		})
+	}
+}
+
+// withoutOrigin remove o fato Origem, que só existe no Teams
+func withoutOrigin(fields map[string]string) map[string]string {
+	delete(fields, "Origem")
+	return fields
+}
+
+// rotationLambdaSummary lê o resumo do aviso: o título do attachment no Slack ou o segundo
+// TextBlock no Teams
+func rotationLambdaSummary(body map[string]interface{}) string {
+	attachments, _ := body["attachments"].([]interface{})
+	if len(attachments) == 0 {
+		return ""
+	}
+	attachment, _ := attachments[0].(map[string]interface{})
+	if title, ok := attachment["title"].(string); ok {
+		return title
+	}
+	content, _ := attachment["content"].(map[string]interface{})
+	elements, _ := content["body"].([]interface{})
+	if len(elements) < 2 {
+		return ""
+	}
+	element, _ := elements[1].(map[string]interface{})
+	text, _ := element["text"].(string)
+	return text
+}
+
+// normalizeRotationPayload converte o payload para JSON genérico sem o horário (ts) do Slack
+func normalizeRotationPayload(t *testing.T, payload map[string]interface{}) map[string]interface{} {
+	content, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Erro ao serializar o payload: %v", err)
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(content, &normalized); err != nil {
+		t.Fatalf("Erro ao ler o payload: %v", err)
+	}
+	attachments, _ := normalized["attachments"].([]interface{})
+	for _, item := range attachments {
+		if attachment, ok := item.(map[string]interface{}); ok {
+			delete(attachment, "ts")
+		}
+	}
+	return normalized
+}
+
+// TestCredentialRotationGracePeriod verifica se token_expiration_days cobre o intervalo entre rotações
+func TestCredentialRotationGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	grace, err := rotationGracePeriod("0 0 1 * *", 45, start)
+	if err != nil {
+		t.Fatalf("Erro ao calcular período de carência: %v", err)
+	}
+	assert.Equal(t, 14*24*time.Hour, grace, "Com 45 dias de validade sobram 14 dias após o maior mês")
+
+	grace, err = rotationGracePeriod("cron(0 0 ? * 2 *)", 10, start)
+	if err != nil {
+		t.Fatalf("Erro ao calcular período de carência: %v", err)
+	}
+	assert.Equal(t, 3*24*time.Hour, grace, "Rotação semanal às segundas deixa 3 dias de carência")
+
+	// Valores padrão de variables.tf: rotação mensal com tokens de 30 dias
+	grace, err = rotationGracePeriod("0 0 1 * *", 30, start)
+	if err != nil {
+		t.Fatalf("Erro ao calcular período de carência: %v", err)
+	}
+	assert.Negative(t, int64(grace), "Nos meses de 31 dias o token padrão expira antes da próxima rotação")
+
+	_, err = rotationGracePeriod("0 0 1 *", 30, start)
+	assert.Error(t, err, "Expressões cron incompletas devem ser rejeitadas")
+}
+
+// advanceToNextRotation avança o relógio até a próxima execução de rotation_schedule
+func advanceToNextRotation(t *testing.T, clock *fakeClock, schedule string) time.Time {
+	next, err := nextCronTime(schedule, clock.Now())
+	if err != nil {
+		t.Fatalf("Erro ao interpretar rotation_schedule: %v", err)
+	}
+	clock.Set(next)
+	return next
+}
+
+// digitalOceanStatus consulta o fake do DigitalOcean autenticando com o token informado
+func digitalOceanStatus(t *testing.T, fake *fakeDigitalOceanTokens, token string) int {
+	req, err := http.NewRequest(http.MethodGet, fake.Server.URL+"/v2/tokens", nil)
+	if err != nil {
+		t.Fatalf("Erro ao montar requisição: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Erro ao consultar fake do DigitalOcean: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule é uma expressão cron já interpretada. Cada campo guarda os valores permitidos.
+type cronSchedule struct {
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+	// anyDay e anyWeekday indicam "*" ou "?", necessários para a regra de dia do mês OU dia da semana
+	anyDay     bool
+	anyWeekday bool
+}
+
+// parseCron interpreta expressões cron de 5 campos ("0 0 1 * *") e o formato do EventBridge
+// ("cron(0 0 1 * ? *)"), que usa 6 campos e dias da semana de 1 (domingo) a 7
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	eventBridge := strings.HasPrefix(expr, "cron(") && strings.HasSuffix(expr, ")")
+	if eventBridge {
+		expr = strings.TrimSuffix(strings.TrimPrefix(expr, "cron("), ")")
+	}
+
+	fields := strings.Fields(expr)
+	switch {
+	case eventBridge && len(fields) != 6:
+		return nil, fmt.Errorf("expressão cron do EventBridge deve ter 6 campos: %q", expr)
+	case !eventBridge && len(fields) != 5:
+		return nil, fmt.Errorf("expressão cron deve ter 5 campos: %q", expr)
+	}
+
+	weekdayMin, weekdayMax := 0, 7
+	if eventBridge {
+		weekdayMin = 1
+	}
+
+	s := &cronSchedule{
+		anyDay:     fields[2] == "*" || fields[2] == "?",
+		anyWeekday: fields[4] == "*" || fields[4] == "?",
+	}
+	var err error
+	if s.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if s.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if s.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if s.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	weekdays, err := parseCronField(fields[4], weekdayMin, weekdayMax)
+	if err != nil {
+		return nil, err
+	}
+
+	// Normaliza para time.Weekday (0 = domingo)
+	s.weekdays = map[int]bool{}
+	for d := range weekdays {
+		switch {
+		case eventBridge:
+			s.weekdays[d-1] = true
+		case d == 7:
+			s.weekdays[0] = true
+		default:
+			s.weekdays[d] = true
+		}
+	}
+	return s, nil
+}
+
+// parseCronField aceita "*", "?", valores, intervalos "a-b", listas "a,b" e passos "*/n" ou "a-b/n"
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("passo inválido em %q", field)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("intervalo inválido em %q", field)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("valor inválido em %q", field)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("valor fora do intervalo %d-%d em %q", min, max, field)
+		}
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// matchesDay aplica a regra do cron: quando dia do mês e dia da semana são restritos, basta um deles coincidir
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	day := s.days[t.Day()]
+	weekday := s.weekdays[int(t.Weekday())]
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// Next retorna o primeiro instante estritamente posterior a after que satisfaz a expressão
+func (s *cronSchedule) Next(after time.Time) (time.Time, error) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.months[int(t.Month())] || !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("nenhuma execução encontrada nos próximos 5 anos")
+}
+
+// nextCronTime interpreta a expressão e retorna a próxima execução após after
+func nextCronTime(expr string, after time.Time) (time.Time, error) {
+	s, err := parseCron(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.Next(after)
+}
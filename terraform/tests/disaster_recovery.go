@@ -0,0 +1,617 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+)
+
+// Região padrão do alias aws.secondary em providers.tf
+const drDefaultSecondaryRegion = "us-west-2"
+
+// drRunbookPath é o README do módulo, que documenta a sequência de failover
+var drRunbookPath = filepath.Join("..", "modules", "disaster_recovery", "aws", "README.md")
+
+// tfJSONConfigModule é o trecho de configuration.root_module do JSON do plano usado para
+// descobrir qual provider atende cada recurso e a quais recursos cada atributo faz referência
+type tfJSONConfigModule struct {
+	Resources []struct {
+		Address           string                     `json:"address"`
+		ProviderConfigKey string                     `json:"provider_config_key"`
+		Expressions       map[string]json.RawMessage `json:"expressions"`
+	} `json:"resources"`
+	ModuleCalls map[string]struct {
+		Module tfJSONConfigModule `json:"module"`
+	} `json:"module_calls"`
+}
+
+var planIndexPattern = regexp.MustCompile(`\[[^\]]*\]`)
+
+// planResourceRegions lê a saída de `terraform show -json` e retorna a região de cada recurso,
+// indexada pelo endereço sem índices (module.dr.aws_db_instance.replica). A região vem do valor
+// constante de region no bloco provider usado pelo recurso; recursos cujo provider não tem região
+// constante ficam com a região vazia
+func planResourceRegions(content []byte) (map[string]string, error) {
+	var document struct {
+		Configuration struct {
+			ProviderConfig map[string]struct {
+				Expressions map[string]struct {
+					ConstantValue interface{} `json:"constant_value"`
+				} `json:"expressions"`
+			} `json:"provider_config"`
+			RootModule tfJSONConfigModule `json:"root_module"`
+		} `json:"configuration"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar JSON do Terraform: %v", err)
+	}
+
+	regionOf := func(key string) string {
+		// Versões antigas do Terraform prefixam a chave com o módulo (dr:aws.secondary)
+		for _, candidate := range []string{key, key[strings.LastIndex(key, ":")+1:]} {
+			if provider, ok := document.Configuration.ProviderConfig[candidate]; ok {
+				if region, ok := provider.Expressions["region"].ConstantValue.(string); ok {
+					return region
+				}
+				return ""
+			}
+		}
+		return ""
+	}
+
+	regions := map[string]string{}
+	var walk func(prefix string, module tfJSONConfigModule)
+	walk = func(prefix string, module tfJSONConfigModule) {
+		for _, resource := range module.Resources {
+			if strings.HasPrefix(resource.Address, "data.") {
+				continue
+			}
+			regions[prefix+resource.Address] = regionOf(resource.ProviderConfigKey)
+		}
+		for name, call := range module.ModuleCalls {
+			walk(prefix+"module."+name+".", call.Module)
+		}
+	}
+	walk("", document.Configuration.RootModule)
+	return regions, nil
+}
+
+// plannedResourceRegion retorna a região de um recurso do plano a partir do mapa de planResourceRegions
+func plannedResourceRegion(regions map[string]string, address string) (string, bool) {
+	region, ok := regions[planIndexPattern.ReplaceAllString(address, "")]
+	return region, ok
+}
+
+// drPlanRootTF chama o módulo disaster_recovery/aws com os dois providers apontando para regiões
+// fixas e credenciais falsas, suficientes para o plano
+const drPlanRootTF = `provider "aws" {
+  region                      = "us-east-1"
+  access_key                  = "test"
+  secret_key                  = "test"
+  skip_credentials_validation = true
+  skip_requesting_account_id  = true
+  skip_metadata_api_check     = true
+}
+
+provider "aws" {
+  alias                       = "secondary"
+  region                      = "us-west-2"
+  access_key                  = "test"
+  secret_key                  = "test"
+  skip_credentials_validation = true
+  skip_requesting_account_id  = true
+  skip_metadata_api_check     = true
+}
+
+module "disaster_recovery" {
+  source = "./modules/disaster_recovery/aws"
+
+  environment       = "staging"
+  project_name      = "boilerplate-nestjs"
+  source_db_arn     = "arn:aws:rds:us-east-1:123456789012:db:boilerplate-nestjs-staging"
+  state_bucket_name = "terraform-state-boilerplate-nestjs"
+
+  providers = {
+    aws           = aws
+    aws.secondary = aws.secondary
+  }
+}
+`
+
+// prepareDRPlanRoot monta em dir uma raiz Terraform com o módulo disaster_recovery/aws
+func prepareDRPlanRoot(dir string) error {
+	if err := files.CopyFolderContents(filepath.Join("..", "modules", "disaster_recovery", "aws"), filepath.Join(dir, "modules", "disaster_recovery", "aws")); err != nil {
+		return err
+	}
+	versions, err := os.ReadFile(filepath.Join("..", "versions.tf"))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "versions.tf"), versions, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "main.tf"), []byte(drPlanRootTF), 0644)
+}
+
+var drRunbookStepPattern = regexp.MustCompile("^\\d+\\. `([a-z0-9-]+)`")
+
+// loadFailoverRunbook retorna os identificadores dos passos numerados da seção "## Failover" do README
+func loadFailoverRunbook(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var steps []string
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "## ") {
+			inSection = strings.TrimSpace(strings.TrimPrefix(line, "## ")) == "Failover"
+			continue
+		}
+		if match := drRunbookStepPattern.FindStringSubmatch(line); inSection && match != nil {
+			steps = append(steps, match[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("nenhum passo de failover encontrado em %s", path)
+	}
+	return steps, nil
+}
+
+// rdsInstance é o estado de uma instância mantida pelo fakeRDS
+type rdsInstance struct {
+	Identifier string
+	Status     string
+	SourceARN  string
+	Address    string
+	Port       int
+
+	// describesUntilAvailable conta quantas consultas faltam para a promoção terminar
+	describesUntilAvailable int
+}
+
+// fakeRDS simula o protocolo Query (form + XML) do RDS de uma região, com as operações usadas no failover
+type fakeRDS struct {
+	Server *httptest.Server
+	Region string
+
+	// PromotionDescribes é o número de DescribeDBInstances em que a instância fica em modifying após a promoção
+	PromotionDescribes int
+
+	mu        sync.Mutex
+	instances map[string]*rdsInstance
+	actions   []string
+}
+
+// newFakeRDS inicia o fake do RDS da região e o encerra ao final do teste
+func newFakeRDS(t *testing.T, region string) *fakeRDS {
+	fake := &fakeRDS{
+		Region:             region,
+		PromotionDescribes: 2,
+		instances:          map[string]*rdsInstance{},
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	return fake
+}
+
+// AddInstance registra uma instância; com sourceARN preenchido ela é uma réplica de leitura
+func (f *fakeRDS) AddInstance(identifier, status, sourceARN string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.instances[identifier] = &rdsInstance{
+		Identifier: identifier,
+		Status:     status,
+		SourceARN:  sourceARN,
+		Address:    fmt.Sprintf("%s.abcdefghijkl.%s.rds.amazonaws.com", identifier, f.Region),
+		Port:       5432,
+	}
+}
+
+// Instance retorna uma cópia do estado atual da instância
+func (f *fakeRDS) Instance(identifier string) (rdsInstance, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	instance, ok := f.instances[identifier]
+	if !ok {
+		return rdsInstance{}, false
+	}
+	return *instance, true
+}
+
+// Actions retorna as operações recebidas, na ordem
+func (f *fakeRDS) Actions() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.actions...)
+}
+
+func (f *fakeRDS) handle(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeRDSError(w, http.StatusBadRequest, "MalformedQueryString", err.Error())
+		return
+	}
+	action := r.Form.Get("Action")
+	identifier := r.Form.Get("DBInstanceIdentifier")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.actions = append(f.actions, action)
+
+	instance, ok := f.instances[identifier]
+	if !ok {
+		writeRDSError(w, http.StatusNotFound, "DBInstanceNotFound", fmt.Sprintf("DBInstance %s not found.", identifier))
+		return
+	}
+
+	switch action {
+	case "DescribeDBInstances":
+		if instance.describesUntilAvailable > 0 {
+			instance.describesUntilAvailable--
+			if instance.describesUntilAvailable == 0 {
+				instance.Status = "available"
+			}
+		}
+		writeRDSResponse(w, action, instance)
+
+	case "PromoteReadReplica":
+		if instance.SourceARN == "" {
+			writeRDSError(w, http.StatusBadRequest, "InvalidDBInstanceState", fmt.Sprintf("DB Instance %s is not a read replica.", identifier))
+			return
+		}
+		if instance.Status != "available" {
+			writeRDSError(w, http.StatusBadRequest, "InvalidDBInstanceState", fmt.Sprintf("DB Instance %s is not in available state.", identifier))
+			return
+		}
+		instance.SourceARN = ""
+		instance.Status = "modifying"
+		instance.describesUntilAvailable = f.PromotionDescribes
+		if f.PromotionDescribes == 0 {
+			instance.Status = "available"
+		}
+		writeRDSResponse(w, action, instance)
+
+	default:
+		writeRDSError(w, http.StatusBadRequest, "InvalidAction", fmt.Sprintf("ação %s não suportada", action))
+	}
+}
+
+// rdsXMLInstance é o elemento DBInstance das respostas do RDS
+type rdsXMLInstance struct {
+	DBInstanceIdentifier                  string `xml:"DBInstanceIdentifier"`
+	DBInstanceStatus                      string `xml:"DBInstanceStatus"`
+	ReadReplicaSourceDBInstanceIdentifier string `xml:"ReadReplicaSourceDBInstanceIdentifier,omitempty"`
+	Endpoint                              struct {
+		Address string `xml:"Address"`
+		Port    int    `xml:"Port"`
+	} `xml:"Endpoint"`
+}
+
+func writeRDSResponse(w http.ResponseWriter, action string, instance *rdsInstance) {
+	xmlInstance := rdsXMLInstance{
+		DBInstanceIdentifier:                  instance.Identifier,
+		DBInstanceStatus:                      instance.Status,
+		ReadReplicaSourceDBInstanceIdentifier: instance.SourceARN,
+	}
+	xmlInstance.Endpoint.Address = instance.Address
+	xmlInstance.Endpoint.Port = instance.Port
+
+	var result interface{}
+	if action == "DescribeDBInstances" {
+		result = struct {
+			XMLName   xml.Name         `xml:"DescribeDBInstancesResponse"`
+			Instances []rdsXMLInstance `xml:"DescribeDBInstancesResult>DBInstances>DBInstance"`
+		}{Instances: []rdsXMLInstance{xmlInstance}}
+	} else {
+		result = struct {
+			XMLName  xml.Name       `xml:"PromoteReadReplicaResponse"`
+			Instance rdsXMLInstance `xml:"PromoteReadReplicaResult>DBInstance"`
+		}{Instance: xmlInstance}
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func writeRDSError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<ErrorResponse><Error><Type>Sender</Type><Code>%s</Code><Message>%s</Message></Error></ErrorResponse>`, code, message)
+}
+
+// rdsClient chama o protocolo Query do RDS sem assinar as requisições, portanto serve apenas para o fake local
+type rdsClient struct {
+	endpoint string
+}
+
+func (c *rdsClient) call(action, identifier string) (rdsXMLInstance, error) {
+	form := url.Values{"Action": {action}, "Version": {"2014-10-31"}, "DBInstanceIdentifier": {identifier}}
+	resp, err := http.PostForm(c.endpoint, form)
+	if err != nil {
+		return rdsXMLInstance{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var rdsErr struct {
+			Code    string `xml:"Error>Code"`
+			Message string `xml:"Error>Message"`
+		}
+		xml.NewDecoder(resp.Body).Decode(&rdsErr)
+		return rdsXMLInstance{}, fmt.Errorf("%s falhou: %s: %s", action, rdsErr.Code, rdsErr.Message)
+	}
+
+	var output struct {
+		Instances []rdsXMLInstance `xml:"DescribeDBInstancesResult>DBInstances>DBInstance"`
+		Promoted  *rdsXMLInstance  `xml:"PromoteReadReplicaResult>DBInstance"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return rdsXMLInstance{}, err
+	}
+	switch {
+	case output.Promoted != nil:
+		return *output.Promoted, nil
+	case len(output.Instances) == 1:
+		return output.Instances[0], nil
+	default:
+		return rdsXMLInstance{}, fmt.Errorf("%s retornou %d instâncias", action, len(output.Instances))
+	}
+}
+
+// drFailoverConfig espelha os nomes usados pelos módulos database/aws e disaster_recovery/aws
+type drFailoverConfig struct {
+	ProjectName     string
+	Environment     string
+	SecondaryRegion string
+	StateBucket     string
+}
+
+// ReplicaIdentifier retorna o identificador da réplica (aws_db_instance.replica)
+func (c drFailoverConfig) ReplicaIdentifier() string {
+	return fmt.Sprintf("%s-%s-dr", c.ProjectName, c.Environment)
+}
+
+// DBSecretName retorna o segredo com as credenciais do banco (aws_secretsmanager_secret.db_credentials)
+func (c drFailoverConfig) DBSecretName() string {
+	return fmt.Sprintf("%s/%s/db", c.ProjectName, c.Environment)
+}
+
+// BackendConfig retorna os argumentos de `terraform init` para usar o bucket de estado replicado
+func (c drFailoverConfig) BackendConfig() []string {
+	return []string{
+		"-reconfigure",
+		"-backend-config=bucket=" + c.StateBucket + "-dr",
+		"-backend-config=region=" + c.SecondaryRegion,
+	}
+}
+
+// drFailover executa a sequência de failover documentada no README do módulo disaster_recovery/aws
+type drFailover struct {
+	Config drFailoverConfig
+	RDS    *rdsClient
+	// Secrets usa o cliente do Secrets Manager da rotação de credenciais, apontado para o segredo do banco
+	Secrets *secretsManagerStore
+	// Reconfigure recebe os argumentos de `terraform init` do passo trocar-backend
+	Reconfigure func(args []string) error
+
+	PollInterval time.Duration
+	MaxPolls     int
+
+	// Notify recebe o resultado do failover (webhooks do segredo <projeto>-<ambiente>-webhook-urls)
+	Notify []notificationTarget
+
+	// Executed registra os passos concluídos, na ordem
+	Executed []string
+
+	replicaAddress string
+	replicaPort    int
+}
+
+// Run executa os passos em ordem, para no primeiro erro e avisa o resultado aos destinos de Notify
+func (f *drFailover) Run() error {
+	steps := []struct {
+		ID  string
+		Run func() error
+	}{
+		{"verificar-replica", f.verifyReplica},
+		{"promover-replica", f.promoteReplica},
+		{"aguardar-disponivel", f.waitAvailable},
+		{"atualizar-segredo", f.updateSecret},
+		{"trocar-backend", func() error { return f.Reconfigure(f.Config.BackendConfig()) }},
+	}
+	var failure error
+	for _, step := range steps {
+		if err := step.Run(); err != nil {
+			failure = fmt.Errorf("passo %s: %v", step.ID, err)
+			break
+		}
+		f.Executed = append(f.Executed, step.ID)
+	}
+
+	if err := f.notify(failure); err != nil {
+		if failure != nil {
+			return fmt.Errorf("%v (%v)", failure, err)
+		}
+		return err
+	}
+	return failure
+}
+
+// notify avisa a conclusão ou a interrupção do failover. O aviso não é um passo do runbook: uma falha
+// de entrega é devolvida por Run, mas não desfaz os passos executados
+func (f *drFailover) notify(failure error) error {
+	if len(f.Notify) == 0 {
+		return nil
+	}
+
+	replica := f.Config.ReplicaIdentifier()
+	last := "nenhum"
+	if len(f.Executed) > 0 {
+		last = f.Executed[len(f.Executed)-1]
+	}
+	n := notification{
+		Source:      "disaster_recovery",
+		Project:     f.Config.ProjectName,
+		Environment: f.Config.Environment,
+		Resource:    replica,
+		DedupKey:    "disaster_recovery-" + replica,
+		Fields: []notificationField{
+			{Name: "Região secundária", Value: f.Config.SecondaryRegion},
+			{Name: "Último passo", Value: last},
+		},
+		Time: time.Now().UTC(),
+	}
+	if failure != nil {
+		n.Title = "Failover interrompido"
+		n.Severity = "critical"
+		n.Summary = fmt.Sprintf("Failover de %s interrompido no %v", replica, failure)
+	} else {
+		n.Title = "Failover concluído"
+		n.Severity = "warning"
+		n.Summary = fmt.Sprintf("%s promovida em %s; o banco agora responde em %s:%d", replica, f.Config.SecondaryRegion, f.replicaAddress, f.replicaPort)
+		n.Details = "O Terraform passou a usar o bucket de estado " + f.Config.StateBucket + "-dr."
+	}
+	return notifyTargets(f.Notify, n)
+}
+
+func (f *drFailover) verifyReplica() error {
+	instance, err := f.RDS.call("DescribeDBInstances", f.Config.ReplicaIdentifier())
+	if err != nil {
+		return err
+	}
+	if instance.ReadReplicaSourceDBInstanceIdentifier == "" {
+		return fmt.Errorf("%s não é uma réplica de leitura", instance.DBInstanceIdentifier)
+	}
+	if instance.DBInstanceStatus != "available" {
+		return fmt.Errorf("réplica %s está %s", instance.DBInstanceIdentifier, instance.DBInstanceStatus)
+	}
+	return nil
+}
+
+func (f *drFailover) promoteReplica() error {
+	_, err := f.RDS.call("PromoteReadReplica", f.Config.ReplicaIdentifier())
+	return err
+}
+
+func (f *drFailover) waitAvailable() error {
+	for poll := 0; poll < f.MaxPolls; poll++ {
+		instance, err := f.RDS.call("DescribeDBInstances", f.Config.ReplicaIdentifier())
+		if err != nil {
+			return err
+		}
+		if instance.DBInstanceStatus == "available" && instance.ReadReplicaSourceDBInstanceIdentifier == "" {
+			f.replicaAddress = instance.Endpoint.Address
+			f.replicaPort = instance.Endpoint.Port
+			return nil
+		}
+		time.Sleep(f.PollInterval)
+	}
+	return fmt.Errorf("%s não ficou disponível após %d consultas", f.Config.ReplicaIdentifier(), f.MaxPolls)
+}
+
+// updateSecret troca host e port do segredo do banco, mantendo os demais campos
+func (f *drFailover) updateSecret() error {
+	var current struct {
+		SecretString string
+	}
+	if err := f.Secrets.call("GetSecretValue", map[string]string{"SecretId": f.Secrets.name}, &current); err != nil {
+		return err
+	}
+	var credentials map[string]interface{}
+	if err := json.Unmarshal([]byte(current.SecretString), &credentials); err != nil {
+		return fmt.Errorf("segredo %s não é JSON: %v", f.Secrets.name, err)
+	}
+	credentials["host"] = f.replicaAddress
+	credentials["port"] = f.replicaPort
+
+	value, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	var output struct {
+		VersionId string
+	}
+	return f.Secrets.call("PutSecretValue", map[string]string{"SecretId": f.Secrets.name, "SecretString": string(value)}, &output)
+}
+
+// drPlanExpectations lista os recursos que o módulo disaster_recovery/aws precisa planejar e em
+// qual das regiões cada um deve ficar (true para a secundária)
+var drPlanExpectations = []struct {
+	Resource  string
+	Secondary bool
+}{
+	{"aws_db_instance.replica", true},
+	{"aws_db_instance_automated_backups_replication.main", true},
+	{"aws_s3_bucket.state_replica", true},
+	// A regra de replicação é configurada no bucket de origem, na região principal
+	{"aws_s3_bucket_replication_configuration.state", false},
+}
+
+// checkDRPlanRegions confere no JSON do plano que cada recurso de drPlanExpectations foi planejado
+// na região esperada e que a réplica e a cópia de backups apontam para um banco da região principal
+func checkDRPlanRegions(content []byte, primary, secondary string) ([]string, error) {
+	resources, err := loadPlannedResources(content)
+	if err != nil {
+		return nil, err
+	}
+	regions, err := planResourceRegions(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, expected := range drPlanExpectations {
+		want := primary
+		if expected.Secondary {
+			want = secondary
+		}
+
+		found := false
+		for _, resource := range resources {
+			if resource.Type+"."+resource.Name != expected.Resource {
+				continue
+			}
+			found = true
+			region, ok := plannedResourceRegion(regions, resource.Address)
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s não aparece na configuração do plano", resource.Address))
+			case region != want:
+				problems = append(problems, fmt.Sprintf("%s planejado em %q, esperado %q", resource.Address, region, want))
+			}
+
+			for _, attribute := range []string{"replicate_source_db", "source_db_instance_arn"} {
+				arn := planString(resource.Values, attribute)
+				if arn != "" && !strings.HasPrefix(arn, "arn:aws:rds:"+primary+":") {
+					problems = append(problems, fmt.Sprintf("%s.%s aponta para %s, fora da região principal %s", resource.Address, attribute, arn, primary))
+				}
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s não foi planejado", expected.Resource))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,242 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDisasterRecoveryPlan planeja o módulo disaster_recovery/aws com o provider principal em
+// us-east-1 e o alias secundário em us-west-2 e confere a região de cada recurso no plano
+func TestDisasterRecoveryPlan(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("Este teste requer terraform instalado")
+	}
+
+	dir := t.TempDir()
+	if err := prepareDRPlanRoot(dir); err != nil {
+		t.Fatalf("Erro ao preparar o módulo: %v", err)
+	}
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
+		TerraformDir: dir,
+		PlanFilePath: "dr.tfplan",
+		NoColor:      true,
+	})
+	planJSON, err := terraform.InitAndPlanAndShowE(t, terraformOptions)
+	if err != nil {
+		t.Fatalf("Erro ao planejar o módulo: %v", err)
+	}
+
+	problems, err := checkDRPlanRegions([]byte(planJSON), "us-east-1", "us-west-2")
+	if err != nil {
+		t.Fatalf("Erro ao ler o plano: %v", err)
+	}
+	for _, problem := range problems {
+		assert.Fail(t, "Recurso de DR fora da região esperada", problem)
+	}
+}
+
+// TestDisasterRecoveryPlanRegions confere a leitura das regiões com o plano de exemplo
+func TestDisasterRecoveryPlanRegions(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile(filepath.Join("testdata", "disaster_recovery", "plan.json"))
+	if err != nil {
+		t.Fatalf("Erro ao ler plan.json: %v", err)
+	}
+
+	regions, err := planResourceRegions(content)
+	if err != nil {
+		t.Fatalf("Erro ao ler o plano: %v", err)
+	}
+	// A chave prefixada com o módulo (formato antigo) resolve para o mesmo provider
+	assert.Equal(t, "us-west-2", regions["module.disaster_recovery.aws_kms_key.replica"])
+	assert.Equal(t, "us-east-1", regions["module.disaster_recovery.aws_s3_bucket_replication_configuration.state"])
+	// Região vinda de variável não é constante no plano
+	assert.Equal(t, "", regions["aws_s3_bucket.logs"])
+	assert.NotContains(t, regions, "module.disaster_recovery.data.aws_caller_identity.current")
+
+	region, ok := plannedResourceRegion(regions, "module.disaster_recovery.aws_db_instance.replica[0]")
+	assert.True(t, ok)
+	assert.Equal(t, "us-west-2", region)
+
+	problems, err := checkDRPlanRegions(content, "us-east-1", "us-west-2")
+	if err != nil {
+		t.Fatalf("Erro ao ler o plano: %v", err)
+	}
+	assert.Empty(t, problems)
+
+	// Com as regiões invertidas, todos os recursos e os ARNs de origem ficam fora do lugar
+	problems, _ = checkDRPlanRegions(content, "us-west-2", "us-east-1")
+	joined := strings.Join(problems, "\n")
+	assert.Len(t, problems, 6)
+	assert.Contains(t, joined, `module.disaster_recovery.aws_db_instance.replica[0] planejado em "us-west-2", esperado "us-east-1"`)
+	assert.Contains(t, joined, "module.disaster_recovery.aws_db_instance.replica[0].replicate_source_db aponta para arn:aws:rds:us-east-1:")
+}
+
+// newDRFailover prepara o failover do ambiente staging contra o RDS e o Secrets Manager locais
+func newDRFailover(t *testing.T) (*drFailover, *fakeRDS, *fakeSecretsManager, *[]string) {
+	cfg, err := loadEnvironmentConfig("staging")
+	if err != nil {
+		t.Fatalf("Erro ao ler config.yaml de staging: %v", err)
+	}
+	secondary := cfg.Provider.AWS.SecondaryRegion
+	if secondary == "" {
+		secondary = drDefaultSecondaryRegion
+	}
+	config := drFailoverConfig{
+		ProjectName:     "boilerplate-nestjs",
+		Environment:     "staging",
+		SecondaryRegion: secondary,
+		StateBucket:     cfg.DisasterRecovery.StateBucket,
+	}
+
+	rds := newFakeRDS(t, secondary)
+	secrets := newFakeSecretsManager(t, newFakeClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)).Now)
+	store := &secretsManagerStore{endpoint: secrets.Server.URL, name: config.DBSecretName()}
+
+	// Segredo no formato gravado por aws_secretsmanager_secret_version.db_credentials
+	credentials, _ := json.Marshal(map[string]interface{}{
+		"username": "app_user",
+		"password": "senha-atual",
+		"engine":   "postgres",
+		"host":     "boilerplate-nestjs-staging.abcdefghijkl.us-east-1.rds.amazonaws.com",
+		"port":     5432,
+		"dbname":   "boilerplate_nestjs_staging",
+	})
+	var created struct{ VersionId string }
+	if err := store.call("CreateSecret", map[string]string{"Name": config.DBSecretName(), "SecretString": string(credentials)}, &created); err != nil {
+		t.Fatalf("Erro ao criar o segredo do banco: %v", err)
+	}
+
+	var initArgs []string
+	failover := &drFailover{
+		Config:       config,
+		RDS:          &rdsClient{endpoint: rds.Server.URL},
+		Secrets:      store,
+		Reconfigure:  func(args []string) error { initArgs = args; return nil },
+		PollInterval: time.Millisecond,
+		MaxPolls:     5,
+	}
+	return failover, rds, secrets, &initArgs
+}
+
+// TestDisasterRecoveryFailover executa a sequência do README do módulo contra os fakes e confere
+// que ela promove a réplica, aponta o segredo do banco para a região secundária e troca o backend
+func TestDisasterRecoveryFailover(t *testing.T) {
+	t.Parallel()
+
+	runbook, err := loadFailoverRunbook(drRunbookPath)
+	if err != nil {
+		t.Fatalf("Erro ao ler o runbook: %v", err)
+	}
+
+	failover, rds, secrets, initArgs := newDRFailover(t)
+	sink := newWebhookSink(t)
+	failover.Notify = sinkTargets(sink)
+	replica := failover.Config.ReplicaIdentifier()
+	rds.AddInstance(replica, "available", "arn:aws:rds:us-east-1:123456789012:db:boilerplate-nestjs-staging")
+
+	if err := failover.Run(); err != nil {
+		t.Fatalf("Failover falhou: %v", err)
+	}
+	assert.Equal(t, runbook, failover.Executed, "A sequência executada diverge do README do módulo")
+
+	instance, _ := rds.Instance(replica)
+	assert.Equal(t, "available", instance.Status)
+	assert.Empty(t, instance.SourceARN, "A réplica deveria ter sido promovida")
+	assert.Equal(t, []string{"DescribeDBInstances", "PromoteReadReplica", "DescribeDBInstances", "DescribeDBInstances"}, rds.Actions())
+
+	versions := secrets.Versions(failover.Config.DBSecretName())
+	if assert.Len(t, versions, 2) {
+		var current map[string]interface{}
+		if err := json.Unmarshal([]byte(versions[1].Value), &current); err != nil {
+			t.Fatalf("Segredo atualizado não é JSON: %v", err)
+		}
+		assert.Equal(t, instance.Address, current["host"])
+		assert.Contains(t, current["host"], ".us-west-2.rds.amazonaws.com")
+		assert.Equal(t, "senha-atual", current["password"], "Os demais campos do segredo devem ser mantidos")
+		assert.Equal(t, []string{"AWSCURRENT"}, versions[1].Stages)
+	}
+
+	assert.Equal(t, []string{
+		"-reconfigure",
+		"-backend-config=bucket=terraform-state-boilerplate-nestjs-dr",
+		"-backend-config=region=us-west-2",
+	}, *initArgs)
+
+	// O resultado vai a todos os webhooks do segredo webhook-urls, sem virar um passo do runbook
+	assert.Empty(t, sink.Rejected())
+	for _, target := range notificationTargetTypes {
+		requests := sink.Requests("/" + target)
+		if assert.Len(t, requests, 1, target) {
+			assert.Equal(t, "trocar-backend", requests[0].Fields()["Último passo"], target)
+			assert.Equal(t, "us-west-2", requests[0].Fields()["Região secundária"], target)
+		}
+	}
+	slack := sink.Requests("/slack")[0]
+	assert.Equal(t, fmt.Sprintf("[FIRING] Failover concluído %s promovida em us-west-2; o banco agora responde em %s:%d",
+		replica, instance.Address, instance.Port), alertNotificationSummary(slack))
+	pagerduty := sink.Requests("/pagerduty")[0].Body
+	assert.Equal(t, "disaster_recovery-"+replica, pagerduty["dedup_key"])
+	assert.Equal(t, "warning", pagerduty["payload"].(map[string]interface{})["severity"])
+}
+
+// TestDisasterRecoveryFailoverStops confere que o failover não promove uma réplica indisponível
+// e não altera o segredo quando a promoção não termina
+func TestDisasterRecoveryFailoverStops(t *testing.T) {
+	t.Parallel()
+
+	failover, rds, _, _ := newDRFailover(t)
+	replica := failover.Config.ReplicaIdentifier()
+	rds.AddInstance(replica, "storage-full", "arn:aws:rds:us-east-1:123456789012:db:boilerplate-nestjs-staging")
+
+	err := failover.Run()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "passo verificar-replica: réplica "+replica+" está storage-full")
+	}
+	assert.Empty(t, failover.Executed)
+	assert.NotContains(t, rds.Actions(), "PromoteReadReplica")
+
+	failover, rds, secrets, initArgs := newDRFailover(t)
+	sink := newWebhookSink(t)
+	failover.Notify = sinkTargets(sink)
+	rds.PromotionDescribes = 10
+	rds.AddInstance(replica, "available", "arn:aws:rds:us-east-1:123456789012:db:boilerplate-nestjs-staging")
+
+	err = failover.Run()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "passo aguardar-disponivel")
+	}
+	assert.Equal(t, []string{"verificar-replica", "promover-replica"}, failover.Executed)
+	assert.Len(t, secrets.Versions(failover.Config.DBSecretName()), 1)
+	assert.Nil(t, *initArgs)
+
+	// A interrupção é avisada como crítica, com o último passo concluído
+	opsgenie := sink.Requests("/opsgenie")
+	if assert.Len(t, opsgenie, 1) {
+		assert.Equal(t, "P1", opsgenie[0].Body["priority"])
+		assert.Equal(t, "promover-replica", opsgenie[0].Fields()["Último passo"])
+		assert.Contains(t, opsgenie[0].Body["message"], "Failover de "+replica+" interrompido no passo aguardar-disponivel")
+	}
+
+	// Uma falha de entrega aparece no erro do failover, sem esconder o passo que falhou
+	failover, rds, _, _ = newDRFailover(t)
+	failover.Notify = []notificationTarget{{Type: notifyPagerDuty, URL: sink.URL(notifyPagerDuty)}}
+	rds.AddInstance(replica, "storage-full", "arn:aws:rds:us-east-1:123456789012:db:boilerplate-nestjs-staging")
+	err = failover.Run()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "passo verificar-replica: réplica "+replica+" está storage-full")
+		assert.Contains(t, err.Error(), "falha ao notificar pagerduty: payload de pagerduty inválido: routing_key deve ter 32 caracteres alfanuméricos")
+	}
+}
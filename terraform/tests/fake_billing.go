@@ -0,0 +1,753 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+)
+
+// Fakes em processo das APIs de orçamento usadas pelos módulos cost_monitor: AWS Budgets (com o
+// GetCallerIdentity do STS, de onde o provider tira o ID da conta), Billing Budgets, Pub/Sub e
+// canais de notificação do GCP e alertas de monitoramento do DigitalOcean. Os fakes guardam o que o
+// Terraform cria e reproduzem um mês de gastos sobre as regras de aviso de cada orçamento.
+
+// Tipos de aviso de orçamento, com os nomes da AWS
+const (
+	budgetActual     = "ACTUAL"
+	budgetForecasted = "FORECASTED"
+)
+
+// budgetForecastMinDays é o número de dias de gasto antes de o fake calcular a previsão do mês
+const budgetForecastMinDays = 7
+
+// fakeAwsAccountID é o ID da conta devolvido pelo GetCallerIdentity do fake
+const fakeAwsAccountID = "123456789012"
+
+// budgetRule é uma regra de aviso de um orçamento: porcentagem do valor sobre o gasto real ou previsto
+type budgetRule struct {
+	Budget    string
+	Amount    float64
+	Kind      string
+	Threshold float64
+	// Recipients são os e-mails avisados quando a regra dispara
+	Recipients []string
+}
+
+// budgetNotification é um aviso enviado durante o replay de um mês de gastos
+type budgetNotification struct {
+	Day        int
+	Budget     string
+	Kind       string
+	Threshold  float64
+	Spend      float64
+	Recipients []string
+}
+
+// String descreve o aviso sem o orçamento e o valor, como nas comparações dos testes
+func (n budgetNotification) String() string {
+	return fmt.Sprintf("dia %d: %s %g%%", n.Day, n.Kind, n.Threshold)
+}
+
+// syntheticBudgetMonth gera 30 dias de gasto que terminam em 120% do orçamento: 2% ao dia nos
+// primeiros dez dias, 4% nos dez seguintes e 6% no fim do mês
+func syntheticBudgetMonth(amount float64) []float64 {
+	daily := make([]float64, 30)
+	for day := range daily {
+		percent := 2.0
+		if day >= 20 {
+			percent = 6
+		} else if day >= 10 {
+			percent = 4
+		}
+		daily[day] = amount * percent / 100
+	}
+	return daily
+}
+
+// replayBudgetMonth acumula o gasto diário e dispara cada regra uma vez, no primeiro dia em que o
+// gasto real (ou a previsão linear do mês, a partir de budgetForecastMinDays) passa da porcentagem
+func replayBudgetMonth(rules []budgetRule, daily []float64) []budgetNotification {
+	var notifications []budgetNotification
+	fired := make([]bool, len(rules))
+	actual := 0.0
+	for i, spend := range daily {
+		day := i + 1
+		actual += spend
+		forecast := 0.0
+		if day >= budgetForecastMinDays {
+			forecast = actual / float64(day) * float64(len(daily))
+		}
+		for j, rule := range rules {
+			value := actual
+			if rule.Kind == budgetForecasted {
+				value = forecast
+			}
+			if fired[j] || value <= rule.Amount*rule.Threshold/100 {
+				continue
+			}
+			fired[j] = true
+			notifications = append(notifications, budgetNotification{
+				Day:        day,
+				Budget:     rule.Budget,
+				Kind:       rule.Kind,
+				Threshold:  rule.Threshold,
+				Spend:      value,
+				Recipients: rule.Recipients,
+			})
+		}
+	}
+	return notifications
+}
+
+// awsBudgetNotification espelha Notification da API do AWS Budgets
+type awsBudgetNotification struct {
+	NotificationType   string  `json:"NotificationType"`
+	ComparisonOperator string  `json:"ComparisonOperator"`
+	Threshold          float64 `json:"Threshold"`
+	ThresholdType      string  `json:"ThresholdType,omitempty"`
+	NotificationState  string  `json:"NotificationState,omitempty"`
+}
+
+func (n awsBudgetNotification) key() string {
+	return fmt.Sprintf("%s/%s/%g/%s", n.NotificationType, n.ComparisonOperator, n.Threshold, n.ThresholdType)
+}
+
+// awsBudgetSubscriber espelha Subscriber da API do AWS Budgets
+type awsBudgetSubscriber struct {
+	SubscriptionType string `json:"SubscriptionType"`
+	Address          string `json:"Address"`
+}
+
+// awsBudget é um orçamento guardado pelo fake, com o corpo de Budget como o provider o enviou
+type awsBudget struct {
+	Budget        map[string]interface{}
+	Notifications map[string]awsBudgetNotification
+	Subscribers   map[string][]awsBudgetSubscriber
+}
+
+// Amount e Unit leem o BudgetLimit do orçamento
+func (b *awsBudget) Amount() float64 {
+	limit, _ := b.Budget["BudgetLimit"].(map[string]interface{})
+	amount, _ := strconv.ParseFloat(fmt.Sprint(limit["Amount"]), 64)
+	return amount
+}
+
+func (b *awsBudget) Unit() string {
+	limit, _ := b.Budget["BudgetLimit"].(map[string]interface{})
+	unit, _ := limit["Unit"].(string)
+	return unit
+}
+
+// fakeAwsBudgets simula o AWS Budgets (JSON 1.1) e o GetCallerIdentity do STS em um único servidor
+type fakeAwsBudgets struct {
+	Server *httptest.Server
+
+	mu      sync.Mutex
+	budgets map[string]*awsBudget
+	actions []string
+}
+
+// newFakeAwsBudgets inicia o fake e o encerra ao final do teste
+func newFakeAwsBudgets(t *testing.T) *fakeAwsBudgets {
+	fake := &fakeAwsBudgets{budgets: map[string]*awsBudget{}}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	return fake
+}
+
+// Budget retorna o orçamento com o nome informado
+func (f *fakeAwsBudgets) Budget(name string) (*awsBudget, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	budget, ok := f.budgets[name]
+	return budget, ok
+}
+
+// Actions retorna as operações recebidas, na ordem
+func (f *fakeAwsBudgets) Actions() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.actions...)
+}
+
+func (f *fakeAwsBudgets) handle(w http.ResponseWriter, r *http.Request) {
+	target := r.Header.Get("X-Amz-Target")
+	if target == "" {
+		f.handleSTS(w, r)
+		return
+	}
+	action := strings.TrimPrefix(target, "AWSBudgetServiceGateway.")
+
+	var input struct {
+		AccountID                    string                 `json:"AccountId"`
+		BudgetName                   string                 `json:"BudgetName"`
+		Budget                       map[string]interface{} `json:"Budget"`
+		NewBudget                    map[string]interface{} `json:"NewBudget"`
+		NotificationsWithSubscribers []struct {
+			Notification awsBudgetNotification `json:"Notification"`
+			Subscribers  []awsBudgetSubscriber `json:"Subscribers"`
+		} `json:"NotificationsWithSubscribers"`
+		Notification    awsBudgetNotification `json:"Notification"`
+		OldNotification awsBudgetNotification `json:"OldNotification"`
+		NewNotification awsBudgetNotification `json:"NewNotification"`
+		Subscriber      awsBudgetSubscriber   `json:"Subscriber"`
+		Subscribers     []awsBudgetSubscriber `json:"Subscribers"`
+		OldSubscriber   awsBudgetSubscriber   `json:"OldSubscriber"`
+		NewSubscriber   awsBudgetSubscriber   `json:"NewSubscriber"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeAwsError(w, "InvalidParameterException", err.Error())
+		return
+	}
+	if input.AccountID != "" && input.AccountID != fakeAwsAccountID {
+		writeAwsError(w, "AccessDeniedException", "conta desconhecida: "+input.AccountID)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.actions = append(f.actions, action)
+
+	if action == "CreateBudget" {
+		name, _ := input.Budget["BudgetName"].(string)
+		if _, exists := f.budgets[name]; exists {
+			writeAwsError(w, "DuplicateRecordException", "orçamento já existe: "+name)
+			return
+		}
+		budget := &awsBudget{Budget: input.Budget, Notifications: map[string]awsBudgetNotification{}, Subscribers: map[string][]awsBudgetSubscriber{}}
+		for _, item := range input.NotificationsWithSubscribers {
+			budget.Notifications[item.Notification.key()] = item.Notification
+			budget.Subscribers[item.Notification.key()] = item.Subscribers
+		}
+		f.budgets[name] = budget
+		writeJSON(w, map[string]interface{}{})
+		return
+	}
+	if action == "ListTagsForResource" || action == "TagResource" || action == "UntagResource" {
+		writeJSON(w, map[string]interface{}{"ResourceTags": []interface{}{}})
+		return
+	}
+
+	name := input.BudgetName
+	if input.NewBudget != nil {
+		name, _ = input.NewBudget["BudgetName"].(string)
+	}
+	budget, ok := f.budgets[name]
+	if !ok {
+		writeAwsError(w, "NotFoundException", "orçamento não encontrado: "+name)
+		return
+	}
+
+	switch action {
+	case "DescribeBudget":
+		writeJSON(w, map[string]interface{}{"Budget": budget.Budget})
+	case "UpdateBudget":
+		budget.Budget = input.NewBudget
+		writeJSON(w, map[string]interface{}{})
+	case "DeleteBudget":
+		delete(f.budgets, name)
+		writeJSON(w, map[string]interface{}{})
+	case "DescribeNotificationsForBudget":
+		keys := make([]string, 0, len(budget.Notifications))
+		for key := range budget.Notifications {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		notifications := []awsBudgetNotification{}
+		for _, key := range keys {
+			notifications = append(notifications, budget.Notifications[key])
+		}
+		writeJSON(w, map[string]interface{}{"Notifications": notifications})
+	case "DescribeSubscribersForNotification":
+		subscribers, ok := budget.Subscribers[input.Notification.key()]
+		if !ok {
+			writeAwsError(w, "NotFoundException", "notificação não encontrada: "+input.Notification.key())
+			return
+		}
+		writeJSON(w, map[string]interface{}{"Subscribers": subscribers})
+	case "CreateNotification":
+		budget.Notifications[input.Notification.key()] = input.Notification
+		budget.Subscribers[input.Notification.key()] = input.Subscribers
+		writeJSON(w, map[string]interface{}{})
+	case "UpdateNotification":
+		subscribers := budget.Subscribers[input.OldNotification.key()]
+		delete(budget.Notifications, input.OldNotification.key())
+		delete(budget.Subscribers, input.OldNotification.key())
+		budget.Notifications[input.NewNotification.key()] = input.NewNotification
+		budget.Subscribers[input.NewNotification.key()] = subscribers
+		writeJSON(w, map[string]interface{}{})
+	case "DeleteNotification":
+		delete(budget.Notifications, input.Notification.key())
+		delete(budget.Subscribers, input.Notification.key())
+		writeJSON(w, map[string]interface{}{})
+	case "CreateSubscriber", "DeleteSubscriber", "UpdateSubscriber":
+		key := input.Notification.key()
+		var kept []awsBudgetSubscriber
+		for _, subscriber := range budget.Subscribers[key] {
+			if subscriber != input.Subscriber && subscriber != input.OldSubscriber {
+				kept = append(kept, subscriber)
+			}
+		}
+		switch action {
+		case "CreateSubscriber":
+			kept = append(kept, input.Subscriber)
+		case "UpdateSubscriber":
+			kept = append(kept, input.NewSubscriber)
+		}
+		budget.Subscribers[key] = kept
+		writeJSON(w, map[string]interface{}{})
+	default:
+		writeAwsError(w, "UnknownOperationException", "operação não suportada pelo fake: "+action)
+	}
+}
+
+// handleSTS responde o GetCallerIdentity usado pelo provider para descobrir a conta
+func (f *fakeAwsBudgets) handleSTS(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("Action") != "GetCallerIdentity" {
+		http.Error(w, "requisição não suportada pelo fake", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	fmt.Fprintf(w, `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:iam::%[1]s:user/terratest</Arn>
+    <UserId>AIDAFAKEBUDGETS</UserId>
+    <Account>%[1]s</Account>
+  </GetCallerIdentityResult>
+  <ResponseMetadata><RequestId>fake-budgets</RequestId></ResponseMetadata>
+</GetCallerIdentityResponse>`, fakeAwsAccountID)
+}
+
+// ReplayMonth reproduz o gasto diário sobre as notificações de porcentagem de todos os orçamentos;
+// os assinantes EMAIL são os destinatários e os tópicos SNS aparecem como sns:<arn>
+func (f *fakeAwsBudgets) ReplayMonth(daily []float64) []budgetNotification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.budgets))
+	for name := range f.budgets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rules []budgetRule
+	for _, name := range names {
+		budget := f.budgets[name]
+		keys := make([]string, 0, len(budget.Notifications))
+		for key := range budget.Notifications {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			notification := budget.Notifications[key]
+			// Com gasto crescente ao longo do mês, só GREATER_THAN dispara
+			if notification.ComparisonOperator != "GREATER_THAN" {
+				continue
+			}
+			threshold := notification.Threshold
+			if notification.ThresholdType == "ABSOLUTE_VALUE" {
+				threshold = threshold / budget.Amount() * 100
+			}
+			var recipients []string
+			for _, subscriber := range budget.Subscribers[key] {
+				if subscriber.SubscriptionType == "SNS" {
+					recipients = append(recipients, "sns:"+subscriber.Address)
+				} else {
+					recipients = append(recipients, subscriber.Address)
+				}
+			}
+			rules = append(rules, budgetRule{Budget: name, Amount: budget.Amount(), Kind: notification.NotificationType, Threshold: threshold, Recipients: recipients})
+		}
+	}
+	return replayBudgetMonth(rules, daily)
+}
+
+// pubsubMessage é uma mensagem publicada pelo fake do GCP em um tópico, com o data já decodificado
+type pubsubMessage struct {
+	Attributes map[string]string
+	Data       map[string]interface{}
+}
+
+// fakeGcpBilling simula, com um armazenamento REST genérico por nome de recurso, as APIs do GCP
+// usadas pelo cost_monitor/gcp: Billing Budgets (v1), Pub/Sub (v1) e canais de notificação do
+// Cloud Monitoring (v3)
+type fakeGcpBilling struct {
+	Server *httptest.Server
+
+	mu        sync.Mutex
+	resources map[string]map[string]interface{}
+	messages  map[string][]pubsubMessage
+	nextID    int
+}
+
+// newFakeGcpBilling inicia o fake e o encerra ao final do teste
+func newFakeGcpBilling(t *testing.T) *fakeGcpBilling {
+	fake := &fakeGcpBilling{resources: map[string]map[string]interface{}{}, messages: map[string][]pubsubMessage{}}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	return fake
+}
+
+// Resources retorna os recursos cujo nome contém a coleção informada (budgets, topics, notificationChannels...)
+func (f *fakeGcpBilling) Resources(collection string) map[string]map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	resources := map[string]map[string]interface{}{}
+	for name, resource := range f.resources {
+		if strings.Contains(name, "/"+collection+"/") {
+			resources[name] = resource
+		}
+	}
+	return resources
+}
+
+// Messages retorna as mensagens publicadas no tópico (projects/<projeto>/topics/<nome>)
+func (f *fakeGcpBilling) Messages(topic string) []pubsubMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]pubsubMessage(nil), f.messages[topic]...)
+}
+
+func (f *fakeGcpBilling) handle(w http.ResponseWriter, r *http.Request) {
+	// Os nomes dos recursos não levam a versão da API
+	path := strings.Trim(r.URL.Path, "/")
+	if version, rest, ok := strings.Cut(path, "/"); ok && (version == "v1" || version == "v3") {
+		path = rest
+	}
+
+	var body map[string]interface{}
+	if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeGcpError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPost:
+		// Criação em uma coleção: budgets e notificationChannels recebem um ID gerado
+		f.nextID++
+		name := fmt.Sprintf("%s/%d", path, f.nextID)
+		body["name"] = name
+		f.resources[name] = body
+		writeJSON(w, body)
+	case http.MethodPut:
+		// Pub/Sub cria tópicos e assinaturas com o nome no caminho
+		if _, exists := f.resources[path]; exists {
+			writeGcpError(w, http.StatusConflict, "ALREADY_EXISTS", "recurso já existe: "+path)
+			return
+		}
+		body["name"] = path
+		f.resources[path] = body
+		writeJSON(w, body)
+	case http.MethodGet:
+		resource, ok := f.resources[path]
+		if !ok {
+			writeGcpError(w, http.StatusNotFound, "NOT_FOUND", "recurso não encontrado: "+path)
+			return
+		}
+		writeJSON(w, resource)
+	case http.MethodPatch:
+		resource, ok := f.resources[path]
+		if !ok {
+			writeGcpError(w, http.StatusNotFound, "NOT_FOUND", "recurso não encontrado: "+path)
+			return
+		}
+		for key, value := range body {
+			resource[key] = value
+		}
+		resource["name"] = path
+		writeJSON(w, resource)
+	case http.MethodDelete:
+		if _, ok := f.resources[path]; !ok {
+			writeGcpError(w, http.StatusNotFound, "NOT_FOUND", "recurso não encontrado: "+path)
+			return
+		}
+		delete(f.resources, path)
+		writeJSON(w, map[string]interface{}{})
+	default:
+		writeGcpError(w, http.StatusMethodNotAllowed, "UNIMPLEMENTED", "método não suportado pelo fake: "+r.Method)
+	}
+}
+
+func writeGcpError(w http.ResponseWriter, code int, status, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{"code": code, "message": message, "status": status},
+	})
+}
+
+// ReplayMonth reproduz o gasto diário sobre as thresholdRules de cada orçamento. Os destinatários
+// são os e-mails dos canais de monitoringNotificationChannels e cada aviso é publicado no
+// pubsubTopic de allUpdatesRule, no formato das notificações programáticas do Cloud Billing
+func (f *fakeGcpBilling) ReplayMonth(daily []float64) []budgetNotification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0)
+	for name := range f.resources {
+		if strings.Contains(name, "/budgets/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var notifications []budgetNotification
+	for _, name := range names {
+		budget := f.resources[name]
+		displayName, _ := budget["displayName"].(string)
+		amount, currency := gcpBudgetAmount(budget)
+		updates, _ := budget["allUpdatesRule"].(map[string]interface{})
+
+		var recipients []string
+		channels, _ := updates["monitoringNotificationChannels"].([]interface{})
+		for _, channel := range channels {
+			labels, _ := f.resources[fmt.Sprint(channel)]["labels"].(map[string]interface{})
+			if email, ok := labels["email_address"].(string); ok {
+				recipients = append(recipients, email)
+			}
+		}
+
+		var rules []budgetRule
+		thresholdRules, _ := budget["thresholdRules"].([]interface{})
+		for _, item := range thresholdRules {
+			rule, _ := item.(map[string]interface{})
+			percent, _ := rule["thresholdPercent"].(float64)
+			kind := budgetActual
+			if rule["spendBasis"] == "FORECASTED_SPEND" {
+				kind = budgetForecasted
+			}
+			rules = append(rules, budgetRule{Budget: displayName, Amount: amount, Kind: kind, Threshold: percent * 100, Recipients: recipients})
+		}
+
+		replayed := replayBudgetMonth(rules, daily)
+		topic, _ := updates["pubsubTopic"].(string)
+		for _, notification := range replayed {
+			data := map[string]interface{}{
+				"budgetDisplayName": displayName,
+				"costAmount":        notification.Spend,
+				"costIntervalStart": "2026-11-01T00:00:00Z",
+				"budgetAmount":      amount,
+				"budgetAmountType":  "SPECIFIED_AMOUNT",
+				"currencyCode":      currency,
+			}
+			if notification.Kind == budgetForecasted {
+				data["forecastThresholdExceeded"] = notification.Threshold / 100
+			} else {
+				data["alertThresholdExceeded"] = notification.Threshold / 100
+			}
+			if topic != "" {
+				billingAccount, budgetID, _ := strings.Cut(strings.TrimPrefix(name, "billingAccounts/"), "/budgets/")
+				f.messages[topic] = append(f.messages[topic], pubsubMessage{
+					Attributes: map[string]string{"billingAccountId": billingAccount, "budgetId": budgetID, "schemaVersion": "1.0"},
+					Data:       data,
+				})
+			}
+		}
+		notifications = append(notifications, replayed...)
+	}
+	return notifications
+}
+
+// gcpBudgetAmount lê amount.specifiedAmount; units chega como string por ser int64 na API
+func gcpBudgetAmount(budget map[string]interface{}) (float64, string) {
+	amount, _ := budget["amount"].(map[string]interface{})
+	specified, _ := amount["specifiedAmount"].(map[string]interface{})
+	units, _ := strconv.ParseFloat(fmt.Sprint(specified["units"]), 64)
+	currency, _ := specified["currencyCode"].(string)
+	return units, currency
+}
+
+// fakeDigitalOceanAlerts simula a API de políticas de alerta do DigitalOcean (/v2/monitoring/alerts)
+type fakeDigitalOceanAlerts struct {
+	Server *httptest.Server
+
+	mu       sync.Mutex
+	policies map[string]map[string]interface{}
+	nextID   int
+}
+
+// newFakeDigitalOceanAlerts inicia o fake e o encerra ao final do teste
+func newFakeDigitalOceanAlerts(t *testing.T) *fakeDigitalOceanAlerts {
+	fake := &fakeDigitalOceanAlerts{policies: map[string]map[string]interface{}{}}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	return fake
+}
+
+// Policies retorna as políticas criadas, ordenadas pela descrição
+func (f *fakeDigitalOceanAlerts) Policies() []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	policies := make([]map[string]interface{}, 0, len(f.policies))
+	for _, policy := range f.policies {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return fmt.Sprint(policies[i]["description"]) < fmt.Sprint(policies[j]["description"])
+	})
+	return policies
+}
+
+func (f *fakeDigitalOceanAlerts) handle(w http.ResponseWriter, r *http.Request) {
+	const prefix = "/v2/monitoring/alerts"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		writeDigitalOceanError(w, http.StatusNotFound, "not_found", "caminho não suportado pelo fake: "+r.URL.Path)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	var body map[string]interface{}
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeDigitalOceanError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		f.nextID++
+		body["uuid"] = fmt.Sprintf("00000000-0000-4000-8000-%012d", f.nextID)
+		f.policies[body["uuid"].(string)] = body
+		writeJSON(w, map[string]interface{}{"policy": body})
+	case id == "" && r.Method == http.MethodGet:
+		policies := []interface{}{}
+		for _, policy := range f.policies {
+			policies = append(policies, policy)
+		}
+		writeJSON(w, map[string]interface{}{"policies": policies, "links": map[string]interface{}{}, "meta": map[string]interface{}{"total": len(policies)}})
+	case f.policies[id] == nil:
+		writeDigitalOceanError(w, http.StatusNotFound, "not_found", "política não encontrada: "+id)
+	case r.Method == http.MethodGet:
+		writeJSON(w, map[string]interface{}{"policy": f.policies[id]})
+	case r.Method == http.MethodPut:
+		body["uuid"] = id
+		f.policies[id] = body
+		writeJSON(w, map[string]interface{}{"policy": body})
+	case r.Method == http.MethodDelete:
+		delete(f.policies, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeDigitalOceanError(w, http.StatusMethodNotAllowed, "method_not_allowed", "método não suportado pelo fake: "+r.Method)
+	}
+}
+
+func writeDigitalOceanError(w http.ResponseWriter, status int, id, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"id": id, "message": message})
+}
+
+// costMonitorProviders são os blocos provider que apontam cada módulo cost_monitor para os fakes
+var costMonitorProviders = map[string]string{
+	"aws": `provider "aws" {
+  region                      = "us-east-1"
+  access_key                  = "test"
+  secret_key                  = "test"
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_region_validation      = true
+
+  endpoints {
+    budgets = "%[1]s"
+    sts     = "%[1]s"
+  }
+}
+`,
+	"gcp": `provider "google" {
+  project                    = "fake-project"
+  access_token               = "fake-token"
+  billing_custom_endpoint    = "%[1]s/v1/"
+  pubsub_custom_endpoint     = "%[1]s/v1/"
+  monitoring_custom_endpoint = "%[1]s/"
+}
+`,
+	"digitalocean": `provider "digitalocean" {
+  token        = "fake-token"
+  api_endpoint = "%[1]s"
+}
+`,
+}
+
+// costMonitorRoot copia modules/cost_monitor/<provedor> para um diretório temporário com o
+// versions.tf da raiz (os módulos não têm required_providers, e sem ele o digitalocean seria
+// procurado em hashicorp/) e adiciona o provider apontado para o endpoint do fake
+func costMonitorRoot(t *testing.T, provider, endpoint string) string {
+	module := map[string]string{"aws": "aws", "gcp": "gcp", "digitalocean": "digital-ocean"}[provider]
+	dir := t.TempDir()
+	if err := files.CopyFolderContents(filepath.Join("..", "modules", "cost_monitor", module), dir); err != nil {
+		t.Fatalf("Erro ao copiar o módulo cost_monitor/%s: %v", module, err)
+	}
+	versions, err := os.ReadFile(filepath.Join("..", "versions.tf"))
+	if err != nil {
+		t.Fatalf("Erro ao ler versions.tf: %v", err)
+	}
+	content := map[string]string{
+		"versions.tf":       string(versions),
+		"fake_providers.tf": fmt.Sprintf(costMonitorProviders[provider], endpoint),
+	}
+	for name, body := range content {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatalf("Erro ao gravar %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// costMonitorRootProviders apontam os providers padrão da raiz para os fakes. Os blocos "aws" e
+// "digitalocean" de main.tf são sobrepostos por um arquivo _override.tf; o "google" padrão não tem
+// bloco na raiz e é criado em um arquivo comum
+var costMonitorRootProviders = map[string][2]string{
+	"aws": {"fake_providers_override.tf", `provider "aws" {
+  region                      = "us-east-1"
+  profile                     = null
+  access_key                  = "test"
+  secret_key                  = "test"
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_region_validation      = true
+
+  endpoints {
+    budgets = "%[1]s"
+    sts     = "%[1]s"
+  }
+}
+`},
+	"gcp":          {"fake_providers.tf", costMonitorProviders["gcp"]},
+	"digitalocean": {"fake_providers_override.tf", costMonitorProviders["digitalocean"]},
+}
+
+// costMonitorPlanRoot copia a raiz do Terraform para um diretório temporário, sem o
+// backend_override.tf local, e aponta o provider do cost_monitor de provider para o fake
+func costMonitorPlanRoot(t *testing.T, provider, endpoint string) string {
+	dir := test_structure.CopyTerraformFolderToTemp(t, "..", ".")
+	if err := os.Remove(filepath.Join(dir, backendOverrideFile)); err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Erro ao remover o backend da cópia: %v", err)
+	}
+	override := costMonitorRootProviders[provider]
+	if err := os.WriteFile(filepath.Join(dir, override[0]), []byte(fmt.Sprintf(override[1], endpoint)), 0644); err != nil {
+		t.Fatalf("Erro ao gravar o provider do fake: %v", err)
+	}
+	return dir
+}
@@ -0,0 +1,563 @@
+package test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Fakes em processo do Prometheus e do Alertmanager usados na simulação de alertas.
+// O Prometheus responde /api/v1/query avaliando PromQL sobre séries sintéticas; o Alertmanager
+// recebe alertas em /api/v2/alerts e notifica os receivers no formato de cada integração.
+
+// fakePrometheus expõe a API HTTP de consulta instantânea sobre um promStorage
+type fakePrometheus struct {
+	Server  *httptest.Server
+	Storage *promStorage
+}
+
+// newFakePrometheus inicia o fake do Prometheus e o encerra ao final do teste
+func newFakePrometheus(t *testing.T) *fakePrometheus {
+	fake := &fakePrometheus{Storage: newPromStorage()}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	return fake
+}
+
+func (f *fakePrometheus) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/query" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writePrometheusError(w, "bad_data", err.Error())
+		return
+	}
+
+	at := time.Now()
+	if value := r.Form.Get("time"); value != "" {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			writePrometheusError(w, "bad_data", fmt.Sprintf("time inválido: %v", err))
+			return
+		}
+		at = time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+	}
+
+	expr, err := parsePromQL(r.Form.Get("query"))
+	if err != nil {
+		writePrometheusError(w, "bad_data", err.Error())
+		return
+	}
+	result, err := evalPromQL(expr, f.Storage, at)
+	if err != nil {
+		writePrometheusError(w, "execution", err.Error())
+		return
+	}
+
+	timestamp := float64(at.UnixNano()) / float64(time.Second)
+	data := map[string]interface{}{}
+	if result.IsScalar {
+		data["resultType"] = "scalar"
+		data["result"] = []interface{}{timestamp, strconv.FormatFloat(result.Scalar, 'f', -1, 64)}
+	} else {
+		samples := []interface{}{}
+		for _, sample := range result.Vector {
+			samples = append(samples, map[string]interface{}{
+				"metric": sample.Labels,
+				"value":  []interface{}{timestamp, strconv.FormatFloat(sample.Value, 'f', -1, 64)},
+			})
+		}
+		data["resultType"] = "vector"
+		data["result"] = samples
+	}
+	writeJSON(w, map[string]interface{}{"status": "success", "data": data})
+}
+
+func writePrometheusError(w http.ResponseWriter, errorType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"status": "error", "errorType": errorType, "error": message})
+}
+
+// prometheusClient consulta a API HTTP do Prometheus
+type prometheusClient struct {
+	endpoint string
+}
+
+// Query executa uma consulta instantânea e retorna o vetor resultante (escalares viram um elemento sem labels)
+func (c *prometheusClient) Query(query string, at time.Time) ([]promVectorSample, error) {
+	form := url.Values{
+		"query": {query},
+		"time":  {strconv.FormatFloat(float64(at.UnixNano())/float64(time.Second), 'f', 3, 64)},
+	}
+	resp, err := http.PostForm(c.endpoint+"/api/v1/query", form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Status != "success" {
+		return nil, fmt.Errorf("consulta %q falhou: %s", query, body.Error)
+	}
+
+	parseValue := func(pair []interface{}) (float64, error) {
+		if len(pair) != 2 {
+			return 0, fmt.Errorf("valor malformado: %v", pair)
+		}
+		text, _ := pair[1].(string)
+		return strconv.ParseFloat(text, 64)
+	}
+
+	switch body.Data.ResultType {
+	case "scalar":
+		var pair []interface{}
+		if err := json.Unmarshal(body.Data.Result, &pair); err != nil {
+			return nil, err
+		}
+		value, err := parseValue(pair)
+		if err != nil {
+			return nil, err
+		}
+		return []promVectorSample{{Labels: map[string]string{}, Value: value}}, nil
+
+	case "vector":
+		var samples []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		}
+		if err := json.Unmarshal(body.Data.Result, &samples); err != nil {
+			return nil, err
+		}
+		var vector []promVectorSample
+		for _, sample := range samples {
+			value, err := parseValue(sample.Value)
+			if err != nil {
+				return nil, err
+			}
+			vector = append(vector, promVectorSample{Labels: sample.Metric, Value: value})
+		}
+		return vector, nil
+
+	default:
+		return nil, fmt.Errorf("tipo de resultado %q não suportado", body.Data.ResultType)
+	}
+}
+
+// alertmanagerAlert segue o formato de /api/v2/alerts do Alertmanager
+type alertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// Fingerprint identifica o alerta pelos labels, como o Alertmanager
+func (a alertmanagerAlert) Fingerprint() string {
+	sum := sha256.Sum256([]byte(promLabelsKey(a.Labels)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// alertReceiver é um destino de notificações; Type é webhook (formato do Alertmanager) ou um dos
+// destinos da biblioteca de notificações (slack, teams, pagerduty, opsgenie)
+type alertReceiver struct {
+	Name       string
+	Type       string
+	URL        string
+	Channel    string
+	RoutingKey string
+	APIKey     string
+}
+
+func (r alertReceiver) target() notificationTarget {
+	return notificationTarget{Type: r.Type, URL: r.URL, Channel: r.Channel, RoutingKey: r.RoutingKey, APIKey: r.APIKey}
+}
+
+// alertNotification converte o alerta para o formato comum: o alertname é o título, as anotações
+// summary e description o resumo e os detalhes, e os demais labels viram campos
+func alertNotification(alert alertmanagerAlert) notification {
+	var names []string
+	for name := range alert.Labels {
+		if name != "alertname" && name != "severity" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var fields []notificationField
+	for _, name := range names {
+		fields = append(fields, notificationField{Name: name, Value: alert.Labels[name]})
+	}
+
+	at := alert.StartsAt
+	if !alert.EndsAt.IsZero() {
+		at = alert.EndsAt
+	}
+	return notification{
+		Source:      "monitoring",
+		Environment: alert.Labels["environment"],
+		Title:       alert.Labels["alertname"],
+		Summary:     alert.Annotations["summary"],
+		Details:     alert.Annotations["description"],
+		Resource:    alert.Labels["instance"],
+		Severity:    alert.Labels["severity"],
+		Resolved:    !alert.EndsAt.IsZero(),
+		DedupKey:    alert.Fingerprint(),
+		Fields:      fields,
+		Time:        at,
+	}
+}
+
+// fakeAlertmanager recebe alertas e notifica todos os receivers quando um alerta começa ou é resolvido
+type fakeAlertmanager struct {
+	Server    *httptest.Server
+	Receivers []alertReceiver
+
+	mu     sync.Mutex
+	active map[string]alertmanagerAlert
+	errors []string
+}
+
+// newFakeAlertmanager inicia o fake do Alertmanager e o encerra ao final do teste
+func newFakeAlertmanager(t *testing.T, receivers []alertReceiver) *fakeAlertmanager {
+	fake := &fakeAlertmanager{Receivers: receivers, active: map[string]alertmanagerAlert{}}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	return fake
+}
+
+func (f *fakeAlertmanager) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v2/alerts" || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var alerts []alertmanagerAlert
+	if err := json.NewDecoder(r.Body).Decode(&alerts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Só mudanças de estado geram notificação; alertas reenviados a cada avaliação são ignorados
+	var changed []alertmanagerAlert
+	for _, alert := range alerts {
+		if alert.Labels["alertname"] == "" {
+			http.Error(w, "alerta sem label alertname", http.StatusBadRequest)
+			return
+		}
+		fingerprint := alert.Fingerprint()
+		_, wasActive := f.active[fingerprint]
+		resolved := !alert.EndsAt.IsZero()
+		switch {
+		case resolved && wasActive:
+			delete(f.active, fingerprint)
+			changed = append(changed, alert)
+		case !resolved && !wasActive:
+			f.active[fingerprint] = alert
+			changed = append(changed, alert)
+		}
+	}
+
+	for _, alert := range changed {
+		for _, receiver := range f.Receivers {
+			if err := notifyAlertReceiver(receiver, alert); err != nil {
+				f.errors = append(f.errors, fmt.Sprintf("%s: %v", receiver.Name, err))
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Active retorna os alertas ativos, ordenados por alertname e instance
+func (f *fakeAlertmanager) Active() []alertmanagerAlert {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var alerts []alertmanagerAlert
+	for _, alert := range f.active {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return promLabelsKey(alerts[i].Labels) < promLabelsKey(alerts[j].Labels)
+	})
+	return alerts
+}
+
+// Errors retorna as falhas de entrega aos receivers
+func (f *fakeAlertmanager) Errors() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.errors...)
+}
+
+// notifyAlertReceiver envia um alerta no formato da integração do receiver
+func notifyAlertReceiver(receiver alertReceiver, alert alertmanagerAlert) error {
+	status := "firing"
+	if !alert.EndsAt.IsZero() {
+		status = "resolved"
+	}
+
+	var payload interface{}
+	switch receiver.Type {
+	case "webhook":
+		payload = map[string]interface{}{
+			"version":           "4",
+			"groupKey":          "{}:{alertname=\"" + alert.Labels["alertname"] + "\"}",
+			"status":            status,
+			"receiver":          receiver.Name,
+			"groupLabels":       map[string]string{"alertname": alert.Labels["alertname"]},
+			"commonLabels":      alert.Labels,
+			"commonAnnotations": alert.Annotations,
+			"alerts": []map[string]interface{}{{
+				"status":      status,
+				"labels":      alert.Labels,
+				"annotations": alert.Annotations,
+				"startsAt":    alert.StartsAt,
+				"endsAt":      alert.EndsAt,
+				"fingerprint": alert.Fingerprint(),
+			}},
+		}
+
+	case notifySlack, notifyTeams, notifyPagerDuty, notifyOpsgenie:
+		return sendNotification(receiver.target(), alertNotification(alert))
+
+	default:
+		return fmt.Errorf("tipo de receiver %q não suportado", receiver.Type)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(receiver.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// webhookRequest é uma requisição recebida pelo webhookSink; Error é o motivo da recusa, se houve
+type webhookRequest struct {
+	Path  string
+	Body  map[string]interface{}
+	Error string
+}
+
+// Headline lê a primeira linha de uma notificação do Slack (text) ou do Teams (primeiro TextBlock)
+func (r webhookRequest) Headline() string {
+	if text, ok := r.Body["text"].(string); ok {
+		return text
+	}
+	for _, element := range r.teamsBody() {
+		if element["type"] == "TextBlock" {
+			text, _ := element["text"].(string)
+			return text
+		}
+	}
+	return ""
+}
+
+// Fields lê os campos de uma notificação do Slack (fields do attachment), do Teams (FactSet) ou os
+// detalhes do PagerDuty (custom_details) e do OpsGenie (details)
+func (r webhookRequest) Fields() map[string]string {
+	fields := map[string]string{}
+	add := func(items []interface{}, name, value string) {
+		for _, item := range items {
+			field, _ := item.(map[string]interface{})
+			key, _ := field[name].(string)
+			fields[key], _ = field[value].(string)
+		}
+	}
+	addMap := func(details map[string]interface{}) {
+		for key, value := range details {
+			fields[key], _ = value.(string)
+		}
+	}
+
+	attachments, _ := r.Body["attachments"].([]interface{})
+	if len(attachments) > 0 {
+		if attachment, _ := attachments[0].(map[string]interface{}); attachment["fields"] != nil {
+			items, _ := attachment["fields"].([]interface{})
+			add(items, "title", "value")
+		}
+	}
+	for _, element := range r.teamsBody() {
+		if element["type"] == "FactSet" {
+			items, _ := element["facts"].([]interface{})
+			add(items, "title", "value")
+		}
+	}
+	if payload, ok := r.Body["payload"].(map[string]interface{}); ok {
+		details, _ := payload["custom_details"].(map[string]interface{})
+		addMap(details)
+	}
+	if details, ok := r.Body["details"].(map[string]interface{}); ok {
+		addMap(details)
+	}
+	return fields
+}
+
+func (r webhookRequest) teamsBody() []map[string]interface{} {
+	attachments, _ := r.Body["attachments"].([]interface{})
+	if len(attachments) == 0 {
+		return nil
+	}
+	attachment, _ := attachments[0].(map[string]interface{})
+	card, _ := attachment["content"].(map[string]interface{})
+	items, _ := card["body"].([]interface{})
+	var elements []map[string]interface{}
+	for _, item := range items {
+		element, _ := item.(map[string]interface{})
+		elements = append(elements, element)
+	}
+	return elements
+}
+
+// webhookSink registra as notificações recebidas, no lugar dos webhooks do Slack, Teams, PagerDuty e
+// OpsGenie. O primeiro segmento do caminho (/slack, /teams, /pagerduty, /opsgenie) escolhe o serviço
+// imitado: o payload é validado como o serviço faria e recusado com o erro correspondente
+type webhookSink struct {
+	Server *httptest.Server
+
+	mu       sync.Mutex
+	requests []webhookRequest
+	rejected []webhookRequest
+}
+
+// newWebhookSink inicia o receptor de webhooks e o encerra ao final do teste
+func newWebhookSink(t *testing.T) *webhookSink {
+	sink := &webhookSink{}
+	sink.Server = httptest.NewServer(http.HandlerFunc(sink.handle))
+	t.Cleanup(sink.Server.Close)
+	return sink
+}
+
+// URL retorna o endereço do sink que imita o destino informado
+func (s *webhookSink) URL(target string) string {
+	return s.Server.URL + "/" + target
+}
+
+func (s *webhookSink) handle(w http.ResponseWriter, r *http.Request) {
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(content, &body); err != nil {
+		http.Error(w, "payload inválido: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	target, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	kind := target
+	if target == notifyOpsgenie && strings.HasSuffix(r.URL.Path, "/close") {
+		kind = notifyOpsgenieClose
+	}
+	if containsString(notificationTargetTypes, target) {
+		if target == notifyOpsgenie && !strings.HasPrefix(r.Header.Get("Authorization"), "GenieKey ") {
+			s.reject(r.URL.Path, body, "Authorization sem GenieKey")
+			writeWebhookJSON(w, http.StatusUnauthorized, map[string]interface{}{"message": "Could not authenticate", "took": 0})
+			return
+		}
+		if err := validateNotificationPayload(kind, content); err != nil {
+			s.reject(r.URL.Path, body, err.Error())
+			writeWebhookError(w, target, err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, webhookRequest{Path: r.URL.Path, Body: body})
+	s.mu.Unlock()
+
+	// Respostas de sucesso de cada serviço: o Slack responde "ok", o Teams 202 sem corpo, e o PagerDuty
+	// e o OpsGenie 202 com JSON
+	switch target {
+	case notifyTeams:
+		w.WriteHeader(http.StatusAccepted)
+	case notifyPagerDuty:
+		writeWebhookJSON(w, http.StatusAccepted, map[string]interface{}{"status": "success", "message": "Event processed", "dedup_key": body["dedup_key"]})
+	case notifyOpsgenie:
+		writeWebhookJSON(w, http.StatusAccepted, map[string]interface{}{"result": "Request will be processed", "requestId": fmt.Sprintf("req-%d", len(s.Requests(r.URL.Path)))})
+	default:
+		fmt.Fprint(w, "ok")
+	}
+}
+
+func (s *webhookSink) reject(path string, body map[string]interface{}, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejected = append(s.rejected, webhookRequest{Path: path, Body: body, Error: reason})
+}
+
+func writeWebhookJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeWebhookError responde a um payload recusado no formato de erro do serviço
+func writeWebhookError(w http.ResponseWriter, target string, err error) {
+	switch target {
+	case notifyPagerDuty:
+		writeWebhookJSON(w, http.StatusBadRequest, map[string]interface{}{"status": "invalid event", "message": "Event object is invalid", "errors": []string{err.Error()}})
+	case notifyOpsgenie:
+		writeWebhookJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"message": err.Error(), "took": 0})
+	case notifySlack:
+		http.Error(w, "invalid_payload: "+err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Rejected retorna as requisições recusadas pela validação, na ordem de chegada
+func (s *webhookSink) Rejected() []webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]webhookRequest(nil), s.rejected...)
+}
+
+// Requests retorna as requisições aceitas em um caminho ou abaixo dele, na ordem de chegada
+func (s *webhookSink) Requests(path string) []webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []webhookRequest
+	for _, request := range s.requests {
+		if request.Path == path || strings.HasPrefix(request.Path, path+"/") {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
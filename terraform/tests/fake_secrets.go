@@ -0,0 +1,442 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Fakes em processo das APIs usadas pelo módulo security/credential_rotation.
+// Implementam apenas o subconjunto de operações que a função de rotação utiliza,
+// com o mesmo formato de requisição e resposta dos serviços reais.
+
+// secretVersion representa uma versão armazenada de um segredo
+type secretVersion struct {
+	ID        string
+	Value     string
+	Stages    []string
+	CreatedAt time.Time
+}
+
+// fakeSecretsManager simula o protocolo JSON 1.1 do AWS Secrets Manager
+type fakeSecretsManager struct {
+	Server *httptest.Server
+
+	mu      sync.Mutex
+	secrets map[string][]*secretVersion
+	clock   func() time.Time
+}
+
+// newFakeSecretsManager inicia o fake do Secrets Manager e o encerra ao final do teste
+func newFakeSecretsManager(t *testing.T, clock func() time.Time) *fakeSecretsManager {
+	fake := &fakeSecretsManager{
+		secrets: map[string][]*secretVersion{},
+		clock:   clock,
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	return fake
+}
+
+func (f *fakeSecretsManager) handle(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name         string
+		SecretId     string
+		SecretString string
+		VersionId    string
+		VersionStage string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeAwsError(w, "InvalidRequestException", err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "secretsmanager.") {
+	case "CreateSecret":
+		if _, exists := f.secrets[input.Name]; exists {
+			writeAwsError(w, "ResourceExistsException", fmt.Sprintf("o segredo %s já existe", input.Name))
+			return
+		}
+		f.secrets[input.Name] = nil
+		version := f.addVersion(input.Name, input.SecretString)
+		writeJSON(w, map[string]interface{}{"ARN": f.arn(input.Name), "Name": input.Name, "VersionId": version.ID})
+
+	case "GetSecretValue":
+		version, err := f.findVersion(input.SecretId, input.VersionId, input.VersionStage)
+		if err != nil {
+			writeAwsError(w, "ResourceNotFoundException", err.Error())
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"ARN":           f.arn(input.SecretId),
+			"Name":          input.SecretId,
+			"SecretString":  version.Value,
+			"VersionId":     version.ID,
+			"VersionStages": version.Stages,
+			"CreatedDate":   version.CreatedAt.Unix(),
+		})
+
+	case "PutSecretValue", "UpdateSecret":
+		if _, exists := f.secrets[input.SecretId]; !exists {
+			writeAwsError(w, "ResourceNotFoundException", fmt.Sprintf("segredo %s não encontrado", input.SecretId))
+			return
+		}
+		version := f.addVersion(input.SecretId, input.SecretString)
+		writeJSON(w, map[string]interface{}{"ARN": f.arn(input.SecretId), "Name": input.SecretId, "VersionId": version.ID})
+
+	default:
+		writeAwsError(w, "UnknownOperationException", r.Header.Get("X-Amz-Target"))
+	}
+}
+
+// addVersion grava uma nova versão como AWSCURRENT e move a anterior para AWSPREVIOUS
+func (f *fakeSecretsManager) addVersion(name, value string) *secretVersion {
+	versions := f.secrets[name]
+	for _, v := range versions {
+		switch {
+		case hasStage(v, "AWSCURRENT"):
+			v.Stages = []string{"AWSPREVIOUS"}
+		case hasStage(v, "AWSPREVIOUS"):
+			v.Stages = nil
+		}
+	}
+
+	version := &secretVersion{
+		ID:        fmt.Sprintf("v%d", len(versions)+1),
+		Value:     value,
+		Stages:    []string{"AWSCURRENT"},
+		CreatedAt: f.clock(),
+	}
+	f.secrets[name] = append(versions, version)
+	return version
+}
+
+func (f *fakeSecretsManager) findVersion(name, versionID, stage string) (*secretVersion, error) {
+	versions, exists := f.secrets[name]
+	if !exists {
+		return nil, fmt.Errorf("segredo %s não encontrado", name)
+	}
+	if versionID == "" && stage == "" {
+		stage = "AWSCURRENT"
+	}
+	for _, v := range versions {
+		if (versionID != "" && v.ID == versionID) || (versionID == "" && hasStage(v, stage)) {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("versão %s%s do segredo %s não encontrada", versionID, stage, name)
+}
+
+// Versions retorna todas as versões gravadas para o segredo, da mais antiga para a mais recente
+func (f *fakeSecretsManager) Versions(name string) []secretVersion {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var versions []secretVersion
+	for _, v := range f.secrets[name] {
+		versions = append(versions, *v)
+	}
+	return versions
+}
+
+func (f *fakeSecretsManager) arn(name string) string {
+	return fmt.Sprintf("arn:aws:secretsmanager:us-east-1:000000000000:secret:%s", name)
+}
+
+func hasStage(v *secretVersion, stage string) bool {
+	for _, s := range v.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAwsError(w http.ResponseWriter, errorType, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"__type": errorType, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// fakeVault simula a engine KV versão 2 do HashiCorp Vault montada em secret/
+type fakeVault struct {
+	Server *httptest.Server
+	Token  string
+
+	mu      sync.Mutex
+	secrets map[string][]*secretVersion
+	clock   func() time.Time
+}
+
+// newFakeVault inicia o fake do Vault exigindo o token informado no cabeçalho X-Vault-Token
+func newFakeVault(t *testing.T, token string, clock func() time.Time) *fakeVault {
+	fake := &fakeVault{
+		Token:   token,
+		secrets: map[string][]*secretVersion{},
+		clock:   clock,
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	return fake
+}
+
+func (f *fakeVault) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != f.Token {
+		writeVaultError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		switch r.Method {
+		case http.MethodGet:
+			f.read(w, path, r.URL.Query().Get("version"))
+		case http.MethodPost, http.MethodPut:
+			f.write(w, r, path)
+		default:
+			writeVaultError(w, http.StatusMethodNotAllowed, "método não suportado")
+		}
+
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/") && r.Method == http.MethodGet:
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/")
+		versions, exists := f.secrets[path]
+		if !exists {
+			writeVaultError(w, http.StatusNotFound, "")
+			return
+		}
+		metadata := map[string]interface{}{}
+		for i, v := range versions {
+			metadata[strconv.Itoa(i+1)] = map[string]interface{}{
+				"created_time":  v.CreatedAt.Format(time.RFC3339Nano),
+				"deletion_time": "",
+				"destroyed":     false,
+			}
+		}
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+			"current_version": len(versions),
+			"oldest_version":  1,
+			"versions":        metadata,
+		}})
+
+	default:
+		writeVaultError(w, http.StatusNotFound, "")
+	}
+}
+
+func (f *fakeVault) read(w http.ResponseWriter, path, version string) {
+	versions, exists := f.secrets[path]
+	if !exists {
+		writeVaultError(w, http.StatusNotFound, "")
+		return
+	}
+
+	index := len(versions)
+	if version != "" {
+		n, err := strconv.Atoi(version)
+		if err != nil || n < 1 || n > len(versions) {
+			writeVaultError(w, http.StatusNotFound, "")
+			return
+		}
+		index = n
+	}
+
+	v := versions[index-1]
+	var data map[string]interface{}
+	json.Unmarshal([]byte(v.Value), &data)
+	writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+		"data": data,
+		"metadata": map[string]interface{}{
+			"version":       index,
+			"created_time":  v.CreatedAt.Format(time.RFC3339Nano),
+			"deletion_time": "",
+			"destroyed":     false,
+		},
+	}})
+}
+
+func (f *fakeVault) write(w http.ResponseWriter, r *http.Request, path string) {
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Data == nil {
+		writeVaultError(w, http.StatusBadRequest, "corpo da requisição sem o campo data")
+		return
+	}
+
+	value, _ := json.Marshal(body.Data)
+	versions := f.secrets[path]
+	version := &secretVersion{
+		ID:        strconv.Itoa(len(versions) + 1),
+		Value:     string(value),
+		CreatedAt: f.clock(),
+	}
+	f.secrets[path] = append(versions, version)
+
+	writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+		"version":       len(versions) + 1,
+		"created_time":  version.CreatedAt.Format(time.RFC3339Nano),
+		"deletion_time": "",
+		"destroyed":     false,
+	}})
+}
+
+// Versions retorna todas as versões gravadas no caminho, da mais antiga para a mais recente
+func (f *fakeVault) Versions(path string) []secretVersion {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var versions []secretVersion
+	for _, v := range f.secrets[path] {
+		versions = append(versions, *v)
+	}
+	return versions
+}
+
+func writeVaultError(w http.ResponseWriter, status int, message string) {
+	errors := []string{}
+	if message != "" {
+		errors = append(errors, message)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": errors})
+}
+
+// doToken representa um token emitido pela API de tokens do DigitalOcean
+type doToken struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Token     string    `json:"token,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Revoked   bool      `json:"-"`
+}
+
+// fakeDigitalOceanTokens simula os endpoints /v2/tokens chamados pela função de rotação
+type fakeDigitalOceanTokens struct {
+	Server *httptest.Server
+
+	mu     sync.Mutex
+	tokens []*doToken
+	clock  func() time.Time
+}
+
+// newFakeDigitalOceanTokens inicia o fake já com um token inicial válido por ttl
+func newFakeDigitalOceanTokens(t *testing.T, clock func() time.Time, ttl time.Duration) (*fakeDigitalOceanTokens, doToken) {
+	fake := &fakeDigitalOceanTokens{clock: clock}
+	initial := fake.issue("terraform-token-inicial", ttl)
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	return fake, *initial
+}
+
+func (f *fakeDigitalOceanTokens) issue(name string, ttl time.Duration) *doToken {
+	now := f.clock()
+	token := &doToken{
+		ID:        len(f.tokens) + 1,
+		Name:      name,
+		Token:     fmt.Sprintf("dop_v1_fake%04d", len(f.tokens)+1),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	f.tokens = append(f.tokens, token)
+	return token
+}
+
+func (f *fakeDigitalOceanTokens) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	caller := f.authenticate(r)
+	if caller == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]string{"id": "unauthorized", "message": "Unable to authenticate you"})
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/v2/tokens" && r.Method == http.MethodPost:
+		var body struct {
+			Name string `json:"name"`
+			TTL  int    `json:"ttl"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TTL <= 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			writeJSON(w, map[string]string{"id": "unprocessable_entity", "message": "ttl inválido"})
+			return
+		}
+		token := f.issue(body.Name, time.Duration(body.TTL)*time.Second)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"token": token})
+
+	case r.URL.Path == "/v2/tokens" && r.Method == http.MethodGet:
+		var active []doToken
+		for _, token := range f.tokens {
+			if !token.Revoked {
+				listed := *token
+				listed.Token = ""
+				active = append(active, listed)
+			}
+		}
+		writeJSON(w, map[string]interface{}{"tokens": active})
+
+	case strings.HasPrefix(r.URL.Path, "/v2/tokens/") && r.Method == http.MethodDelete:
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/v2/tokens/"))
+		if err != nil || id < 1 || id > len(f.tokens) || f.tokens[id-1].Revoked {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(w, map[string]string{"id": "not_found", "message": "token não encontrado"})
+			return
+		}
+		f.tokens[id-1].Revoked = true
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// authenticate retorna o token do cabeçalho Authorization se ele existir, não estiver revogado e não tiver expirado
+func (f *fakeDigitalOceanTokens) authenticate(r *http.Request) *doToken {
+	value := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	for _, token := range f.tokens {
+		if token.Token == value && !token.Revoked && f.clock().Before(token.ExpiresAt) {
+			return token
+		}
+	}
+	return nil
+}
+
+// Revoked retorna os IDs dos tokens revogados em ordem crescente
+func (f *fakeDigitalOceanTokens) Revoked() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var ids []int
+	for _, token := range f.tokens {
+		if token.Revoked {
+			ids = append(ids, token.ID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
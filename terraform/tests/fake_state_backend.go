@@ -0,0 +1,427 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Fake em processo do S3 e do DynamoDB usados pelo backend "s3" do Terraform: objetos de estado
+// com criptografia no servidor (o bucket recusa PutObject sem x-amz-server-side-encryption, como
+// a política de bucket recomendada) e a tabela de lock com escrita condicional. Um único servidor
+// atende os dois serviços; as requisições do DynamoDB são identificadas pelo X-Amz-Target.
+
+// stateObject é um objeto gravado no bucket
+type stateObject struct {
+	Body       []byte
+	ETag       string
+	Encryption string
+	KMSKeyID   string
+}
+
+// stateLockEvent registra uma operação sobre o item de lock, na ordem em que o fake a recebeu
+type stateLockEvent struct {
+	// Action é acquire, conflict (lock já tomado) ou release
+	Action string
+	LockID string
+	// Holder é o ID do lock do Terraform (campo ID de Info)
+	Holder string
+	At     time.Time
+}
+
+// fakeStateBackend simula o S3 (estilo path) e o DynamoDB (JSON 1.0) com as operações do backend "s3"
+type fakeStateBackend struct {
+	Server *httptest.Server
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*stateObject
+	tables  map[string]map[string]map[string]string
+	events  []stateLockEvent
+	gates   map[string]*stateGate
+	paused  chan string
+}
+
+// stateGate segura um GetObject até ser aberto
+type stateGate struct {
+	armed   bool
+	release chan struct{}
+	once    sync.Once
+}
+
+func (g *stateGate) open() {
+	g.once.Do(func() { close(g.release) })
+}
+
+// newFakeStateBackend inicia o fake com o bucket e a tabela de lock do backend e o encerra ao final do teste
+func newFakeStateBackend(t *testing.T, backend stateBackend) *fakeStateBackend {
+	fake := &fakeStateBackend{
+		buckets: map[string]map[string]*stateObject{backend.Bucket: {}},
+		tables:  map[string]map[string]map[string]string{backend.DynamoDBTable: {}},
+		gates:   map[string]*stateGate{},
+		paused:  make(chan string, 1),
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Server.Close)
+	t.Cleanup(fake.releaseAll)
+	return fake
+}
+
+// Endpoints retorna os endpoints do fake no formato de stateBackend.Endpoints
+func (f *fakeStateBackend) Endpoints() map[string]string {
+	return map[string]string{"s3": f.Server.URL, "dynamodb": f.Server.URL}
+}
+
+// Object retorna uma cópia do objeto gravado
+func (f *fakeStateBackend) Object(bucket, key string) (stateObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	object, ok := f.buckets[bucket][key]
+	if !ok {
+		return stateObject{}, false
+	}
+	copied := *object
+	copied.Body = append([]byte(nil), object.Body...)
+	return copied, true
+}
+
+// LockEvents retorna as operações de lock recebidas, na ordem
+func (f *fakeStateBackend) LockEvents() []stateLockEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]stateLockEvent(nil), f.events...)
+}
+
+// Lock retorna o Info do lock ativo para o LockID, ou vazio se não houver lock
+func (f *fakeStateBackend) Lock(table, lockID string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tables[table][lockID]["Info"]
+}
+
+// PauseNextGet segura o próximo GetObject da chave até a função retornada ser chamada. Paused
+// recebe a chave quando a leitura fica parada, o que permite manter o lock de uma execução
+// enquanto outra tenta obtê-lo
+func (f *fakeStateBackend) PauseNextGet(key string) (release func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	gate := &stateGate{armed: true, release: make(chan struct{})}
+	if previous, ok := f.gates[key]; ok {
+		previous.open()
+	}
+	f.gates[key] = gate
+	return gate.open
+}
+
+// Paused é notificado com a chave do GetObject parado por PauseNextGet
+func (f *fakeStateBackend) Paused() <-chan string {
+	return f.paused
+}
+
+func (f *fakeStateBackend) releaseAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, gate := range f.gates {
+		gate.open()
+	}
+}
+
+func (f *fakeStateBackend) handle(w http.ResponseWriter, r *http.Request) {
+	if target := r.Header.Get("X-Amz-Target"); strings.HasPrefix(target, "DynamoDB_20120810.") {
+		f.handleDynamoDB(w, r, strings.TrimPrefix(target, "DynamoDB_20120810."))
+		return
+	}
+	f.handleS3(w, r)
+}
+
+// s3Error é o corpo XML de erro do S3
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(s3Error{Code: code, Message: message})
+}
+
+// s3ListResult é a resposta de ListObjectsV2
+type s3ListResult struct {
+	XMLName  xml.Name `xml:"ListBucketResult"`
+	Name     string   `xml:"Name"`
+	Prefix   string   `xml:"Prefix"`
+	KeyCount int      `xml:"KeyCount"`
+	MaxKeys  int      `xml:"MaxKeys"`
+	Contents []struct {
+		Key  string `xml:"Key"`
+		ETag string `xml:"ETag"`
+		Size int    `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated bool `xml:"IsTruncated"`
+}
+
+func (f *fakeStateBackend) handleS3(w http.ResponseWriter, r *http.Request) {
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	f.mu.Lock()
+	bucket, ok := f.buckets[bucketName]
+	gate, paused := f.gates[key]
+	paused = paused && gate.armed && key != "" && r.Method == http.MethodGet
+	if paused {
+		gate.armed = false
+	}
+	f.mu.Unlock()
+
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket", fmt.Sprintf("The specified bucket %s does not exist", bucketName))
+		return
+	}
+	if paused {
+		select {
+		case f.paused <- key:
+		default:
+		}
+		<-gate.release
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+
+	case key == "" && r.Method == http.MethodGet:
+		prefix := r.URL.Query().Get("prefix")
+		result := s3ListResult{Name: bucketName, Prefix: prefix, MaxKeys: 1000}
+		keys := make([]string, 0, len(bucket))
+		for name := range bucket {
+			if strings.HasPrefix(name, prefix) {
+				keys = append(keys, name)
+			}
+		}
+		sort.Strings(keys)
+		for _, name := range keys {
+			result.Contents = append(result.Contents, struct {
+				Key  string `xml:"Key"`
+				ETag string `xml:"ETag"`
+				Size int    `xml:"Size"`
+			}{name, strconv.Quote(bucket[name].ETag), len(bucket[name].Body)})
+		}
+		result.KeyCount = len(result.Contents)
+		w.Header().Set("Content-Type", "application/xml")
+		xml.NewEncoder(w).Encode(result)
+
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := bucket[key]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("ETag", strconv.Quote(object.ETag))
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.Body)))
+		w.Header().Set("x-amz-server-side-encryption", object.Encryption)
+		if object.KMSKeyID != "" {
+			w.Header().Set("x-amz-server-side-encryption-aws-kms-key-id", object.KMSKeyID)
+		}
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(object.Body)
+		}
+
+	case r.Method == http.MethodPut:
+		encryption := r.Header.Get("x-amz-server-side-encryption")
+		if encryption != "AES256" && encryption != "aws:kms" {
+			writeS3Error(w, http.StatusForbidden, "AccessDenied", "a política do bucket exige x-amz-server-side-encryption")
+			return
+		}
+		body, err := readS3Body(r)
+		if err != nil {
+			writeS3Error(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		sum := md5.Sum(body)
+		object := &stateObject{
+			Body:       body,
+			ETag:       hex.EncodeToString(sum[:]),
+			Encryption: encryption,
+			KMSKeyID:   r.Header.Get("x-amz-server-side-encryption-aws-kms-key-id"),
+		}
+		bucket[key] = object
+		w.Header().Set("ETag", strconv.Quote(object.ETag))
+		w.Header().Set("x-amz-server-side-encryption", encryption)
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodDelete:
+		delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", fmt.Sprintf("método %s não suportado", r.Method))
+	}
+}
+
+// readS3Body lê o corpo do PutObject, decodificando o formato aws-chunked que o SDK usa ao enviar
+// checksums como trailer
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") &&
+		!strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	reader := bufio.NewReader(r.Body)
+	var body bytes.Buffer
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("chunk sem cabeçalho: %v", err)
+		}
+		sizeField, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeField, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("tamanho de chunk inválido %q", sizeField)
+		}
+		if size == 0 {
+			return body.Bytes(), nil
+		}
+		if _, err := io.CopyN(&body, reader, size); err != nil {
+			return nil, fmt.Errorf("chunk incompleto: %v", err)
+		}
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, fmt.Errorf("chunk sem terminador: %v", err)
+		}
+	}
+}
+
+// dynamoItem é um item com atributos do tipo S, os únicos usados pelo backend
+type dynamoItem map[string]map[string]string
+
+func (item dynamoItem) strings() map[string]string {
+	values := map[string]string{}
+	for name, value := range item {
+		values[name] = value["S"]
+	}
+	return values
+}
+
+func writeDynamoDBError(w http.ResponseWriter, errorType, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#" + errorType, "message": message})
+}
+
+func (f *fakeStateBackend) handleDynamoDB(w http.ResponseWriter, r *http.Request, operation string) {
+	var input struct {
+		TableName                 string
+		Item                      dynamoItem
+		Key                       dynamoItem
+		ConditionExpression       string
+		ExpressionAttributeValues dynamoItem
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeDynamoDBError(w, "SerializationException", err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	table, ok := f.tables[input.TableName]
+	if !ok {
+		writeDynamoDBError(w, "ResourceNotFoundException", fmt.Sprintf("Requested resource not found: Table: %s not found", input.TableName))
+		return
+	}
+
+	switch operation {
+	case "PutItem":
+		item := input.Item.strings()
+		lockID := item["LockID"]
+		_, exists := table[lockID]
+		switch input.ConditionExpression {
+		case "":
+		case "attribute_not_exists(LockID)":
+			if exists {
+				f.recordLock("conflict", lockID, item["Info"])
+				writeDynamoDBError(w, "ConditionalCheckFailedException", "The conditional request failed")
+				return
+			}
+		default:
+			writeDynamoDBError(w, "ValidationException", fmt.Sprintf("condição %q não suportada", input.ConditionExpression))
+			return
+		}
+		table[lockID] = item
+		if item["Info"] != "" {
+			f.recordLock("acquire", lockID, item["Info"])
+		}
+		writeDynamoDBJSON(w, map[string]interface{}{})
+
+	case "GetItem":
+		item, exists := table[input.Key.strings()["LockID"]]
+		if !exists {
+			writeDynamoDBJSON(w, map[string]interface{}{})
+			return
+		}
+		attributes := dynamoItem{}
+		for name, value := range item {
+			attributes[name] = map[string]string{"S": value}
+		}
+		writeDynamoDBJSON(w, map[string]interface{}{"Item": attributes})
+
+	case "DeleteItem":
+		lockID := input.Key.strings()["LockID"]
+		item, exists := table[lockID]
+		switch input.ConditionExpression {
+		case "":
+		case "Info = :info":
+			if !exists || item["Info"] != input.ExpressionAttributeValues.strings()[":info"] {
+				writeDynamoDBError(w, "ConditionalCheckFailedException", "The conditional request failed")
+				return
+			}
+		default:
+			writeDynamoDBError(w, "ValidationException", fmt.Sprintf("condição %q não suportada", input.ConditionExpression))
+			return
+		}
+		if exists && item["Info"] != "" {
+			f.recordLock("release", lockID, item["Info"])
+		}
+		delete(table, lockID)
+		writeDynamoDBJSON(w, map[string]interface{}{})
+
+	default:
+		writeDynamoDBError(w, "UnknownOperationException", fmt.Sprintf("operação %s não suportada", operation))
+	}
+}
+
+func writeDynamoDBJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	json.NewEncoder(w).Encode(body)
+}
+
+// recordLock registra a operação com o ID do lock extraído do Info gravado pelo Terraform
+func (f *fakeStateBackend) recordLock(action, lockID, info string) {
+	var lockInfo struct {
+		ID string
+	}
+	json.Unmarshal([]byte(info), &lockInfo)
+	f.events = append(f.events, stateLockEvent{Action: action, LockID: lockID, Holder: lockInfo.ID, At: time.Now()})
+}
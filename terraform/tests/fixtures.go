@@ -0,0 +1,189 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+)
+
+// Raízes mínimas em tests/fixtures, uma por cenário, que compõem rede, banco de dados e Kubernetes
+// ligando as saídas de um módulo às entradas do seguinte. Os testes planejam ou aplicam uma cópia
+// do fixture, e não os diretórios do repositório, para não dividir .terraform e estado entre testes
+const fixturesDir = "fixtures"
+
+// fixtureNames lista os cenários disponíveis em tests/fixtures
+func fixtureNames() ([]string, error) {
+	entries, err := os.ReadDir(fixturesDir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// loadFixture copia a árvore terraform/ para um diretório temporário e retorna o caminho do fixture
+// dentro da cópia, onde os caminhos relativos dos módulos continuam válidos. A cópia é removida ao
+// final do teste, exceto quando alguma etapa está sendo pulada (SKIP_<etapa>): nesse caso o
+// test_structure usa o próprio diretório do fixture, para que as etapas reaproveitem o estado
+func loadFixture(t *testing.T, name string) string {
+	fixture := filepath.Join("tests", fixturesDir, name)
+	if _, err := os.Stat(filepath.Join("..", fixture, "main.tf")); err != nil {
+		t.Fatalf("Fixture %q não encontrado: %v", name, err)
+	}
+
+	dir := test_structure.CopyTerraformFolderToTemp(t, "..", fixture)
+	if !test_structure.SkipStageEnvVarSet() {
+		root := strings.TrimSuffix(dir, fixture)
+		t.Cleanup(func() { os.RemoveAll(root) })
+	}
+	return dir
+}
+
+// fixtureTerraformOptions copia o fixture e monta as opções do Terraform com as variáveis informadas
+// e os erros repetíveis do provedor do fixture
+func fixtureTerraformOptions(t *testing.T, name string, vars map[string]interface{}) *terraform.Options {
+	return withRetryableErrors(t, &terraform.Options{
+		TerraformDir: loadFixture(t, name),
+		Vars:         vars,
+		NoColor:      true,
+	})
+}
+
+// moduleVariable é uma variável declarada por um módulo
+type moduleVariable struct {
+	Name     string
+	Required bool
+	// Default é a expressão HCL do valor padrão, vazia para variáveis obrigatórias
+	Default string
+}
+
+var hclVariableBlock = regexp.MustCompile(`(?m)^variable[ \t]+"([^"]+)"[ \t]*\{`)
+
+// loadModuleVariables lê as variáveis declaradas nos arquivos .tf de um módulo
+func loadModuleVariables(dir string) (map[string]moduleVariable, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("nenhum arquivo .tf em %s", dir)
+	}
+
+	variables := map[string]moduleVariable{}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		src := stripHCLComments(string(content))
+		for _, match := range hclVariableBlock.FindAllStringSubmatchIndex(src, -1) {
+			body, err := hclBlockBody(src, match[1]-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			name := src[match[2]:match[3]]
+			defaultValue, hasDefault := hclAttributes(body)["default"]
+			variables[name] = moduleVariable{Name: name, Required: !hasDefault, Default: hclAttributeValue(defaultValue)}
+		}
+	}
+	return variables, nil
+}
+
+// Argumentos de bloco module que não são variáveis do módulo
+var moduleMetaArguments = map[string]bool{
+	"source": true, "version": true, "count": true, "for_each": true, "providers": true, "depends_on": true,
+}
+
+// Entradas que identificam recursos de rede e devem vir das saídas de outro módulo
+var fixtureNetworkInputs = map[string]bool{
+	"vpc_id": true, "subnet_ids": true, "vpc_self_link": true, "subnet_self_link": true,
+}
+
+// Valores inventados usados nos testes antigos no lugar de recursos reais
+var fixturePlaceholderValue = regexp.MustCompile(`"(dummy-[^"]*|subnet-\d+|vpc-\d+)"`)
+
+// checkFixture confere um fixture: fontes dos módulos existentes, variáveis obrigatórias informadas,
+// nenhum argumento desconhecido, entradas de rede ligadas a saídas de módulos, nenhum valor
+// inventado e nenhum ciclo entre os blocos
+func checkFixture(dir string) ([]string, error) {
+	graph, err := loadModuleGraph(filepath.Base(dir), dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []string
+	var ids []string
+	for id := range graph.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		node := graph.Nodes[id]
+		if node.Kind != "module" {
+			continue
+		}
+
+		source, err := strconv.Unquote(hclAttributeValue(node.Attributes["source"]))
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s: source deve ser um caminho local entre aspas", id))
+			continue
+		}
+		variables, err := loadModuleVariables(filepath.Join(dir, source))
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+
+		var names []string
+		for name := range node.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if moduleMetaArguments[name] || strings.HasPrefix(name, "bloco ") {
+				continue
+			}
+			value := hclAttributeValue(node.Attributes[name])
+			if _, ok := variables[name]; !ok {
+				issues = append(issues, fmt.Sprintf("%s: %s não é variável de %s", id, name, source))
+			}
+			if fixtureNetworkInputs[name] && !strings.Contains(value, "module.") {
+				issues = append(issues, fmt.Sprintf("%s: %s deve vir da saída de outro módulo, não de %s", id, name, value))
+			}
+			if match := fixturePlaceholderValue.FindString(value); match != "" {
+				issues = append(issues, fmt.Sprintf("%s: %s usa o valor inventado %s", id, name, match))
+			}
+		}
+
+		var required []string
+		for name, variable := range variables {
+			if _, ok := node.Attributes[name]; variable.Required && !ok {
+				required = append(required, name)
+			}
+		}
+		sort.Strings(required)
+		for _, name := range required {
+			issues = append(issues, fmt.Sprintf("%s: variável obrigatória %s não informada", id, name))
+		}
+	}
+
+	for _, cycle := range graph.Cycles() {
+		issues = append(issues, fmt.Sprintf("ciclo: %s", strings.Join(cycle, " -> ")))
+	}
+	return issues, nil
+}
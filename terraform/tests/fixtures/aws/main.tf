@@ -0,0 +1,82 @@
+/**
+ * Fixture AWS: rede, banco de dados e cluster EKS ligados pelas saídas dos módulos
+ *
+ * Usado pelos testes com loadFixture, que copia a árvore terraform/ para um diretório
+ * temporário; por isso os módulos são referenciados a partir de tests/fixtures/aws.
+ */
+
+terraform {
+  required_version = ">= 1.0.0"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.5"
+    }
+  }
+}
+
+provider "aws" {
+  region = var.region
+
+  default_tags {
+    tags = local.tags
+  }
+}
+
+locals {
+  tags = {
+    Environment = var.environment
+    Project     = var.project_name
+    ManagedBy   = "Terratest"
+  }
+}
+
+module "network" {
+  source = "../../../modules/network/aws"
+
+  environment        = var.environment
+  project_name       = var.project_name
+  region             = var.region
+  vpc_cidr           = var.vpc_cidr
+  subnet_count       = 2
+  availability_zones = var.availability_zones
+  tags               = local.tags
+}
+
+module "database" {
+  source = "../../../modules/database/aws"
+
+  environment  = var.environment
+  project_name = var.project_name
+  vpc_id       = module.network.vpc_id
+  subnet_ids   = module.network.private_subnet_ids
+  tags         = local.tags
+
+  # Instância descartável: sem proteção contra exclusão nem snapshot final
+  instance_type         = "db.t3.micro"
+  allocated_storage     = 20
+  max_allocated_storage = 20
+  backup_retention_days = 1
+  deletion_protection   = false
+  skip_final_snapshot   = true
+}
+
+module "kubernetes" {
+  source = "../../../modules/kubernetes/aws"
+
+  environment         = var.environment
+  project_name        = var.project_name
+  cluster_version     = var.cluster_version
+  node_instance_types = ["t3.medium"]
+  min_nodes           = 1
+  max_nodes           = 2
+  desired_nodes       = 1
+  vpc_id              = module.network.vpc_id
+  subnet_ids          = module.network.private_subnet_ids
+  tags                = local.tags
+}
@@ -0,0 +1,29 @@
+output "vpc_id" {
+  description = "ID da VPC criada pelo módulo de rede"
+  value       = module.network.vpc_id
+}
+
+output "private_subnet_ids" {
+  description = "Subnets privadas usadas pelo banco e pelo cluster"
+  value       = module.network.private_subnet_ids
+}
+
+output "database_endpoint" {
+  description = "Endpoint do RDS"
+  value       = module.database.endpoint
+}
+
+output "database_port" {
+  description = "Porta do RDS"
+  value       = module.database.port
+}
+
+output "cluster_name" {
+  description = "Nome do cluster EKS"
+  value       = module.kubernetes.cluster_name
+}
+
+output "cluster_endpoint" {
+  description = "Endpoint da API do EKS"
+  value       = module.kubernetes.cluster_endpoint
+}
@@ -0,0 +1,34 @@
+variable "environment" {
+  description = "Ambiente de teste"
+  type        = string
+  default     = "test"
+}
+
+variable "project_name" {
+  description = "Nome do projeto, único por execução do teste"
+  type        = string
+}
+
+variable "region" {
+  description = "Região da AWS"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "availability_zones" {
+  description = "Zonas de disponibilidade das subnets"
+  type        = list(string)
+  default     = ["us-east-1a", "us-east-1b"]
+}
+
+variable "vpc_cidr" {
+  description = "Bloco CIDR da VPC"
+  type        = string
+  default     = "10.10.0.0/16"
+}
+
+variable "cluster_version" {
+  description = "Versão do Kubernetes do EKS"
+  type        = string
+  default     = "1.26"
+}
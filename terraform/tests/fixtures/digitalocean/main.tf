@@ -0,0 +1,78 @@
+/**
+ * Fixture DigitalOcean: VPC, cluster de banco de dados e DOKS ligados pelas saídas dos módulos
+ *
+ * Usado pelos testes com loadFixture, que copia a árvore terraform/ para um diretório
+ * temporário; por isso os módulos são referenciados a partir de tests/fixtures/digitalocean.
+ */
+
+terraform {
+  required_version = ">= 1.0.0"
+
+  required_providers {
+    digitalocean = {
+      source  = "digitalocean/digitalocean"
+      version = "~> 2.36"
+    }
+    kubernetes = {
+      source  = "hashicorp/kubernetes"
+      version = "~> 2.20"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.5"
+    }
+  }
+}
+
+# O token vem de DIGITALOCEAN_TOKEN
+provider "digitalocean" {}
+
+# O rbac.tf do módulo Kubernetes usa o cluster recém-criado
+provider "kubernetes" {
+  host                   = module.kubernetes.cluster_endpoint
+  token                  = local.kube_config.users[0].user.token
+  cluster_ca_certificate = base64decode(local.kube_config.clusters[0].cluster["certificate-authority-data"])
+}
+
+locals {
+  kube_config = yamldecode(module.kubernetes.kube_config)
+}
+
+module "network" {
+  source = "../../../modules/network/digital-ocean"
+
+  environment         = var.environment
+  project_name        = var.project_name
+  region              = var.region
+  vpc_cidr            = var.vpc_cidr
+  create_loadbalancer = false
+}
+
+module "database" {
+  source = "../../../modules/database/digital-ocean"
+
+  environment    = var.environment
+  project_name   = var.project_name
+  region         = var.region
+  vpc_id         = module.network.vpc_id
+  vpc_cidr       = module.network.vpc_cidr
+  instance_type  = "db-s-1vcpu-1gb"
+  node_count     = 1
+  engine         = "pg"
+  engine_version = "14"
+}
+
+module "kubernetes" {
+  source = "../../../modules/kubernetes/digital-ocean"
+
+  environment        = var.environment
+  project_name       = var.project_name
+  region             = var.region
+  vpc_id             = module.network.vpc_id
+  kubernetes_version = var.kubernetes_version
+  node_size          = "s-1vcpu-2gb"
+  node_count         = 1
+  min_nodes          = 1
+  max_nodes          = 2
+  tags               = ["test", "terratest"]
+}
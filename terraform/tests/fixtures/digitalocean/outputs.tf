@@ -0,0 +1,24 @@
+output "vpc_id" {
+  description = "ID da VPC criada pelo módulo de rede"
+  value       = module.network.vpc_id
+}
+
+output "database_host" {
+  description = "Host privado do cluster de banco de dados"
+  value       = module.database.private_host
+}
+
+output "database_port" {
+  description = "Porta do cluster de banco de dados"
+  value       = module.database.port
+}
+
+output "cluster_name" {
+  description = "Nome do cluster DOKS"
+  value       = module.kubernetes.cluster_name
+}
+
+output "cluster_endpoint" {
+  description = "Endpoint da API do DOKS"
+  value       = module.kubernetes.cluster_endpoint
+}
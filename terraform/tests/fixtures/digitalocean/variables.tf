@@ -0,0 +1,28 @@
+variable "environment" {
+  description = "Ambiente de teste"
+  type        = string
+  default     = "test"
+}
+
+variable "project_name" {
+  description = "Nome do projeto, único por execução do teste"
+  type        = string
+}
+
+variable "region" {
+  description = "Região do DigitalOcean"
+  type        = string
+  default     = "nyc1"
+}
+
+variable "vpc_cidr" {
+  description = "Bloco CIDR da VPC"
+  type        = string
+  default     = "10.30.0.0/16"
+}
+
+variable "kubernetes_version" {
+  description = "Versão do Kubernetes do DOKS"
+  type        = string
+  default     = "1.27"
+}
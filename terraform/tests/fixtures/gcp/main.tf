@@ -0,0 +1,78 @@
+/**
+ * Fixture GCP: rede, Cloud SQL e cluster GKE ligados pelas saídas dos módulos
+ *
+ * Usado pelos testes com loadFixture, que copia a árvore terraform/ para um diretório
+ * temporário; por isso os módulos são referenciados a partir de tests/fixtures/gcp.
+ */
+
+terraform {
+  required_version = ">= 1.0.0"
+
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 4.0"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.5"
+    }
+  }
+}
+
+provider "google" {
+  project = var.project_id
+  region  = var.region
+}
+
+locals {
+  tags = {
+    environment = var.environment
+    project     = var.project_name
+    managed_by  = "terratest"
+  }
+}
+
+module "network" {
+  source = "../../../modules/network/gcp"
+
+  environment  = var.environment
+  project_name = var.project_name
+  region       = var.region
+  vpc_cidr     = var.vpc_cidr
+  tags         = local.tags
+}
+
+module "database" {
+  source = "../../../modules/database/gcp"
+
+  environment   = var.environment
+  project_name  = var.project_name
+  project_id    = var.project_id
+  region        = var.region
+  vpc_self_link = module.network.vpc_self_link
+  tags          = local.tags
+
+  # Instância descartável: menor tipo e sem proteção contra exclusão
+  instance_type         = "db-f1-micro"
+  storage_gb            = 10
+  backup_retention_days = 1
+  deletion_protection   = false
+}
+
+module "kubernetes" {
+  source = "../../../modules/kubernetes/gcp"
+
+  environment         = var.environment
+  project_name        = var.project_name
+  project_id          = var.project_id
+  region              = var.region
+  cluster_version     = var.cluster_version
+  node_instance_types = ["e2-standard-2"]
+  min_nodes           = 1
+  max_nodes           = 2
+  desired_nodes       = 1
+  vpc_self_link       = module.network.vpc_self_link
+  subnet_self_link    = module.network.private_subnet_self_link
+  tags                = local.tags
+}
@@ -0,0 +1,29 @@
+output "vpc_self_link" {
+  description = "Self link da VPC criada pelo módulo de rede"
+  value       = module.network.vpc_self_link
+}
+
+output "private_subnet_self_link" {
+  description = "Subnet privada usada pelo cluster"
+  value       = module.network.private_subnet_self_link
+}
+
+output "database_connection_name" {
+  description = "Nome de conexão do Cloud SQL"
+  value       = module.database.instance_connection_name
+}
+
+output "database_endpoint" {
+  description = "Endpoint privado do Cloud SQL"
+  value       = module.database.endpoint
+}
+
+output "cluster_name" {
+  description = "Nome do cluster GKE"
+  value       = module.kubernetes.cluster_name
+}
+
+output "cluster_endpoint" {
+  description = "Endpoint da API do GKE"
+  value       = module.kubernetes.cluster_endpoint
+}
@@ -0,0 +1,33 @@
+variable "environment" {
+  description = "Ambiente de teste"
+  type        = string
+  default     = "test"
+}
+
+variable "project_name" {
+  description = "Nome do projeto, único por execução do teste"
+  type        = string
+}
+
+variable "project_id" {
+  description = "ID do projeto GCP onde os recursos são criados"
+  type        = string
+}
+
+variable "region" {
+  description = "Região da GCP"
+  type        = string
+  default     = "us-central1"
+}
+
+variable "vpc_cidr" {
+  description = "Bloco CIDR da VPC"
+  type        = string
+  default     = "10.20.0.0/16"
+}
+
+variable "cluster_version" {
+  description = "Versão do Kubernetes do GKE"
+  type        = string
+  default     = "1.26"
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFixtures confere a composição de cada fixture em tests/fixtures e que o banco de dados e o
+// cluster Kubernetes recebem a rede pelas saídas do módulo network
+func TestFixtures(t *testing.T) {
+	t.Parallel()
+
+	names, err := fixtureNames()
+	if err != nil {
+		t.Fatalf("Erro ao listar fixtures: %v", err)
+	}
+	assert.ElementsMatch(t, []string{"aws", "digitalocean", "gcp"}, names)
+
+	for _, name := range names {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := filepath.Join(fixturesDir, name)
+			issues, err := checkFixture(dir)
+			if err != nil {
+				t.Fatalf("Erro ao ler o fixture: %v", err)
+			}
+			for _, issue := range issues {
+				t.Errorf("%s", issue)
+			}
+
+			graph, err := loadModuleGraph(name, dir)
+			if err != nil {
+				t.Fatalf("Erro ao montar o grafo do fixture: %v", err)
+			}
+			assert.Contains(t, graph.Edges, moduleGraphEdge{From: "module.database", To: "module.network"})
+			assert.Contains(t, graph.Edges, moduleGraphEdge{From: "module.kubernetes", To: "module.network"})
+		})
+	}
+}
+
+// TestFixtureChecks cobre os problemas apontados por checkFixture em um fixture com erros
+func TestFixtureChecks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	module := filepath.Join(dir, "modules", "cluster")
+	fixture := filepath.Join(dir, "fixture")
+	for _, path := range []string{module, fixture} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatalf("Erro ao criar %s: %v", path, err)
+		}
+	}
+
+	files := map[string]string{
+		filepath.Join(module, "variables.tf"): `
+variable "project_name" {
+  type = string
+}
+
+variable "vpc_id" {
+  type = string
+}
+
+variable "subnet_ids" {
+  type    = list(string)
+  default = [] # Opcional
+}
+`,
+		filepath.Join(fixture, "main.tf"): `
+module "network" {
+  source = "../modules/missing"
+}
+
+module "kubernetes" {
+  source = "../modules/cluster"
+
+  vpc_id     = "dummy-vpc-id"
+  subnet_ids = ["subnet-1", "subnet-2"]
+  node_size  = "s-1vcpu-2gb"
+}
+`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Erro ao gravar %s: %v", path, err)
+		}
+	}
+
+	issues, err := checkFixture(fixture)
+	if err != nil {
+		t.Fatalf("Erro ao ler o fixture: %v", err)
+	}
+	assert.Equal(t, []string{
+		"module.kubernetes: node_size não é variável de ../modules/cluster",
+		"module.kubernetes: subnet_ids deve vir da saída de outro módulo, não de [\"subnet-1\", \"subnet-2\"]",
+		"module.kubernetes: subnet_ids usa o valor inventado \"subnet-1\"",
+		"module.kubernetes: vpc_id deve vir da saída de outro módulo, não de \"dummy-vpc-id\"",
+		"module.kubernetes: vpc_id usa o valor inventado \"dummy-vpc-id\"",
+		"module.kubernetes: variável obrigatória project_name não informada",
+		"module.network: nenhum arquivo .tf em " + filepath.Join(dir, "modules", "missing"),
+	}, issues)
+}
+
+// TestLoadFixture garante que cada chamada recebe sua própria cópia, com os módulos acessíveis
+// pelos mesmos caminhos relativos do fixture original
+func TestLoadFixture(t *testing.T) {
+	t.Parallel()
+
+	if test_structure.SkipStageEnvVarSet() {
+		t.Skip("Etapas puladas: loadFixture usa o diretório do próprio fixture")
+	}
+
+	first := loadFixture(t, "aws")
+	second := loadFixture(t, "aws")
+	assert.NotEqual(t, first, second)
+	assert.False(t, strings.HasPrefix(first, ".."), "a cópia deve ficar fora do repositório")
+
+	for _, dir := range []string{first, second} {
+		assert.FileExists(t, filepath.Join(dir, "main.tf"))
+		assert.DirExists(t, filepath.Join(dir, "../../../modules/network/aws"))
+	}
+
+	// Estado gravado em uma cópia não aparece na outra
+	if err := os.WriteFile(filepath.Join(first, "terraform.tfstate"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("Erro ao gravar o estado: %v", err)
+	}
+	assert.NoFileExists(t, filepath.Join(second, "terraform.tfstate"))
+	assert.NoFileExists(t, filepath.Join(fixturesDir, "aws", "terraform.tfstate"))
+}
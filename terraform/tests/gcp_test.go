@@ -20,7 +20,7 @@ func TestGcpInfrastructure(t *testing.T) {
 	projectID := gcp.GetGoogleProjectIDFromEnvVar(t)
 
 	// Configurações do terratest para GCP
-	terraformOptions := &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		// Diretório onde estão os arquivos Terraform para teste
 		TerraformDir: "../environments/dev",
 
@@ -38,7 +38,7 @@ func TestGcpInfrastructure(t *testing.T) {
 
 		// Configura log detalhado
 		NoColor: true,
-	}
+	})
 
 	// Este teste é configuracional apenas, não realiza deploy real
 	terraform.InitAndPlan(t, terraformOptions)
@@ -71,23 +71,14 @@ func TestGcpKubernetesCluster(t *testing.T) {
 	projectName := fmt.Sprintf("test-gke-%s", uniqueID)
 	projectID := gcp.GetGoogleProjectIDFromEnvVar(t)
 
-	terraformOptions := &terraform.Options{
-		TerraformDir: "../modules/kubernetes/gcp",
-		Vars: map[string]interface{}{
-			"environment":      "test",
-			"project_name":     projectName,
-			"project_id":       projectID,
-			"region":           "us-central1",
-			"cluster_version":  "1.26",
-			"node_instance_types": []string{"e2-standard-2"},
-			"min_nodes":        1,
-			"max_nodes":        2,
-			"desired_nodes":    1,
-			"vpc_self_link":    "dummy-vpc-self-link", // Seria substituído por uma VPC real em testes de integração
-			"subnet_self_link": "dummy-subnet-self-link", // Seria substituído por subnets reais
-		},
-		NoColor: true,
-	}
+	// O fixture liga o cluster à VPC e à subnet privada criadas pelo módulo de rede
+	terraformOptions := fixtureTerraformOptions(t, "gcp", map[string]interface{}{
+		"project_name":    projectName,
+		"project_id":      projectID,
+		"region":          "us-central1",
+		"cluster_version": "1.26",
+	})
+	terraformOptions.Targets = []string{"module.kubernetes"}
 
 	// Este teste é configuracional apenas, não realiza deploy real
 	terraform.InitAndPlan(t, terraformOptions)
@@ -102,7 +93,7 @@ func TestGcpCostMonitoring(t *testing.T) {
 	projectID := gcp.GetGoogleProjectIDFromEnvVar(t)
 	billingAccountID := "ABCDEF-123456-GHIJKL" // Substitua por um ID real em testes de integração
 
-	terraformOptions := &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../modules/cost_monitor/gcp",
 		Vars: map[string]interface{}{
 			"environment":            "test",
@@ -115,7 +106,7 @@ func TestGcpCostMonitoring(t *testing.T) {
 			"alert_emails":           []string{"test@example.com"},
 		},
 		NoColor: true,
-	}
+	})
 
 	// Este teste é configuracional apenas, não realiza deploy real
 	terraform.InitAndPlan(t, terraformOptions)
@@ -129,7 +120,7 @@ func TestGcpNetwork(t *testing.T) {
 	projectName := fmt.Sprintf("test-net-%s", uniqueID)
 	projectID := gcp.GetGoogleProjectIDFromEnvVar(t)
 
-	terraformOptions := &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../modules/network/gcp",
 		Vars: map[string]interface{}{
 			"environment":  "test",
@@ -138,7 +129,7 @@ func TestGcpNetwork(t *testing.T) {
 			"vpc_cidr":     "10.0.0.0/16",
 		},
 		NoColor: true,
-	}
+	})
 
 	// Este teste é configuracional apenas, não realiza deploy real
 	terraform.InitAndPlan(t, terraformOptions)
@@ -152,19 +143,12 @@ func TestGcpDatabase(t *testing.T) {
 	projectName := fmt.Sprintf("test-db-%s", uniqueID)
 	projectID := gcp.GetGoogleProjectIDFromEnvVar(t)
 
-	terraformOptions := &terraform.Options{
-		TerraformDir: "../modules/database/gcp",
-		Vars: map[string]interface{}{
-			"environment":     "test",
-			"project_name":    projectName,
-			"project_id":      projectID,
-			"instance_type":   "db-custom-1-3840",
-			"storage_gb":      20,
-			"engine_version":  "POSTGRES_14",
-			"vpc_self_link":   "dummy-vpc-self-link", // Seria substituído por uma VPC real em testes de integração
-		},
-		NoColor: true,
-	}
+	// O fixture liga a instância à VPC criada pelo módulo de rede
+	terraformOptions := fixtureTerraformOptions(t, "gcp", map[string]interface{}{
+		"project_name": projectName,
+		"project_id":   projectID,
+	})
+	terraformOptions.Targets = []string{"module.database"}
 
 	// Este teste é configuracional apenas, não realiza deploy real
 	terraform.InitAndPlan(t, terraformOptions)
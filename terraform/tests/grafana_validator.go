@@ -0,0 +1,589 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// grafanaIssue é um problema encontrado em um dashboard ou regra de alerta do módulo monitoring/grafana
+type grafanaIssue struct {
+	File     string
+	Location string
+	Message  string
+}
+
+func (i grafanaIssue) String() string {
+	if i.Location == "" {
+		return fmt.Sprintf("%s: %s", i.File, i.Message)
+	}
+	return fmt.Sprintf("%s (%s): %s", i.File, i.Location, i.Message)
+}
+
+// grafanaDatasource é uma fonte de dados declarada no ConfigMap grafana_datasources
+type grafanaDatasource struct {
+	Name      string `yaml:"name"`
+	UID       string `yaml:"uid"`
+	Type      string `yaml:"type"`
+	IsDefault bool   `yaml:"isDefault"`
+}
+
+// grafanaValidator valida dashboards e alertas renderizados para um ambiente
+type grafanaValidator struct {
+	Datasources          []grafanaDatasource
+	AlertThresholdCPU    float64
+	AlertThresholdMemory float64
+}
+
+var (
+	templateFileCall   = regexp.MustCompile(`templatefile\("\$\{path\.module\}/templates/([^"]+)"`)
+	templateExpression = regexp.MustCompile(`\$\$\{|\$\{\s*([^}]*?)\s*\}|%\{`)
+	grafanaVariableUse = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)(?::[^}]*)?\}|\$([A-Za-z_][A-Za-z0-9_]*)|\[\[([A-Za-z0-9_]+)\]\]`)
+)
+
+// grafanaBuiltinVariables são variáveis fornecidas pelo próprio Grafana
+var grafanaBuiltinVariables = map[string]bool{
+	"__interval": true, "__interval_ms": true, "__rate_interval": true, "__range": true,
+	"__range_s": true, "__range_ms": true, "__from": true, "__to": true, "__name": true,
+	"__dashboard": true, "__org": true, "__user": true, "timeFilter": true, "__timeFilter": true,
+}
+
+// renderTemplateFile reproduz o templatefile() do Terraform para interpolações simples ${nome}.
+// Diretivas %{...} e expressões não suportadas retornam erro, assim como variáveis não informadas.
+func renderTemplateFile(content string, vars map[string]interface{}) (string, error) {
+	var renderErr error
+	rendered := templateExpression.ReplaceAllStringFunc(content, func(match string) string {
+		switch {
+		case match == "$${":
+			return "${"
+		case match == "%{":
+			if renderErr == nil {
+				renderErr = fmt.Errorf("diretivas %%{...} não são suportadas pelo renderizador de testes")
+			}
+			return match
+		}
+
+		name := templateExpression.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			if renderErr == nil {
+				renderErr = fmt.Errorf("variável %q não fornecida ao templatefile (use $${...} para variáveis do Grafana)", name)
+			}
+			return match
+		}
+		return fmt.Sprint(value)
+	})
+	return rendered, renderErr
+}
+
+// loadGrafanaDatasources extrai as fontes de dados do heredoc do ConfigMap grafana_datasources em main.tf
+func loadGrafanaDatasources(mainTF string) ([]grafanaDatasource, error) {
+	start := strings.Index(mainTF, `resource "kubernetes_config_map" "grafana_datasources"`)
+	if start < 0 {
+		return nil, fmt.Errorf("ConfigMap grafana_datasources não encontrado")
+	}
+	block := mainTF[start:]
+
+	open := strings.Index(block, "<<-EOF")
+	if open < 0 {
+		return nil, fmt.Errorf("heredoc datasources.yaml não encontrado em grafana_datasources")
+	}
+	body := block[open+len("<<-EOF"):]
+	end := regexp.MustCompile(`(?m)^\s*EOF\s*$`).FindStringIndex(body)
+	if end == nil {
+		return nil, fmt.Errorf("heredoc datasources.yaml não terminado")
+	}
+
+	var document struct {
+		Datasources []grafanaDatasource `yaml:"datasources"`
+	}
+	// O conteúdo ainda contém interpolações do Terraform, que são escalares válidos em YAML
+	if err := yaml.Unmarshal([]byte(dedent(body[:end[0]])), &document); err != nil {
+		return nil, fmt.Errorf("datasources.yaml inválido: %v", err)
+	}
+	return document.Datasources, nil
+}
+
+// dedent remove a indentação comum, como o heredoc <<- do Terraform
+func dedent(text string) string {
+	lines := strings.Split(text, "\n")
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validateGrafanaModule renderiza e valida todos os templates referenciados por templatefile() no módulo
+func validateGrafanaModule(moduleDir string, vars map[string]interface{}, thresholdCPU, thresholdMemory float64) ([]grafanaIssue, error) {
+	mainTF, err := os.ReadFile(filepath.Join(moduleDir, "main.tf"))
+	if err != nil {
+		return nil, err
+	}
+
+	datasources, err := loadGrafanaDatasources(string(mainTF))
+	if err != nil {
+		return nil, err
+	}
+	validator := &grafanaValidator{
+		Datasources:          datasources,
+		AlertThresholdCPU:    thresholdCPU,
+		AlertThresholdMemory: thresholdMemory,
+	}
+
+	var issues []grafanaIssue
+	for _, match := range templateFileCall.FindAllStringSubmatch(string(mainTF), -1) {
+		name := match[1]
+		if name == "grafana-values.yaml" {
+			// Valores do Helm chart, não é um dashboard nem uma regra de alerta
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(moduleDir, "templates", name))
+		if err != nil {
+			issues = append(issues, grafanaIssue{File: name, Message: "template referenciado em main.tf não existe"})
+			continue
+		}
+
+		rendered, err := renderTemplateFile(string(content), vars)
+		if err != nil {
+			issues = append(issues, grafanaIssue{File: name, Message: err.Error()})
+			continue
+		}
+
+		switch filepath.Ext(name) {
+		case ".json":
+			issues = append(issues, validator.ValidateDashboard(name, []byte(rendered))...)
+		case ".yaml", ".yml":
+			issues = append(issues, validator.ValidateAlerts(name, []byte(rendered))...)
+		}
+	}
+	return issues, nil
+}
+
+// resolveDatasource aceita o formato antigo (nome) e o atual ({"type", "uid"}) de referência a fonte de dados
+func (v *grafanaValidator) resolveDatasource(ref interface{}) (*grafanaDatasource, string) {
+	var key string
+	switch r := ref.(type) {
+	case nil:
+		for i := range v.Datasources {
+			if v.Datasources[i].IsDefault {
+				return &v.Datasources[i], ""
+			}
+		}
+		return nil, "nenhuma fonte de dados padrão (isDefault) declarada"
+	case string:
+		key = r
+	case map[string]interface{}:
+		key, _ = r["uid"].(string)
+		if key == "" {
+			key, _ = r["type"].(string)
+		}
+	}
+
+	switch key {
+	case "-- Grafana --", "-- Mixed --", "-- Dashboard --", "grafana":
+		return &grafanaDatasource{Name: key, Type: "grafana"}, ""
+	}
+	for i := range v.Datasources {
+		ds := &v.Datasources[i]
+		if key == ds.Name || (ds.UID != "" && key == ds.UID) {
+			return ds, ""
+		}
+	}
+	return nil, fmt.Sprintf("fonte de dados %q não declarada em grafana_datasources", key)
+}
+
+type grafanaDashboard struct {
+	Title      string                   `json:"title"`
+	UID        string                   `json:"uid"`
+	Panels     []map[string]interface{} `json:"panels"`
+	Templating struct {
+		List []struct {
+			Name       string      `json:"name"`
+			Type       string      `json:"type"`
+			Datasource interface{} `json:"datasource"`
+			Query      interface{} `json:"query"`
+		} `json:"list"`
+	} `json:"templating"`
+	Annotations struct {
+		List []struct {
+			Name       string      `json:"name"`
+			Datasource interface{} `json:"datasource"`
+		} `json:"list"`
+	} `json:"annotations"`
+}
+
+// ValidateDashboard verifica IDs de painel, fontes de dados, PromQL, variáveis de template e limiares
+func (v *grafanaValidator) ValidateDashboard(file string, content []byte) []grafanaIssue {
+	var dashboard grafanaDashboard
+	if err := json.Unmarshal(content, &dashboard); err != nil {
+		return []grafanaIssue{{File: file, Message: fmt.Sprintf("JSON inválido: %v", err)}}
+	}
+
+	var issues []grafanaIssue
+	report := func(location, format string, args ...interface{}) {
+		issues = append(issues, grafanaIssue{File: file, Location: location, Message: fmt.Sprintf(format, args...)})
+	}
+
+	defined := map[string]bool{}
+	for _, variable := range dashboard.Templating.List {
+		if variable.Name == "" {
+			report("templating", "variável de template sem nome")
+			continue
+		}
+		defined[variable.Name] = true
+		if variable.Type == "query" {
+			if _, msg := v.resolveDatasource(variable.Datasource); msg != "" {
+				report("templating."+variable.Name, msg)
+			}
+		}
+	}
+	for _, annotation := range dashboard.Annotations.List {
+		if _, msg := v.resolveDatasource(annotation.Datasource); msg != "" {
+			report("annotations."+annotation.Name, msg)
+		}
+	}
+
+	// Painéis dentro de linhas recolhidas também contam para a unicidade dos IDs
+	var panels []map[string]interface{}
+	for _, panel := range dashboard.Panels {
+		panels = append(panels, panel)
+		if nested, ok := panel["panels"].([]interface{}); ok {
+			for _, n := range nested {
+				if p, ok := n.(map[string]interface{}); ok {
+					panels = append(panels, p)
+				}
+			}
+		}
+	}
+
+	ids := map[int]string{}
+	for _, panel := range panels {
+		title, _ := panel["title"].(string)
+		id, ok := panel["id"].(float64)
+		location := fmt.Sprintf("painel %q", title)
+		if !ok {
+			report(location, "painel sem id numérico")
+		} else {
+			if previous, exists := ids[int(id)]; exists {
+				report(location, "id %d duplicado (também usado por %q)", int(id), previous)
+			}
+			ids[int(id)] = title
+			location = fmt.Sprintf("painel %d %q", int(id), title)
+		}
+
+		for _, used := range grafanaVariablesUsed(title) {
+			if !defined[used] && !grafanaBuiltinVariables[used] {
+				report(location, "variável de template $%s não definida", used)
+			}
+		}
+
+		if panel["type"] == "row" {
+			continue
+		}
+
+		targets, _ := panel["targets"].([]interface{})
+		var metrics []string
+		for i, raw := range targets {
+			target, _ := raw.(map[string]interface{})
+			targetLocation := fmt.Sprintf("%s, target %d", location, i)
+			if refID, _ := target["refId"].(string); refID != "" {
+				targetLocation = fmt.Sprintf("%s, target %s", location, refID)
+			}
+
+			datasourceRef, hasOwn := target["datasource"]
+			if !hasOwn || datasourceRef == nil {
+				datasourceRef = panel["datasource"]
+			}
+			datasource, msg := v.resolveDatasource(datasourceRef)
+			if msg != "" {
+				report(targetLocation, msg)
+			}
+
+			expr, _ := target["expr"].(string)
+			if expr == "" {
+				continue
+			}
+			for _, used := range grafanaVariablesUsed(expr) {
+				if !defined[used] && !grafanaBuiltinVariables[used] {
+					report(targetLocation, "variável de template $%s não definida", used)
+				}
+			}
+			if datasource != nil && datasource.Type != "prometheus" && datasource.Type != "grafana" {
+				continue
+			}
+			parsed, err := parsePromQL(substituteGrafanaVariables(expr))
+			if err != nil {
+				report(targetLocation, "PromQL inválido: %v", err)
+				continue
+			}
+			metrics = append(metrics, promMetricNames(parsed)...)
+		}
+
+		issues = append(issues, v.checkPanelThresholds(file, location, panel, metrics)...)
+	}
+	return issues
+}
+
+// checkPanelThresholds compara o limiar crítico dos painéis de CPU e memória com o config.yaml do ambiente
+func (v *grafanaValidator) checkPanelThresholds(file, location string, panel map[string]interface{}, metrics []string) []grafanaIssue {
+	resource, expected := v.classifyMetrics(metrics)
+	if resource == "" {
+		return nil
+	}
+
+	var critical, warning []float64
+	// Painéis atuais: fieldConfig.defaults.thresholds.steps; o último passo é o crítico e o
+	// penúltimo, quando tem valor, o de atenção
+	if fieldConfig, ok := panel["fieldConfig"].(map[string]interface{}); ok {
+		if defaults, ok := fieldConfig["defaults"].(map[string]interface{}); ok {
+			if thresholds, ok := defaults["thresholds"].(map[string]interface{}); ok {
+				if steps, ok := thresholds["steps"].([]interface{}); ok && len(steps) > 1 {
+					if last, ok := steps[len(steps)-1].(map[string]interface{}); ok {
+						if value, ok := last["value"].(float64); ok {
+							critical = append(critical, value)
+						}
+					}
+					if previous, ok := steps[len(steps)-2].(map[string]interface{}); ok {
+						if value, ok := previous["value"].(float64); ok {
+							warning = append(warning, value)
+						}
+					}
+				}
+			}
+		}
+	}
+	// Painéis "graph" legados: thresholds com colorMode critical e warning
+	if thresholds, ok := panel["thresholds"].([]interface{}); ok {
+		for _, raw := range thresholds {
+			threshold, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if value, ok := threshold["value"].(float64); ok {
+				switch threshold["colorMode"] {
+				case "critical":
+					critical = append(critical, value)
+				case "warning":
+					warning = append(warning, value)
+				}
+			}
+		}
+	}
+
+	var issues []grafanaIssue
+	report := func(format string, args ...interface{}) {
+		issues = append(issues, grafanaIssue{File: file, Location: location, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, value := range critical {
+		if value != expected {
+			report("limiar crítico de %s é %v, mas alert_threshold_%s do ambiente é %v", resource, value, resource, expected)
+		}
+	}
+	for _, value := range warning {
+		if value >= expected {
+			report("limiar de atenção de %s (%v) não é menor que o crítico (%v): as cores do painel ficam invertidas", resource, value, expected)
+		} else if value != expected-grafanaWarningMargin {
+			report("limiar de atenção de %s é %v, mas o módulo usa alert_threshold_%s - %v = %v", resource, value, resource, grafanaWarningMargin, expected-grafanaWarningMargin)
+		}
+	}
+	return issues
+}
+
+// classifyMetrics identifica se as métricas medem CPU ou memória e retorna o limiar esperado
+func (v *grafanaValidator) classifyMetrics(metrics []string) (string, float64) {
+	for _, metric := range metrics {
+		switch {
+		case strings.HasPrefix(metric, "node_cpu_") || strings.HasPrefix(metric, "container_cpu_"):
+			return "cpu", v.AlertThresholdCPU
+		case strings.HasPrefix(metric, "node_memory_") || strings.HasPrefix(metric, "container_memory_"):
+			return "memory", v.AlertThresholdMemory
+		}
+	}
+	return "", 0
+}
+
+// grafanaVariablesUsed retorna os nomes das variáveis $var, ${var} e [[var]] usadas no texto
+func grafanaVariablesUsed(text string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, match := range grafanaVariableUse.FindAllStringSubmatch(text, -1) {
+		name := match[1] + match[2] + match[3]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// substituteGrafanaVariables troca as variáveis do Grafana por valores sintaticamente válidos antes do parse
+func substituteGrafanaVariables(expr string) string {
+	return grafanaVariableUse.ReplaceAllStringFunc(expr, func(match string) string {
+		groups := grafanaVariableUse.FindStringSubmatch(match)
+		name := groups[1] + groups[2] + groups[3]
+		switch name {
+		case "__interval", "__rate_interval", "__range":
+			return "5m"
+		case "__interval_ms", "__range_ms", "__range_s":
+			return "300000"
+		}
+		return name
+	})
+}
+
+// grafanaAlertFile segue o formato de provisionamento de alertas do Grafana (apiVersion 1)
+type grafanaAlertFile struct {
+	APIVersion int `yaml:"apiVersion"`
+	Groups     []struct {
+		Name     string             `yaml:"name"`
+		Folder   string             `yaml:"folder"`
+		Interval string             `yaml:"interval"`
+		Rules    []grafanaAlertRule `yaml:"rules"`
+	} `yaml:"groups"`
+}
+
+type grafanaAlertRule struct {
+	UID       string            `yaml:"uid"`
+	Title     string            `yaml:"title"`
+	Condition string            `yaml:"condition"`
+	For       string            `yaml:"for"`
+	Labels    map[string]string `yaml:"labels"`
+	Data      []struct {
+		RefID         string `yaml:"refId"`
+		DatasourceUID string `yaml:"datasourceUid"`
+		Model         struct {
+			Expr       string `yaml:"expr"`
+			Type       string `yaml:"type"`
+			Expression string `yaml:"expression"`
+			Reducer    string `yaml:"reducer"`
+			Conditions []struct {
+				Evaluator struct {
+					Type   string    `yaml:"type"`
+					Params []float64 `yaml:"params"`
+				} `yaml:"evaluator"`
+			} `yaml:"conditions"`
+		} `yaml:"model"`
+	} `yaml:"data"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// ValidateAlerts verifica as regras de alerta provisionadas pelo ConfigMap grafana_alerts
+func (v *grafanaValidator) ValidateAlerts(file string, content []byte) []grafanaIssue {
+	var alerts grafanaAlertFile
+	if err := yaml.Unmarshal(content, &alerts); err != nil {
+		return []grafanaIssue{{File: file, Message: fmt.Sprintf("YAML inválido: %v", err)}}
+	}
+
+	var issues []grafanaIssue
+	report := func(location, format string, args ...interface{}) {
+		issues = append(issues, grafanaIssue{File: file, Location: location, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if alerts.APIVersion != 1 {
+		report("", "apiVersion deve ser 1")
+	}
+
+	uids := map[string]bool{}
+	for _, group := range alerts.Groups {
+		if _, err := parsePromDuration(group.Interval); err != nil {
+			report("grupo "+group.Name, "interval inválido: %v", err)
+		}
+
+		for _, rule := range group.Rules {
+			location := fmt.Sprintf("regra %q", rule.Title)
+			if rule.UID == "" {
+				report(location, "regra sem uid")
+			} else if uids[rule.UID] {
+				report(location, "uid %q duplicado", rule.UID)
+			}
+			uids[rule.UID] = true
+
+			if rule.For != "" {
+				if _, err := parsePromDuration(rule.For); err != nil {
+					report(location, "for inválido: %v", err)
+				}
+			}
+
+			refs := map[string]bool{}
+			var metrics []string
+			var thresholds []float64
+			for _, data := range rule.Data {
+				refs[data.RefID] = true
+				dataLocation := fmt.Sprintf("%s, %s", location, data.RefID)
+
+				if data.DatasourceUID == "__expr__" {
+					for _, condition := range data.Model.Conditions {
+						if len(condition.Evaluator.Params) > 0 {
+							thresholds = append(thresholds, condition.Evaluator.Params[0])
+						}
+					}
+					continue
+				}
+
+				if _, msg := v.resolveDatasource(data.DatasourceUID); msg != "" {
+					report(dataLocation, msg)
+				}
+				if data.Model.Expr == "" {
+					report(dataLocation, "consulta sem expr")
+					continue
+				}
+				parsed, err := parsePromQL(substituteGrafanaVariables(data.Model.Expr))
+				if err != nil {
+					report(dataLocation, "PromQL inválido: %v", err)
+					continue
+				}
+				metrics = append(metrics, promMetricNames(parsed)...)
+			}
+
+			if !refs[rule.Condition] {
+				report(location, "condition %q não corresponde a nenhum refId", rule.Condition)
+			}
+
+			resource, expected := v.classifyMetrics(metrics)
+			for _, value := range thresholds {
+				if resource != "" && value != expected {
+					report(location, "limiar de %s é %v, mas alert_threshold_%s do ambiente é %v", resource, value, resource, expected)
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// grafanaWarningMargin é a distância entre o limiar crítico e o de atenção dos dashboards
+// (locals warning_threshold_* do módulo monitoring/grafana)
+const grafanaWarningMargin = 10
+
+// grafanaThresholdVars monta as variáveis passadas ao templatefile para um ambiente
+func grafanaThresholdVars(projectName, environment string, cfg *environmentConfig) map[string]interface{} {
+	format := func(value float64) string { return strconv.FormatFloat(value, 'f', -1, 64) }
+	return map[string]interface{}{
+		"project_name":             projectName,
+		"environment":              environment,
+		"alert_threshold_cpu":      format(cfg.Monitoring.AlertThresholdCPU),
+		"alert_threshold_memory":   format(cfg.Monitoring.AlertThresholdMemory),
+		"warning_threshold_cpu":    format(cfg.Monitoring.AlertThresholdCPU - grafanaWarningMargin),
+		"warning_threshold_memory": format(cfg.Monitoring.AlertThresholdMemory - grafanaWarningMargin),
+	}
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const grafanaModuleDir = "../modules/monitoring/grafana"
+
+// TestGrafanaDashboards renderiza os dashboards e alertas do módulo Grafana para cada ambiente
+// e valida IDs de painel, fontes de dados, PromQL, variáveis de template e limiares de alerta
+func TestGrafanaDashboards(t *testing.T) {
+	t.Parallel()
+
+	for _, environment := range environments {
+		environment := environment
+		t.Run(environment, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := loadEnvironmentConfig(environment)
+			if err != nil {
+				t.Fatalf("Erro ao carregar config.yaml: %v", err)
+			}
+
+			vars := grafanaThresholdVars("boilerplate-nestjs", environment, cfg)
+			issues, err := validateGrafanaModule(grafanaModuleDir, vars, cfg.Monitoring.AlertThresholdCPU, cfg.Monitoring.AlertThresholdMemory)
+			if err != nil {
+				t.Fatalf("Erro ao validar módulo Grafana: %v", err)
+			}
+
+			for _, issue := range issues {
+				t.Errorf("%s", issue)
+			}
+		})
+	}
+}
+
+// TestGrafanaValidatorDetectsProblems garante que o validador aponta cada tipo de problema
+func TestGrafanaValidatorDetectsProblems(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile("testdata/grafana/dashboard-com-problemas.json")
+	if err != nil {
+		t.Fatalf("Erro ao ler dashboard de teste: %v", err)
+	}
+
+	validator := &grafanaValidator{
+		Datasources:          []grafanaDatasource{{Name: "Prometheus", UID: "prometheus", Type: "prometheus", IsDefault: true}},
+		AlertThresholdCPU:    80,
+		AlertThresholdMemory: 80,
+	}
+	issues := validator.ValidateDashboard("dashboard-com-problemas.json", content)
+
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+	report := strings.Join(messages, "\n")
+
+	assert.Contains(t, report, "id 1 duplicado")
+	assert.Contains(t, report, "variável de template $cluster não definida")
+	assert.Contains(t, report, "rate espera um vetor de intervalo")
+	assert.Contains(t, report, `fonte de dados "Elasticsearch" não declarada`)
+	assert.Contains(t, report, "PromQL inválido")
+	assert.Contains(t, report, "limiar crítico de cpu é 90, mas alert_threshold_cpu do ambiente é 80")
+	assert.NotContains(t, report, "$job", "Variáveis definidas em templating não devem ser reportadas")
+	assert.Len(t, issues, 6)
+}
+
+// TestGrafanaAlertThresholdMismatch garante que limiares fixos nas regras de alerta são detectados
+func TestGrafanaAlertThresholdMismatch(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile(grafanaModuleDir + "/templates/alerts.yaml")
+	if err != nil {
+		t.Fatalf("Erro ao ler alerts.yaml: %v", err)
+	}
+
+	// Renderiza com 90% e valida contra um ambiente que espera 70%
+	rendered, err := renderTemplateFile(string(content), map[string]interface{}{
+		"project_name":           "boilerplate-nestjs",
+		"environment":            "staging",
+		"alert_threshold_cpu":    90,
+		"alert_threshold_memory": 70,
+	})
+	if err != nil {
+		t.Fatalf("Erro ao renderizar alerts.yaml: %v", err)
+	}
+
+	validator := &grafanaValidator{
+		Datasources:          []grafanaDatasource{{Name: "Prometheus", UID: "prometheus", Type: "prometheus", IsDefault: true}},
+		AlertThresholdCPU:    70,
+		AlertThresholdMemory: 70,
+	}
+	issues := validator.ValidateAlerts("alerts.yaml", []byte(rendered))
+	if assert.Len(t, issues, 1) {
+		assert.Contains(t, issues[0].Message, "limiar de cpu é 90")
+	}
+}
+
+// TestGrafanaWarningThreshold garante que o amarelo dos dashboards fica abaixo do vermelho mesmo
+// com limiares de alerta baixos e que a margem do validador é a mesma dos locals do módulo
+func TestGrafanaWarningThreshold(t *testing.T) {
+	t.Parallel()
+
+	mainTF, err := os.ReadFile(grafanaModuleDir + "/main.tf")
+	if err != nil {
+		t.Fatalf("Erro ao ler main.tf: %v", err)
+	}
+	for _, resource := range []string{"cpu", "memory"} {
+		assert.Regexp(t, fmt.Sprintf(`warning_threshold_%[1]s\s*=\s*var\.alert_threshold_%[1]s - %d\n`, resource, grafanaWarningMargin), string(mainTF))
+	}
+
+	content, err := os.ReadFile(grafanaModuleDir + "/templates/infrastructure-dashboard.json")
+	if err != nil {
+		t.Fatalf("Erro ao ler infrastructure-dashboard.json: %v", err)
+	}
+	validator := &grafanaValidator{
+		Datasources:          []grafanaDatasource{{Name: "Prometheus", UID: "prometheus", Type: "prometheus", IsDefault: true}},
+		AlertThresholdCPU:    60,
+		AlertThresholdMemory: 60,
+	}
+	render := func(warning int) string {
+		rendered, err := renderTemplateFile(string(content), map[string]interface{}{
+			"project_name":             "boilerplate-nestjs",
+			"environment":              "staging",
+			"alert_threshold_cpu":      60,
+			"alert_threshold_memory":   60,
+			"warning_threshold_cpu":    warning,
+			"warning_threshold_memory": warning,
+		})
+		if err != nil {
+			t.Fatalf("Erro ao renderizar infrastructure-dashboard.json: %v", err)
+		}
+		return rendered
+	}
+
+	// Amarelo fixo em 60, como antes dos locals: vermelho e amarelo no mesmo valor
+	var messages []string
+	for _, issue := range validator.ValidateDashboard("infrastructure-dashboard.json", []byte(render(60))) {
+		messages = append(messages, issue.Message)
+	}
+	assert.Contains(t, strings.Join(messages, "\n"), "limiar de atenção de cpu (60) não é menor que o crítico (60)")
+	assert.Contains(t, strings.Join(messages, "\n"), "limiar de atenção de memory (60) não é menor que o crítico (60)")
+
+	assert.Empty(t, validator.ValidateDashboard("infrastructure-dashboard.json", []byte(render(60-grafanaWarningMargin))))
+}
+
+// TestRenderTemplateFile verifica a reprodução do templatefile usada pelo validador
+func TestRenderTemplateFile(t *testing.T) {
+	t.Parallel()
+
+	rendered, err := renderTemplateFile(`{"title": "${project_name}", "expr": "up{job=\"$${job}\"}"}`, map[string]interface{}{"project_name": "demo"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"title": "demo", "expr": "up{job=\"${job}\"}"}`, rendered)
+
+	_, err = renderTemplateFile(`{"title": "${instance}"}`, map[string]interface{}{})
+	assert.Error(t, err, "Variáveis do Grafana sem escape quebram o templatefile do Terraform")
+}
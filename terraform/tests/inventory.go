@@ -0,0 +1,128 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// inventoryResource é um recurso gerenciado extraído de um plano ou estado em JSON (terraform show -json)
+type inventoryResource struct {
+	Address string
+	Type    string
+	Name    string
+	Tags    map[string]string
+}
+
+// HasTag indica se o recurso possui a tag, seja como chave de um mapa (AWS/GCP) ou item de lista (DigitalOcean)
+func (r inventoryResource) HasTag(tag string) bool {
+	_, ok := r.Tags[tag]
+	return ok
+}
+
+type tfJSONModule struct {
+	Resources []struct {
+		Address string                 `json:"address"`
+		Mode    string                 `json:"mode"`
+		Type    string                 `json:"type"`
+		Name    string                 `json:"name"`
+		Values  map[string]interface{} `json:"values"`
+	} `json:"resources"`
+	ChildModules []tfJSONModule `json:"child_modules"`
+}
+
+// plannedResource é um recurso gerenciado com os valores de um plano ou estado em JSON
+type plannedResource struct {
+	Address string
+	Type    string
+	Name    string
+	Values  map[string]interface{}
+}
+
+// loadPlannedResources lê a saída de `terraform show -json` de um plano (planned_values)
+// ou de um estado (values) e retorna os recursos gerenciados ordenados pelo endereço
+func loadPlannedResources(content []byte) ([]plannedResource, error) {
+	var document struct {
+		PlannedValues *struct {
+			RootModule tfJSONModule `json:"root_module"`
+		} `json:"planned_values"`
+		Values *struct {
+			RootModule tfJSONModule `json:"root_module"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar JSON do Terraform: %v", err)
+	}
+
+	var root tfJSONModule
+	switch {
+	case document.PlannedValues != nil:
+		root = document.PlannedValues.RootModule
+	case document.Values != nil:
+		root = document.Values.RootModule
+	default:
+		return nil, fmt.Errorf("JSON não contém planned_values nem values")
+	}
+
+	var resources []plannedResource
+	var walk func(module tfJSONModule)
+	walk = func(module tfJSONModule) {
+		for _, resource := range module.Resources {
+			if resource.Mode != "" && resource.Mode != "managed" {
+				continue
+			}
+			resources = append(resources, plannedResource{
+				Address: resource.Address,
+				Type:    resource.Type,
+				Name:    resource.Name,
+				Values:  resource.Values,
+			})
+		}
+		for _, child := range module.ChildModules {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Address < resources[j].Address })
+	return resources, nil
+}
+
+// loadResourceInventory retorna os recursos gerenciados do plano ou estado com as tags normalizadas
+func loadResourceInventory(content []byte) ([]inventoryResource, error) {
+	planned, err := loadPlannedResources(content)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]inventoryResource, 0, len(planned))
+	for _, resource := range planned {
+		resources = append(resources, inventoryResource{
+			Address: resource.Address,
+			Type:    resource.Type,
+			Name:    resource.Name,
+			Tags:    resourceTags(resource.Values),
+		})
+	}
+	return resources, nil
+}
+
+// resourceTags normaliza tags (mapa na AWS, lista no DigitalOcean) e labels (GCP) em um único mapa
+func resourceTags(values map[string]interface{}) map[string]string {
+	tags := map[string]string{}
+	for _, key := range []string{"tags_all", "tags", "labels"} {
+		switch value := values[key].(type) {
+		case map[string]interface{}:
+			for k, v := range value {
+				tags[k] = fmt.Sprint(v)
+			}
+		case []interface{}:
+			// Tags do DigitalOcean não têm valor; "chave:valor" é a convenção usada para simular um
+			for _, item := range value {
+				key, tagValue, _ := strings.Cut(fmt.Sprint(item), ":")
+				tags[key] = tagValue
+			}
+		}
+	}
+	return tags
+}
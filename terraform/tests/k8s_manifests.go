@@ -0,0 +1,311 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// k8sManifestsDir é o diretório com os manifestos aplicados pelo workflow de CD (kubectl apply -f k8s/$ENV/)
+const k8sManifestsDir = "../../k8s"
+
+// k8sOverlays lista os diretórios de manifestos por ambiente
+var k8sOverlays = []string{"hmg", "prod"}
+
+// Grace period padrão do Kubernetes quando terminationGracePeriodSeconds não é informado
+const k8sDefaultGracePeriod = 30 * time.Second
+
+type k8sMetadata struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace"`
+	Labels    map[string]string `yaml:"labels"`
+}
+
+type k8sContainer struct {
+	Name            string `yaml:"name"`
+	Image           string `yaml:"image"`
+	ImagePullPolicy string `yaml:"imagePullPolicy"`
+	Lifecycle       struct {
+		PreStop struct {
+			Exec struct {
+				Command []string `yaml:"command"`
+			} `yaml:"exec"`
+		} `yaml:"preStop"`
+	} `yaml:"lifecycle"`
+	Resources struct {
+		Limits   map[string]string `yaml:"limits"`
+		Requests map[string]string `yaml:"requests"`
+	} `yaml:"resources"`
+	Ports []struct {
+		ContainerPort int `yaml:"containerPort"`
+	} `yaml:"ports"`
+	EnvFrom []struct {
+		SecretRef struct {
+			Name string `yaml:"name"`
+		} `yaml:"secretRef"`
+	} `yaml:"envFrom"`
+}
+
+// PreStopSleep retorna a duração do `sleep` executado no hook preStop, se houver
+func (c k8sContainer) PreStopSleep() (time.Duration, bool) {
+	command := c.Lifecycle.PreStop.Exec.Command
+	if len(command) != 2 || command[0] != "sleep" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(command[1])
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+type k8sDeployment struct {
+	Metadata k8sMetadata `yaml:"metadata"`
+	Spec     struct {
+		Replicas int `yaml:"replicas"`
+		Selector struct {
+			MatchLabels map[string]string `yaml:"matchLabels"`
+		} `yaml:"selector"`
+		Template struct {
+			Metadata k8sMetadata `yaml:"metadata"`
+			Spec     struct {
+				TerminationGracePeriodSeconds *int           `yaml:"terminationGracePeriodSeconds"`
+				Containers                    []k8sContainer `yaml:"containers"`
+			} `yaml:"spec"`
+		} `yaml:"template"`
+	} `yaml:"spec"`
+}
+
+// GracePeriod retorna o terminationGracePeriodSeconds efetivo dos pods do Deployment
+func (d *k8sDeployment) GracePeriod() time.Duration {
+	if d.Spec.Template.Spec.TerminationGracePeriodSeconds == nil {
+		return k8sDefaultGracePeriod
+	}
+	return time.Duration(*d.Spec.Template.Spec.TerminationGracePeriodSeconds) * time.Second
+}
+
+type k8sService struct {
+	Metadata k8sMetadata `yaml:"metadata"`
+	Spec     struct {
+		Type     string            `yaml:"type"`
+		Selector map[string]string `yaml:"selector"`
+		Ports    []struct {
+			Port       int `yaml:"port"`
+			TargetPort int `yaml:"targetPort"`
+			NodePort   int `yaml:"nodePort"`
+		} `yaml:"ports"`
+	} `yaml:"spec"`
+}
+
+type k8sIngress struct {
+	Metadata k8sMetadata `yaml:"metadata"`
+	Spec     struct {
+		Rules []struct {
+			Host string `yaml:"host"`
+			HTTP struct {
+				Paths []struct {
+					Path    string `yaml:"path"`
+					Backend struct {
+						Service struct {
+							Name string `yaml:"name"`
+							Port struct {
+								Number int `yaml:"number"`
+							} `yaml:"port"`
+						} `yaml:"service"`
+					} `yaml:"backend"`
+				} `yaml:"paths"`
+			} `yaml:"http"`
+		} `yaml:"rules"`
+	} `yaml:"spec"`
+}
+
+type k8sHPA struct {
+	Metadata k8sMetadata `yaml:"metadata"`
+	Spec     struct {
+		ScaleTargetRef struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Name       string `yaml:"name"`
+		} `yaml:"scaleTargetRef"`
+		MinReplicas int `yaml:"minReplicas"`
+		MaxReplicas int `yaml:"maxReplicas"`
+	} `yaml:"spec"`
+}
+
+// k8sOverlay reúne os manifestos de um ambiente em k8s/<ambiente>
+type k8sOverlay struct {
+	Name       string
+	Dir        string
+	Files      []string
+	Deployment *k8sDeployment
+	Service    *k8sService
+	Ingress    *k8sIngress
+	HPA        *k8sHPA
+}
+
+// loadK8sOverlay lê os manifestos de k8s/<ambiente> e os separa pelo campo kind
+func loadK8sOverlay(name string) (*k8sOverlay, error) {
+	overlay := &k8sOverlay{Name: name, Dir: filepath.Join(k8sManifestsDir, name)}
+
+	entries, err := os.ReadDir(overlay.Dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(overlay.Dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var header struct {
+			Kind string `yaml:"kind"`
+		}
+		if err := yaml.Unmarshal(content, &header); err != nil {
+			return nil, fmt.Errorf("erro ao interpretar %s: %v", path, err)
+		}
+
+		var target interface{}
+		switch header.Kind {
+		case "Deployment":
+			overlay.Deployment = &k8sDeployment{}
+			target = overlay.Deployment
+		case "Service":
+			overlay.Service = &k8sService{}
+			target = overlay.Service
+		case "Ingress":
+			overlay.Ingress = &k8sIngress{}
+			target = overlay.Ingress
+		case "HorizontalPodAutoscaler":
+			overlay.HPA = &k8sHPA{}
+			target = overlay.HPA
+		default:
+			return nil, fmt.Errorf("%s: kind %q não suportado", path, header.Kind)
+		}
+		if err := yaml.Unmarshal(content, target); err != nil {
+			return nil, fmt.Errorf("erro ao interpretar %s: %v", path, err)
+		}
+		overlay.Files = append(overlay.Files, path)
+	}
+	sort.Strings(overlay.Files)
+
+	if overlay.Deployment == nil || overlay.Service == nil || overlay.Ingress == nil || overlay.HPA == nil {
+		return nil, fmt.Errorf("%s deve conter deployment, service, ingress e hpa", overlay.Dir)
+	}
+	return overlay, nil
+}
+
+// Validate confere a consistência entre os manifestos do ambiente e retorna os problemas encontrados
+func (o *k8sOverlay) Validate() []string {
+	var problems []string
+	deployment := o.Deployment
+
+	if len(deployment.Spec.Template.Spec.Containers) != 1 {
+		return append(problems, fmt.Sprintf("deployment %s deve ter exatamente um container", deployment.Metadata.Name))
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+
+	if !labelsMatch(deployment.Spec.Selector.MatchLabels, deployment.Spec.Template.Metadata.Labels) {
+		problems = append(problems, "selector do deployment não corresponde aos labels do template")
+	}
+
+	if sleep, ok := container.PreStopSleep(); ok && deployment.GracePeriod() <= sleep {
+		problems = append(problems, fmt.Sprintf("terminationGracePeriodSeconds (%s) não cobre o sleep do preStop (%s)", deployment.GracePeriod(), sleep))
+	}
+
+	for _, resource := range []string{"cpu", "memory"} {
+		if container.Resources.Requests[resource] == "" || container.Resources.Limits[resource] == "" {
+			problems = append(problems, fmt.Sprintf("requests e limits de %s devem ser definidos", resource))
+		}
+	}
+
+	containerPorts := map[int]bool{}
+	for _, port := range container.Ports {
+		containerPorts[port.ContainerPort] = true
+	}
+	if !labelsMatch(o.Service.Spec.Selector, deployment.Spec.Template.Metadata.Labels) {
+		problems = append(problems, fmt.Sprintf("service %s não seleciona os pods do deployment", o.Service.Metadata.Name))
+	}
+	servicePorts := map[int]bool{}
+	for _, port := range o.Service.Spec.Ports {
+		servicePorts[port.Port] = true
+		if !containerPorts[port.TargetPort] {
+			problems = append(problems, fmt.Sprintf("service %s aponta para a porta %d, que o container não expõe", o.Service.Metadata.Name, port.TargetPort))
+		}
+	}
+
+	for _, rule := range o.Ingress.Spec.Rules {
+		for _, path := range rule.HTTP.Paths {
+			backend := path.Backend.Service
+			if backend.Name != o.Service.Metadata.Name || !servicePorts[backend.Port.Number] {
+				problems = append(problems, fmt.Sprintf("ingress %s aponta para %s:%d, que não existe", o.Ingress.Metadata.Name, backend.Name, backend.Port.Number))
+			}
+		}
+	}
+
+	target := o.HPA.Spec.ScaleTargetRef
+	if target.Kind != "Deployment" || target.Name != deployment.Metadata.Name {
+		problems = append(problems, fmt.Sprintf("hpa %s aponta para %s/%s em vez de Deployment/%s", o.HPA.Metadata.Name, target.Kind, target.Name, deployment.Metadata.Name))
+	}
+
+	for _, namespace := range []string{o.Service.Metadata.Namespace, o.Ingress.Metadata.Namespace, o.HPA.Metadata.Namespace} {
+		if namespace != deployment.Metadata.Namespace {
+			problems = append(problems, fmt.Sprintf("manifestos em namespaces diferentes: %s e %s", deployment.Metadata.Namespace, namespace))
+		}
+	}
+	return problems
+}
+
+// labelsMatch indica se todos os labels do selector estão presentes em labels
+func labelsMatch(selector, labels map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// renderK8sManifest troca a imagem e a imagePullPolicy dos containers de um manifesto,
+// da mesma forma que o workflow de CD faz com sed antes do kubectl apply
+func renderK8sManifest(content []byte, image, pullPolicy string) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	replaceYAMLValues(&document, map[string]string{"image": image, "imagePullPolicy": pullPolicy})
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func replaceYAMLValues(node *yaml.Node, values map[string]string) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if value, ok := values[node.Content[i].Value]; ok && node.Content[i+1].Kind == yaml.ScalarNode {
+				node.Content[i+1].Value = value
+			}
+		}
+	}
+	for _, child := range node.Content {
+		replaceYAMLValues(child, values)
+	}
+}
@@ -0,0 +1,164 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/docker"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+// Imagem construída a partir de testdata/k8s/stub-image e carregada no kind no lugar da aplicação
+const k8sStubImage = "boilerplate-stub:test"
+
+// TestK8sOverlayManifests confere, sem cluster, a consistência entre deployment, service, ingress e hpa de cada ambiente
+func TestK8sOverlayManifests(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range k8sOverlays {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			overlay, err := loadK8sOverlay(name)
+			if err != nil {
+				t.Fatalf("Erro ao carregar manifestos: %v", err)
+			}
+			for _, problem := range overlay.Validate() {
+				t.Errorf("%s", problem)
+			}
+		})
+	}
+}
+
+// TestK8sOverlaysOnKind aplica os manifestos de cada ambiente em um cluster kind com uma imagem
+// de teste e verifica rollout, hook preStop, recursos, roteamento do Service e alvo do HPA
+func TestK8sOverlaysOnKind(t *testing.T) {
+	t.Parallel()
+
+	requireKind(t)
+	docker.Build(t, "testdata/k8s/stub-image", &docker.BuildOptions{Tags: []string{k8sStubImage}})
+
+	for _, name := range k8sOverlays {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			overlay, err := loadK8sOverlay(name)
+			if err != nil {
+				t.Fatalf("Erro ao carregar manifestos: %v", err)
+			}
+
+			cluster := createKindCluster(t, "k8s-"+name)
+			cluster.LoadImage(t, k8sStubImage)
+
+			deployment := overlay.Deployment
+			namespace := deployment.Metadata.Namespace
+			kubectlOptions := cluster.KubectlOptions(namespace)
+			k8s.CreateNamespace(t, kubectlOptions, namespace)
+
+			container := deployment.Spec.Template.Spec.Containers[0]
+			for _, envFrom := range container.EnvFrom {
+				k8s.RunKubectl(t, kubectlOptions, "create", "secret", "generic", envFrom.SecretRef.Name, "--from-literal=NODE_ENV=test")
+			}
+
+			// A imagem de teste entra nos nós com `kind load` e não existe em nenhum registry. O prod usa
+			// imagePullPolicy: Always, que tentaria baixá-la e pararia em ErrImagePull; o hmg usa Never, que
+			// funcionaria aqui só porque a imagem já foi carregada. Os dois overlays são aplicados com a
+			// imagem de teste e IfNotPresent, que usa a cópia dos nós sem depender da política de cada um
+			renderedDir := t.TempDir()
+			for _, file := range overlay.Files {
+				content, err := os.ReadFile(file)
+				if err != nil {
+					t.Fatalf("Erro ao ler %s: %v", file, err)
+				}
+				rendered, err := renderK8sManifest(content, k8sStubImage, "IfNotPresent")
+				if err != nil {
+					t.Fatalf("Erro ao preparar %s: %v", file, err)
+				}
+				renderedFile := filepath.Join(renderedDir, filepath.Base(file))
+				if err := os.WriteFile(renderedFile, rendered, 0644); err != nil {
+					t.Fatalf("Erro ao gravar %s: %v", renderedFile, err)
+				}
+				k8s.KubectlApply(t, kubectlOptions, renderedFile)
+			}
+
+			// Rollout
+			k8s.RunKubectl(t, kubectlOptions, "rollout", "status", "deployment/"+deployment.Metadata.Name, "--timeout=180s")
+
+			podName, err := k8s.RunKubectlAndGetOutputE(t, kubectlOptions, "get", "pods",
+				"-l", selectorString(deployment.Spec.Selector.MatchLabels), "-o", "jsonpath={.items[0].metadata.name}")
+			if err != nil {
+				t.Fatalf("Erro ao obter pod do deployment: %v", err)
+			}
+
+			// Requests e limits aceitos pelo API server sem alteração
+			resourcesJSON, err := k8s.RunKubectlAndGetOutputE(t, kubectlOptions, "get", "pod", podName,
+				"-o", "jsonpath={.spec.containers[0].resources}")
+			if err != nil {
+				t.Fatalf("Erro ao obter recursos do pod: %v", err)
+			}
+			var resources struct {
+				Limits   map[string]string `json:"limits"`
+				Requests map[string]string `json:"requests"`
+			}
+			if err := json.Unmarshal([]byte(resourcesJSON), &resources); err != nil {
+				t.Fatalf("Erro ao interpretar recursos do pod: %v", err)
+			}
+			assert.Equal(t, container.Resources.Limits, resources.Limits)
+			assert.Equal(t, container.Resources.Requests, resources.Requests)
+
+			// Service roteando para a porta 3000 dos pods
+			service := overlay.Service
+			endpointPort, err := k8s.RunKubectlAndGetOutputE(t, kubectlOptions, "get", "endpoints", service.Metadata.Name,
+				"-o", "jsonpath={.subsets[0].ports[0].port}")
+			assert.NoError(t, err)
+			assert.Equal(t, "3000", endpointPort)
+
+			url := fmt.Sprintf("http://%s.%s.svc.cluster.local:%d/", service.Metadata.Name, namespace, service.Spec.Ports[0].Port)
+			response := retry.DoWithRetry(t, "Acessando o service "+service.Metadata.Name, 10, 3*time.Second, func() (string, error) {
+				return k8s.RunKubectlAndGetOutputE(t, kubectlOptions, "run", "probe-"+strings.ToLower(name), "--rm", "-i",
+					"--restart=Never", "--image="+k8sStubImage, "--image-pull-policy=Never", "--", "wget", "-qO-", url)
+			})
+			assert.Contains(t, response, "ok")
+
+			// HPA apontando para um Deployment existente
+			hpa := overlay.HPA
+			k8s.RunKubectl(t, kubectlOptions, "get", "deployment", hpa.Spec.ScaleTargetRef.Name)
+			reason := retry.DoWithRetry(t, "Aguardando condições do HPA", 20, 3*time.Second, func() (string, error) {
+				output, err := k8s.RunKubectlAndGetOutputE(t, kubectlOptions, "get", "hpa", hpa.Metadata.Name,
+					"-o", `jsonpath={.status.conditions[?(@.type=="AbleToScale")].reason}`)
+				if err == nil && output == "" {
+					err = fmt.Errorf("condição AbleToScale ainda não publicada")
+				}
+				return output, err
+			})
+			assert.NotEqual(t, "FailedGetScale", reason, "O HPA não encontrou o Deployment alvo")
+
+			// preStop: a exclusão do pod deve aguardar o sleep configurado
+			if sleep, ok := container.PreStopSleep(); ok {
+				start := time.Now()
+				k8s.RunKubectl(t, kubectlOptions, "delete", "pod", podName, "--wait=true", "--timeout=120s")
+				elapsed := time.Since(start)
+				assert.GreaterOrEqual(t, elapsed, sleep, "O pod foi finalizado antes do fim do hook preStop")
+				assert.Less(t, elapsed, deployment.GracePeriod()+10*time.Second)
+			}
+		})
+	}
+}
+
+// selectorString converte labels em um seletor do kubectl (-l chave=valor,...)
+func selectorString(labels map[string]string) string {
+	var parts []string
+	for key, value := range labels {
+		parts = append(parts, key+"="+value)
+	}
+	return strings.Join(parts, ",")
+}
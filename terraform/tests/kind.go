@@ -0,0 +1,148 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// kindCluster é um cluster Kubernetes local criado com kind para os testes
+type kindCluster struct {
+	Name           string
+	KubeconfigPath string
+}
+
+// KubectlOptions retorna as opções do terratest apontando para o cluster no namespace informado
+func (c *kindCluster) KubectlOptions(namespace string) *k8s.KubectlOptions {
+	return k8s.NewKubectlOptions("", c.KubeconfigPath, namespace)
+}
+
+// requireKind pula o teste quando kind, kubectl ou docker não estão instalados
+func requireKind(t *testing.T) {
+	for _, binary := range []string{"kind", "kubectl", "docker"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("Este teste requer %s instalado", binary)
+		}
+	}
+}
+
+// createKindCluster cria um cluster kind com kubeconfig próprio e o remove ao final do teste
+func createKindCluster(t *testing.T, prefix string) *kindCluster {
+	return createKindClusterWithConfig(t, prefix, "")
+}
+
+// createKindClusterWithConfig cria o cluster a partir de um arquivo de configuração do kind (nós,
+// labels, mapeamento de portas); sem arquivo, o kind cria um único nó
+func createKindClusterWithConfig(t *testing.T, prefix, configPath string) *kindCluster {
+	requireKind(t)
+
+	cluster := &kindCluster{
+		Name:           fmt.Sprintf("%s-%s", prefix, strings.ToLower(random.UniqueId())),
+		KubeconfigPath: filepath.Join(t.TempDir(), "kubeconfig"),
+	}
+
+	t.Cleanup(func() {
+		if os.Getenv("SKIP_TEARDOWN") == "true" {
+			t.Logf("SKIP_TEARDOWN definido, mantendo o cluster kind %s", cluster.Name)
+			return
+		}
+		shell.RunCommand(t, shell.Command{
+			Command: "kind",
+			Args:    []string{"delete", "cluster", "--name", cluster.Name, "--kubeconfig", cluster.KubeconfigPath},
+		})
+	})
+
+	args := []string{"create", "cluster", "--name", cluster.Name, "--kubeconfig", cluster.KubeconfigPath, "--wait", "180s"}
+	if configPath != "" {
+		args = append(args, "--config", configPath)
+	}
+	shell.RunCommand(t, shell.Command{
+		Command: "kind",
+		Args:    args,
+	})
+	return cluster
+}
+
+// kindConfigPath resolve kubernetes.local.kind_config_path, relativo ao diretório do ambiente
+func kindConfigPath(environment string, cfg *environmentConfig) string {
+	if cfg.Kubernetes.Local.KindConfigPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(environmentConfigPath(environment)), cfg.Kubernetes.Local.KindConfigPath)
+}
+
+// createEnvironmentKindCluster cria o cluster descrito em kubernetes.local do config.yaml do
+// ambiente: o nome vem de kind_cluster_name (com um sufixo único, para testes em paralelo) e os
+// nós de kind_config_path. O teste é pulado quando enable_kind está desligado
+func createEnvironmentKindCluster(t *testing.T, environment string) *kindCluster {
+	cfg, err := loadEnvironmentConfig(environment)
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	local := cfg.Kubernetes.Local
+	if !local.EnableKind {
+		t.Skipf("kubernetes.local.enable_kind desligado no ambiente %s", environment)
+	}
+	if local.KindClusterName == "" {
+		t.Fatalf("kubernetes.local.kind_cluster_name não definido no ambiente %s", environment)
+	}
+
+	configPath := kindConfigPath(environment, cfg)
+	if configPath != "" {
+		if _, err := os.Stat(configPath); err != nil {
+			t.Fatalf("Configuração do kind não encontrada: %v", err)
+		}
+	}
+	return createKindClusterWithConfig(t, local.KindClusterName, configPath)
+}
+
+// Context é o contexto que o kind grava no kubeconfig
+func (c *kindCluster) Context() string {
+	return "kind-" + c.Name
+}
+
+// TerraformEnvVars aponta os providers kubernetes e helm para o cluster. As variáveis de ambiente
+// valem para qualquer diretório testado, mesmo módulos sem bloco provider
+func (c *kindCluster) TerraformEnvVars() map[string]string {
+	return map[string]string{
+		"KUBE_CONFIG_PATH": c.KubeconfigPath,
+		"KUBE_CTX":         c.Context(),
+	}
+}
+
+// TerraformOptions monta as opções do Terraform para aplicar um diretório no cluster, com os erros
+// repetíveis do provedor local-k8s
+func (c *kindCluster) TerraformOptions(t *testing.T, terraformDir string, vars map[string]interface{}) *terraform.Options {
+	return withRetryableErrors(t, &terraform.Options{
+		TerraformDir: terraformDir,
+		Vars:         vars,
+		EnvVars:      c.TerraformEnvVars(),
+		NoColor:      true,
+	})
+}
+
+// RootTerraformVars são as variáveis do módulo raiz no modo active_provider = "local-k8s"
+func (c *kindCluster) RootTerraformVars(environment string) map[string]interface{} {
+	return map[string]interface{}{
+		"environment":     environment,
+		"active_provider": "local-k8s",
+		"kubeconfig_path": c.KubeconfigPath,
+		"kube_context":    c.Context(),
+	}
+}
+
+// LoadImage copia uma imagem do docker local para os nós do cluster, evitando pull de registry
+func (c *kindCluster) LoadImage(t *testing.T, image string) {
+	shell.RunCommand(t, shell.Command{
+		Command: "kind",
+		Args:    []string{"load", "docker-image", image, "--name", c.Name},
+	})
+}
@@ -1,104 +1,126 @@
 package test
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/gruntwork-io/terratest/modules/k8s"
 	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
 	"github.com/stretchr/testify/assert"
 )
 
-// TestKubernetesCluster verifica se o cluster Kubernetes é implantado corretamente
+// TestKubernetesCluster verifica se o cluster Kubernetes é implantado corretamente.
+// Com SKIP_teardown=true o cluster é mantido, e com SKIP_setup=true SKIP_deploy=true as próximas
+// execuções só repetem a validação contra ele, usando as opções e saídas gravadas em .test-data
 func TestKubernetesCluster(t *testing.T) {
 	t.Parallel()
 
-	// Configurar as opções do Terraform
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
-		// Diretório onde os arquivos do Terraform estão localizados
-		TerraformDir: "../environments/dev/digital-ocean",
+	// Diretório onde os arquivos do Terraform estão localizados
+	workingDir := "../environments/dev/digital-ocean"
 
-		// Variáveis a serem passadas para o Terraform CLI
-		Vars: map[string]interface{}{
-			"environment": "test",
-		},
+	// No final do teste, execute terraform destroy
+	defer test_structure.RunTestStage(t, stageTeardown, func() {
+		terraformOptions := loadStageTerraformOptions(t, workingDir)
+		terraform.Destroy(t, terraformOptions)
+		test_structure.CleanupTestDataFolder(t, workingDir)
+	})
 
-		// Variáveis de ambiente a serem passadas para o Terraform CLI
-		EnvVars: map[string]string{
-			"DIGITALOCEAN_TOKEN": "", // Preencher via variável de ambiente no ambiente de CI
-		},
+	test_structure.RunTestStage(t, stageSetup, func() {
+		// Configurar as opções do Terraform
+		terraformOptions := withRetryableErrors(t, &terraform.Options{
+			TerraformDir: workingDir,
+
+			// Variáveis a serem passadas para o Terraform CLI
+			Vars: map[string]interface{}{
+				"environment": "test",
+			},
+
+			// Variáveis de ambiente a serem passadas para o Terraform CLI
+			EnvVars: map[string]string{
+				"DIGITALOCEAN_TOKEN": "", // Preencher via variável de ambiente no ambiente de CI
+			},
+		})
+		test_structure.SaveTerraformOptions(t, workingDir, terraformOptions)
 	})
 
-	// No final do teste, execute terraform destroy
-	defer terraform.Destroy(t, terraformOptions)
+	test_structure.RunTestStage(t, stageDeploy, func() {
+		terraformOptions := loadStageTerraformOptions(t, workingDir)
 
-	// Inicialize e aplique a configuração do Terraform
-	terraform.InitAndApply(t, terraformOptions)
+		// Inicialize e aplique a configuração do Terraform
+		terraform.InitAndApply(t, terraformOptions)
+		saveStageOutputs(t, workingDir, terraformOptions)
+	})
 
-	// Obtenha o nome do cluster Kubernetes
-	clusterName := terraform.Output(t, terraformOptions, "kubernetes_cluster_name")
-	assert.NotEmpty(t, clusterName, "O nome do cluster não deve estar vazio")
+	test_structure.RunTestStage(t, stageValidate, func() {
+		outputs := loadStageOutputs(t, workingDir)
 
-	// Obtenha o endpoint do cluster
-	clusterEndpoint := terraform.Output(t, terraformOptions, "kubernetes_cluster_endpoint")
-	assert.NotEmpty(t, clusterEndpoint, "O endpoint do cluster não deve estar vazio")
+		// Obtenha o nome do cluster Kubernetes
+		clusterName := outputs.String("kubernetes_cluster_name")
+		assert.NotEmpty(t, clusterName, "O nome do cluster não deve estar vazio")
 
-	// Obtenha o arquivo kubeconfig
-	kubeConfigPath := terraform.Output(t, terraformOptions, "kubeconfig_path")
-	
-	// Configuração do Kubernetes
-	kubectlOptions := k8s.NewKubectlOptions(
-		"",
-		kubeConfigPath,
-		"default",
-	)
+		// Obtenha o endpoint do cluster
+		clusterEndpoint := outputs.String("kubernetes_cluster_endpoint")
+		assert.NotEmpty(t, clusterEndpoint, "O endpoint do cluster não deve estar vazio")
+
+		// Obtenha o arquivo kubeconfig
+		kubeConfigPath := outputs.String("kubeconfig_path")
+
+		// Configuração do Kubernetes
+		kubectlOptions := k8s.NewKubectlOptions(
+			"",
+			kubeConfigPath,
+			"default",
+		)
+
+		// Teste a conectividade com o cluster
+		retry.DoWithRetry(t, "Testando conexão com o cluster Kubernetes", 30, 10*time.Second, func() (string, error) {
+			_, err := k8s.GetNodesE(t, kubectlOptions)
+			if err != nil {
+				return "", err
+			}
+
+			return "", nil
+		})
 
-	// Teste a conectividade com o cluster
-	retry.DoWithRetry(t, "Testando conexão com o cluster Kubernetes", 30, 10*time.Second, func() (string, error) {
+		// Verifique se o número de nós corresponde ao esperado
 		nodes, err := k8s.GetNodesE(t, kubectlOptions)
 		if err != nil {
-			return "", err
+			t.Fatalf("Erro ao obter nós do cluster: %v", err)
 		}
-		
-		return "", nil
-	})
 
-	// Verifique se o número de nós corresponde ao esperado
-	nodes, err := k8s.GetNodesE(t, kubectlOptions)
-	if err != nil {
-		t.Fatalf("Erro ao obter nós do cluster: %v", err)
-	}
-	
-	minNodes := 1 // Número mínimo de nós esperados
-	assert.GreaterOrEqual(t, len(nodes), minNodes, "O cluster deve ter pelo menos %d nós", minNodes)
-
-	// Verifique a versão do Kubernetes
-	version, err := k8s.GetKubernetesClusterVersionE(t, kubectlOptions)
-	if err != nil {
-		t.Fatalf("Erro ao obter a versão do Kubernetes: %v", err)
-	}
-	assert.NotEmpty(t, version, "A versão do Kubernetes não deve estar vazia")
-
-	// Verifique se namespaces padrões foram criados
-	namespaces, err := k8s.GetNamespacesE(t, kubectlOptions)
-	if err != nil {
-		t.Fatalf("Erro ao obter namespaces: %v", err)
-	}
-	
-	// Verifique se o namespace default existe
-	hasDefaultNamespace := false
-	for _, ns := range namespaces {
-		if ns.Name == "default" {
-			hasDefaultNamespace = true
-			break
+		minNodes := 1 // Número mínimo de nós esperados
+		assert.GreaterOrEqual(t, len(nodes), minNodes, "O cluster deve ter pelo menos %d nós", minNodes)
+
+		// Verifique a versão do Kubernetes
+		version, err := k8s.GetKubernetesClusterVersionE(t, kubectlOptions)
+		if err != nil {
+			t.Fatalf("Erro ao obter a versão do Kubernetes: %v", err)
+		}
+		assert.NotEmpty(t, version, "A versão do Kubernetes não deve estar vazia")
+
+		// Verifique se namespaces padrões foram criados
+		namespaces, err := k8s.GetNamespacesE(t, kubectlOptions)
+		if err != nil {
+			t.Fatalf("Erro ao obter namespaces: %v", err)
+		}
+
+		// Verifique se o namespace default existe
+		hasDefaultNamespace := false
+		for _, ns := range namespaces {
+			if ns.Name == "default" {
+				hasDefaultNamespace = true
+				break
+			}
 		}
-	}
-	assert.True(t, hasDefaultNamespace, "O namespace 'default' deve existir")
+		assert.True(t, hasDefaultNamespace, "O namespace 'default' deve existir")
 
-	// Verifique a saúde do cluster
-	clusterHealth := terraform.OutputMap(t, terraformOptions, "cluster_status")
-	assert.Equal(t, "running", clusterHealth["status"], "O status do cluster deve ser 'running'")
+		// Verifique a saúde do cluster
+		clusterHealth := outputs.Map("cluster_status")
+		assert.Equal(t, "running", clusterHealth["status"], "O status do cluster deve ser 'running'")
+	})
 }
 
 // TestKubernetesNetwork verifica as configurações de rede do cluster
@@ -106,7 +128,7 @@ func TestKubernetesNetwork(t *testing.T) {
 	t.Parallel()
 
 	// Configurar as opções do Terraform
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../environments/dev/digital-ocean",
 		Vars: map[string]interface{}{
 			"environment": "test",
@@ -135,7 +157,7 @@ func TestKubernetesConfigMap(t *testing.T) {
 	t.Parallel()
 
 	// Configurar as opções do Terraform
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../environments/dev/digital-ocean",
 		Vars: map[string]interface{}{
 			"environment": "test",
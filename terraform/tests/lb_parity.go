@@ -0,0 +1,479 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+)
+
+// lbParityProviders são os valores aceitos por provider_name em modules/load_balancing/main
+var lbParityProviders = []string{"aws", "gcp", "azure", "digitalocean"}
+
+// lbProviderModules associa cada provedor ao diretório da implementação em modules/load_balancing
+var lbProviderModules = map[string]string{
+	"aws":          "aws",
+	"gcp":          "gcp",
+	"azure":        "azure",
+	"digitalocean": "digital-ocean",
+}
+
+// Certificados fictícios no formato esperado por cada provedor; o plano não consulta a API
+var lbParityCertificates = map[string]string{
+	"aws":          "arn:aws:acm:us-east-1:123456789012:certificate/00000000-0000-0000-0000-000000000000",
+	"gcp":          "projects/parity-project/global/sslCertificates/parity",
+	"azure":        "parity-certificate",
+	"digitalocean": "00000000-0000-0000-0000-000000000000",
+}
+
+// lbParityVars retorna a entrada abstrata comum aos provedores, com os campos específicos
+// de cada um preenchidos com valores fictícios suficientes para o plano
+func lbParityVars(provider string) map[string]interface{} {
+	vars := map[string]interface{}{
+		"provider_name":              provider,
+		"name":                       "parity",
+		"environment":                "test",
+		"enable_deletion_protection": false,
+		"ssl_certificate_id":         lbParityCertificates[provider],
+		"forwarding_rules": []map[string]interface{}{
+			{"entry_port": 80, "entry_protocol": "http", "target_port": 8080, "target_protocol": "http"},
+		},
+		"healthcheck": map[string]interface{}{"port": 8080, "protocol": "http", "path": "/health"},
+		"target_groups": []map[string]interface{}{
+			{"name": "app", "port": 8080, "protocol": "http", "targets": []string{"projects/parity-project/zones/us-central1-a/instanceGroups/app"}},
+		},
+	}
+
+	switch provider {
+	case "aws":
+		vars["region"] = "us-east-1"
+		vars["vpc_id"] = "vpc-0123456789abcdef0"
+		vars["subnet_ids"] = []string{"subnet-0123456789abcdef0", "subnet-0fedcba9876543210"}
+	case "gcp":
+		vars["region"] = "us-central1"
+		vars["gcp_project_id"] = "parity-project"
+	case "azure":
+		vars["region"] = "brazilsouth"
+		vars["azure_location"] = "brazilsouth"
+		vars["resource_group_name"] = "parity-rg"
+		vars["azure_virtual_network_name"] = "parity-vnet"
+		vars["azure_subnet_name"] = "parity-subnet"
+	case "digitalocean":
+		vars["region"] = "nyc1"
+	}
+	return vars
+}
+
+// addLBForwardingRule acrescenta uma regra às forwarding_rules da entrada
+func addLBForwardingRule(vars map[string]interface{}, rule map[string]interface{}) {
+	rules, _ := vars["forwarding_rules"].([]map[string]interface{})
+	vars["forwarding_rules"] = append(rules, rule)
+}
+
+// lbFeature é um recurso da interface abstrata: como pedi-lo na entrada e como reconhecê-lo
+// no plano de cada provedor. Provedores sem detector não têm como honrar o recurso.
+type lbFeature struct {
+	// Name é o nome usado pelo módulo na mensagem de recursos não suportados
+	Name        string
+	Description string
+	Request     func(vars map[string]interface{})
+	Honored     map[string]func(resources []plannedResource) bool
+}
+
+// lbFeatures são os recursos comparados na matriz de paridade
+var lbFeatures = []lbFeature{
+	{
+		Name:        "https",
+		Description: "Terminação HTTPS",
+		Request: func(vars map[string]interface{}) {
+			addLBForwardingRule(vars, map[string]interface{}{"entry_port": 443, "entry_protocol": "https", "target_port": 8080, "target_protocol": "http"})
+		},
+		Honored: map[string]func([]plannedResource) bool{
+			"aws": func(resources []plannedResource) bool {
+				return anyPlannedResource(resources, "aws_lb_listener", func(values map[string]interface{}) bool {
+					return values["protocol"] == "HTTPS" && planString(values, "certificate_arn") != ""
+				})
+			},
+			"gcp": func(resources []plannedResource) bool {
+				return anyPlannedResource(resources, "google_compute_target_https_proxy", func(values map[string]interface{}) bool {
+					certificates, _ := values["ssl_certificates"].([]interface{})
+					return len(certificates) > 0
+				})
+			},
+			"digitalocean": func(resources []plannedResource) bool {
+				return anyPlannedBlock(resources, "digitalocean_loadbalancer", "forwarding_rule", func(rule map[string]interface{}) bool {
+					return rule["entry_protocol"] == "https" && rule["tls_passthrough"] != true &&
+						(planString(rule, "certificate_id") != "" || planString(rule, "certificate_name") != "")
+				})
+			},
+		},
+	},
+	{
+		Name:        "tls_passthrough",
+		Description: "TLS passthrough",
+		Request: func(vars map[string]interface{}) {
+			addLBForwardingRule(vars, map[string]interface{}{"entry_port": 443, "entry_protocol": "https", "target_port": 8443, "target_protocol": "https", "tls_passthrough": true})
+		},
+		Honored: map[string]func([]plannedResource) bool{
+			"aws": func(resources []plannedResource) bool {
+				return anyPlannedResource(resources, "aws_lb_listener", func(values map[string]interface{}) bool {
+					return values["protocol"] == "TCP" && values["port"] == float64(443)
+				})
+			},
+			"azure": func(resources []plannedResource) bool {
+				return anyPlannedResource(resources, "azurerm_lb_rule", func(values map[string]interface{}) bool {
+					return values["protocol"] == "Tcp" && values["frontend_port"] == float64(443) && values["backend_port"] == float64(8443)
+				})
+			},
+			"digitalocean": func(resources []plannedResource) bool {
+				return anyPlannedBlock(resources, "digitalocean_loadbalancer", "forwarding_rule", func(rule map[string]interface{}) bool {
+					return rule["tls_passthrough"] == true
+				})
+			},
+		},
+	},
+	{
+		Name:        "sticky_sessions",
+		Description: "Sticky sessions",
+		Request: func(vars map[string]interface{}) {
+			vars["sticky_sessions"] = map[string]interface{}{"enabled": true, "type": "cookies", "cookie_name": "parity", "cookie_ttl_seconds": 600}
+		},
+		Honored: map[string]func([]plannedResource) bool{
+			"aws": func(resources []plannedResource) bool {
+				return anyPlannedBlock(resources, "aws_lb_target_group", "stickiness", func(stickiness map[string]interface{}) bool {
+					return stickiness["enabled"] == true
+				})
+			},
+			"gcp": func(resources []plannedResource) bool {
+				return anyPlannedResource(resources, "google_compute_backend_service", func(values map[string]interface{}) bool {
+					return values["session_affinity"] == "GENERATED_COOKIE"
+				})
+			},
+			"digitalocean": func(resources []plannedResource) bool {
+				return anyPlannedBlock(resources, "digitalocean_loadbalancer", "sticky_sessions", func(sticky map[string]interface{}) bool {
+					return sticky["type"] == "cookies"
+				})
+			},
+		},
+	},
+	{
+		Name:        "least_connections",
+		Description: "Algoritmo least_connections",
+		Request: func(vars map[string]interface{}) {
+			vars["algorithm"] = "least_connections"
+		},
+		// No DigitalOcean o campo algorithm aparece no plano, mas a API o ignora; não conta como honrado
+		Honored: map[string]func([]plannedResource) bool{
+			"aws": func(resources []plannedResource) bool {
+				return anyPlannedResource(resources, "aws_lb_target_group", func(values map[string]interface{}) bool {
+					return values["load_balancing_algorithm_type"] == "least_outstanding_requests"
+				})
+			},
+			"gcp": func(resources []plannedResource) bool {
+				return anyPlannedResource(resources, "google_compute_backend_service", func(values map[string]interface{}) bool {
+					return values["locality_lb_policy"] == "LEAST_REQUEST"
+				})
+			},
+		},
+	},
+	{
+		Name:        "redirect_http_to_https",
+		Description: "Redirecionamento HTTP → HTTPS",
+		Request: func(vars map[string]interface{}) {
+			addLBForwardingRule(vars, map[string]interface{}{"entry_port": 443, "entry_protocol": "https", "target_port": 8080, "target_protocol": "http"})
+			vars["redirect_http_to_https"] = true
+		},
+		Honored: map[string]func([]plannedResource) bool{
+			"aws": func(resources []plannedResource) bool {
+				return anyPlannedBlock(resources, "aws_lb_listener", "default_action", func(action map[string]interface{}) bool {
+					redirects, _ := action["redirect"].([]interface{})
+					for _, redirect := range redirects {
+						if values, ok := redirect.(map[string]interface{}); ok && values["protocol"] == "HTTPS" {
+							return action["type"] == "redirect"
+						}
+					}
+					return false
+				})
+			},
+			"gcp": func(resources []plannedResource) bool {
+				return anyPlannedBlock(resources, "google_compute_url_map", "default_url_redirect", func(redirect map[string]interface{}) bool {
+					return redirect["https_redirect"] == true
+				})
+			},
+			"digitalocean": func(resources []plannedResource) bool {
+				return anyPlannedResource(resources, "digitalocean_loadbalancer", func(values map[string]interface{}) bool {
+					return values["redirect_http_to_https"] == true
+				})
+			},
+		},
+	},
+}
+
+// anyPlannedResource indica se algum recurso do tipo satisfaz a condição
+func anyPlannedResource(resources []plannedResource, resourceType string, match func(values map[string]interface{}) bool) bool {
+	for _, resource := range resources {
+		if resource.Type == resourceType && match(resource.Values) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyPlannedBlock indica se algum bloco aninhado (lista de objetos no JSON do plano) de um recurso do tipo satisfaz a condição
+func anyPlannedBlock(resources []plannedResource, resourceType, block string, match func(values map[string]interface{}) bool) bool {
+	return anyPlannedResource(resources, resourceType, func(values map[string]interface{}) bool {
+		items, _ := values[block].([]interface{})
+		for _, item := range items {
+			if nested, ok := item.(map[string]interface{}); ok && match(nested) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func planString(values map[string]interface{}, key string) string {
+	value, _ := values[key].(string)
+	return value
+}
+
+// Estados de uma célula da matriz de paridade
+const (
+	lbParityHonored  = "honrado"
+	lbParityRejected = "rejeitado"
+	lbParityDropped  = "descartado"
+	lbParityFailed   = "erro"
+	lbParitySkipped  = "não verificado"
+)
+
+// lbParityCell é o resultado de pedir um recurso a um provedor
+type lbParityCell struct {
+	Status string
+	Detail string
+}
+
+// Mensagem da precondition null_resource.feature_support em modules/load_balancing/main
+var lbUnsupportedPattern = regexp.MustCompile(`Recursos não suportados pelo provedor (\w+): ([a-z_, ]+)\.`)
+
+// normalizeTerraformOutput junta as linhas das mensagens de erro, que o Terraform quebra e prefixa com "│"
+func normalizeTerraformOutput(output string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(output, "│", " ")), " ")
+}
+
+// classifyLBParity interpreta o plano de um provedor com um recurso pedido: o recurso precisa
+// aparecer nos recursos planejados ou ser rejeitado pelo módulo; aceitar o plano sem ele é descartá-lo
+func classifyLBParity(feature lbFeature, provider, planJSON string, planErr error) lbParityCell {
+	if planErr != nil {
+		message := normalizeTerraformOutput(planErr.Error())
+		if match := lbUnsupportedPattern.FindStringSubmatch(message); match != nil && match[1] == provider {
+			for _, name := range strings.Split(match[2], ",") {
+				if strings.TrimSpace(name) == feature.Name {
+					return lbParityCell{Status: lbParityRejected, Detail: match[0]}
+				}
+			}
+			return lbParityCell{Status: lbParityFailed, Detail: fmt.Sprintf("rejeitado sem citar %s: %s", feature.Name, match[0])}
+		}
+		return lbParityCell{Status: lbParityFailed, Detail: terraformErrorSummary(message)}
+	}
+
+	resources, err := loadPlannedResources([]byte(planJSON))
+	if err != nil {
+		return lbParityCell{Status: lbParityFailed, Detail: err.Error()}
+	}
+	if honored, ok := feature.Honored[provider]; ok && honored(resources) {
+		return lbParityCell{Status: lbParityHonored}
+	}
+	return lbParityCell{Status: lbParityDropped, Detail: "plano aceito sem a configuração correspondente"}
+}
+
+// terraformErrorSummary retorna o trecho a partir do primeiro "Error:" da saída normalizada
+func terraformErrorSummary(message string) string {
+	if index := strings.Index(message, "Error:"); index >= 0 {
+		message = message[index:]
+	}
+	if len(message) > 300 {
+		message = message[:300] + "..."
+	}
+	return message
+}
+
+// lbParityMatrix guarda o resultado de cada recurso (linhas) em cada provedor (colunas)
+type lbParityMatrix struct {
+	Providers []string
+	Features  []lbFeature
+	Cells     map[string]map[string]lbParityCell
+}
+
+func newLBParityMatrix(providers []string, features []lbFeature) *lbParityMatrix {
+	return &lbParityMatrix{Providers: providers, Features: features, Cells: map[string]map[string]lbParityCell{}}
+}
+
+func (m *lbParityMatrix) Set(feature, provider string, cell lbParityCell) {
+	if m.Cells[feature] == nil {
+		m.Cells[feature] = map[string]lbParityCell{}
+	}
+	m.Cells[feature][provider] = cell
+}
+
+func (m *lbParityMatrix) Get(feature, provider string) lbParityCell {
+	cell, ok := m.Cells[feature][provider]
+	if !ok {
+		return lbParityCell{Status: lbParitySkipped}
+	}
+	return cell
+}
+
+// Problems lista as células que quebram o contrato da interface: recursos descartados em silêncio e planos com erro
+func (m *lbParityMatrix) Problems() []string {
+	var problems []string
+	for _, feature := range m.Features {
+		for _, provider := range m.Providers {
+			cell := m.Get(feature.Name, provider)
+			if cell.Status == lbParityDropped || cell.Status == lbParityFailed {
+				problems = append(problems, fmt.Sprintf("%s em %s: %s (%s)", feature.Name, provider, cell.Status, cell.Detail))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// String formata a matriz como tabela Markdown
+func (m *lbParityMatrix) String() string {
+	var builder strings.Builder
+	builder.WriteString("| Recurso | " + strings.Join(m.Providers, " | ") + " |\n")
+	builder.WriteString("|---|" + strings.Repeat("---|", len(m.Providers)) + "\n")
+	for _, feature := range m.Features {
+		row := []string{feature.Description}
+		for _, provider := range m.Providers {
+			row = append(row, m.Get(feature.Name, provider).Status)
+		}
+		builder.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	return builder.String()
+}
+
+// Provedores com credenciais fictícias: o plano não consulta as APIs para os recursos do módulo
+var lbParityProviderConfigs = map[string]string{
+	"aws": `provider "aws" {
+  region                      = "us-east-1"
+  access_key                  = "parity"
+  secret_key                  = "parity"
+  skip_credentials_validation = true
+  skip_requesting_account_id  = true
+  skip_metadata_api_check     = true
+}
+`,
+	"gcp": `provider "google" {
+  project      = "parity-project"
+  region       = "us-central1"
+  access_token = "parity"
+}
+`,
+	"digitalocean": `provider "digitalocean" {
+  token = "parity"
+}
+`,
+	// O azurerm autentica já na configuração do provider, então exige credenciais reais (ARM_*)
+	"azure": `provider "azurerm" {
+  features {}
+}
+`,
+}
+
+const lbParityRootTF = `variable "lb" {
+  type = any
+}
+
+module "load_balancing" {
+  source = "./modules/load_balancing/main"
+
+  provider_name              = var.lb.provider_name
+  name                       = var.lb.name
+  region                     = var.lb.region
+  environment                = var.lb.environment
+  vpc_id                     = try(var.lb.vpc_id, "")
+  subnet_ids                 = try(var.lb.subnet_ids, [])
+  target_groups              = var.lb.target_groups
+  algorithm                  = try(var.lb.algorithm, "round_robin")
+  forwarding_rules           = var.lb.forwarding_rules
+  healthcheck                = var.lb.healthcheck
+  sticky_sessions            = try(var.lb.sticky_sessions, { enabled = false })
+  redirect_http_to_https     = try(var.lb.redirect_http_to_https, false)
+  ssl_certificate_id         = var.lb.ssl_certificate_id
+  enable_deletion_protection = var.lb.enable_deletion_protection
+  gcp_project_id             = try(var.lb.gcp_project_id, "")
+  resource_group_name        = try(var.lb.resource_group_name, "")
+  azure_location             = try(var.lb.azure_location, "")
+  azure_virtual_network_name = try(var.lb.azure_virtual_network_name, "")
+  azure_subnet_name          = try(var.lb.azure_subnet_name, "")
+}
+`
+
+// prepareLBParityRoot monta em dir uma raiz Terraform que chama modules/load_balancing/main para
+// o provedor informado. As implementações dos demais provedores são trocadas por módulos só com
+// variáveis, para que o plano não precise configurar providers que não serão usados.
+func prepareLBParityRoot(dir, provider string) error {
+	modulesDir := filepath.Join(dir, "modules", "load_balancing")
+	if err := files.CopyFolderContents(filepath.Join("..", "modules", "load_balancing", "main"), filepath.Join(modulesDir, "main")); err != nil {
+		return err
+	}
+
+	for _, other := range lbParityProviders {
+		source := filepath.Join("..", "modules", "load_balancing", lbProviderModules[other])
+		target := filepath.Join(modulesDir, lbProviderModules[other])
+		if other == provider {
+			if err := files.CopyFolderContents(source, target); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeLBStubModule(source, target); err != nil {
+			return err
+		}
+	}
+
+	versions, err := os.ReadFile(filepath.Join("..", "versions.tf"))
+	if err != nil {
+		return err
+	}
+	files := map[string]string{
+		"versions.tf":  string(versions),
+		"providers.tf": lbParityProviderConfigs[provider],
+		"main.tf":      lbParityRootTF,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLBStubModule cria um módulo com as variáveis da implementação original e saídas nulas
+func writeLBStubModule(source, target string) error {
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	variables, err := os.ReadFile(filepath.Join(source, "variables.tf"))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(target, "variables.tf"), variables, 0644); err != nil {
+		return err
+	}
+
+	outputs, err := os.ReadFile(filepath.Join(source, "outputs.tf"))
+	if err != nil {
+		return err
+	}
+	var stub strings.Builder
+	for _, match := range regexp.MustCompile(`(?m)^output\s+"([^"]+)"`).FindAllStringSubmatch(string(outputs), -1) {
+		fmt.Fprintf(&stub, "output %q {\n  value = null\n}\n\n", match[1])
+	}
+	return os.WriteFile(filepath.Join(target, "outputs.tf"), []byte(stub.String()), 0644)
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadBalancingParity planeja a mesma entrada abstrata de modules/load_balancing/main em cada
+// provedor, pedindo um recurso por vez, e falha quando um provedor aceita o plano sem honrá-lo
+func TestLoadBalancingParity(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("Este teste requer terraform instalado")
+	}
+
+	matrix := newLBParityMatrix(lbParityProviders, lbFeatures)
+	for _, provider := range lbParityProviders {
+		// O azurerm valida as credenciais ao configurar o provider, mesmo só para o plano
+		if provider == "azure" && os.Getenv("ARM_SUBSCRIPTION_ID") == "" {
+			t.Logf("ARM_SUBSCRIPTION_ID não definido, coluna azure não verificada")
+			continue
+		}
+
+		dir := t.TempDir()
+		if err := prepareLBParityRoot(dir, provider); err != nil {
+			t.Fatalf("Erro ao preparar o módulo para %s: %v", provider, err)
+		}
+
+		// Sem nenhum recurso pedido o plano precisa passar e nenhum detector pode disparar
+		baseline, err := planLBParity(t, dir, lbParityVars(provider))
+		if err != nil {
+			t.Fatalf("Plano base de %s falhou: %v", provider, err)
+		}
+		for _, feature := range lbFeatures {
+			cell := classifyLBParity(feature, provider, baseline, nil)
+			assert.NotEqual(t, lbParityHonored, cell.Status, "%s detectado em %s sem ter sido pedido", feature.Name, provider)
+		}
+
+		for _, feature := range lbFeatures {
+			vars := lbParityVars(provider)
+			feature.Request(vars)
+			planJSON, err := planLBParity(t, dir, vars)
+			matrix.Set(feature.Name, provider, classifyLBParity(feature, provider, planJSON, err))
+		}
+	}
+
+	t.Logf("Matriz de paridade do load balancing:\n%s", matrix)
+	for _, problem := range matrix.Problems() {
+		assert.Fail(t, "Recurso sem paridade", problem)
+	}
+}
+
+// planLBParity roda init, plan e show -json na raiz preparada por prepareLBParityRoot
+func planLBParity(t *testing.T, dir string, vars map[string]interface{}) (string, error) {
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
+		TerraformDir: dir,
+		Vars:         map[string]interface{}{"lb": vars},
+		PlanFilePath: "parity.tfplan",
+		NoColor:      true,
+	})
+	return terraform.InitAndPlanAndShowE(t, terraformOptions)
+}
+
+// TestLBParityClassification confere a classificação das células com planos de exemplo
+func TestLBParityClassification(t *testing.T) {
+	t.Parallel()
+
+	features := map[string]lbFeature{}
+	for _, feature := range lbFeatures {
+		features[feature.Name] = feature
+	}
+
+	doPlan := `{"planned_values": {"root_module": {"child_modules": [{"resources": [{
+		"address": "module.load_balancing.module.digitalocean_lb[0].digitalocean_loadbalancer.main",
+		"mode": "managed", "type": "digitalocean_loadbalancer", "name": "main",
+		"values": {
+			"algorithm": "least_connections",
+			"redirect_http_to_https": false,
+			"forwarding_rule": [
+				{"entry_port": 80, "entry_protocol": "http", "tls_passthrough": false},
+				{"entry_port": 443, "entry_protocol": "https", "tls_passthrough": false, "certificate_id": "00000000-0000-0000-0000-000000000000"}
+			],
+			"sticky_sessions": [{"type": "none"}]
+		}
+	}]}]}}}`
+
+	assert.Equal(t, lbParityHonored, classifyLBParity(features["https"], "digitalocean", doPlan, nil).Status)
+	assert.Equal(t, lbParityDropped, classifyLBParity(features["sticky_sessions"], "digitalocean", doPlan, nil).Status)
+	assert.Equal(t, lbParityDropped, classifyLBParity(features["redirect_http_to_https"], "digitalocean", doPlan, nil).Status)
+	// algorithm aparece no plano, mas não é honrado pela API do DigitalOcean
+	assert.Equal(t, lbParityDropped, classifyLBParity(features["least_connections"], "digitalocean", doPlan, nil).Status)
+
+	awsPlan := `{"planned_values": {"root_module": {"child_modules": [{"resources": [
+		{"address": "aws_lb_listener.http[0]", "mode": "managed", "type": "aws_lb_listener", "name": "http",
+		 "values": {"port": 80, "protocol": "HTTP", "default_action": [{"type": "redirect", "redirect": [{"protocol": "HTTPS", "port": "443"}]}]}},
+		{"address": "aws_lb_target_group.main[0]", "mode": "managed", "type": "aws_lb_target_group", "name": "main",
+		 "values": {"load_balancing_algorithm_type": "round_robin", "stickiness": [{"enabled": true, "type": "lb_cookie"}]}}
+	]}]}}}`
+
+	assert.Equal(t, lbParityHonored, classifyLBParity(features["redirect_http_to_https"], "aws", awsPlan, nil).Status)
+	assert.Equal(t, lbParityHonored, classifyLBParity(features["sticky_sessions"], "aws", awsPlan, nil).Status)
+	assert.Equal(t, lbParityDropped, classifyLBParity(features["least_connections"], "aws", awsPlan, nil).Status)
+
+	// A mensagem da precondition chega quebrada em linhas e prefixada com "│"
+	rejection := errors.New("╷\n│ Error: Resource precondition failed\n│ \n│ Recursos não suportados pelo provedor azure: https,\n│ sticky_sessions.\n╵")
+	cell := classifyLBParity(features["sticky_sessions"], "azure", "", rejection)
+	assert.Equal(t, lbParityRejected, cell.Status)
+	assert.Equal(t, lbParityFailed, classifyLBParity(features["least_connections"], "azure", "", rejection).Status, "Rejeição que não cita o recurso é erro")
+	assert.Equal(t, lbParityFailed, classifyLBParity(features["https"], "gcp", "", errors.New("Error: Invalid value for input variable")).Status)
+
+	matrix := newLBParityMatrix([]string{"azure", "digitalocean"}, lbFeatures[:2])
+	matrix.Set("https", "azure", cell)
+	matrix.Set("https", "digitalocean", lbParityCell{Status: lbParityDropped, Detail: "plano aceito sem a configuração correspondente"})
+	assert.Contains(t, matrix.String(), "| Terminação HTTPS | rejeitado | descartado |")
+	assert.Contains(t, matrix.String(), "| TLS passthrough | não verificado | não verificado |")
+	assert.Equal(t, []string{"https em digitalocean: descartado (plano aceito sem a configuração correspondente)"}, matrix.Problems())
+}
+
+// TestLBParityRoot confere que a raiz gerada usa a implementação real só do provedor testado
+func TestLBParityRoot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := prepareLBParityRoot(dir, "digitalocean"); err != nil {
+		t.Fatalf("Erro ao preparar o módulo: %v", err)
+	}
+
+	real, err := os.ReadFile(filepath.Join(dir, "modules", "load_balancing", "digital-ocean", "main.tf"))
+	if err != nil {
+		t.Fatalf("Implementação do DigitalOcean não copiada: %v", err)
+	}
+	assert.Contains(t, string(real), "digitalocean_loadbalancer")
+
+	for _, stub := range []string{"aws", "gcp", "azure"} {
+		_, err := os.Stat(filepath.Join(dir, "modules", "load_balancing", stub, "main.tf"))
+		assert.True(t, os.IsNotExist(err), "%s deveria ser um módulo sem recursos", stub)
+
+		outputs, err := os.ReadFile(filepath.Join(dir, "modules", "load_balancing", stub, "outputs.tf"))
+		if err != nil {
+			t.Fatalf("Saídas do módulo %s não geradas: %v", stub, err)
+		}
+		assert.Contains(t, string(outputs), "value = null")
+	}
+}
@@ -15,7 +15,7 @@ func TestLoadBalancingAbstractModule(t *testing.T) {
 	testName := "lb-test"
 	envName := "test"
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../modules/load_balancing/main",
 		Vars: map[string]interface{}{
 			"name":        testName,
@@ -52,7 +52,7 @@ func TestLoadBalancingDigitalOcean(t *testing.T) {
 	// Skip este teste se não houver credenciais configuradas
 	t.Skip("Este teste requer credenciais do Digital Ocean configuradas")
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../modules/load_balancing/digital-ocean",
 		Vars: map[string]interface{}{
 			"name":        "do-test-lb",
@@ -82,7 +82,7 @@ func TestLoadBalancingAzure(t *testing.T) {
 
 	// Normalmente teríamos recursos pré-existentes como grupo de recursos e VNet
 	// Para testes, podemos usar valores fictícios
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../modules/load_balancing/azure",
 		Vars: map[string]interface{}{
 			"name":                "azure-test-lb",
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// TestLocalKubernetesConfig confere kubernetes.local do ambiente dev, o arquivo do kind e a ligação
+// entre o cluster e o módulo raiz no modo local-k8s, sem criar o cluster
+func TestLocalKubernetesConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadEnvironmentConfig("dev")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	assert.True(t, cfg.Kubernetes.Local.EnableKind)
+	assert.Equal(t, "boilerplate-nestjs-dev", cfg.Kubernetes.Local.KindClusterName)
+
+	configPath := kindConfigPath("dev", cfg)
+	assert.Equal(t, filepath.Join("..", "environments", "dev", "kind-config.yaml"), configPath)
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Erro ao ler a configuração do kind: %v", err)
+	}
+	var kindConfig struct {
+		Kind       string `yaml:"kind"`
+		APIVersion string `yaml:"apiVersion"`
+		Nodes      []struct {
+			Role string `yaml:"role"`
+		} `yaml:"nodes"`
+	}
+	if err := yaml.Unmarshal(content, &kindConfig); err != nil {
+		t.Fatalf("Erro ao interpretar a configuração do kind: %v", err)
+	}
+	assert.Equal(t, "Cluster", kindConfig.Kind)
+	assert.Equal(t, "kind.x-k8s.io/v1alpha4", kindConfig.APIVersion)
+	if assert.NotEmpty(t, kindConfig.Nodes) {
+		assert.Equal(t, "control-plane", kindConfig.Nodes[0].Role)
+	}
+
+	cluster := &kindCluster{Name: "boilerplate-nestjs-dev-abc123", KubeconfigPath: "/tmp/kind/kubeconfig"}
+	assert.Equal(t, map[string]string{
+		"KUBE_CONFIG_PATH": "/tmp/kind/kubeconfig",
+		"KUBE_CTX":         "kind-boilerplate-nestjs-dev-abc123",
+	}, cluster.TerraformEnvVars())
+
+	options := cluster.TerraformOptions(t, t.TempDir(), nil)
+	assert.Equal(t, cluster.TerraformEnvVars(), options.EnvVars)
+	assert.Equal(t, retryableErrorCatalog["local-k8s"].MaxRetries, options.MaxRetries)
+
+	// Variáveis do modo local-k8s declaradas no módulo raiz
+	variables, err := loadModuleVariables("..")
+	if err != nil {
+		t.Fatalf("Erro ao ler as variáveis do módulo raiz: %v", err)
+	}
+	for name := range cluster.RootTerraformVars("dev") {
+		assert.Contains(t, variables, name)
+	}
+
+	graph, err := loadModuleGraph("root", "..")
+	if err != nil {
+		t.Fatalf("Erro ao montar o grafo do módulo raiz: %v", err)
+	}
+	grafana, ok := graph.Nodes["module.monitoring_grafana_local"]
+	if assert.True(t, ok, "módulo do Grafana no modo local-k8s") {
+		assert.Contains(t, grafana.Count, `"local-k8s"`)
+		assert.Contains(t, grafana.Attributes["providers"], "helm.primary")
+		assert.Contains(t, grafana.Attributes["providers"], "kubernetes.primary")
+	}
+	rbac, ok := graph.Nodes["module.kubernetes_rbac_local"]
+	if assert.True(t, ok, "módulo de RBAC no modo local-k8s") {
+		assert.Contains(t, rbac.Count, `"local-k8s"`)
+		assert.Contains(t, rbac.Attributes["providers"], "kubernetes.primary")
+		for _, name := range []string{"rbac_groups", "rbac_namespaces", "rbac_restrict_to_namespaces"} {
+			assert.Contains(t, variables, name)
+			assert.Contains(t, rbac.Attributes[name], "var."+name)
+		}
+	}
+}
+
+// TestLocalKubernetesRoot cria o cluster kind de kubernetes.local do ambiente dev e aplica o módulo
+// raiz com active_provider = "local-k8s", que implanta o Grafana pelos providers kubernetes e helm.
+// Com GRAFANA_CHART_PATH apontando para um chart baixado e TF_PROVIDER_MIRROR para o espelho de
+// providers, o teste roda sem acesso à internet
+func TestLocalKubernetesRoot(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("Este teste requer terraform instalado")
+	}
+	cluster := createEnvironmentKindCluster(t, "dev")
+	kubectlOptions := cluster.KubectlOptions("monitoring")
+
+	nodes, err := k8s.RunKubectlAndGetOutputE(t, cluster.KubectlOptions(""), "get", "nodes", "-l", "environment=dev", "-o", "name")
+	if err != nil {
+		t.Fatalf("Erro ao listar os nós: %v", err)
+	}
+	assert.Len(t, strings.Fields(nodes), 2, "nós criados a partir de kind_config_path")
+
+	dir := test_structure.CopyTerraformFolderToTemp(t, "..", ".")
+	vars := cluster.RootTerraformVars("dev")
+	if chart := os.Getenv("GRAFANA_CHART_PATH"); chart != "" {
+		vars["grafana_chart_path"] = chart
+	}
+
+	options := cluster.TerraformOptions(t, dir, vars)
+	options.PluginDir = os.Getenv("TF_PROVIDER_MIRROR")
+
+	// Sem terraform destroy: o namespace monitoring tem prevent_destroy, e o cluster inteiro é
+	// removido ao final do teste
+	terraform.InitAndApply(t, options)
+
+	k8s.WaitUntilDeploymentAvailable(t, kubectlOptions, "grafana", 30, 10*time.Second)
+	for _, configMap := range []string{"grafana-datasources", "grafana-dashboards-provider", "infrastructure-dashboard"} {
+		_, err := k8s.RunKubectlAndGetOutputE(t, kubectlOptions, "get", "configmap", configMap)
+		assert.NoError(t, err, configMap)
+	}
+}
@@ -0,0 +1,428 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/shell"
+)
+
+// localModuleDir é o módulo que cria o ambiente local com Docker
+const localModuleDir = "../modules/local"
+
+// localService é um contêiner criado por modules/local
+type localService struct {
+	Name         string
+	Container    string
+	Enabled      bool
+	InternalPort int
+	ExternalPort int
+}
+
+// localStack descreve o que modules/local deve ter criado para um conjunto de variáveis, com os
+// padrões de variables.tf para as que não foram informadas
+type localStack struct {
+	ProjectName       string
+	Environment       string
+	NetworkName       string
+	PostgresUser      string
+	PostgresPassword  string
+	PostgresDB        string
+	AppInternalPort   int
+	AdditionalEnvVars []string
+	PgAdminEmail      string
+	PgAdminPassword   string
+	Services          []localService
+	// Host onde as portas dos contêineres são publicadas
+	Host string
+}
+
+// newLocalStack combina as variáveis do teste com os padrões declarados no módulo
+func newLocalStack(moduleDir string, vars map[string]interface{}) (*localStack, error) {
+	variables, err := loadModuleVariables(moduleDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []string
+	value := func(name string) string {
+		if v, ok := vars[name]; ok {
+			return fmt.Sprint(v)
+		}
+		variable, ok := variables[name]
+		if !ok || variable.Required {
+			errs = append(errs, fmt.Sprintf("%s não informada", name))
+			return ""
+		}
+		if unquoted, err := strconv.Unquote(variable.Default); err == nil {
+			return unquoted
+		}
+		return variable.Default
+	}
+	number := func(name string) int {
+		n, err := strconv.Atoi(value(name))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s não é um número", name))
+		}
+		return n
+	}
+	flag := func(name string) bool {
+		return value(name) == "true"
+	}
+
+	stack := &localStack{
+		ProjectName:      value("project_name"),
+		Environment:      value("environment"),
+		NetworkName:      value("network_name"),
+		PostgresUser:     value("postgres_user"),
+		PostgresPassword: value("postgres_password"),
+		PostgresDB:       value("postgres_db"),
+		AppInternalPort:  number("app_internal_port"),
+		PgAdminEmail:     value("pgadmin_email"),
+		PgAdminPassword:  value("pgadmin_password"),
+		Host:             "localhost",
+	}
+	switch extra := vars["additional_environment_vars"].(type) {
+	case []string:
+		stack.AdditionalEnvVars = extra
+	case []interface{}:
+		for _, entry := range extra {
+			stack.AdditionalEnvVars = append(stack.AdditionalEnvVars, fmt.Sprint(entry))
+		}
+	}
+
+	enabled := flag("enabled")
+	prefix := stack.ProjectName + "-" + stack.Environment
+	stack.Services = []localService{
+		{"postgres", prefix + "-db", enabled && flag("create_database"), 5432, number("postgres_port")},
+		{"app", prefix + "-app", enabled && flag("create_app"), stack.AppInternalPort, number("app_external_port")},
+		{"redis", prefix + "-redis", enabled && flag("create_redis"), 6379, number("redis_port")},
+		{"pgadmin", prefix + "-pgadmin", enabled && flag("create_pgadmin"), 80, number("pgadmin_port")},
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("variáveis de %s: %s", moduleDir, strings.Join(errs, ", "))
+	}
+	return stack, nil
+}
+
+// Service retorna o serviço pelo nome (postgres, app, redis ou pgadmin)
+func (s *localStack) Service(name string) localService {
+	for _, service := range s.Services {
+		if service.Name == name {
+			return service
+		}
+	}
+	return localService{}
+}
+
+// DatabaseURL é a URL que o contêiner da aplicação recebe, apontando para o banco pela rede Docker
+func (s *localStack) DatabaseURL() string {
+	return fmt.Sprintf("postgresql://%s:%s@%s:5432/%s", s.PostgresUser, s.PostgresPassword, s.Service("postgres").Container, s.PostgresDB)
+}
+
+// containerInspect é o subconjunto da saída de `docker inspect` usado na verificação
+type containerInspect struct {
+	Name  string `json:"Name"`
+	State struct {
+		Running bool   `json:"Running"`
+		Status  string `json:"Status"`
+	} `json:"State"`
+	Config struct {
+		Env []string `json:"Env"`
+	} `json:"Config"`
+	HostConfig struct {
+		RestartPolicy struct {
+			Name string `json:"Name"`
+		} `json:"RestartPolicy"`
+		PortBindings map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"PortBindings"`
+	} `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]json.RawMessage `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// dockerInspector retorna o contêiner pelo nome, ou nil se ele não existir
+type dockerInspector func(name string) (*containerInspect, error)
+
+// dockerCLIInspector consulta os contêineres com `docker inspect`
+func dockerCLIInspector(t *testing.T) dockerInspector {
+	return func(name string) (*containerInspect, error) {
+		output, err := shell.RunCommandAndGetStdOutE(t, shell.Command{
+			Command: "docker",
+			Args:    []string{"inspect", "--type", "container", name},
+		})
+		if err != nil {
+			if strings.Contains(output, "[]") || strings.Contains(err.Error(), "No such") {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return parseContainerInspect(output)
+	}
+}
+
+// parseContainerInspect interpreta a saída de `docker inspect`, uma lista com um contêiner
+func parseContainerInspect(output string) (*containerInspect, error) {
+	var containers []containerInspect
+	if err := json.Unmarshal([]byte(output), &containers); err != nil {
+		return nil, fmt.Errorf("saída inválida do docker inspect: %v", err)
+	}
+	if len(containers) == 0 {
+		return nil, nil
+	}
+	return &containers[0], nil
+}
+
+// localStackVerifier confere cada serviço de um localStack: contêiner em execução, rede, política de
+// reinício e porta publicada, variáveis de ambiente da aplicação, PING no Redis e login no pgAdmin
+type localStackVerifier struct {
+	Stack   *localStack
+	Inspect dockerInspector
+	// Redis e pgAdmin levam alguns segundos para aceitar conexões depois que o contêiner sobe
+	MaxRetries         int
+	TimeBetweenRetries time.Duration
+}
+
+// newLocalStackVerifier cria o verificador que consulta o Docker local
+func newLocalStackVerifier(t *testing.T, stack *localStack) *localStackVerifier {
+	return &localStackVerifier{
+		Stack:              stack,
+		Inspect:            dockerCLIInspector(t),
+		MaxRetries:         20,
+		TimeBetweenRetries: 3 * time.Second,
+	}
+}
+
+// Restart policy definida em todos os contêineres de modules/local
+const localRestartPolicy = "unless-stopped"
+
+// Verify retorna os problemas encontrados, um por linha, prefixados pelo serviço
+func (v *localStackVerifier) Verify(t *testing.T) []string {
+	var issues []string
+	for _, service := range v.Stack.Services {
+		for _, issue := range v.verifyService(t, service) {
+			issues = append(issues, fmt.Sprintf("%s (%s): %s", service.Name, service.Container, issue))
+		}
+	}
+	return issues
+}
+
+func (v *localStackVerifier) verifyService(t *testing.T, service localService) []string {
+	container, err := v.Inspect(service.Container)
+	if err != nil {
+		return []string{fmt.Sprintf("erro no docker inspect: %v", err)}
+	}
+	if !service.Enabled {
+		if container != nil {
+			return []string{"serviço desabilitado, mas o contêiner existe"}
+		}
+		return nil
+	}
+	if container == nil {
+		return []string{"contêiner não encontrado"}
+	}
+
+	var issues []string
+	if !container.State.Running {
+		issues = append(issues, fmt.Sprintf("contêiner não está em execução (%s)", container.State.Status))
+	}
+	if _, ok := container.NetworkSettings.Networks[v.Stack.NetworkName]; !ok {
+		var networks []string
+		for network := range container.NetworkSettings.Networks {
+			networks = append(networks, network)
+		}
+		sort.Strings(networks)
+		issues = append(issues, fmt.Sprintf("fora da rede %s (redes: %s)", v.Stack.NetworkName, strings.Join(networks, ", ")))
+	}
+	if policy := container.HostConfig.RestartPolicy.Name; policy != localRestartPolicy {
+		issues = append(issues, fmt.Sprintf("restart policy %q, esperado %q", policy, localRestartPolicy))
+	}
+	if !publishes(container, service.InternalPort, service.ExternalPort) {
+		issues = append(issues, fmt.Sprintf("porta %d do contêiner não publicada na porta %d do host", service.InternalPort, service.ExternalPort))
+	}
+
+	switch service.Name {
+	case "app":
+		issues = append(issues, v.verifyAppEnv(container)...)
+	case "redis":
+		if err := v.retry(t, "PING no Redis", func() error { return redisPing(v.address(service)) }); err != nil {
+			issues = append(issues, err.Error())
+		}
+	case "pgadmin":
+		endpoint := fmt.Sprintf("http://%s", v.address(service))
+		if err := v.retry(t, "Login no pgAdmin", func() error {
+			return pgAdminLogin(endpoint, v.Stack.PgAdminEmail, v.Stack.PgAdminPassword)
+		}); err != nil {
+			issues = append(issues, err.Error())
+		}
+	}
+	return issues
+}
+
+// verifyAppEnv confere as variáveis de ambiente que main.tf passa para o contêiner da aplicação
+func (v *localStackVerifier) verifyAppEnv(container *containerInspect) []string {
+	env := map[string]string{}
+	for _, entry := range container.Config.Env {
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			env[name] = value
+		}
+	}
+
+	expected := []string{
+		"NODE_ENV=" + v.Stack.Environment,
+		"DATABASE_URL=" + v.Stack.DatabaseURL(),
+		"PORT=" + strconv.Itoa(v.Stack.AppInternalPort),
+	}
+	expected = append(expected, v.Stack.AdditionalEnvVars...)
+
+	var issues []string
+	for _, entry := range expected {
+		name, value, _ := strings.Cut(entry, "=")
+		actual, ok := env[name]
+		switch {
+		case !ok:
+			issues = append(issues, fmt.Sprintf("variável %s ausente", name))
+		case actual != value && name == "DATABASE_URL":
+			// A URL contém a senha do banco, que não deve aparecer no log
+			issues = append(issues, fmt.Sprintf("DATABASE_URL aponta para %s, esperado %s", redactURL(actual), redactURL(value)))
+		case actual != value:
+			issues = append(issues, fmt.Sprintf("variável %s=%q, esperado %q", name, actual, value))
+		}
+	}
+	return issues
+}
+
+func (v *localStackVerifier) address(service localService) string {
+	return net.JoinHostPort(v.Stack.Host, strconv.Itoa(service.ExternalPort))
+}
+
+func (v *localStackVerifier) retry(t *testing.T, description string, check func() error) error {
+	_, err := retry.DoWithRetryE(t, description, v.MaxRetries, v.TimeBetweenRetries, func() (string, error) {
+		return "", check()
+	})
+	return err
+}
+
+// publishes verifica se a porta interna está publicada na porta informada do host
+func publishes(container *containerInspect, internal, external int) bool {
+	for _, binding := range container.HostConfig.PortBindings[fmt.Sprintf("%d/tcp", internal)] {
+		if binding.HostPort == strconv.Itoa(external) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL remove a senha de uma URL de conexão
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "(URL inválida)"
+	}
+	return parsed.Redacted()
+}
+
+// redisPing envia PING no protocolo do Redis (RESP) e espera +PONG
+func redisPing(address string) error {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := io.WriteString(conn, "*1\r\n$4\r\nPING\r\n"); err != nil {
+		return err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("sem resposta ao PING: %v", err)
+	}
+	if reply = strings.TrimSpace(reply); reply != "+PONG" {
+		return fmt.Errorf("resposta inesperada ao PING: %s", reply)
+	}
+	return nil
+}
+
+// O token CSRF aparece como campo oculto do formulário (pgAdmin 4 até a versão 6) ou na
+// configuração JavaScript da página de login (versões com a interface em React)
+var pgAdminCSRFToken = []*regexp.Regexp{
+	regexp.MustCompile(`name="csrf_token"[^>]*value="([^"]+)"`),
+	regexp.MustCompile(`value="([^"]+)"[^>]*name="csrf_token"`),
+	regexp.MustCompile(`["']?csrf_?[tT]oken["']?\s*[:=]\s*["']([^"']+)["']`),
+}
+
+// pgAdminLogin entra no pgAdmin com e-mail e senha: busca o token CSRF em /login e envia o
+// formulário para /authenticate/login. O login é aceito quando o redirecionamento não volta para
+// a página de login
+func pgAdminLogin(endpoint, email, password string) error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Jar:     jar,
+		Timeout: 10 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	response, err := client.Get(endpoint + "/login")
+	if err != nil {
+		return err
+	}
+	page, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET /login retornou %d", response.StatusCode)
+	}
+
+	var token string
+	for _, pattern := range pgAdminCSRFToken {
+		if match := pattern.FindSubmatch(page); match != nil {
+			token = string(match[1])
+			break
+		}
+	}
+	if token == "" {
+		return fmt.Errorf("token CSRF não encontrado na página de login")
+	}
+
+	response, err = client.PostForm(endpoint+"/authenticate/login", url.Values{
+		"csrf_token": {token},
+		"email":      {email},
+		"password":   {password},
+		"language":   {"en"},
+	})
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	location := response.Header.Get("Location")
+	if response.StatusCode != http.StatusFound || strings.Contains(location, "/login") {
+		return fmt.Errorf("login no pgAdmin recusado para %s (status %d, redirecionado para %q)", email, response.StatusCode, location)
+	}
+	return nil
+}
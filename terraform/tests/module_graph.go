@@ -0,0 +1,485 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// moduleGraphNode é um bloco de uma raiz Terraform: módulo, recurso, data source, local ou output
+type moduleGraphNode struct {
+	ID   string
+	Kind string
+	File string
+	// Count é a expressão de count ou for_each, vazia quando o bloco não é indexado
+	Count string
+	// Attributes guarda as expressões do bloco, usadas na checagem de referências indexadas
+	Attributes map[string]string
+}
+
+// moduleGraphEdge liga um bloco a outro de que ele depende; Explicit marca entradas de depends_on
+type moduleGraphEdge struct {
+	From     string
+	To       string
+	Explicit bool
+}
+
+// moduleGraph é o grafo de dependências de uma raiz, montado a partir do HCL ou de `terraform graph`
+type moduleGraph struct {
+	Name  string
+	Nodes map[string]*moduleGraphNode
+	Edges []moduleGraphEdge
+}
+
+func newModuleGraph(name string) *moduleGraph {
+	return &moduleGraph{Name: name, Nodes: map[string]*moduleGraphNode{}}
+}
+
+// AddEdge acrescenta uma aresta, ignorando duplicadas e laços do bloco com ele mesmo
+func (g *moduleGraph) AddEdge(from, to string, explicit bool) {
+	if from == to {
+		return
+	}
+	for _, edge := range g.Edges {
+		if edge.From == from && edge.To == to && edge.Explicit == explicit {
+			return
+		}
+	}
+	g.Edges = append(g.Edges, moduleGraphEdge{From: from, To: to, Explicit: explicit})
+}
+
+var (
+	hclTopLevelBlock    = regexp.MustCompile(`(?m)^(resource|data|module|output|locals)((?:[ \t]+"[^"]*")*)[ \t]*\{`)
+	hclBlockLabel       = regexp.MustCompile(`"([^"]*)"`)
+	hclAttributeStart   = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+)\s*=(?:[^=]|$)`)
+	hclNestedBlockStart = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+)(?:\s+"[^"]*")*\s*\{`)
+	hclReference        = regexp.MustCompile(`(?:^|[^\w.])(module\.[A-Za-z0-9_-]+|data\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+|local\.[A-Za-z0-9_-]+|[a-z][a-z0-9]*_[a-z0-9_]+\.[A-Za-z0-9_-]+)`)
+	hclIndexedReference = regexp.MustCompile(`(module\.[A-Za-z0-9_-]+|[a-z][a-z0-9]*_[a-z0-9_]+\.[A-Za-z0-9_-]+)\[([^\]*][^\]]*)\]`)
+	hclActiveProvider   = regexp.MustCompile(`^local\.active_provider\s*==\s*"([^"]+)"\s*\?\s*1\s*:\s*0$`)
+)
+
+// hclAttributes separa o corpo de um bloco em atributos de primeiro nível; o conteúdo de blocos
+// aninhados (lifecycle, dynamic...) fica sob a chave "bloco <nome>"
+func hclAttributes(body string) map[string]string {
+	attributes := map[string]string{}
+	depth := 0
+	current := ""
+	for _, line := range strings.Split(body, "\n") {
+		if depth == 0 {
+			if match := hclAttributeStart.FindStringSubmatch(line); match != nil {
+				current = match[1]
+			} else if match := hclNestedBlockStart.FindStringSubmatch(line); match != nil {
+				current = "bloco " + match[1]
+			}
+		}
+		if current != "" {
+			attributes[current] += line + "\n"
+		}
+		depth += hclDepthChange(line)
+	}
+	for name, value := range attributes {
+		attributes[name] = strings.TrimSpace(value)
+	}
+	return attributes
+}
+
+// hclDepthChange conta a variação de chaves, colchetes e parênteses de uma linha, fora de strings
+func hclDepthChange(line string) int {
+	change := 0
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[' || c == '(':
+			change++
+		case c == '}' || c == ']' || c == ')':
+			change--
+		}
+	}
+	return change
+}
+
+// hclAttributeValue retorna a expressão do atributo, sem o "nome ="
+func hclAttributeValue(attribute string) string {
+	if index := strings.Index(attribute, "="); index >= 0 {
+		return strings.TrimSpace(attribute[index+1:])
+	}
+	return attribute
+}
+
+// loadModuleGraph monta o grafo de uma raiz Terraform a partir dos arquivos .tf do diretório
+// (sem descer nos módulos filhos): referências nas expressões viram arestas implícitas e
+// entradas de depends_on viram arestas explícitas
+func loadModuleGraph(name, dir string) (*moduleGraph, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	graph := newModuleGraph(name)
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		src := stripHCLComments(string(content))
+		for _, match := range hclTopLevelBlock.FindAllStringSubmatchIndex(src, -1) {
+			body, err := hclBlockBody(src, match[1]-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			kind := src[match[2]:match[3]]
+			var labels []string
+			for _, label := range hclBlockLabel.FindAllStringSubmatch(src[match[4]:match[5]], -1) {
+				labels = append(labels, label[1])
+			}
+			attributes := hclAttributes(body)
+
+			if kind == "locals" {
+				for local, expression := range attributes {
+					id := "local." + local
+					graph.Nodes[id] = &moduleGraphNode{ID: id, Kind: "local", File: filepath.Base(path), Attributes: map[string]string{local: expression}}
+				}
+				continue
+			}
+
+			node := &moduleGraphNode{Kind: kind, File: filepath.Base(path), Attributes: attributes}
+			switch {
+			case kind == "resource" && len(labels) == 2:
+				node.ID = labels[0] + "." + labels[1]
+			case kind == "data" && len(labels) == 2:
+				node.ID = "data." + labels[0] + "." + labels[1]
+			case (kind == "module" || kind == "output") && len(labels) == 1:
+				node.ID = kind + "." + labels[0]
+			default:
+				return nil, fmt.Errorf("%s: bloco %s com rótulos inesperados %v", path, kind, labels)
+			}
+			for _, key := range []string{"count", "for_each"} {
+				if expression, ok := attributes[key]; ok {
+					node.Count = strings.Join(strings.Fields(hclAttributeValue(expression)), " ")
+				}
+			}
+			graph.Nodes[node.ID] = node
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		for attribute, expression := range node.Attributes {
+			if attribute == "depends_on" {
+				for _, reference := range hclReference.FindAllStringSubmatch(expression, -1) {
+					graph.AddEdge(node.ID, reference[1], true)
+				}
+				continue
+			}
+			for _, reference := range hclReference.FindAllStringSubmatch(expression, -1) {
+				if _, ok := graph.Nodes[reference[1]]; ok {
+					graph.AddEdge(node.ID, reference[1], false)
+				}
+			}
+		}
+	}
+	sortModuleGraphEdges(graph.Edges)
+	return graph, nil
+}
+
+func sortModuleGraphEdges(edges []moduleGraphEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return !edges[i].Explicit && edges[j].Explicit
+	})
+}
+
+var (
+	dotEdgePattern = regexp.MustCompile(`^\s*"([^"]+)"\s*->\s*"([^"]+)"`)
+	dotNodeSuffix  = regexp.MustCompile(`\s+\([^)]*\)$`)
+	graphModuleID  = regexp.MustCompile(`^(module\.[A-Za-z0-9_-]+)(?:\[[^\]]*\])?`)
+)
+
+// normalizeGraphNode converte um nó de `terraform graph` no bloco da raiz correspondente. Nós
+// dentro de módulos são agrupados no módulo; providers, variáveis e nós internos são descartados.
+func normalizeGraphNode(node string) (string, string) {
+	node = strings.TrimPrefix(node, "[root] ")
+	node = dotNodeSuffix.ReplaceAllString(node, "")
+	if match := graphModuleID.FindStringSubmatch(node); match != nil {
+		return match[1], "module"
+	}
+	switch {
+	case strings.HasPrefix(node, "data."):
+		return node, "data"
+	case strings.HasPrefix(node, "local."):
+		return node, "local"
+	case strings.HasPrefix(node, "output."):
+		return node, "output"
+	case strings.HasPrefix(node, "provider"), strings.HasPrefix(node, "var."), strings.HasPrefix(node, "meta."), node == "root":
+		return "", ""
+	case strings.Contains(node, "."):
+		return node, "resource"
+	}
+	return "", ""
+}
+
+// parseTerraformGraph monta o grafo a partir da saída em DOT de `terraform graph`. O DOT não distingue
+// depends_on de referências, então todas as arestas são implícitas.
+func parseTerraformGraph(name, content string) *moduleGraph {
+	graph := newModuleGraph(name)
+	for _, line := range strings.Split(content, "\n") {
+		match := dotEdgePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		from, fromKind := normalizeGraphNode(match[1])
+		to, toKind := normalizeGraphNode(match[2])
+		if from == "" || to == "" {
+			continue
+		}
+		graph.Nodes[from] = &moduleGraphNode{ID: from, Kind: fromKind}
+		graph.Nodes[to] = &moduleGraphNode{ID: to, Kind: toKind}
+		graph.AddEdge(from, to, false)
+	}
+	sortModuleGraphEdges(graph.Edges)
+	return graph
+}
+
+// ForEnvironment remove os blocos cujo count depende de local.active_provider e não
+// corresponde ao provedor ativo do ambiente
+func (g *moduleGraph) ForEnvironment(name, activeProvider string) *moduleGraph {
+	filtered := newModuleGraph(name)
+	for id, node := range g.Nodes {
+		if match := hclActiveProvider.FindStringSubmatch(node.Count); match != nil && match[1] != activeProvider {
+			continue
+		}
+		filtered.Nodes[id] = node
+	}
+	for _, edge := range g.Edges {
+		if filtered.Nodes[edge.From] != nil && filtered.Nodes[edge.To] != nil {
+			filtered.Edges = append(filtered.Edges, edge)
+		}
+	}
+	return filtered
+}
+
+// reachable indica se to é alcançável a partir de from sem passar pela aresta ignorada
+func (g *moduleGraph) reachable(from, to string, skip moduleGraphEdge) bool {
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.Edges {
+			if edge.From != current || edge == skip || visited[edge.To] {
+				continue
+			}
+			if edge.To == to {
+				return true
+			}
+			visited[edge.To] = true
+			queue = append(queue, edge.To)
+		}
+	}
+	return false
+}
+
+// Cycles retorna os ciclos do grafo, cada um como a sequência de blocos que volta ao primeiro
+func (g *moduleGraph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, edge := range g.Edges {
+			if edge.From != id {
+				continue
+			}
+			switch state[edge.To] {
+			case unvisited:
+				visit(edge.To)
+			case visiting:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == edge.To {
+						cycle := append(append([]string{}, stack[i:]...), edge.To)
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	var ids []string
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return cycles
+}
+
+// RedundantDependsOn lista as entradas de depends_on cujo alvo já é alcançado por outro caminho
+// (uma referência direta, um local ou outro depends_on)
+func (g *moduleGraph) RedundantDependsOn() []string {
+	var redundant []string
+	for _, edge := range g.Edges {
+		if edge.Explicit && g.reachable(edge.From, edge.To, edge) {
+			redundant = append(redundant, fmt.Sprintf("%s: depends_on = [%s] já é garantido por outro caminho", edge.From, edge.To))
+		}
+	}
+	return redundant
+}
+
+// UnguardedIndexes lista referências como module.x[0] a blocos com count/for_each fora de uma
+// guarda: length(module.x), try(...), a mesma condição do count do alvo em um condicional ou um
+// bloco de origem com o mesmo count (sem instâncias, suas expressões não são avaliadas)
+func (g *moduleGraph) UnguardedIndexes() []string {
+	var problems []string
+	for _, node := range g.Nodes {
+		for attribute, expression := range node.Attributes {
+			if attribute == "depends_on" {
+				continue
+			}
+			normalized := strings.Join(strings.Fields(expression), " ")
+			for _, match := range hclIndexedReference.FindAllStringSubmatch(expression, -1) {
+				target := g.Nodes[match[1]]
+				if target == nil || target.Count == "" {
+					continue
+				}
+				if node.Count != "" && node.Count == target.Count {
+					continue
+				}
+				if strings.Contains(normalized, "length("+match[1]+")") || strings.Contains(normalized, "try(") {
+					continue
+				}
+				if condition, _, ok := strings.Cut(target.Count, "?"); ok && strings.Contains(normalized, strings.TrimSpace(condition)+" ?") {
+					continue
+				}
+				problems = append(problems, fmt.Sprintf("%s.%s usa %s[%s] sem verificar se %s tem instâncias (count = %s)", node.ID, strings.TrimPrefix(attribute, "bloco "), match[1], match[2], match[1], target.Count))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// visibleNodes retorna os módulos, recursos e data sources, que são os nós desenhados nos diagramas
+func (g *moduleGraph) visibleNodes() []string {
+	var ids []string
+	for id, node := range g.Nodes {
+		if node.Kind != "local" && node.Kind != "output" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// visibleEdges projeta o grafo nos nós visíveis: dependências que passam por locals viram arestas diretas
+func (g *moduleGraph) visibleEdges() []moduleGraphEdge {
+	var edges []moduleGraphEdge
+	seen := map[moduleGraphEdge]bool{}
+	add := func(edge moduleGraphEdge) {
+		if !seen[edge] && edge.From != edge.To {
+			seen[edge] = true
+			edges = append(edges, edge)
+		}
+	}
+
+	for _, from := range g.visibleNodes() {
+		visited := map[string]bool{}
+		var walk func(id string, explicit bool)
+		walk = func(id string, explicit bool) {
+			for _, edge := range g.Edges {
+				if edge.From != id || g.Nodes[edge.To] == nil {
+					continue
+				}
+				if g.Nodes[edge.To].Kind == "local" {
+					if !visited[edge.To] {
+						visited[edge.To] = true
+						walk(edge.To, explicit || edge.Explicit)
+					}
+					continue
+				}
+				add(moduleGraphEdge{From: from, To: edge.To, Explicit: explicit || edge.Explicit})
+			}
+		}
+		walk(from, false)
+	}
+	sortModuleGraphEdges(edges)
+	return edges
+}
+
+// DOT renderiza o grafo para o Graphviz; depends_on aparece tracejado
+func (g *moduleGraph) DOT() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "digraph %q {\n  rankdir = \"LR\";\n", g.Name)
+	for _, id := range g.visibleNodes() {
+		shape := "ellipse"
+		switch g.Nodes[id].Kind {
+		case "module":
+			shape = "box"
+		case "data":
+			shape = "note"
+		}
+		fmt.Fprintf(&builder, "  %q [shape=%s];\n", id, shape)
+	}
+	for _, edge := range g.visibleEdges() {
+		if edge.Explicit {
+			fmt.Fprintf(&builder, "  %q -> %q [style=dashed, label=\"depends_on\"];\n", edge.From, edge.To)
+		} else {
+			fmt.Fprintf(&builder, "  %q -> %q;\n", edge.From, edge.To)
+		}
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// Mermaid renderiza o grafo como flowchart; depends_on aparece pontilhado
+func (g *moduleGraph) Mermaid() string {
+	var builder strings.Builder
+	builder.WriteString("flowchart LR\n")
+	ids := map[string]string{}
+	for i, id := range g.visibleNodes() {
+		ids[id] = fmt.Sprintf("n%d", i)
+		if g.Nodes[id].Kind == "module" {
+			fmt.Fprintf(&builder, "  %s[\"%s\"]\n", ids[id], id)
+		} else {
+			fmt.Fprintf(&builder, "  %s([\"%s\"])\n", ids[id], id)
+		}
+	}
+	for _, edge := range g.visibleEdges() {
+		if edge.Explicit {
+			fmt.Fprintf(&builder, "  %s -.->|depends_on| %s\n", ids[edge.From], ids[edge.To])
+		} else {
+			fmt.Fprintf(&builder, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+		}
+	}
+	return builder.String()
+}
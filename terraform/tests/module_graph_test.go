@@ -0,0 +1,156 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// moduleGraphRoots retorna as raízes Terraform analisadas: a raiz terraform/, filtrada pelo
+// provedor ativo de cada ambiente, e cada diretório de environments/ que tem main.tf
+func moduleGraphRoots(t *testing.T) []*moduleGraph {
+	root, err := loadModuleGraph("raiz", "..")
+	if err != nil {
+		t.Fatalf("Erro ao ler a raiz: %v", err)
+	}
+
+	var graphs []*moduleGraph
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao ler config.yaml de %s: %v", environment, err)
+		}
+		graphs = append(graphs, root.ForEnvironment("raiz-"+environment, cfg.Provider.Active))
+	}
+
+	mainFiles, err := filepath.Glob(filepath.Join("..", "environments", "*", "main.tf"))
+	if err != nil {
+		t.Fatalf("Erro ao listar ambientes: %v", err)
+	}
+	nested, _ := filepath.Glob(filepath.Join("..", "environments", "*", "*", "main.tf"))
+	mainFiles = append(mainFiles, nested...)
+	sort.Strings(mainFiles)
+	for _, mainFile := range mainFiles {
+		dir := filepath.Dir(mainFile)
+		name := strings.ReplaceAll(filepath.ToSlash(strings.TrimPrefix(dir, filepath.Join("..", "environments")+string(filepath.Separator))), "/", "-")
+		graph, err := loadModuleGraph(name, dir)
+		if err != nil {
+			t.Fatalf("Erro ao ler %s: %v", dir, err)
+		}
+		graphs = append(graphs, graph)
+	}
+	return graphs
+}
+
+// TestModuleGraph monta o grafo de dependências de cada raiz e falha em ciclos e em referências
+// a módulos com count sem guarda. depends_on redundantes são apenas relatados, pois em módulos
+// eles também atrasam data sources. Com MODULE_GRAPH_DIR definido, grava os diagramas DOT e Mermaid.
+func TestModuleGraph(t *testing.T) {
+	t.Parallel()
+
+	outputDir := os.Getenv("MODULE_GRAPH_DIR")
+	graphs := moduleGraphRoots(t)
+
+	// Saídas de `terraform graph` geradas no pipeline (separadas por ":")
+	for _, path := range filepath.SplitList(os.Getenv("MODULE_GRAPH_DOT")) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Erro ao ler %s: %v", path, err)
+		}
+		graphs = append(graphs, parseTerraformGraph(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), string(content)))
+	}
+
+	for _, graph := range graphs {
+		for _, cycle := range graph.Cycles() {
+			assert.Fail(t, "Ciclo de dependências", "%s: %s", graph.Name, strings.Join(cycle, " -> "))
+		}
+		for _, problem := range graph.UnguardedIndexes() {
+			assert.Fail(t, "Referência indexada sem guarda", "%s: %s", graph.Name, problem)
+		}
+		for _, redundant := range graph.RedundantDependsOn() {
+			t.Logf("%s: %s", graph.Name, redundant)
+		}
+
+		if outputDir == "" {
+			continue
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			t.Fatalf("Erro ao criar %s: %v", outputDir, err)
+		}
+		for extension, content := range map[string]string{".dot": graph.DOT(), ".mmd": graph.Mermaid()} {
+			if err := os.WriteFile(filepath.Join(outputDir, graph.Name+extension), []byte(content), 0644); err != nil {
+				t.Fatalf("Erro ao gravar o diagrama de %s: %v", graph.Name, err)
+			}
+		}
+	}
+}
+
+// TestModuleGraphChecks confere as checagens com a raiz de exemplo em testdata/module_graph
+func TestModuleGraphChecks(t *testing.T) {
+	t.Parallel()
+
+	graph, err := loadModuleGraph("exemplo", filepath.Join("testdata", "module_graph", "root"))
+	if err != nil {
+		t.Fatalf("Erro ao ler a raiz de exemplo: %v", err)
+	}
+
+	assert.Equal(t, `local.active_provider == "aws" ? 1 : 0`, graph.Nodes["module.database_aws"].Count)
+	assert.Contains(t, graph.Edges, moduleGraphEdge{From: "local.vpc_id", To: "module.network_aws"})
+	assert.Contains(t, graph.Edges, moduleGraphEdge{From: "module.kubernetes_aws", To: "module.database_aws", Explicit: true})
+
+	assert.Equal(t, [][]string{{"module.dns", "null_resource.bootstrap", "module.dns"}}, graph.Cycles())
+
+	assert.ElementsMatch(t, []string{
+		"module.database_aws: depends_on = [module.network_aws] já é garantido por outro caminho",
+		"module.monitoring_aws: depends_on = [module.kubernetes_aws] já é garantido por outro caminho",
+		"module.monitoring_aws: depends_on = [module.database_aws] já é garantido por outro caminho",
+	}, graph.RedundantDependsOn())
+
+	unguarded := graph.UnguardedIndexes()
+	assert.Len(t, unguarded, 2)
+	assert.Contains(t, strings.Join(unguarded, "\n"), "module.kubernetes_aws.vpc_id usa module.network_aws[0]")
+	assert.Contains(t, strings.Join(unguarded, "\n"), "output.database_endpoint.value usa module.database_aws[0]")
+
+	gcp := graph.ForEnvironment("exemplo-gcp", "gcp")
+	assert.Nil(t, gcp.Nodes["module.network_aws"])
+	assert.NotNil(t, gcp.Nodes["module.network_gcp"])
+	assert.NotContains(t, gcp.DOT(), "module.database_aws")
+
+	// Dependências via locals aparecem como arestas diretas; locals e outputs não são desenhados
+	dot := graph.DOT()
+	assert.Contains(t, dot, `"module.database_aws" -> "module.network_aws";`)
+	assert.Contains(t, dot, `"module.kubernetes_aws" -> "module.database_aws" [style=dashed, label="depends_on"];`)
+	assert.NotContains(t, dot, "local.vpc_id")
+	assert.NotContains(t, dot, "output.database_endpoint")
+
+	mermaid := graph.Mermaid()
+	assert.True(t, strings.HasPrefix(mermaid, "flowchart LR\n"))
+	assert.Contains(t, mermaid, `["module.dns"]`)
+	assert.Contains(t, mermaid, `(["null_resource.bootstrap"])`)
+	assert.Contains(t, mermaid, "-.->|depends_on|")
+}
+
+// TestModuleGraphFromTerraformGraph confere a leitura da saída em DOT de `terraform graph`
+func TestModuleGraphFromTerraformGraph(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile(filepath.Join("testdata", "module_graph", "graph.dot"))
+	if err != nil {
+		t.Fatalf("Erro ao ler graph.dot: %v", err)
+	}
+	graph := parseTerraformGraph("dev", string(content))
+
+	// Nós internos dos módulos são agrupados no módulo; providers e variáveis são descartados
+	assert.ElementsMatch(t, []moduleGraphEdge{
+		{From: "digitalocean_project_resources.main", To: "module.kubernetes"},
+		{From: "module.database", To: "module.network"},
+		{From: "module.kubernetes", To: "module.network"},
+		{From: "output.vpc_id", To: "module.network"},
+	}, graph.Edges)
+	assert.Empty(t, graph.Cycles())
+	assert.Contains(t, graph.Mermaid(), `["module.network"]`)
+}
@@ -1,20 +1,38 @@
 package test
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNetworkModuleAWS(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	// O layout esperado vem do config.yaml do ambiente que usa AWS
+	cfg, err := loadEnvironmentConfig("staging")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	plan, err := planNetwork("aws", cfg.Network.VPCCIDR, cfg.Network.SubnetCount, awsDefaultZones)
+	if err != nil {
+		t.Fatalf("Erro ao planejar a rede: %v", err)
+	}
+
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../modules/network/aws",
 		Vars: map[string]interface{}{
-			"environment":  "test",
-			"project_name": "test-project",
-			"vpc_cidr":     "10.0.0.0/16",
+			"environment":        "test",
+			"project_name":       "test-project",
+			"vpc_cidr":           cfg.Network.VPCCIDR,
+			"subnet_count":       cfg.Network.SubnetCount,
+			"availability_zones": awsDefaultZones,
 			"tags": map[string]string{
 				"TestName": "NetworkModuleTest",
 			},
@@ -30,6 +48,7 @@ func TestNetworkModuleAWS(t *testing.T) {
 	// Validar os outputs
 	vpcId := terraform.Output(t, terraformOptions, "vpc_id")
 	assert.NotEmpty(t, vpcId, "VPC ID não deve ser vazio")
+	assert.Equal(t, plan.VPC.String(), terraform.Output(t, terraformOptions, "vpc_cidr"))
 	
 	publicSubnetIds := terraform.OutputList(t, terraformOptions, "public_subnet_ids")
 	assert.NotEmpty(t, publicSubnetIds, "IDs de subnets públicas não devem ser vazios")
@@ -37,9 +56,82 @@ func TestNetworkModuleAWS(t *testing.T) {
 	privateSubnetIds := terraform.OutputList(t, terraformOptions, "private_subnet_ids")
 	assert.NotEmpty(t, privateSubnetIds, "IDs de subnets privadas não devem ser vazios")
 
-	// Validar quantidade de subnets
-	assert.Equal(t, 3, len(publicSubnetIds), "Devem existir 3 subnets públicas")
-	assert.Equal(t, 3, len(privateSubnetIds), "Devem existir 3 subnets privadas")
+	// Validar quantidade e faixas das subnets conforme o subnet_count do config.yaml
+	assert.Len(t, publicSubnetIds, cfg.Network.SubnetCount, "Quantidade de subnets públicas difere de network.subnet_count")
+	assert.Len(t, privateSubnetIds, cfg.Network.SubnetCount, "Quantidade de subnets privadas difere de network.subnet_count")
+	assert.Equal(t, plan.CIDRs("public"), terraform.OutputList(t, terraformOptions, "public_subnet_cidrs"))
+	assert.Equal(t, plan.CIDRs("private"), terraform.OutputList(t, terraformOptions, "private_subnet_cidrs"))
+}
+
+// TestNetworkModuleAWSSubnetAddresses planeja o módulo sem credenciais e confere que as subnets
+// privadas mantêm os endereços do layout original (netnum a partir do número de zonas): com
+// subnet_count no padrão nenhum cidr_block muda, e portanto nenhuma subnet é recriada, e mudar
+// subnet_count só acrescenta ou remove subnets no fim da lista
+func TestNetworkModuleAWSSubnetAddresses(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform não encontrado no PATH")
+	}
+
+	dir := test_structure.CopyTerraformFolderToTemp(t, "../modules/network/aws", ".")
+	provider := `provider "aws" {
+  region                      = "us-east-1"
+  access_key                  = "test"
+  secret_key                  = "test"
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_region_validation      = true
+  skip_requesting_account_id  = true
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "fake_provider.tf"), []byte(provider), 0644); err != nil {
+		t.Fatalf("Erro ao gravar o provider: %v", err)
+	}
+
+	// Endereços antes de private_subnet_offset: cidrsubnet(vpc_cidr, 8, i + length(availability_zones))
+	original := map[string]string{}
+	for i := range awsDefaultZones {
+		original[fmt.Sprintf("public[%d]", i)] = fmt.Sprintf("10.0.%d.0/24", i)
+		original[fmt.Sprintf("private[%d]", i)] = fmt.Sprintf("10.0.%d.0/24", i+len(awsDefaultZones))
+	}
+
+	for _, subnetCount := range []int{0, 2} {
+		vars := map[string]interface{}{"environment": "test", "project_name": "test-project"}
+		if subnetCount > 0 {
+			vars["subnet_count"] = subnetCount
+		}
+		options := withRetryableErrors(t, &terraform.Options{
+			TerraformDir: dir,
+			Vars:         vars,
+			PlanFilePath: fmt.Sprintf("network-%d.tfplan", subnetCount),
+			PluginDir:    os.Getenv("TF_PROVIDER_MIRROR"),
+			NoColor:      true,
+		})
+		planJSON, err := terraform.InitAndPlanAndShowE(t, options)
+		if err != nil {
+			t.Fatalf("Erro ao planejar o módulo com subnet_count=%d: %v", subnetCount, err)
+		}
+		resources, err := loadPlannedResources([]byte(planJSON))
+		if err != nil {
+			t.Fatalf("Erro ao ler o plano: %v", err)
+		}
+
+		planned := map[string]string{}
+		for _, resource := range resources {
+			if resource.Type == "aws_subnet" {
+				planned[strings.TrimPrefix(resource.Address, "aws_subnet.")] = fmt.Sprint(resource.Values["cidr_block"])
+			}
+		}
+		if subnetCount == 0 {
+			assert.Equal(t, original, planned, "subnet_count no padrão deve manter todos os endereços")
+			continue
+		}
+		for key, cidr := range planned {
+			assert.Equal(t, original[key], cidr, "subnet_count=%d mudou o endereço de %s", subnetCount, key)
+		}
+		assert.Len(t, planned, 2*subnetCount)
+	}
 }
 
 func TestNetworkModuleDigitalOcean(t *testing.T) {
@@ -48,7 +140,7 @@ func TestNetworkModuleDigitalOcean(t *testing.T) {
 	// Skip este teste se não houver credenciais configuradas
 	t.Skip("Este teste requer credenciais do Digital Ocean configuradas")
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../modules/network/digital-ocean",
 		Vars: map[string]interface{}{
 			"environment":  "test",
@@ -74,7 +166,7 @@ func TestNetworkModuleGCP(t *testing.T) {
 	// Skip este teste se não houver credenciais configuradas
 	t.Skip("Este teste requer credenciais do GCP configuradas")
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := withRetryableErrors(t, &terraform.Options{
 		TerraformDir: "../modules/network/gcp",
 		Vars: map[string]interface{}{
 			"environment":  "test",
@@ -0,0 +1,648 @@
+package test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Biblioteca de notificações: um formato comum para os avisos dos módulos (monitoramento, rotação de
+// credenciais, recuperação de desastres e backups), renderizado para cada webhook guardado pelo
+// módulo secrets/aws e validado contra o payload aceito pelo serviço. O webhookSink de
+// fake_monitoring.go aplica a mesma validação às requisições que recebe.
+
+// Destinos de notificação suportados
+const (
+	notifySlack     = "slack"
+	notifyTeams     = "teams"
+	notifyPagerDuty = "pagerduty"
+	notifyOpsgenie  = "opsgenie"
+	// notifyOpsgenieClose valida o corpo de POST /v2/alerts/<alias>/close, que resolve o alerta
+	notifyOpsgenieClose = "opsgenie-close"
+)
+
+var notificationTargetTypes = []string{notifySlack, notifyTeams, notifyPagerDuty, notifyOpsgenie}
+
+// Severidades aceitas, na nomenclatura do PagerDuty
+var notificationSeverities = []string{"critical", "error", "warning", "info"}
+
+// webhookSecretKeys liga as chaves do segredo <projeto>-<ambiente>-webhook-urls (secrets/aws) ao destino
+var webhookSecretKeys = map[string]string{
+	"slack_alerts_webhook": notifySlack,
+	"teams_webhook":        notifyTeams,
+	"pagerduty_webhook":    notifyPagerDuty,
+	"opsgenie_webhook":     notifyOpsgenie,
+}
+
+// webhookHosts identifica o serviço pelo host da URL; hosts iniciados por ponto aceitam subdomínios
+var webhookHosts = []struct {
+	Host string
+	Type string
+}{
+	{"hooks.slack.com", notifySlack},
+	{".webhook.office.com", notifyTeams},
+	{".logic.azure.com", notifyTeams},
+	{"events.pagerduty.com", notifyPagerDuty},
+	{"events.eu.pagerduty.com", notifyPagerDuty},
+	{"api.opsgenie.com", notifyOpsgenie},
+	{"api.eu.opsgenie.com", notifyOpsgenie},
+}
+
+// webhookTargetType deduz o destino pela URL do webhook. Receptores locais, como o webhookSink, e
+// proxies usam o destino como primeiro segmento do caminho (http://127.0.0.1:port/slack)
+func webhookTargetType(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("URL de webhook inválida: %q", rawURL)
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, known := range webhookHosts {
+		if host == known.Host || (strings.HasPrefix(known.Host, ".") && strings.HasSuffix(host, known.Host)) {
+			return known.Type, nil
+		}
+	}
+	segment, _, _ := strings.Cut(strings.TrimPrefix(parsed.Path, "/"), "/")
+	if containsString(notificationTargetTypes, segment) {
+		return segment, nil
+	}
+	return "", fmt.Errorf("serviço do webhook %s não reconhecido", parsed.Host)
+}
+
+// notificationTarget é um webhook de destino com as credenciais que o serviço exige no payload ou no cabeçalho
+type notificationTarget struct {
+	Type string
+	URL  string
+	// Channel sobrescreve o canal padrão do webhook do Slack
+	Channel string
+	// RoutingKey é a chave de integração do Events API v2 do PagerDuty (32 caracteres)
+	RoutingKey string
+	// APIKey é a chave da integração de API do OpsGenie, enviada como GenieKey
+	APIKey string
+}
+
+// notificationField é um par nome/valor exibido junto do aviso
+type notificationField struct {
+	Name  string
+	Value string
+}
+
+// notification é um aviso no formato comum a todos os módulos
+type notification struct {
+	// Source é o módulo que gerou o aviso: monitoring, credential_rotation, disaster_recovery ou backup
+	Source      string
+	Project     string
+	Environment string
+	// Title identifica o aviso (o alertname no monitoramento) e Summary descreve a ocorrência
+	Title   string
+	Summary string
+	Details string
+	// Resource é o recurso afetado (instância, réplica, cluster), usado como source no PagerDuty
+	Resource string
+	Severity string
+	Resolved bool
+	// DedupKey liga o disparo à resolução no PagerDuty e no OpsGenie; vazio, é derivado do aviso
+	DedupKey string
+	Fields   []notificationField
+	Time     time.Time
+}
+
+// Status é RESOLVED para avisos resolvidos, INFO para avisos informativos e FIRING para os demais
+func (n notification) Status() string {
+	switch {
+	case n.Resolved:
+		return "RESOLVED"
+	case n.severity() == "info":
+		return "INFO"
+	default:
+		return "FIRING"
+	}
+}
+
+// Headline é a primeira linha do aviso em todos os destinos
+func (n notification) Headline() string {
+	return fmt.Sprintf("[%s] %s", n.Status(), n.Title)
+}
+
+// severity normaliza a severidade; valores fora de notificationSeverities viram error
+func (n notification) severity() string {
+	severity := strings.ToLower(n.Severity)
+	if !containsString(notificationSeverities, severity) {
+		return "error"
+	}
+	return severity
+}
+
+func (n notification) dedupKey() string {
+	if n.DedupKey != "" {
+		return n.DedupKey
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{n.Source, n.Project, n.Environment, n.Title, n.Resource}, "|")))
+	return n.Source + "-" + hex.EncodeToString(sum[:8])
+}
+
+// context junta projeto, ambiente e módulo de origem para o rodapé dos avisos
+func (n notification) context() string {
+	var parts []string
+	for _, part := range []string{n.Project, n.Environment, n.Source} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (n notification) summary() string {
+	if n.Summary != "" {
+		return n.Summary
+	}
+	return n.Headline()
+}
+
+func (n notification) timestamp() time.Time {
+	if n.Time.IsZero() {
+		return time.Now().UTC()
+	}
+	return n.Time.UTC()
+}
+
+// truncateText corta o texto em max caracteres, terminando com reticências
+func truncateText(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:max-1]) + "…"
+}
+
+// Limites dos payloads de cada serviço, usados na renderização e na validação
+const (
+	slackTextMax           = 40000
+	slackAttachmentsMax    = 100
+	teamsPayloadMax        = 28 * 1024
+	pagerDutySummaryMax    = 1024
+	pagerDutyDedupKeyMax   = 255
+	opsgenieMessageMax     = 130
+	opsgenieAliasMax       = 512
+	opsgenieDescriptionMax = 15000
+	opsgenieTagsMax        = 20
+	opsgenieTagMax         = 50
+	opsgenieSourceMax      = 100
+	opsgenieEntityMax      = 512
+	opsgenieNoteMax        = 25000
+)
+
+var (
+	slackColorPattern       = regexp.MustCompile(`^(good|warning|danger|#[0-9A-Fa-f]{6})$`)
+	pagerDutyRoutingPattern = regexp.MustCompile(`^[A-Za-z0-9]{32}$`)
+	opsgeniePriorityPattern = regexp.MustCompile(`^P[1-5]$`)
+)
+
+// renderNotification monta o payload do aviso para o destino
+func renderNotification(target notificationTarget, n notification) (map[string]interface{}, error) {
+	switch target.Type {
+	case notifySlack:
+		return renderSlackNotification(target, n), nil
+	case notifyTeams:
+		return renderTeamsNotification(n), nil
+	case notifyPagerDuty:
+		return renderPagerDutyNotification(target, n), nil
+	case notifyOpsgenie:
+		return renderOpsgenieNotification(n), nil
+	default:
+		return nil, fmt.Errorf("destino de notificação %q não suportado", target.Type)
+	}
+}
+
+func renderSlackNotification(target notificationTarget, n notification) map[string]interface{} {
+	color := map[string]string{"critical": "danger", "error": "danger", "warning": "warning", "info": "#439FE0"}[n.severity()]
+	if n.Resolved {
+		color = "good"
+	}
+	fields := []map[string]interface{}{}
+	for _, field := range n.Fields {
+		fields = append(fields, map[string]interface{}{"title": field.Name, "value": field.Value, "short": len(field.Value) <= 40})
+	}
+	payload := map[string]interface{}{
+		"text": truncateText(n.Headline(), slackTextMax),
+		"attachments": []map[string]interface{}{{
+			"fallback": truncateText(n.Headline()+": "+n.summary(), slackTextMax),
+			"color":    color,
+			"title":    n.Summary,
+			"text":     truncateText(n.Details, slackTextMax),
+			"fields":   fields,
+			"footer":   n.context(),
+			"ts":       n.timestamp().Unix(),
+		}},
+	}
+	if target.Channel != "" {
+		payload["channel"] = target.Channel
+	}
+	return payload
+}
+
+func renderTeamsNotification(n notification) map[string]interface{} {
+	color := map[string]string{"critical": "Attention", "error": "Attention", "warning": "Warning", "info": "Accent"}[n.severity()]
+	if n.Resolved {
+		color = "Good"
+	}
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": n.Headline(), "weight": "Bolder", "size": "Medium", "color": color, "wrap": true},
+	}
+	if n.Summary != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": n.Summary, "wrap": true})
+	}
+	if n.Details != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": n.Details, "isSubtle": true, "wrap": true})
+	}
+	facts := []map[string]string{}
+	for _, field := range n.Fields {
+		facts = append(facts, map[string]string{"title": field.Name, "value": field.Value})
+	}
+	if context := n.context(); context != "" {
+		facts = append(facts, map[string]string{"title": "Origem", "value": context})
+	}
+	if len(facts) > 0 {
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]interface{}{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}
+}
+
+func renderPagerDutyNotification(target notificationTarget, n notification) map[string]interface{} {
+	action := "trigger"
+	if n.Resolved {
+		action = "resolve"
+	}
+	source := n.Resource
+	if source == "" {
+		source = strings.Trim(n.Project+"-"+n.Environment, "-")
+	}
+	details := map[string]string{}
+	for _, field := range n.Fields {
+		details[field.Name] = field.Value
+	}
+	if n.Details != "" {
+		details["details"] = n.Details
+	}
+	return map[string]interface{}{
+		"routing_key":  target.RoutingKey,
+		"event_action": action,
+		"dedup_key":    truncateText(n.dedupKey(), pagerDutyDedupKeyMax),
+		"payload": map[string]interface{}{
+			"summary":        truncateText(n.summary(), pagerDutySummaryMax),
+			"source":         source,
+			"severity":       n.severity(),
+			"timestamp":      n.timestamp().Format(time.RFC3339),
+			"component":      n.Source,
+			"group":          n.Environment,
+			"custom_details": details,
+		},
+	}
+}
+
+func renderOpsgenieNotification(n notification) map[string]interface{} {
+	if n.Resolved {
+		return map[string]interface{}{
+			"source": truncateText(n.Source, opsgenieSourceMax),
+			"note":   truncateText(n.Headline()+": "+n.summary(), opsgenieNoteMax),
+		}
+	}
+	priority := map[string]string{"critical": "P1", "error": "P2", "warning": "P3", "info": "P5"}[n.severity()]
+	var tags []string
+	for _, tag := range []string{n.Source, n.Environment, n.Project} {
+		if tag != "" {
+			tags = append(tags, truncateText(tag, opsgenieTagMax))
+		}
+	}
+	details := map[string]string{}
+	for _, field := range n.Fields {
+		details[field.Name] = field.Value
+	}
+	payload := map[string]interface{}{
+		"message":     truncateText(n.summary(), opsgenieMessageMax),
+		"alias":       truncateText(n.dedupKey(), opsgenieAliasMax),
+		"description": truncateText(n.Details, opsgenieDescriptionMax),
+		"priority":    priority,
+		"tags":        tags,
+		"details":     details,
+		"source":      truncateText(n.Source, opsgenieSourceMax),
+	}
+	if n.Resource != "" {
+		payload["entity"] = truncateText(n.Resource, opsgenieEntityMax)
+	}
+	return payload
+}
+
+// sendNotification renderiza, valida e envia o aviso. No OpsGenie a resolução fecha o alerta pelo
+// alias em <url>/<alias>/close
+func sendNotification(target notificationTarget, n notification) error {
+	payload, err := renderNotification(target, n)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	kind, endpoint := target.Type, target.URL
+	if target.Type == notifyOpsgenie && n.Resolved {
+		kind = notifyOpsgenieClose
+		endpoint = strings.TrimSuffix(target.URL, "/") + "/" + url.PathEscape(n.dedupKey()) + "/close?identifierType=alias"
+	}
+	if err := validateNotificationPayload(kind, body); err != nil {
+		return fmt.Errorf("payload de %s inválido: %v", target.Type, err)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if target.Type == notifyOpsgenie {
+		request.Header.Set("Authorization", "GenieKey "+target.APIKey)
+	}
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// notifyTargets envia o aviso a todos os destinos e junta as falhas de entrega
+func notifyTargets(targets []notificationTarget, n notification) error {
+	var problems []string
+	for _, target := range targets {
+		if err := sendNotification(target, n); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", target.Type, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("falha ao notificar %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateNotificationPayload confere o corpo JSON contra o formato aceito pelo serviço: campos
+// obrigatórios, valores enumerados e limites de tamanho
+func validateNotificationPayload(kind string, body []byte) error {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("corpo não é um objeto JSON: %v", err)
+	}
+
+	var problems []string
+	switch kind {
+	case notifySlack:
+		problems = validateSlackPayload(payload)
+	case notifyTeams:
+		if len(body) > teamsPayloadMax {
+			problems = append(problems, fmt.Sprintf("payload com %d bytes, acima de %d", len(body), teamsPayloadMax))
+		}
+		problems = append(problems, validateTeamsPayload(payload)...)
+	case notifyPagerDuty:
+		problems = validatePagerDutyPayload(payload)
+	case notifyOpsgenie:
+		problems = validateOpsgeniePayload(payload)
+	case notifyOpsgenieClose:
+		problems = validateOpsgenieClose(payload)
+	default:
+		return fmt.Errorf("destino de notificação %q não suportado", kind)
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// payloadString lê um campo de texto; ok é falso quando o campo existe com outro tipo
+func payloadString(payload map[string]interface{}, key string) (string, bool) {
+	value, exists := payload[key]
+	if !exists || value == nil {
+		return "", true
+	}
+	text, ok := value.(string)
+	return text, ok
+}
+
+// checkPayloadString valida tipo, obrigatoriedade e tamanho de um campo de texto
+func checkPayloadString(problems []string, payload map[string]interface{}, path, key string, required bool, max int) []string {
+	text, ok := payloadString(payload, key)
+	switch {
+	case !ok:
+		return append(problems, path+key+" deve ser texto")
+	case required && text == "":
+		return append(problems, path+key+" é obrigatório")
+	case max > 0 && utf8.RuneCountInString(text) > max:
+		return append(problems, fmt.Sprintf("%s%s com %d caracteres, acima de %d", path, key, utf8.RuneCountInString(text), max))
+	}
+	return problems
+}
+
+func validateSlackPayload(payload map[string]interface{}) []string {
+	var problems []string
+	problems = checkPayloadString(problems, payload, "", "text", false, slackTextMax)
+	problems = checkPayloadString(problems, payload, "", "channel", false, 0)
+
+	text, _ := payloadString(payload, "text")
+	attachments, ok := payload["attachments"].([]interface{})
+	if _, exists := payload["attachments"]; exists && !ok {
+		problems = append(problems, "attachments deve ser uma lista")
+	}
+	blocks, hasBlocks := payload["blocks"].([]interface{})
+	if text == "" && len(attachments) == 0 && len(blocks) == 0 {
+		problems = append(problems, "text é obrigatório sem attachments ou blocks")
+	}
+	if hasBlocks && len(blocks) > 50 {
+		problems = append(problems, fmt.Sprintf("%d blocks, acima de 50", len(blocks)))
+	}
+	if len(attachments) > slackAttachmentsMax {
+		problems = append(problems, fmt.Sprintf("%d attachments, acima de %d", len(attachments), slackAttachmentsMax))
+	}
+	for i, item := range attachments {
+		path := fmt.Sprintf("attachments[%d].", i)
+		attachment, ok := item.(map[string]interface{})
+		if !ok {
+			problems = append(problems, path+" deve ser um objeto")
+			continue
+		}
+		for _, key := range []string{"fallback", "title", "text", "footer"} {
+			problems = checkPayloadString(problems, attachment, path, key, false, slackTextMax)
+		}
+		if color, _ := payloadString(attachment, "color"); color != "" && !slackColorPattern.MatchString(color) {
+			problems = append(problems, fmt.Sprintf("%scolor %q não é good, warning, danger ou #RRGGBB", path, color))
+		}
+		fields, _ := attachment["fields"].([]interface{})
+		for j, item := range fields {
+			field, _ := item.(map[string]interface{})
+			fieldPath := fmt.Sprintf("%sfields[%d].", path, j)
+			problems = checkPayloadString(problems, field, fieldPath, "title", true, 0)
+			problems = checkPayloadString(problems, field, fieldPath, "value", false, 0)
+		}
+	}
+	return problems
+}
+
+func validateTeamsPayload(payload map[string]interface{}) []string {
+	var problems []string
+	if payload["type"] != "message" {
+		problems = append(problems, `type deve ser "message"`)
+	}
+	attachments, _ := payload["attachments"].([]interface{})
+	if len(attachments) == 0 {
+		problems = append(problems, "attachments deve ter um cartão")
+	}
+	for i, item := range attachments {
+		path := fmt.Sprintf("attachments[%d].", i)
+		attachment, _ := item.(map[string]interface{})
+		if attachment["contentType"] != "application/vnd.microsoft.card.adaptive" {
+			problems = append(problems, path+"contentType deve ser application/vnd.microsoft.card.adaptive")
+		}
+		card, _ := attachment["content"].(map[string]interface{})
+		if card["type"] != "AdaptiveCard" {
+			problems = append(problems, path+`content.type deve ser "AdaptiveCard"`)
+		}
+		if version, _ := card["version"].(string); !containsString([]string{"1.0", "1.1", "1.2", "1.3", "1.4", "1.5"}, version) {
+			problems = append(problems, fmt.Sprintf("%scontent.version %q não é suportada pelo Teams", path, version))
+		}
+		elements, _ := card["body"].([]interface{})
+		if len(elements) == 0 {
+			problems = append(problems, path+"content.body está vazio")
+		}
+		for j, item := range elements {
+			element, _ := item.(map[string]interface{})
+			elementPath := fmt.Sprintf("%scontent.body[%d].", path, j)
+			switch element["type"] {
+			case "TextBlock":
+				problems = checkPayloadString(problems, element, elementPath, "text", true, 0)
+			case "FactSet":
+				facts, _ := element["facts"].([]interface{})
+				if len(facts) == 0 {
+					problems = append(problems, elementPath+"facts está vazio")
+				}
+				for k, item := range facts {
+					fact, _ := item.(map[string]interface{})
+					factPath := fmt.Sprintf("%sfacts[%d].", elementPath, k)
+					problems = checkPayloadString(problems, fact, factPath, "title", true, 0)
+					problems = checkPayloadString(problems, fact, factPath, "value", false, 0)
+				}
+			default:
+				problems = append(problems, fmt.Sprintf("%stype %v não suportado", elementPath, element["type"]))
+			}
+		}
+	}
+	return problems
+}
+
+func validatePagerDutyPayload(payload map[string]interface{}) []string {
+	var problems []string
+	if key, _ := payloadString(payload, "routing_key"); !pagerDutyRoutingPattern.MatchString(key) {
+		problems = append(problems, "routing_key deve ter 32 caracteres alfanuméricos")
+	}
+	action, _ := payloadString(payload, "event_action")
+	if !containsString([]string{"trigger", "acknowledge", "resolve"}, action) {
+		problems = append(problems, fmt.Sprintf("event_action %q não é trigger, acknowledge ou resolve", action))
+	}
+	problems = checkPayloadString(problems, payload, "", "dedup_key", action != "trigger", pagerDutyDedupKeyMax)
+
+	details, ok := payload["payload"].(map[string]interface{})
+	if !ok {
+		if action == "trigger" {
+			problems = append(problems, "payload é obrigatório em trigger")
+		}
+		return problems
+	}
+	problems = checkPayloadString(problems, details, "payload.", "summary", true, pagerDutySummaryMax)
+	problems = checkPayloadString(problems, details, "payload.", "source", true, 0)
+	if severity, _ := payloadString(details, "severity"); !containsString(notificationSeverities, severity) {
+		problems = append(problems, fmt.Sprintf("payload.severity %q não é critical, error, warning ou info", severity))
+	}
+	if timestamp, _ := payloadString(details, "timestamp"); timestamp != "" {
+		if _, err := time.Parse(time.RFC3339, timestamp); err != nil {
+			problems = append(problems, "payload.timestamp não está em ISO 8601")
+		}
+	}
+	if custom, exists := details["custom_details"]; exists {
+		if _, ok := custom.(map[string]interface{}); !ok {
+			problems = append(problems, "payload.custom_details deve ser um objeto")
+		}
+	}
+	return problems
+}
+
+func validateOpsgeniePayload(payload map[string]interface{}) []string {
+	var problems []string
+	problems = checkPayloadString(problems, payload, "", "message", true, opsgenieMessageMax)
+	problems = checkPayloadString(problems, payload, "", "alias", false, opsgenieAliasMax)
+	problems = checkPayloadString(problems, payload, "", "description", false, opsgenieDescriptionMax)
+	problems = checkPayloadString(problems, payload, "", "entity", false, opsgenieEntityMax)
+	problems = checkPayloadString(problems, payload, "", "source", false, opsgenieSourceMax)
+	if priority, _ := payloadString(payload, "priority"); priority != "" && !opsgeniePriorityPattern.MatchString(priority) {
+		problems = append(problems, fmt.Sprintf("priority %q não está entre P1 e P5", priority))
+	}
+	if value, exists := payload["tags"]; exists && value != nil {
+		tags, ok := value.([]interface{})
+		if !ok {
+			problems = append(problems, "tags deve ser uma lista")
+		}
+		if len(tags) > opsgenieTagsMax {
+			problems = append(problems, fmt.Sprintf("%d tags, acima de %d", len(tags), opsgenieTagsMax))
+		}
+		for i, tag := range tags {
+			if text, ok := tag.(string); !ok || utf8.RuneCountInString(text) > opsgenieTagMax {
+				problems = append(problems, fmt.Sprintf("tags[%d] deve ser texto com até %d caracteres", i, opsgenieTagMax))
+			}
+		}
+	}
+	if value, exists := payload["details"]; exists && value != nil {
+		details, ok := value.(map[string]interface{})
+		if !ok {
+			problems = append(problems, "details deve ser um objeto")
+		}
+		for key, detail := range details {
+			if _, ok := detail.(string); !ok {
+				problems = append(problems, "details."+key+" deve ser texto")
+			}
+		}
+	}
+	return problems
+}
+
+func validateOpsgenieClose(payload map[string]interface{}) []string {
+	var problems []string
+	for key := range payload {
+		if !containsString([]string{"user", "source", "note"}, key) {
+			problems = append(problems, key+" não é aceito ao fechar um alerta")
+		}
+	}
+	problems = checkPayloadString(problems, payload, "", "user", false, opsgenieSourceMax)
+	problems = checkPayloadString(problems, payload, "", "source", false, opsgenieSourceMax)
+	problems = checkPayloadString(problems, payload, "", "note", false, opsgenieNoteMax)
+	return problems
+}
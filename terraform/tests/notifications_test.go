@@ -0,0 +1,382 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// Credenciais fictícias dos destinos que exigem chave no payload ou no cabeçalho
+const (
+	testRoutingKey  = "0123456789abcdef0123456789abcdef"
+	testOpsgenieKey = "00000000-0000-0000-0000-000000000000"
+)
+
+// sinkTargets aponta os quatro destinos da biblioteca para o webhookSink
+func sinkTargets(sink *webhookSink) []notificationTarget {
+	return []notificationTarget{
+		{Type: notifySlack, URL: sink.URL(notifySlack), Channel: "#alertas"},
+		{Type: notifyTeams, URL: sink.URL(notifyTeams)},
+		{Type: notifyPagerDuty, URL: sink.URL(notifyPagerDuty), RoutingKey: testRoutingKey},
+		{Type: notifyOpsgenie, URL: sink.URL(notifyOpsgenie) + "/v2/alerts", APIKey: testOpsgenieKey},
+	}
+}
+
+// httpPostJSON envia um corpo JSON sem passar pela validação de sendNotification e retorna o status
+func httpPostJSON(url, body string) (int, error) {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func sampleNotification() notification {
+	return notification{
+		Source:      "monitoring",
+		Project:     "boilerplate-nestjs",
+		Environment: "prod",
+		Title:       "Utilização de CPU acima de 80%",
+		Summary:     "CPU acima de 80% em node-1",
+		Details:     "A CPU de node-1 está em 95% há 5 minutos",
+		Resource:    "node-1",
+		Severity:    "critical",
+		Fields:      []notificationField{{Name: "instance", Value: "node-1"}},
+		Time:        time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// TestNotificationPayloads renderiza o mesmo aviso para cada destino e confere o payload contra o
+// formato do serviço
+func TestNotificationPayloads(t *testing.T) {
+	t.Parallel()
+
+	firing := sampleNotification()
+	resolved := firing
+	resolved.Resolved = true
+	info := firing
+	info.Severity = "info"
+	long := firing
+	long.Summary = strings.Repeat("resumo longo ", 200)
+
+	render := func(target notificationTarget, n notification) map[string]interface{} {
+		t.Helper()
+		payload, err := renderNotification(target, n)
+		if err != nil {
+			t.Fatalf("Erro ao renderizar para %s: %v", target.Type, err)
+		}
+		body, _ := json.Marshal(payload)
+		kind := target.Type
+		if kind == notifyOpsgenie && n.Resolved {
+			kind = notifyOpsgenieClose
+		}
+		assert.NoError(t, validateNotificationPayload(kind, body), "%s %s", target.Type, n.Status())
+
+		var decoded map[string]interface{}
+		json.Unmarshal(body, &decoded)
+		return decoded
+	}
+
+	slack := notificationTarget{Type: notifySlack, Channel: "#alertas"}
+	payload := render(slack, firing)
+	assert.Equal(t, "[FIRING] Utilização de CPU acima de 80%", payload["text"])
+	assert.Equal(t, "#alertas", payload["channel"])
+	attachment := payload["attachments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "danger", attachment["color"])
+	assert.Equal(t, "CPU acima de 80% em node-1", attachment["title"])
+	assert.Equal(t, "boilerplate-nestjs · prod · monitoring", attachment["footer"])
+	assert.Equal(t, map[string]string{"instance": "node-1"}, webhookRequest{Body: payload}.Fields())
+	assert.Equal(t, "[RESOLVED] Utilização de CPU acima de 80%", render(slack, resolved)["text"])
+	assert.Equal(t, "[INFO] Utilização de CPU acima de 80%", render(slack, info)["text"])
+	assert.NotContains(t, render(notificationTarget{Type: notifySlack}, firing), "channel")
+
+	teams := notificationTarget{Type: notifyTeams}
+	request := webhookRequest{Body: render(teams, firing)}
+	assert.Equal(t, "[FIRING] Utilização de CPU acima de 80%", request.Headline())
+	assert.Equal(t, map[string]string{"instance": "node-1", "Origem": "boilerplate-nestjs · prod · monitoring"}, request.Fields())
+	assert.Equal(t, "Good", webhookRequest{Body: render(teams, resolved)}.teamsBody()[0]["color"])
+
+	pagerduty := notificationTarget{Type: notifyPagerDuty, RoutingKey: testRoutingKey}
+	trigger, resolve := render(pagerduty, firing), render(pagerduty, resolved)
+	assert.Equal(t, "trigger", trigger["event_action"])
+	assert.Equal(t, "resolve", resolve["event_action"])
+	assert.Equal(t, trigger["dedup_key"], resolve["dedup_key"], "A resolução deve usar a chave do disparo")
+	details := trigger["payload"].(map[string]interface{})
+	assert.Equal(t, "node-1", details["source"])
+	assert.Equal(t, "critical", details["severity"])
+	assert.Equal(t, "2024-03-01T12:00:00Z", details["timestamp"])
+	summary := render(pagerduty, long)["payload"].(map[string]interface{})["summary"].(string)
+	assert.Equal(t, pagerDutySummaryMax, utf8.RuneCountInString(summary))
+
+	opsgenie := notificationTarget{Type: notifyOpsgenie, APIKey: testOpsgenieKey}
+	created := render(opsgenie, firing)
+	assert.Equal(t, "P1", created["priority"])
+	assert.Equal(t, []interface{}{"monitoring", "prod", "boilerplate-nestjs"}, created["tags"])
+	assert.Equal(t, "node-1", created["entity"])
+	assert.Equal(t, "P5", render(opsgenie, info)["priority"])
+	assert.Equal(t, opsgenieMessageMax, utf8.RuneCountInString(render(opsgenie, long)["message"].(string)))
+	assert.Equal(t, map[string]interface{}{"source": "monitoring", "note": "[RESOLVED] Utilização de CPU acima de 80%: CPU acima de 80% em node-1"}, render(opsgenie, resolved))
+
+	unknown := firing
+	unknown.Severity = "page"
+	assert.Equal(t, "error", render(pagerduty, unknown)["payload"].(map[string]interface{})["severity"])
+
+	_, err := renderNotification(notificationTarget{Type: "discord"}, firing)
+	assert.EqualError(t, err, `destino de notificação "discord" não suportado`)
+}
+
+// TestNotificationPayloadValidation confere que payloads fora do formato de cada serviço são recusados
+func TestNotificationPayloadValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Kind    string
+		Body    string
+		Problem string
+	}{
+		{notifySlack, `[]`, "corpo não é um objeto JSON"},
+		{notifySlack, `{}`, "text é obrigatório sem attachments ou blocks"},
+		{notifySlack, `{"text": 1}`, "text deve ser texto"},
+		{notifySlack, `{"text": "x", "attachments": [{"color": "red"}]}`, `attachments[0].color "red" não é good, warning, danger ou #RRGGBB`},
+		{notifySlack, `{"text": "x", "attachments": [{"fields": [{"value": "v"}]}]}`, "attachments[0].fields[0].title é obrigatório"},
+		{notifyTeams, `{"text": "x"}`, `type deve ser "message"`},
+		{notifyTeams, `{"type": "message", "attachments": [{"contentType": "application/vnd.microsoft.card.adaptive", "content": {"type": "AdaptiveCard", "version": "1.6", "body": [{"type": "TextBlock", "text": "x"}]}}]}`, `content.version "1.6" não é suportada pelo Teams`},
+		{notifyTeams, `{"type": "message", "attachments": [{"contentType": "application/vnd.microsoft.card.adaptive", "content": {"type": "AdaptiveCard", "version": "1.4", "body": [{"type": "Image", "url": "x"}]}}]}`, "content.body[0].type Image não suportado"},
+		{notifyPagerDuty, `{"routing_key": "chave-de-teste", "event_action": "trigger", "payload": {"summary": "x", "source": "y", "severity": "error"}}`, "routing_key deve ter 32 caracteres alfanuméricos"},
+		{notifyPagerDuty, `{"routing_key": "` + testRoutingKey + `", "event_action": "fire", "payload": {"summary": "x", "source": "y", "severity": "error"}}`, `event_action "fire" não é trigger, acknowledge ou resolve`},
+		{notifyPagerDuty, `{"routing_key": "` + testRoutingKey + `", "event_action": "trigger", "payload": {"summary": "x", "source": "y", "severity": "high"}}`, `payload.severity "high" não é critical, error, warning ou info`},
+		{notifyPagerDuty, `{"routing_key": "` + testRoutingKey + `", "event_action": "resolve"}`, "dedup_key é obrigatório"},
+		{notifyPagerDuty, `{"routing_key": "` + testRoutingKey + `", "event_action": "trigger"}`, "payload é obrigatório em trigger"},
+		{notifyOpsgenie, `{"description": "x"}`, "message é obrigatório"},
+		{notifyOpsgenie, `{"message": "` + strings.Repeat("a", 131) + `"}`, "message com 131 caracteres, acima de 130"},
+		{notifyOpsgenie, `{"message": "x", "priority": "P0"}`, `priority "P0" não está entre P1 e P5`},
+		{notifyOpsgenie, `{"message": "x", "details": {"n": 1}}`, "details.n deve ser texto"},
+		{notifyOpsgenieClose, `{"message": "x"}`, "message não é aceito ao fechar um alerta"},
+		{"discord", `{}`, `destino de notificação "discord" não suportado`},
+	}
+	for _, c := range cases {
+		err := validateNotificationPayload(c.Kind, []byte(c.Body))
+		if assert.Error(t, err, "%s %s", c.Kind, c.Body) {
+			assert.Contains(t, err.Error(), c.Problem, c.Kind)
+		}
+	}
+}
+
+// TestWebhookTargetType confere o destino deduzido de cada URL e que toda chave do segredo
+// webhook-urls do módulo secrets/aws tem um destino na biblioteca
+func TestWebhookTargetType(t *testing.T) {
+	t.Parallel()
+
+	for rawURL, expected := range map[string]string{
+		"https://hooks.slack.com/services/T000/B000/XXXX":                        notifySlack,
+		"https://empresa.webhook.office.com/webhookb2/abc@def/IncomingWebhook/1": notifyTeams,
+		"https://prod-00.westus.logic.azure.com/workflows/abc/triggers/manual":   notifyTeams,
+		"https://events.pagerduty.com/v2/enqueue":                                notifyPagerDuty,
+		"https://api.eu.opsgenie.com/v2/alerts":                                  notifyOpsgenie,
+		"http://127.0.0.1:8080/pagerduty":                                        notifyPagerDuty,
+	} {
+		target, err := webhookTargetType(rawURL)
+		assert.NoError(t, err, rawURL)
+		assert.Equal(t, expected, target, rawURL)
+	}
+	for _, rawURL := range []string{"https://evilhooks.slack.com/services/x", "https://discord.com/api/webhooks/1", "hooks.slack.com"} {
+		_, err := webhookTargetType(rawURL)
+		assert.Error(t, err, rawURL)
+	}
+
+	content, err := os.ReadFile(filepath.Join("..", "modules", "secrets", "aws", "main.tf"))
+	if err != nil {
+		t.Fatalf("Erro ao ler o módulo secrets/aws: %v", err)
+	}
+	var keys []string
+	for _, match := range regexp.MustCompile(`(?m)^\s*(\w+_webhook)\s*=\s*var\.`).FindAllStringSubmatch(string(content), -1) {
+		keys = append(keys, match[1])
+	}
+	var expected []string
+	for key := range webhookSecretKeys {
+		expected = append(expected, key)
+	}
+	assert.ElementsMatch(t, expected, keys, "chaves de webhook_urls em secrets/aws")
+}
+
+// TestNotificationSink envia disparo e resolução a cada destino pelo webhookSink, que valida os
+// payloads como os serviços, e confere as recusas do receptor
+func TestNotificationSink(t *testing.T) {
+	t.Parallel()
+
+	sink := newWebhookSink(t)
+	firing := sampleNotification()
+	resolved := firing
+	resolved.Resolved = true
+
+	for _, target := range sinkTargets(sink) {
+		assert.NoError(t, sendNotification(target, firing), target.Type)
+		assert.NoError(t, sendNotification(target, resolved), target.Type)
+	}
+	assert.Empty(t, sink.Rejected())
+
+	for _, target := range notificationTargetTypes {
+		assert.Len(t, sink.Requests("/"+target), 2, target)
+	}
+	var opsgenie []string
+	for _, request := range sink.Requests("/opsgenie") {
+		opsgenie = append(opsgenie, request.Path)
+	}
+	alias := renderOpsgenieNotification(firing)["alias"].(string)
+	assert.Equal(t, []string{"/opsgenie/v2/alerts", "/opsgenie/v2/alerts/" + alias + "/close"}, opsgenie)
+
+	// Sem a chave do OpsGenie e com payloads fora do formato, o receptor responde como o serviço
+	noKey := sinkTargets(sink)[3]
+	noKey.APIKey = ""
+	err := sendNotification(noKey, firing)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "status 401")
+	}
+	badKey := sinkTargets(sink)[2]
+	badKey.RoutingKey = "chave-de-teste"
+	err = sendNotification(badKey, firing)
+	assert.EqualError(t, err, "payload de pagerduty inválido: routing_key deve ter 32 caracteres alfanuméricos")
+
+	resp, err := httpPostJSON(sink.URL(notifyPagerDuty), `{"event_action": "trigger"}`)
+	if err != nil {
+		t.Fatalf("Erro ao enviar ao receptor: %v", err)
+	}
+	assert.Equal(t, http.StatusBadRequest, resp)
+	resp, err = httpPostJSON(sink.URL(notifySlack), `{"attachments": []}`)
+	if err != nil {
+		t.Fatalf("Erro ao enviar ao receptor: %v", err)
+	}
+	assert.Equal(t, http.StatusBadRequest, resp)
+
+	rejected := sink.Rejected()
+	if assert.Len(t, rejected, 3) {
+		assert.Equal(t, "Authorization sem GenieKey", rejected[0].Error)
+		assert.Contains(t, rejected[1].Error, "routing_key deve ter 32 caracteres alfanuméricos")
+		assert.Equal(t, "text é obrigatório sem attachments ou blocks", rejected[2].Error)
+	}
+
+	assert.NoError(t, notifyTargets(sinkTargets(sink), firing))
+	err = notifyTargets([]notificationTarget{sinkTargets(sink)[0], badKey}, firing)
+	assert.EqualError(t, err, "falha ao notificar pagerduty: payload de pagerduty inválido: routing_key deve ter 32 caracteres alfanuméricos")
+}
+
+// TestBackupAlertPayloads avalia local.backup_alert_commands do módulo disaster_recovery/snapshots
+// com `terraform console`, executa cada comando com `sh -c`, como o script de verificação de
+// backups, e confere que o webhookSink recebe avisos válidos no formato da biblioteca para o Slack
+func TestBackupAlertPayloads(t *testing.T) {
+	t.Parallel()
+
+	for _, tool := range []string{"terraform", "curl"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("Este teste requer %s instalado", tool)
+		}
+	}
+
+	sink := newWebhookSink(t)
+	commands := renderBackupAlertCommands(t, sink.URL(notifySlack))
+	for _, name := range []string{"database", "kubernetes"} {
+		command, ok := commands[name]
+		if !assert.True(t, ok, "local.backup_alert_commands sem %s", name) {
+			continue
+		}
+		// Variáveis do shell que o script de verificação define antes de chamar o comando
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Env = append(os.Environ(), "db_backup_status=error", "backup_name=diario-20240301", "status=PartiallyFailed")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Erro ao executar o alerta %s: %v\n%s", name, err, output)
+		}
+	}
+
+	assert.Empty(t, sink.Rejected())
+	requests := sink.Requests("/slack")
+	var received []string
+	for _, request := range requests {
+		received = append(received, alertNotificationSummary(request))
+	}
+	assert.Equal(t, []string{
+		"[FIRING] Backup do banco de dados Backup do banco de dados falhou com status: error",
+		"[FIRING] Backup do Kubernetes Backup do Kubernetes diario-20240301 falhou com status: PartiallyFailed",
+	}, received)
+
+	// Cada chave recebida tem o valor que a biblioteca gera para o mesmo aviso
+	for _, request := range requests {
+		attachments, _ := request.Body["attachments"].([]interface{})
+		if !assert.Len(t, attachments, 1) {
+			continue
+		}
+		attachment, _ := attachments[0].(map[string]interface{})
+		title, _ := request.Body["text"].(string)
+		summary, _ := attachment["title"].(string)
+		expected := renderSlackNotification(notificationTarget{}, notification{
+			Source: "backup", Project: "boilerplate-nestjs", Environment: "dev",
+			Title: strings.TrimPrefix(title, "[FIRING] "), Summary: summary, Severity: "error",
+		})
+		assert.Equal(t, expected["text"], request.Body["text"])
+		library := expected["attachments"].([]map[string]interface{})[0]
+		for key, value := range attachment {
+			assert.Equal(t, library[key], value, "%s: attachments[0].%s", title, key)
+		}
+	}
+}
+
+// renderBackupAlertCommands copia o módulo disaster_recovery/snapshots com o versions.tf da raiz e
+// avalia local.backup_alert_commands com `terraform console`, com alert_webhook_url em webhookURL
+func renderBackupAlertCommands(t *testing.T, webhookURL string) map[string]string {
+	dir := t.TempDir()
+	if err := files.CopyFolderContents(filepath.Join("..", "modules", "disaster_recovery", "snapshots"), dir); err != nil {
+		t.Fatalf("Erro ao copiar o módulo snapshots: %v", err)
+	}
+	versions, err := os.ReadFile(filepath.Join("..", "versions.tf"))
+	if err != nil {
+		t.Fatalf("Erro ao ler versions.tf: %v", err)
+	}
+	vars, _ := json.Marshal(map[string]string{
+		"environment":       "dev",
+		"project_name":      "boilerplate-nestjs",
+		"do_token":          "fake-token",
+		"alert_webhook_url": webhookURL,
+	})
+	for name, content := range map[string][]byte{"versions.tf": versions, "terraform.tfvars.json": vars} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatalf("Erro ao gravar %s: %v", name, err)
+		}
+	}
+
+	options := withRetryableErrors(t, &terraform.Options{
+		TerraformDir: dir,
+		PluginDir:    os.Getenv("TF_PROVIDER_MIRROR"),
+		NoColor:      true,
+	})
+	terraform.Init(t, options)
+
+	console := exec.Command("terraform", "console")
+	console.Dir = dir
+	console.Stdin = strings.NewReader("jsonencode(local.backup_alert_commands)\n")
+	output, err := console.Output()
+	if err != nil {
+		t.Fatalf("Erro no terraform console: %v", err)
+	}
+	// O console imprime a string entre aspas, com ${ e %{ escapados como no HCL
+	quoted := strings.NewReplacer("$${", "${", "%%{", "%{").Replace(strings.TrimSpace(string(output)))
+	var encoded string
+	if err := json.Unmarshal([]byte(quoted), &encoded); err != nil {
+		t.Fatalf("Saída inesperada do terraform console %q: %v", output, err)
+	}
+	var commands map[string]string
+	if err := json.Unmarshal([]byte(encoded), &commands); err != nil {
+		t.Fatalf("local.backup_alert_commands não é um mapa de strings: %v", err)
+	}
+	return commands
+}
@@ -0,0 +1,465 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pontuação de cada sinal de risco de uma mudança do plano
+const (
+	planRiskCreate           = 1
+	planRiskUpdate           = 3
+	planRiskDelete           = 10
+	planRiskReplace          = 12
+	planRiskStatefulLoss     = 40
+	planRiskPermissions      = 20
+	planRiskPublicWidening   = 30
+	planRiskPrivateWidening  = 10
+	planRiskUnknownSources   = 5
+	planRiskProtectionOff    = 25
+	planRiskCapacityDecrease = 15
+	// planRiskProdMultiplier multiplica a pontuação das mudanças no ambiente prod
+	planRiskProdMultiplier = 2
+)
+
+// Níveis de risco, do menor para o maior
+const (
+	planRiskLow      = "baixo"
+	planRiskMedium   = "médio"
+	planRiskHigh     = "alto"
+	planRiskCritical = "crítico"
+)
+
+// planRiskLevel classifica uma pontuação
+func planRiskLevel(score int) string {
+	switch {
+	case score >= 50:
+		return planRiskCritical
+	case score >= 25:
+		return planRiskHigh
+	case score >= 10:
+		return planRiskMedium
+	default:
+		return planRiskLow
+	}
+}
+
+// planRiskStatefulTypes guardam dados que se perdem quando o recurso é destruído ou substituído
+var planRiskStatefulTypes = []string{
+	"aws_db_instance", "aws_rds_cluster", "aws_s3_bucket", "aws_ebs_volume", "aws_efs_file_system",
+	"aws_dynamodb_table", "aws_elasticache_replication_group", "aws_kms_key", "aws_secretsmanager_secret",
+	"aws_eks_cluster",
+	"google_sql_database_instance", "google_sql_database", "google_storage_bucket", "google_compute_disk",
+	"google_container_cluster", "google_kms_crypto_key", "google_pubsub_subscription",
+	"digitalocean_database_cluster", "digitalocean_database_db", "digitalocean_volume", "digitalocean_spaces_bucket",
+	"digitalocean_kubernetes_cluster",
+	"azurerm_postgresql_flexible_server", "azurerm_storage_account", "azurerm_managed_disk", "azurerm_key_vault",
+	"azurerm_kubernetes_cluster",
+	"kubernetes_secret", "kubernetes_persistent_volume_claim", "docker_volume",
+}
+
+// planRiskPermissionPrefixes identificam recursos de IAM e RBAC pelo prefixo do tipo
+var planRiskPermissionPrefixes = []string{
+	"aws_iam_", "azurerm_role_", "kubernetes_role", "kubernetes_cluster_role", "digitalocean_custom_role",
+}
+
+// planRiskPermissionTypes são políticas de acesso anexadas a recursos
+var planRiskPermissionTypes = []string{"aws_s3_bucket_policy", "aws_kms_key_policy", "aws_lambda_permission"}
+
+// planRiskNodePoolTypes são os recursos com capacidade de nós de Kubernetes
+var planRiskNodePoolTypes = []string{
+	"aws_eks_node_group", "google_container_node_pool", "google_container_cluster",
+	"digitalocean_kubernetes_node_pool", "digitalocean_kubernetes_cluster",
+	"azurerm_kubernetes_cluster", "azurerm_kubernetes_cluster_node_pool",
+}
+
+// planRiskCapacityKeys são os atributos de quantidade de nós, em qualquer nível dos blocos aninhados
+var planRiskCapacityKeys = []string{
+	"node_count", "initial_node_count", "desired_size", "min_size", "max_size",
+	"min_node_count", "max_node_count", "total_min_node_count", "total_max_node_count", "min_nodes", "max_nodes",
+	"min_count", "max_count",
+}
+
+// planRiskProtection é um atributo que protege o recurso ou seus dados enquanto tem o valor Protected
+type planRiskProtection struct {
+	Attribute   string
+	Protected   bool
+	Description string
+}
+
+var planRiskProtections = []planRiskProtection{
+	{"deletion_protection", true, "desliga deletion_protection"},
+	{"enable_deletion_protection", true, "desliga enable_deletion_protection"},
+	{"disable_api_termination", true, "desliga disable_api_termination"},
+	{"skip_final_snapshot", false, "deixa de criar o snapshot final (skip_final_snapshot)"},
+	{"force_destroy", false, "permite destruir o recurso com o conteúdo (force_destroy)"},
+}
+
+// planRiskChange é uma mudança do plano com a pontuação e os motivos
+type planRiskChange struct {
+	Address string
+	Type    string
+	// Action é criar, atualizar, destruir ou substituir
+	Action  string
+	Score   int
+	Reasons []string
+}
+
+// Level retorna o nível de risco da mudança
+func (c planRiskChange) Level() string {
+	return planRiskLevel(c.Score)
+}
+
+// planRiskReport é a análise de um plano inteiro
+type planRiskReport struct {
+	Environment string
+	Multiplier  int
+	// Changes vem ordenado da maior para a menor pontuação
+	Changes []planRiskChange
+}
+
+// Score soma a pontuação de todas as mudanças
+func (r *planRiskReport) Score() int {
+	total := 0
+	for _, change := range r.Changes {
+		total += change.Score
+	}
+	return total
+}
+
+// Level é o nível da mudança mais arriscada; um plano sem mudanças tem risco baixo
+func (r *planRiskReport) Level() string {
+	if len(r.Changes) == 0 {
+		return planRiskLow
+	}
+	return r.Changes[0].Level()
+}
+
+// tfJSONResourceChange é um item de resource_changes do plano em JSON
+type tfJSONResourceChange struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Change  struct {
+		Actions      []string               `json:"actions"`
+		Before       map[string]interface{} `json:"before"`
+		After        map[string]interface{} `json:"after"`
+		ReplacePaths [][]interface{}        `json:"replace_paths"`
+	} `json:"change"`
+}
+
+// analyzePlanRisk pontua cada mudança de resource_changes na saída de `terraform show -json` de um
+// plano. Sem environment, usa a variável environment gravada no plano
+func analyzePlanRisk(content []byte, environment string) (*planRiskReport, error) {
+	var plan struct {
+		ResourceChanges []tfJSONResourceChange `json:"resource_changes"`
+		Variables       map[string]struct {
+			Value interface{} `json:"value"`
+		} `json:"variables"`
+	}
+	if err := json.Unmarshal(content, &plan); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar o plano: %v", err)
+	}
+	if plan.ResourceChanges == nil {
+		return nil, fmt.Errorf("JSON não contém resource_changes")
+	}
+	if environment == "" {
+		environment, _ = plan.Variables["environment"].Value.(string)
+	}
+
+	report := &planRiskReport{Environment: environment, Multiplier: 1}
+	if environment == "prod" {
+		report.Multiplier = planRiskProdMultiplier
+	}
+	for _, resource := range plan.ResourceChanges {
+		if resource.Mode == "data" {
+			continue
+		}
+		change, ok := scorePlanChange(resource)
+		if !ok {
+			continue
+		}
+		change.Score *= report.Multiplier
+		report.Changes = append(report.Changes, change)
+	}
+	sort.SliceStable(report.Changes, func(i, j int) bool {
+		if report.Changes[i].Score != report.Changes[j].Score {
+			return report.Changes[i].Score > report.Changes[j].Score
+		}
+		return report.Changes[i].Address < report.Changes[j].Address
+	})
+	return report, nil
+}
+
+// scorePlanChange aplica as regras de risco a uma mudança; no-op e read não são pontuados
+func scorePlanChange(resource tfJSONResourceChange) (planRiskChange, bool) {
+	change := planRiskChange{Address: resource.Address, Type: resource.Type}
+	actions := strings.Join(resource.Change.Actions, ",")
+	switch actions {
+	case "create":
+		change.Action, change.Score = "criar", planRiskCreate
+	case "update":
+		change.Action, change.Score = "atualizar", planRiskUpdate
+	case "delete":
+		change.Action, change.Score = "destruir", planRiskDelete
+	case "delete,create", "create,delete":
+		change.Action, change.Score = "substituir", planRiskReplace
+		for _, path := range resource.Change.ReplacePaths {
+			change.Reasons = append(change.Reasons, fmt.Sprintf("substituição forçada por `%s`", planRiskPath(path)))
+		}
+	default:
+		return change, false
+	}
+	add := func(score int, reason string) {
+		change.Score += score
+		change.Reasons = append(change.Reasons, reason)
+	}
+	before, after := resource.Change.Before, resource.Change.After
+	removes := change.Action == "destruir" || change.Action == "substituir"
+
+	if removes && containsString(planRiskStatefulTypes, resource.Type) {
+		add(planRiskStatefulLoss, "remove recurso com dados")
+	}
+	if planRiskPermissionResource(resource.Type) {
+		add(planRiskPermissions, "altera permissões de IAM ou RBAC")
+	}
+	for _, rule := range widenedRules(firewallRules(resource.Type, resource.Address, before), firewallRules(resource.Type, resource.Address, after)) {
+		description := strings.TrimPrefix(rule.String(), rule.Firewall+": ")
+		switch {
+		case rule.Unknown:
+			add(planRiskUnknownSources, "regra de entrada com origens só conhecidas no apply")
+		case planRiskPublicSources(rule.Sources):
+			add(planRiskPublicWidening, "amplia acesso de entrada: "+description)
+		default:
+			add(planRiskPrivateWidening, "amplia acesso de entrada: "+description)
+		}
+	}
+	if before != nil && after != nil {
+		for _, protection := range planRiskProtections {
+			was, wasSet := before[protection.Attribute].(bool)
+			is, isSet := after[protection.Attribute].(bool)
+			if wasSet && isSet && was == protection.Protected && is != protection.Protected {
+				add(planRiskProtectionOff, protection.Description)
+			}
+		}
+	}
+	if containsString(planRiskNodePoolTypes, resource.Type) {
+		if change.Action == "destruir" && !containsString(planRiskStatefulTypes, resource.Type) {
+			add(planRiskCapacityDecrease, "remove o node pool")
+		}
+		if before != nil && after != nil {
+			for _, decrease := range capacityDecreases("", before, after) {
+				add(planRiskCapacityDecrease, "reduz "+decrease)
+			}
+		}
+	}
+	return change, true
+}
+
+// planRiskPermissionResource indica se o tipo concede ou retira permissões
+func planRiskPermissionResource(resourceType string) bool {
+	if strings.Contains(resourceType, "_iam_") || containsString(planRiskPermissionTypes, resourceType) {
+		return true
+	}
+	for _, prefix := range planRiskPermissionPrefixes {
+		if strings.HasPrefix(resourceType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// planRiskPublicSources indica se alguma origem inclui endereços da internet
+func planRiskPublicSources(sources []string) bool {
+	for _, source := range sources {
+		if source == "*" || source == internetSource {
+			return true
+		}
+		if network, err := parseSourceCIDR(source); err == nil && publicCIDR(network) {
+			return true
+		}
+	}
+	return false
+}
+
+// planRiskPath formata um caminho de replace_paths como atributo (settings[0].tier)
+func planRiskPath(path []interface{}) string {
+	var builder strings.Builder
+	for _, step := range path {
+		switch value := step.(type) {
+		case float64:
+			fmt.Fprintf(&builder, "[%d]", int(value))
+		default:
+			if builder.Len() > 0 {
+				builder.WriteString(".")
+			}
+			fmt.Fprint(&builder, value)
+		}
+	}
+	return builder.String()
+}
+
+// firewallRules converte as regras de entrada declaradas em um recurso de firewall, com os mesmos
+// conversores do analisador de alcance. Regras de aws_security_group_rule e
+// azurerm_network_security_rule são atribuídas ao próprio recurso
+func firewallRules(resourceType, address string, values map[string]interface{}) []reachabilityRule {
+	if values == nil {
+		return nil
+	}
+	var rules []reachabilityRule
+	switch resourceType {
+	case "aws_security_group":
+		for _, ingress := range planBlocks(values, "ingress") {
+			rules = append(rules, awsIngressRule(address, ingress))
+		}
+	case "aws_security_group_rule":
+		if planString(values, "type") == "ingress" {
+			rules = append(rules, awsIngressRule(address, values))
+		}
+	case "digitalocean_firewall":
+		for _, inbound := range planBlocks(values, "inbound_rule") {
+			rules = append(rules, digitalOceanInboundRule(address, inbound))
+		}
+	case "digitalocean_database_firewall":
+		for _, rule := range planBlocks(values, "rule") {
+			rules = append(rules, digitalOceanDatabaseRule(address, rule))
+		}
+	case "google_compute_firewall":
+		if direction := planString(values, "direction"); (direction == "" || direction == "INGRESS") && !planBool(values, "disabled") {
+			rules = googleFirewallRules(address, values)
+		}
+	case "azurerm_network_security_group":
+		for _, rule := range planBlocks(values, "security_rule") {
+			rules = append(rules, azureSecurityRules(address, rule)...)
+		}
+	case "azurerm_network_security_rule":
+		rules = azureSecurityRules(address, values)
+	}
+	return rules
+}
+
+// widenedRules retorna as regras de liberação de after com as origens que nenhuma regra de before
+// liberava para o mesmo protocolo e portas. Regras de bloqueio removidas não são consideradas
+func widenedRules(before, after []reachabilityRule) []reachabilityRule {
+	var widened []reachabilityRule
+	for _, rule := range after {
+		if rule.Deny {
+			continue
+		}
+		if rule.Unknown {
+			widened = append(widened, rule)
+			continue
+		}
+		var sources []string
+		for _, source := range rule.Sources {
+			covered := false
+			for _, previous := range before {
+				if previous.Deny || previous.Unknown {
+					continue
+				}
+				if (previous.Protocol == "all" || previous.Protocol == rule.Protocol) &&
+					previous.FromPort <= rule.FromPort && previous.ToPort >= rule.ToPort && previous.allows(source) {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				sources = append(sources, source)
+			}
+		}
+		if len(sources) > 0 {
+			rule.Sources = sources
+			widened = append(widened, rule)
+		}
+	}
+	return widened
+}
+
+// capacityDecreases compara os atributos de quantidade de nós em before e after, inclusive em
+// blocos aninhados, e descreve os que diminuem
+func capacityDecreases(path string, before, after interface{}) []string {
+	var decreases []string
+	switch previous := before.(type) {
+	case map[string]interface{}:
+		current, ok := after.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		keys := make([]string, 0, len(previous))
+		for key := range previous {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			if from, ok := previous[key].(float64); ok && containsString(planRiskCapacityKeys, key) {
+				if to, ok := current[key].(float64); ok && to < from {
+					decreases = append(decreases, fmt.Sprintf("%s de %d para %d", child, int(from), int(to)))
+				}
+				continue
+			}
+			decreases = append(decreases, capacityDecreases(child, previous[key], current[key])...)
+		}
+	case []interface{}:
+		current, ok := after.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i := range previous {
+			if i < len(current) {
+				decreases = append(decreases, capacityDecreases(fmt.Sprintf("%s[%d]", path, i), previous[i], current[i])...)
+			}
+		}
+	}
+	return decreases
+}
+
+// Markdown formata o relatório para um comentário de pull request: as mudanças com algum motivo
+// de risco em uma tabela e as demais agrupadas em uma lista recolhida
+func (r *planRiskReport) Markdown() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "### Risco do plano: %s (pontuação %d)\n\n", r.Level(), r.Score())
+	switch {
+	case r.Environment == "":
+		builder.WriteString("Ambiente não identificado no plano.\n\n")
+	case r.Multiplier > 1:
+		fmt.Fprintf(&builder, "Ambiente `%s`: pontuação das mudanças multiplicada por %d.\n\n", r.Environment, r.Multiplier)
+	default:
+		fmt.Fprintf(&builder, "Ambiente `%s`.\n\n", r.Environment)
+	}
+	if len(r.Changes) == 0 {
+		builder.WriteString("Nenhuma mudança em recursos.\n")
+		return builder.String()
+	}
+
+	var routine []planRiskChange
+	var rows []string
+	for _, change := range r.Changes {
+		if len(change.Reasons) == 0 {
+			routine = append(routine, change)
+			continue
+		}
+		reasons := strings.ReplaceAll(strings.Join(change.Reasons, "; "), "|", "\\|")
+		rows = append(rows, fmt.Sprintf("| %s | %d | %s | `%s` | %s |", change.Level(), change.Score, change.Action, change.Address, reasons))
+	}
+	if len(rows) > 0 {
+		builder.WriteString("| Risco | Pontos | Ação | Recurso | Motivos |\n|---|---:|---|---|---|\n")
+		builder.WriteString(strings.Join(rows, "\n") + "\n")
+	}
+	if len(routine) > 0 {
+		if len(rows) > 0 {
+			builder.WriteString("\n")
+		}
+		fmt.Fprintf(&builder, "<details><summary>%d mudança(s) sem risco identificado</summary>\n\n", len(routine))
+		for _, change := range routine {
+			fmt.Fprintf(&builder, "- %s `%s` (%d)\n", change.Action, change.Address, change.Score)
+		}
+		builder.WriteString("\n</details>\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPlanRisk pontua os planos de exemplo de testdata/plan_risk e compara o resumo em Markdown com
+// o arquivo .md de mesmo nome. Com PLAN_RISK_PLAN (saída de `terraform show -json`), pontua o plano
+// informado e grava o resumo em PLAN_RISK_OUTPUT, para ser publicado como comentário no pull request
+func TestPlanRisk(t *testing.T) {
+	t.Parallel()
+
+	plans, err := filepath.Glob(filepath.Join("testdata", "plan_risk", "*.json"))
+	if err != nil || len(plans) == 0 {
+		t.Fatalf("Nenhum plano de exemplo em testdata/plan_risk: %v", err)
+	}
+	for _, path := range plans {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Erro ao ler %s: %v", path, err)
+		}
+		report, err := analyzePlanRisk(content, "")
+		if err != nil {
+			t.Fatalf("Erro ao analisar %s: %v", path, err)
+		}
+		golden, err := os.ReadFile(strings.TrimSuffix(path, ".json") + ".md")
+		if err != nil {
+			t.Fatalf("Erro ao ler o resumo esperado de %s: %v", path, err)
+		}
+		assert.Equal(t, string(golden), report.Markdown(), path)
+	}
+
+	path := os.Getenv("PLAN_RISK_PLAN")
+	if path == "" {
+		return
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Erro ao ler %s: %v", path, err)
+	}
+	report, err := analyzePlanRisk(content, os.Getenv("PLAN_RISK_ENVIRONMENT"))
+	if err != nil {
+		t.Fatalf("Erro ao analisar %s: %v", path, err)
+	}
+	t.Logf("Risco de %s:\n%s", path, report.Markdown())
+	if output := os.Getenv("PLAN_RISK_OUTPUT"); output != "" {
+		if err := os.WriteFile(output, []byte(report.Markdown()), 0644); err != nil {
+			t.Fatalf("Erro ao gravar %s: %v", output, err)
+		}
+	}
+}
+
+// TestPlanRiskRules cobre cada regra de pontuação com mudanças isoladas
+func TestPlanRiskRules(t *testing.T) {
+	t.Parallel()
+
+	change := func(resourceType string, actions []string, before, after map[string]interface{}) tfJSONResourceChange {
+		resource := tfJSONResourceChange{Address: resourceType + ".main", Type: resourceType}
+		resource.Change.Actions = actions
+		resource.Change.Before = before
+		resource.Change.After = after
+		return resource
+	}
+	cases := []struct {
+		name    string
+		change  tfJSONResourceChange
+		score   int
+		reasons []string
+	}{
+		{"destruir banco", change("aws_db_instance", []string{"delete"}, map[string]interface{}{}, nil),
+			planRiskDelete + planRiskStatefulLoss, []string{"remove recurso com dados"}},
+		{"substituir bucket", change("digitalocean_spaces_bucket", []string{"create", "delete"}, map[string]interface{}{}, map[string]interface{}{}),
+			planRiskReplace + planRiskStatefulLoss, []string{"remove recurso com dados"}},
+		{"destruir recurso sem dados", change("digitalocean_record", []string{"delete"}, map[string]interface{}{}, nil),
+			planRiskDelete, nil},
+		{"RBAC", change("kubernetes_cluster_role_binding", []string{"update"}, map[string]interface{}{}, map[string]interface{}{}),
+			planRiskUpdate + planRiskPermissions, []string{"altera permissões de IAM ou RBAC"}},
+		{"IAM do GCP", change("google_storage_bucket_iam_binding", []string{"create"}, nil, map[string]interface{}{}),
+			planRiskCreate + planRiskPermissions, []string{"altera permissões de IAM ou RBAC"}},
+		{"deletion_protection", change("google_container_cluster", []string{"update"},
+			map[string]interface{}{"deletion_protection": true}, map[string]interface{}{"deletion_protection": false}),
+			planRiskUpdate + planRiskProtectionOff, []string{"desliga deletion_protection"}},
+		{"deletion_protection ligado", change("aws_lb", []string{"update"},
+			map[string]interface{}{"enable_deletion_protection": false}, map[string]interface{}{"enable_deletion_protection": true}),
+			planRiskUpdate, nil},
+		{"snapshot final", change("aws_db_instance", []string{"update"},
+			map[string]interface{}{"skip_final_snapshot": false}, map[string]interface{}{"skip_final_snapshot": true}),
+			planRiskUpdate + planRiskProtectionOff, []string{"deixa de criar o snapshot final (skip_final_snapshot)"}},
+		{"node pool removido", change("digitalocean_kubernetes_node_pool", []string{"delete"}, map[string]interface{}{"node_count": 3.0}, nil),
+			planRiskDelete + planRiskCapacityDecrease, []string{"remove o node pool"}},
+		{"node pool reduzido", change("azurerm_kubernetes_cluster", []string{"update"},
+			map[string]interface{}{"default_node_pool": []interface{}{map[string]interface{}{"node_count": 3.0, "max_count": 5.0}}},
+			map[string]interface{}{"default_node_pool": []interface{}{map[string]interface{}{"node_count": 2.0, "max_count": 4.0}}}),
+			planRiskUpdate + 2*planRiskCapacityDecrease, []string{"reduz default_node_pool[0].max_count de 5 para 4", "reduz default_node_pool[0].node_count de 3 para 2"}},
+		{"firewall aberto à internet", change("azurerm_network_security_rule", []string{"create"}, nil,
+			map[string]interface{}{"name": "ssh", "direction": "Inbound", "access": "Allow", "protocol": "Tcp", "source_address_prefix": "Internet", "destination_port_range": "22"}),
+			planRiskCreate + planRiskPublicWidening, []string{"amplia acesso de entrada: libera tcp/22 de internet"}},
+		{"firewall com faixa maior", change("aws_security_group_rule", []string{"update"},
+			map[string]interface{}{"type": "ingress", "protocol": "tcp", "from_port": 5432.0, "to_port": 5432.0, "cidr_blocks": []interface{}{"10.0.1.0/24"}},
+			map[string]interface{}{"type": "ingress", "protocol": "tcp", "from_port": 5432.0, "to_port": 5432.0, "cidr_blocks": []interface{}{"10.0.0.0/16"}}),
+			planRiskUpdate + planRiskPrivateWidening, []string{"amplia acesso de entrada: libera tcp/5432 de 10.0.0.0/16"}},
+		{"firewall restrito", change("aws_security_group_rule", []string{"update"},
+			map[string]interface{}{"type": "ingress", "protocol": "tcp", "from_port": 0.0, "to_port": 65535.0, "cidr_blocks": []interface{}{"10.0.0.0/8"}},
+			map[string]interface{}{"type": "ingress", "protocol": "tcp", "from_port": 5432.0, "to_port": 5432.0, "cidr_blocks": []interface{}{"10.0.1.0/24"}}),
+			planRiskUpdate, nil},
+		{"regra de saída", change("aws_security_group_rule", []string{"create"}, nil,
+			map[string]interface{}{"type": "egress", "protocol": "-1", "from_port": 0.0, "to_port": 0.0, "cidr_blocks": []interface{}{"0.0.0.0/0"}}),
+			planRiskCreate, nil},
+	}
+	for _, c := range cases {
+		scored, ok := scorePlanChange(c.change)
+		if assert.True(t, ok, c.name) {
+			assert.Equal(t, c.score, scored.Score, c.name)
+			assert.Equal(t, c.reasons, scored.Reasons, c.name)
+		}
+	}
+
+	_, ok := scorePlanChange(change("aws_db_instance", []string{"no-op"}, map[string]interface{}{}, map[string]interface{}{}))
+	assert.False(t, ok, "no-op não é pontuado")
+
+	assert.Equal(t, planRiskLow, planRiskLevel(9))
+	assert.Equal(t, planRiskMedium, planRiskLevel(10))
+	assert.Equal(t, planRiskHigh, planRiskLevel(25))
+	assert.Equal(t, planRiskCritical, planRiskLevel(50))
+
+	// O mesmo plano em prod tem a pontuação dobrada
+	plan := []byte(`{"resource_changes": [{"address": "aws_iam_role.app", "mode": "managed", "type": "aws_iam_role",
+		"change": {"actions": ["update"], "before": {}, "after": {}}}]}`)
+	for environment, score := range map[string]int{"dev": 23, "prod": 46} {
+		report, err := analyzePlanRisk(plan, environment)
+		if assert.NoError(t, err) {
+			assert.Equal(t, score, report.Score(), environment)
+		}
+	}
+	report, err := analyzePlanRisk([]byte(`{"format_version": "1.2", "resource_changes": []}`), "staging")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "### Risco do plano: baixo (pontuação 0)\n\nAmbiente `staging`.\n\nNenhuma mudança em recursos.\n", report.Markdown())
+	}
+	_, err = analyzePlanRisk([]byte(`{"values": {}}`), "dev")
+	assert.EqualError(t, err, "JSON não contém resource_changes")
+}
@@ -0,0 +1,222 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Variáveis de modules/local que mapeiam portas do host
+var localPortVariables = []string{"postgres_port", "app_external_port", "redis_port", "pgadmin_port"}
+
+// Tempo após o qual uma reserva é considerada abandonada mesmo que o processo dono ainda exista
+// (pid reaproveitado pelo sistema). Fica acima do -timeout usado nos testes de infraestrutura
+const defaultPortLeaseTTL = 3 * time.Hour
+
+// portAllocator reserva portas livres do host dentro de [Start, End]. Cada reserva é um arquivo
+// <porta>.lock em Dir criado com O_EXCL, o que vale também entre processos de `go test` diferentes
+// (pacotes rodados em paralelo ou execuções simultâneas na mesma máquina)
+type portAllocator struct {
+	Start int
+	End   int
+	Dir   string
+	TTL   time.Duration
+}
+
+// newPortAllocator cria um alocador para a faixa informada, com as reservas no diretório temporário
+// compartilhado da máquina
+func newPortAllocator(start, end int) (*portAllocator, error) {
+	if start <= 0 || end > 65535 || start > end {
+		return nil, fmt.Errorf("faixa de portas inválida: %d-%d", start, end)
+	}
+	return &portAllocator{
+		Start: start,
+		End:   end,
+		Dir:   filepath.Join(os.TempDir(), "terratest-ports"),
+		TTL:   defaultPortLeaseTTL,
+	}, nil
+}
+
+// Lease reserva uma porta para cada nome e as libera ao final do teste, depois dos defers que
+// destroem os contêineres
+func (a *portAllocator) Lease(t *testing.T, names ...string) map[string]int {
+	ports := map[string]int{}
+	for _, name := range names {
+		port, err := a.lease(t.Name() + " " + name)
+		if err != nil {
+			for _, leased := range ports {
+				a.release(leased)
+			}
+			t.Fatalf("Erro ao reservar porta para %s: %v", name, err)
+		}
+		ports[name] = port
+	}
+
+	t.Cleanup(func() {
+		for name, port := range ports {
+			if err := a.release(port); err != nil {
+				t.Logf("Erro ao liberar a porta %d (%s): %v", port, name, err)
+			}
+		}
+	})
+	return ports
+}
+
+// lease percorre a faixa a partir de uma posição aleatória, para que processos concorrentes não
+// disputem sempre as mesmas portas, e retorna a primeira que conseguir travar e esteja livre no host
+func (a *portAllocator) lease(owner string) (int, error) {
+	if err := os.MkdirAll(a.Dir, 0777); err != nil {
+		return 0, err
+	}
+
+	size := a.End - a.Start + 1
+	offset := rand.Intn(size)
+	for i := 0; i < size; i++ {
+		port := a.Start + (offset+i)%size
+		locked, err := a.lock(port, owner)
+		if err != nil {
+			return 0, err
+		}
+		if !locked {
+			continue
+		}
+		// A trava só protege contra outros testes; a porta ainda pode estar em uso por outro programa
+		if !hostPortFree(port) {
+			a.release(port)
+			continue
+		}
+		return port, nil
+	}
+	return 0, fmt.Errorf("nenhuma porta livre entre %d e %d", a.Start, a.End)
+}
+
+// lock cria o arquivo de reserva da porta, retomando reservas abandonadas
+func (a *portAllocator) lock(port int, owner string) (bool, error) {
+	path := a.lockPath(port)
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(file, "%d\n%s\n%s\n", os.Getpid(), owner, time.Now().UTC().Format(time.RFC3339))
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return false, err
+			}
+			return true, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return false, err
+		}
+		if !a.reclaim(path) {
+			return false, nil
+		}
+	}
+	return false, nil
+}
+
+// reclaim remove a reserva se o processo dono terminou ou se ela passou do TTL. O arquivo é
+// renomeado antes de ser removido: se outro processo retomou e travou a porta nesse meio tempo, o
+// conteúdo muda e a reserva nova é devolvida ao lugar
+func (a *portAllocator) reclaim(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		// Liberada entre a tentativa de criação e a leitura
+		return errors.Is(err, os.ErrNotExist)
+	}
+	if !a.stale(string(content)) {
+		return false
+	}
+
+	claimed := fmt.Sprintf("%s.%d.reclaim", path, os.Getpid())
+	if err := os.Rename(path, claimed); err != nil {
+		return false
+	}
+	defer os.Remove(claimed)
+
+	current, err := os.ReadFile(claimed)
+	if err != nil || string(current) != string(content) {
+		os.Link(claimed, path)
+		return false
+	}
+	return true
+}
+
+// stale interpreta o conteúdo de um arquivo de reserva (pid, dono e horário, um por linha)
+func (a *portAllocator) stale(content string) bool {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if len(lines) < 3 {
+		return true
+	}
+	pid, err := strconv.Atoi(lines[0])
+	if err != nil || !processAlive(pid) {
+		return true
+	}
+	leasedAt, err := time.Parse(time.RFC3339, lines[2])
+	return err != nil || time.Since(leasedAt) > a.TTL
+}
+
+// release libera a reserva da porta
+func (a *portAllocator) release(port int) error {
+	err := os.Remove(a.lockPath(port))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (a *portAllocator) lockPath(port int) string {
+	return filepath.Join(a.Dir, fmt.Sprintf("%d.lock", port))
+}
+
+// processAlive envia o sinal 0, que só verifica se o processo existe. EPERM indica um processo de
+// outro usuário, ainda vivo
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// hostPortFree verifica se a porta pode ser publicada pelo Docker, que escuta em todas as interfaces
+func hostPortFree(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// leaseLocalPorts reserva as portas do host de modules/local na faixa test_port_range_start e
+// test_port_range_end do ambiente dev e as retorna como variáveis do Terraform
+func leaseLocalPorts(t *testing.T) map[string]interface{} {
+	cfg, err := loadEnvironmentConfig("dev")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	allocator, err := newPortAllocator(cfg.Provider.Local.TestPortRangeStart, cfg.Provider.Local.TestPortRangeEnd)
+	if err != nil {
+		t.Fatalf("Erro na faixa de portas de provider.local: %v", err)
+	}
+
+	vars := map[string]interface{}{}
+	for name, port := range allocator.Lease(t, localPortVariables...) {
+		vars[name] = port
+	}
+	return vars
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// freePortRange encontra uma faixa de portas livres no host para os testes do alocador
+func freePortRange(t *testing.T, size int) (int, int) {
+	for start := 40000; start+size <= 60000; start += size {
+		free := true
+		for port := start; port < start+size; port++ {
+			if !hostPortFree(port) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return start, start + size - 1
+		}
+	}
+	t.Fatalf("Nenhuma faixa de %d portas livres", size)
+	return 0, 0
+}
+
+func newTestPortAllocator(t *testing.T, start, end int, dir string) *portAllocator {
+	allocator, err := newPortAllocator(start, end)
+	if err != nil {
+		t.Fatalf("Erro ao criar o alocador: %v", err)
+	}
+	allocator.Dir = dir
+	return allocator
+}
+
+func writePortLock(t *testing.T, allocator *portAllocator, port, pid int, leasedAt time.Time) {
+	content := fmt.Sprintf("%d\nOutroTeste postgres_port\n%s\n", pid, leasedAt.UTC().Format(time.RFC3339))
+	if err := os.MkdirAll(allocator.Dir, 0777); err != nil {
+		t.Fatalf("Erro ao criar %s: %v", allocator.Dir, err)
+	}
+	if err := os.WriteFile(allocator.lockPath(port), []byte(content), 0644); err != nil {
+		t.Fatalf("Erro ao gravar a reserva: %v", err)
+	}
+}
+
+// TestPortAllocator cobre as reservas entre alocadores que compartilham o diretório, como dois
+// processos de `go test`, as reservas de processos vivos, as abandonadas e a liberação no cleanup
+func TestPortAllocator(t *testing.T) {
+	t.Parallel()
+
+	start, end := freePortRange(t, 8)
+	dir := t.TempDir()
+
+	t.Run("sem sobreposição", func(t *testing.T) {
+		first := newTestPortAllocator(t, start, end, dir)
+		second := newTestPortAllocator(t, start, end, dir)
+
+		seen := map[int]bool{}
+		for _, ports := range []map[string]int{
+			first.Lease(t, localPortVariables...),
+			second.Lease(t, localPortVariables...),
+		} {
+			assert.Len(t, ports, len(localPortVariables))
+			for name, port := range ports {
+				assert.False(t, seen[port], "porta %d reservada duas vezes (%s)", port, name)
+				assert.GreaterOrEqual(t, port, start)
+				assert.LessOrEqual(t, port, end)
+				assert.FileExists(t, first.lockPath(port))
+				seen[port] = true
+			}
+		}
+
+		// Faixa esgotada
+		_, err := first.lease("extra")
+		assert.Error(t, err)
+	})
+
+	t.Run("liberação no cleanup", func(t *testing.T) {
+		allocator := newTestPortAllocator(t, start, end, dir)
+		var leased map[string]int
+		t.Run("teste", func(t *testing.T) {
+			leased = allocator.Lease(t, "app_external_port")
+		})
+		assert.NoFileExists(t, allocator.lockPath(leased["app_external_port"]))
+	})
+
+	t.Run("reserva de outro processo", func(t *testing.T) {
+		allocator := newTestPortAllocator(t, start, start+1, t.TempDir())
+		// O processo pai do `go test` continua vivo durante o teste
+		writePortLock(t, allocator, start, os.Getppid(), time.Now())
+
+		port, err := allocator.lease("teste")
+		if assert.NoError(t, err) {
+			assert.Equal(t, start+1, port)
+		}
+		content, _ := os.ReadFile(allocator.lockPath(start))
+		assert.Contains(t, string(content), "OutroTeste")
+	})
+
+	t.Run("reservas abandonadas", func(t *testing.T) {
+		allocator := newTestPortAllocator(t, start, start, t.TempDir())
+
+		writePortLock(t, allocator, start, math.MaxInt32, time.Now())
+		port, err := allocator.lease("processo encerrado")
+		assert.NoError(t, err)
+		assert.Equal(t, start, port)
+		assert.NoError(t, allocator.release(port))
+
+		writePortLock(t, allocator, start, os.Getppid(), time.Now().Add(-allocator.TTL-time.Minute))
+		port, err = allocator.lease("reserva expirada")
+		assert.NoError(t, err)
+		assert.Equal(t, start, port)
+
+		matches, _ := filepath.Glob(filepath.Join(allocator.Dir, "*.reclaim"))
+		assert.Empty(t, matches)
+	})
+
+	t.Run("porta ocupada no host", func(t *testing.T) {
+		listener, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Fatalf("Erro ao abrir porta: %v", err)
+		}
+		defer listener.Close()
+		busy := listener.Addr().(*net.TCPAddr).Port
+
+		allocator := newTestPortAllocator(t, busy, busy, t.TempDir())
+		_, err = allocator.lease("teste")
+		assert.Error(t, err)
+		assert.NoFileExists(t, allocator.lockPath(busy), "a trava de uma porta ocupada deve ser desfeita")
+	})
+
+	_, err := newPortAllocator(32999, 32000)
+	assert.Error(t, err)
+}
+
+// TestLeaseLocalPorts confere as variáveis de modules/local reservadas na faixa do ambiente dev
+func TestLeaseLocalPorts(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadEnvironmentConfig("dev")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	assert.Equal(t, 32000, cfg.Provider.Local.TestPortRangeStart)
+	assert.Equal(t, 32999, cfg.Provider.Local.TestPortRangeEnd)
+
+	variables, err := loadModuleVariables(filepath.Join("..", "modules", "local"))
+	if err != nil {
+		t.Fatalf("Erro ao ler as variáveis de modules/local: %v", err)
+	}
+
+	vars := leaseLocalPorts(t)
+	assert.Len(t, vars, len(localPortVariables))
+	for _, name := range localPortVariables {
+		assert.Contains(t, variables, name)
+		port, ok := vars[name].(int)
+		if assert.True(t, ok, name) {
+			assert.GreaterOrEqual(t, port, cfg.Provider.Local.TestPortRangeStart, name)
+			assert.LessOrEqual(t, port, cfg.Provider.Local.TestPortRangeEnd, name)
+		}
+	}
+}
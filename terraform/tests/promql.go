@@ -0,0 +1,683 @@
+package test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Parser de PromQL suficiente para validar as expressões dos dashboards e regras de alerta.
+// Cobre seletores, matchers, ranges, offset, subqueries, funções, agregações e operadores
+// binários com bool/on/ignoring/group_left/group_right, seguindo a precedência do Prometheus.
+
+// promExpr é um nó da árvore sintática de uma expressão PromQL
+type promExpr interface {
+	promNode()
+}
+
+type promNumber struct {
+	Value float64
+}
+
+type promString struct {
+	Value string
+}
+
+// promMatcher é um filtro de label: =, !=, =~ ou !~
+type promMatcher struct {
+	Name  string
+	Op    string
+	Value string
+}
+
+// promSelector é um seletor de vetor instantâneo ou, com Range, de vetor de intervalo
+type promSelector struct {
+	Metric   string
+	Matchers []promMatcher
+	Range    time.Duration
+	Offset   time.Duration
+}
+
+type promCall struct {
+	Func string
+	Args []promExpr
+}
+
+type promAggregate struct {
+	Op       string
+	Param    promExpr
+	Expr     promExpr
+	Grouping []string
+	Without  bool
+}
+
+type promBinary struct {
+	Op         string
+	LHS, RHS   promExpr
+	ReturnBool bool
+	On         bool
+	Labels     []string
+	Group      string
+}
+
+type promUnary struct {
+	Op   string
+	Expr promExpr
+}
+
+type promParen struct {
+	Expr promExpr
+}
+
+type promSubquery struct {
+	Expr  promExpr
+	Range time.Duration
+	Step  time.Duration
+}
+
+func (promNumber) promNode()    {}
+func (promString) promNode()    {}
+func (promSelector) promNode()  {}
+func (promCall) promNode()      {}
+func (promAggregate) promNode() {}
+func (promBinary) promNode()    {}
+func (promUnary) promNode()     {}
+func (promParen) promNode()     {}
+func (promSubquery) promNode()  {}
+
+// promFunctions mapeia as funções conhecidas para o índice do argumento que exige vetor de intervalo (-1 se nenhum)
+var promFunctions = map[string]int{
+	"abs": -1, "absent": -1, "absent_over_time": 0, "avg_over_time": 0, "ceil": -1, "changes": 0,
+	"clamp": -1, "clamp_max": -1, "clamp_min": -1, "count_over_time": 0, "day_of_month": -1,
+	"day_of_week": -1, "day_of_year": -1, "days_in_month": -1, "delta": 0, "deriv": 0, "exp": -1,
+	"floor": -1, "histogram_quantile": -1, "holt_winters": 0, "hour": -1, "idelta": 0, "increase": 0,
+	"irate": 0, "label_join": -1, "label_replace": -1, "last_over_time": 0, "ln": -1, "log10": -1,
+	"log2": -1, "max_over_time": 0, "min_over_time": 0, "minute": -1, "month": -1, "predict_linear": 0,
+	"present_over_time": 0, "quantile_over_time": 1, "rate": 0, "resets": 0, "round": -1, "scalar": -1,
+	"sgn": -1, "sort": -1, "sort_desc": -1, "sqrt": -1, "stddev_over_time": 0, "stdvar_over_time": 0,
+	"sum_over_time": 0, "time": -1, "timestamp": -1, "vector": -1, "year": -1,
+}
+
+// promAggregations lista as agregações e se recebem um parâmetro antes da expressão
+var promAggregations = map[string]bool{
+	"sum": false, "avg": false, "min": false, "max": false, "count": false, "group": false,
+	"stddev": false, "stdvar": false, "topk": true, "bottomk": true, "quantile": true, "count_values": true,
+}
+
+// promBinaryPrecedence segue a ordem do Prometheus: quanto maior, mais forte a associação
+var promBinaryPrecedence = map[string]int{
+	"or": 1, "and": 2, "unless": 2,
+	"==": 3, "!=": 3, "<=": 3, "<": 3, ">=": 3, ">": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5, "atan2": 5,
+	"^": 6,
+}
+
+type promTokenKind int
+
+const (
+	promEOF promTokenKind = iota
+	promIdent
+	promNumberToken
+	promStringToken
+	promDuration
+	promOperator
+	promPunct
+)
+
+type promToken struct {
+	Kind  promTokenKind
+	Value string
+	Pos   int
+}
+
+// lexPromQL separa a expressão em tokens
+func lexPromQL(input string) ([]promToken, error) {
+	var tokens []promToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '"' || r == '\'' || r == '`':
+			start := i
+			var value strings.Builder
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && r != '`' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("string não terminada na posição %d", start)
+			}
+			i++
+			tokens = append(tokens, promToken{promStringToken, value.String(), start})
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '+' || runes[i] == '-') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			// Durações como 5m, 1h30m ou 100ms
+			if i < len(runes) && strings.ContainsRune("smhdwy", runes[i]) {
+				for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune("smhdwy", runes[i])) {
+					i++
+				}
+				tokens = append(tokens, promToken{promDuration, string(runes[start:i]), start})
+				continue
+			}
+			tokens = append(tokens, promToken{promNumberToken, string(runes[start:i]), start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == ':') {
+				i++
+			}
+			tokens = append(tokens, promToken{promIdent, string(runes[start:i]), start})
+
+		default:
+			start := i
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch {
+			case two == "==" || two == "!=" || two == "<=" || two == ">=" || two == "=~" || two == "!~":
+				tokens = append(tokens, promToken{promOperator, two, start})
+				i += 2
+			case strings.ContainsRune("+-*/%^<>=", r):
+				tokens = append(tokens, promToken{promOperator, string(r), start})
+				i++
+			case strings.ContainsRune("(){}[],:@", r):
+				tokens = append(tokens, promToken{promPunct, string(r), start})
+				i++
+			default:
+				return nil, fmt.Errorf("caractere inesperado %q na posição %d", r, start)
+			}
+		}
+	}
+	return append(tokens, promToken{promEOF, "", len(runes)}), nil
+}
+
+type promParser struct {
+	tokens []promToken
+	pos    int
+}
+
+// parsePromQL interpreta a expressão e retorna a árvore sintática
+func parsePromQL(input string) (promExpr, error) {
+	tokens, err := lexPromQL(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, fmt.Errorf("expressão vazia")
+	}
+
+	p := &promParser{tokens: tokens}
+	expr, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.Kind != promEOF {
+		return nil, fmt.Errorf("token inesperado %q na posição %d", tok.Value, tok.Pos)
+	}
+	return expr, nil
+}
+
+func (p *promParser) peek() promToken {
+	return p.tokens[p.pos]
+}
+
+func (p *promParser) next() promToken {
+	tok := p.tokens[p.pos]
+	if tok.Kind != promEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *promParser) expect(value string) error {
+	tok := p.next()
+	if tok.Value != value || tok.Kind == promStringToken {
+		return fmt.Errorf("esperado %q na posição %d, encontrado %q", value, tok.Pos, tok.Value)
+	}
+	return nil
+}
+
+// binaryOperator retorna o operador binário no token atual, se houver
+func (p *promParser) binaryOperator() (string, bool) {
+	tok := p.peek()
+	if tok.Kind != promOperator && tok.Kind != promIdent {
+		return "", false
+	}
+	if _, ok := promBinaryPrecedence[tok.Value]; ok {
+		return tok.Value, true
+	}
+	return "", false
+}
+
+func (p *promParser) parseBinary(minPrecedence int) (promExpr, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.binaryOperator()
+		if !ok || promBinaryPrecedence[op] < minPrecedence {
+			return lhs, nil
+		}
+		p.next()
+
+		binary := promBinary{Op: op, LHS: lhs}
+		if p.peek().Value == "bool" {
+			if promBinaryPrecedence[op] != 3 {
+				return nil, fmt.Errorf("modificador bool só é permitido em comparações, não em %q", op)
+			}
+			p.next()
+			binary.ReturnBool = true
+		}
+		if err := p.parseVectorMatching(&binary); err != nil {
+			return nil, err
+		}
+
+		// ^ é associativo à direita; os demais à esquerda
+		nextPrecedence := promBinaryPrecedence[op] + 1
+		if op == "^" {
+			nextPrecedence = promBinaryPrecedence[op]
+		}
+		if binary.RHS, err = p.parseBinary(nextPrecedence); err != nil {
+			return nil, err
+		}
+		lhs = binary
+	}
+}
+
+func (p *promParser) parseVectorMatching(binary *promBinary) error {
+	switch p.peek().Value {
+	case "on", "ignoring":
+		binary.On = p.next().Value == "on"
+		labels, err := p.parseLabelList()
+		if err != nil {
+			return err
+		}
+		binary.Labels = labels
+	default:
+		return nil
+	}
+
+	switch p.peek().Value {
+	case "group_left", "group_right":
+		binary.Group = p.next().Value
+		if p.peek().Value == "(" {
+			if _, err := p.parseLabelList(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *promParser) parseLabelList() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var labels []string
+	for p.peek().Value != ")" {
+		tok := p.next()
+		if tok.Kind != promIdent {
+			return nil, fmt.Errorf("nome de label esperado na posição %d, encontrado %q", tok.Pos, tok.Value)
+		}
+		labels = append(labels, tok.Value)
+		if p.peek().Value == "," {
+			p.next()
+		}
+	}
+	p.next()
+	return labels, nil
+}
+
+func (p *promParser) parseUnary() (promExpr, error) {
+	if tok := p.peek(); tok.Kind == promOperator && (tok.Value == "-" || tok.Value == "+") {
+		p.next()
+		// O operador unário associa menos que ^: -2^2 == -(2^2)
+		expr, err := p.parseBinary(promBinaryPrecedence["^"])
+		if err != nil {
+			return nil, err
+		}
+		return promUnary{Op: tok.Value, Expr: expr}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *promParser) parsePostfix() (promExpr, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().Value {
+		case "[":
+			p.next()
+			rng, err := p.parseDuration()
+			if err != nil {
+				return nil, err
+			}
+			if p.peek().Value == ":" {
+				p.next()
+				subquery := promSubquery{Expr: expr, Range: rng}
+				if p.peek().Value != "]" {
+					if subquery.Step, err = p.parseDuration(); err != nil {
+						return nil, err
+					}
+				}
+				expr = subquery
+			} else {
+				selector, ok := expr.(promSelector)
+				if !ok || selector.Range != 0 {
+					return nil, fmt.Errorf("range [%s] só pode ser aplicado a um seletor de vetor instantâneo", rng)
+				}
+				selector.Range = rng
+				expr = selector
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+
+		case "offset":
+			p.next()
+			offset, err := p.parseDuration()
+			if err != nil {
+				return nil, err
+			}
+			selector, ok := expr.(promSelector)
+			if !ok {
+				return nil, fmt.Errorf("offset só pode ser aplicado a seletores")
+			}
+			selector.Offset = offset
+			expr = selector
+
+		default:
+			return expr, nil
+		}
+	}
+}
+
+func (p *promParser) parseDuration() (time.Duration, error) {
+	tok := p.next()
+	if tok.Kind != promDuration {
+		return 0, fmt.Errorf("duração esperada na posição %d, encontrado %q", tok.Pos, tok.Value)
+	}
+	return parsePromDuration(tok.Value)
+}
+
+// parsePromDuration aceita as unidades do Prometheus, incluindo d, w e y
+func parsePromDuration(value string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"ms": time.Millisecond, "s": time.Second, "m": time.Minute, "h": time.Hour,
+		"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour,
+	}
+
+	var total time.Duration
+	rest := value
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		j := i
+		for j < len(rest) && (rest[j] < '0' || rest[j] > '9') {
+			j++
+		}
+		n, err := strconv.Atoi(rest[:i])
+		unit, ok := units[rest[i:j]]
+		if err != nil || !ok {
+			return 0, fmt.Errorf("duração inválida %q", value)
+		}
+		total += time.Duration(n) * unit
+		rest = rest[j:]
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("duração deve ser positiva: %q", value)
+	}
+	return total, nil
+}
+
+func (p *promParser) parsePrimary() (promExpr, error) {
+	tok := p.peek()
+	switch tok.Kind {
+	case promNumberToken:
+		p.next()
+		value, err := strconv.ParseFloat(tok.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("número inválido %q", tok.Value)
+		}
+		return promNumber{Value: value}, nil
+
+	case promStringToken:
+		p.next()
+		return promString{Value: tok.Value}, nil
+
+	case promPunct:
+		switch tok.Value {
+		case "(":
+			p.next()
+			expr, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return promParen{Expr: expr}, nil
+		case "{":
+			return p.parseSelector("")
+		}
+
+	case promIdent:
+		p.next()
+		lower := strings.ToLower(tok.Value)
+		if lower == "inf" || lower == "nan" {
+			value, _ := strconv.ParseFloat(tok.Value, 64)
+			return promNumber{Value: value}, nil
+		}
+		if _, ok := promAggregations[tok.Value]; ok && (p.peek().Value == "(" || p.peek().Value == "by" || p.peek().Value == "without") {
+			return p.parseAggregate(tok.Value)
+		}
+		if p.peek().Value == "(" {
+			return p.parseCall(tok)
+		}
+		return p.parseSelector(tok.Value)
+	}
+
+	return nil, fmt.Errorf("token inesperado %q na posição %d", tok.Value, tok.Pos)
+}
+
+func (p *promParser) parseSelector(metric string) (promExpr, error) {
+	selector := promSelector{Metric: metric}
+	if p.peek().Value != "{" {
+		return selector, nil
+	}
+	p.next()
+
+	for p.peek().Value != "}" {
+		name := p.next()
+		if name.Kind != promIdent {
+			return nil, fmt.Errorf("nome de label esperado na posição %d, encontrado %q", name.Pos, name.Value)
+		}
+		op := p.next()
+		if op.Value != "=" && op.Value != "!=" && op.Value != "=~" && op.Value != "!~" {
+			return nil, fmt.Errorf("operador de label inválido %q na posição %d", op.Value, op.Pos)
+		}
+		value := p.next()
+		if value.Kind != promStringToken {
+			return nil, fmt.Errorf("valor de label deve ser string na posição %d", value.Pos)
+		}
+		selector.Matchers = append(selector.Matchers, promMatcher{Name: name.Value, Op: op.Value, Value: value.Value})
+		if p.peek().Value == "," {
+			p.next()
+		}
+	}
+	p.next()
+
+	if metric == "" {
+		for _, m := range selector.Matchers {
+			if m.Value != "" && (m.Op == "=" || m.Op == "=~") {
+				return selector, nil
+			}
+		}
+		return nil, fmt.Errorf("seletor sem nome de métrica precisa de ao menos um matcher que não aceite valor vazio")
+	}
+	return selector, nil
+}
+
+func (p *promParser) parseCall(name promToken) (promExpr, error) {
+	rangeArg, ok := promFunctions[name.Value]
+	if !ok {
+		return nil, fmt.Errorf("função desconhecida %q na posição %d", name.Value, name.Pos)
+	}
+
+	p.next()
+	call := promCall{Func: name.Value}
+	for p.peek().Value != ")" {
+		arg, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+		if p.peek().Value == "," {
+			p.next()
+		} else if p.peek().Value != ")" {
+			return nil, fmt.Errorf("esperado \",\" ou \")\" na posição %d", p.peek().Pos)
+		}
+	}
+	p.next()
+
+	if rangeArg >= 0 {
+		if rangeArg >= len(call.Args) {
+			return nil, fmt.Errorf("%s espera ao menos %d argumentos", call.Func, rangeArg+1)
+		}
+		if !isPromRangeVector(call.Args[rangeArg]) {
+			return nil, fmt.Errorf("%s espera um vetor de intervalo (ex.: metric[5m])", call.Func)
+		}
+	}
+	return call, nil
+}
+
+func isPromRangeVector(expr promExpr) bool {
+	switch e := expr.(type) {
+	case promSelector:
+		return e.Range > 0
+	case promSubquery:
+		return true
+	case promParen:
+		return isPromRangeVector(e.Expr)
+	}
+	return false
+}
+
+func (p *promParser) parseAggregate(op string) (promExpr, error) {
+	aggregate := promAggregate{Op: op}
+	if err := p.parseGrouping(&aggregate); err != nil {
+		return nil, err
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if promAggregations[op] {
+		param, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		aggregate.Param = param
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+	expr, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	aggregate.Expr = expr
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	if aggregate.Grouping == nil {
+		if err := p.parseGrouping(&aggregate); err != nil {
+			return nil, err
+		}
+	}
+	return aggregate, nil
+}
+
+func (p *promParser) parseGrouping(aggregate *promAggregate) error {
+	switch p.peek().Value {
+	case "by", "without":
+		aggregate.Without = p.next().Value == "without"
+		labels, err := p.parseLabelList()
+		if err != nil {
+			return err
+		}
+		if labels == nil {
+			labels = []string{}
+		}
+		aggregate.Grouping = labels
+	}
+	return nil
+}
+
+// promMetricNames retorna os nomes de métricas referenciados pela expressão
+func promMetricNames(expr promExpr) []string {
+	var names []string
+	var walk func(promExpr)
+	walk = func(e promExpr) {
+		switch n := e.(type) {
+		case promSelector:
+			if n.Metric != "" {
+				names = append(names, n.Metric)
+			}
+			for _, m := range n.Matchers {
+				if m.Name == "__name__" && m.Op == "=" {
+					names = append(names, m.Value)
+				}
+			}
+		case promCall:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case promAggregate:
+			if n.Param != nil {
+				walk(n.Param)
+			}
+			walk(n.Expr)
+		case promBinary:
+			walk(n.LHS)
+			walk(n.RHS)
+		case promUnary:
+			walk(n.Expr)
+		case promParen:
+			walk(n.Expr)
+		case promSubquery:
+			walk(n.Expr)
+		}
+	}
+	walk(expr)
+	return names
+}
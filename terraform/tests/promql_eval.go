@@ -0,0 +1,531 @@
+package test
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Avaliador de PromQL sobre a árvore de promql.go, usado pelo fakePrometheus. Implementa o que as
+// regras de alerta e os painéis usam: seletores, funções *_over_time, rate/irate/increase,
+// agregações com by/without e operadores aritméticos e de comparação com casamento um-para-um.
+// rate e increase não fazem a extrapolação das bordas do intervalo feita pelo Prometheus.
+
+// Janela em que um seletor instantâneo ainda enxerga a última amostra (lookback delta do Prometheus)
+const promLookback = 5 * time.Minute
+
+type promSample struct {
+	T time.Time
+	V float64
+}
+
+// promSeries é uma série armazenada; Labels inclui __name__
+type promSeries struct {
+	Labels  map[string]string
+	Samples []promSample
+}
+
+// promVectorSample é um elemento de um vetor instantâneo
+type promVectorSample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// promResult é o resultado da avaliação: um escalar ou um vetor instantâneo
+type promResult struct {
+	IsScalar bool
+	Scalar   float64
+	Vector   []promVectorSample
+}
+
+// promStorage guarda as séries sintéticas em memória
+type promStorage struct {
+	mu     sync.Mutex
+	series map[string]*promSeries
+}
+
+func newPromStorage() *promStorage {
+	return &promStorage{series: map[string]*promSeries{}}
+}
+
+// Add grava uma amostra; as amostras de cada série precisam ser adicionadas em ordem de tempo
+func (s *promStorage) Add(metric string, labels map[string]string, at time.Time, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	full := map[string]string{"__name__": metric}
+	for name, v := range labels {
+		full[name] = v
+	}
+	key := promLabelsKey(full)
+	series, ok := s.series[key]
+	if !ok {
+		series = &promSeries{Labels: full}
+		s.series[key] = series
+	}
+	series.Samples = append(series.Samples, promSample{T: at, V: value})
+}
+
+// selectSeries retorna as séries que atendem o seletor, ordenadas pelos labels
+func (s *promStorage) selectSeries(selector promSelector) ([]*promSeries, error) {
+	matchers := selector.Matchers
+	if selector.Metric != "" {
+		matchers = append([]promMatcher{{Name: "__name__", Op: "=", Value: selector.Metric}}, matchers...)
+	}
+	var regexps []*regexp.Regexp
+	for _, matcher := range matchers {
+		var re *regexp.Regexp
+		if matcher.Op == "=~" || matcher.Op == "!~" {
+			compiled, err := regexp.Compile("^(?:" + matcher.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("regex inválida em %s%s%q: %v", matcher.Name, matcher.Op, matcher.Value, err)
+			}
+			re = compiled
+		}
+		regexps = append(regexps, re)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key, series := range s.series {
+		matches := true
+		for i, matcher := range matchers {
+			value := series.Labels[matcher.Name]
+			switch matcher.Op {
+			case "=":
+				matches = value == matcher.Value
+			case "!=":
+				matches = value != matcher.Value
+			case "=~":
+				matches = regexps[i].MatchString(value)
+			case "!~":
+				matches = !regexps[i].MatchString(value)
+			}
+			if !matches {
+				break
+			}
+		}
+		if matches {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := make([]*promSeries, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, s.series[key])
+	}
+	return result, nil
+}
+
+// samplesBetween retorna as amostras da série no intervalo (from, to]
+func (series *promSeries) samplesBetween(from, to time.Time) []promSample {
+	var samples []promSample
+	for _, sample := range series.Samples {
+		if sample.T.After(from) && !sample.T.After(to) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+// evalPromQL avalia a expressão no instante informado
+func evalPromQL(expr promExpr, storage *promStorage, at time.Time) (promResult, error) {
+	switch n := expr.(type) {
+	case promNumber:
+		return promResult{IsScalar: true, Scalar: n.Value}, nil
+
+	case promParen:
+		return evalPromQL(n.Expr, storage, at)
+
+	case promUnary:
+		value, err := evalPromQL(n.Expr, storage, at)
+		if err != nil || n.Op == "+" {
+			return value, err
+		}
+		return applyPromBinary("*", promResult{IsScalar: true, Scalar: -1}, value, promBinary{})
+
+	case promSelector:
+		if n.Range != 0 {
+			return promResult{}, fmt.Errorf("vetor de intervalo só é aceito como argumento de função")
+		}
+		series, err := storage.selectSeries(n)
+		if err != nil {
+			return promResult{}, err
+		}
+		end := at.Add(-n.Offset)
+		var vector []promVectorSample
+		for _, s := range series {
+			samples := s.samplesBetween(end.Add(-promLookback), end)
+			if len(samples) > 0 {
+				vector = append(vector, promVectorSample{Labels: s.Labels, Value: samples[len(samples)-1].V})
+			}
+		}
+		return promResult{Vector: vector}, nil
+
+	case promCall:
+		return evalPromCall(n, storage, at)
+
+	case promAggregate:
+		return evalPromAggregate(n, storage, at)
+
+	case promBinary:
+		lhs, err := evalPromQL(n.LHS, storage, at)
+		if err != nil {
+			return promResult{}, err
+		}
+		rhs, err := evalPromQL(n.RHS, storage, at)
+		if err != nil {
+			return promResult{}, err
+		}
+		return applyPromBinary(n.Op, lhs, rhs, n)
+
+	default:
+		return promResult{}, fmt.Errorf("expressão %T não suportada pelo avaliador", expr)
+	}
+}
+
+// promRangeFunctions calculam um valor a partir das amostras de um vetor de intervalo
+var promRangeFunctions = map[string]func(samples []promSample, window time.Duration) (float64, bool){
+	"rate": func(samples []promSample, _ time.Duration) (float64, bool) {
+		increase, ok := promCounterIncrease(samples)
+		if !ok {
+			return 0, false
+		}
+		return increase / samples[len(samples)-1].T.Sub(samples[0].T).Seconds(), true
+	},
+	"increase": func(samples []promSample, window time.Duration) (float64, bool) {
+		// Como o Prometheus, extrapola o incremento entre a primeira e a última amostra para a janela inteira
+		increase, ok := promCounterIncrease(samples)
+		if !ok {
+			return 0, false
+		}
+		return increase * window.Seconds() / samples[len(samples)-1].T.Sub(samples[0].T).Seconds(), true
+	},
+	"irate": func(samples []promSample, _ time.Duration) (float64, bool) {
+		if len(samples) < 2 {
+			return 0, false
+		}
+		last, previous := samples[len(samples)-1], samples[len(samples)-2]
+		delta := last.V - previous.V
+		if delta < 0 {
+			// Reinício do contador
+			delta = last.V
+		}
+		return delta / last.T.Sub(previous.T).Seconds(), true
+	},
+	"avg_over_time": func(samples []promSample, _ time.Duration) (float64, bool) {
+		sum := 0.0
+		for _, sample := range samples {
+			sum += sample.V
+		}
+		return sum / float64(len(samples)), len(samples) > 0
+	},
+	"sum_over_time": func(samples []promSample, _ time.Duration) (float64, bool) {
+		sum := 0.0
+		for _, sample := range samples {
+			sum += sample.V
+		}
+		return sum, len(samples) > 0
+	},
+	"max_over_time": func(samples []promSample, _ time.Duration) (float64, bool) {
+		max := math.Inf(-1)
+		for _, sample := range samples {
+			max = math.Max(max, sample.V)
+		}
+		return max, len(samples) > 0
+	},
+	"min_over_time": func(samples []promSample, _ time.Duration) (float64, bool) {
+		min := math.Inf(1)
+		for _, sample := range samples {
+			min = math.Min(min, sample.V)
+		}
+		return min, len(samples) > 0
+	},
+	"count_over_time": func(samples []promSample, _ time.Duration) (float64, bool) {
+		return float64(len(samples)), len(samples) > 0
+	},
+	"last_over_time": func(samples []promSample, _ time.Duration) (float64, bool) {
+		if len(samples) == 0 {
+			return 0, false
+		}
+		return samples[len(samples)-1].V, true
+	},
+}
+
+// promCounterIncrease soma os incrementos entre amostras consecutivas, tratando reinícios do contador
+func promCounterIncrease(samples []promSample) (float64, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	increase := 0.0
+	for i := 1; i < len(samples); i++ {
+		delta := samples[i].V - samples[i-1].V
+		if delta < 0 {
+			delta = samples[i].V
+		}
+		increase += delta
+	}
+	return increase, true
+}
+
+func evalPromCall(call promCall, storage *promStorage, at time.Time) (promResult, error) {
+	if function, ok := promRangeFunctions[call.Func]; ok {
+		if len(call.Args) != 1 {
+			return promResult{}, fmt.Errorf("%s espera um argumento", call.Func)
+		}
+		selector, ok := call.Args[0].(promSelector)
+		if !ok || selector.Range == 0 {
+			return promResult{}, fmt.Errorf("%s espera um seletor com intervalo", call.Func)
+		}
+		series, err := storage.selectSeries(selector)
+		if err != nil {
+			return promResult{}, err
+		}
+		end := at.Add(-selector.Offset)
+		var vector []promVectorSample
+		for _, s := range series {
+			if value, ok := function(s.samplesBetween(end.Add(-selector.Range), end), selector.Range); ok {
+				vector = append(vector, promVectorSample{Labels: promDropName(s.Labels), Value: value})
+			}
+		}
+		return promResult{Vector: vector}, nil
+	}
+
+	switch call.Func {
+	case "abs", "ceil", "floor", "round", "sqrt":
+		if len(call.Args) != 1 {
+			return promResult{}, fmt.Errorf("%s espera um argumento", call.Func)
+		}
+		value, err := evalPromQL(call.Args[0], storage, at)
+		if err != nil {
+			return promResult{}, err
+		}
+		apply := map[string]func(float64) float64{"abs": math.Abs, "ceil": math.Ceil, "floor": math.Floor, "round": math.Round, "sqrt": math.Sqrt}[call.Func]
+		if value.IsScalar {
+			return promResult{IsScalar: true, Scalar: apply(value.Scalar)}, nil
+		}
+		var vector []promVectorSample
+		for _, sample := range value.Vector {
+			vector = append(vector, promVectorSample{Labels: promDropName(sample.Labels), Value: apply(sample.Value)})
+		}
+		return promResult{Vector: vector}, nil
+	}
+	return promResult{}, fmt.Errorf("função %s não suportada pelo avaliador", call.Func)
+}
+
+func evalPromAggregate(aggregate promAggregate, storage *promStorage, at time.Time) (promResult, error) {
+	value, err := evalPromQL(aggregate.Expr, storage, at)
+	if err != nil {
+		return promResult{}, err
+	}
+	if value.IsScalar {
+		return promResult{}, fmt.Errorf("%s espera um vetor", aggregate.Op)
+	}
+
+	type group struct {
+		Labels map[string]string
+		Values []float64
+	}
+	groups := map[string]*group{}
+	var keys []string
+	for _, sample := range value.Vector {
+		labels := map[string]string{}
+		for name, v := range sample.Labels {
+			listed := containsString(aggregate.Grouping, name)
+			if name != "__name__" && listed != aggregate.Without {
+				labels[name] = v
+			}
+		}
+		key := promLabelsKey(labels)
+		if _, ok := groups[key]; !ok {
+			groups[key] = &group{Labels: labels}
+			keys = append(keys, key)
+		}
+		groups[key].Values = append(groups[key].Values, sample.Value)
+	}
+	sort.Strings(keys)
+
+	var vector []promVectorSample
+	for _, key := range keys {
+		g := groups[key]
+		var result float64
+		switch aggregate.Op {
+		case "sum", "avg":
+			for _, v := range g.Values {
+				result += v
+			}
+			if aggregate.Op == "avg" {
+				result /= float64(len(g.Values))
+			}
+		case "min":
+			result = math.Inf(1)
+			for _, v := range g.Values {
+				result = math.Min(result, v)
+			}
+		case "max":
+			result = math.Inf(-1)
+			for _, v := range g.Values {
+				result = math.Max(result, v)
+			}
+		case "count":
+			result = float64(len(g.Values))
+		default:
+			return promResult{}, fmt.Errorf("agregação %s não suportada pelo avaliador", aggregate.Op)
+		}
+		vector = append(vector, promVectorSample{Labels: g.Labels, Value: result})
+	}
+	return promResult{Vector: vector}, nil
+}
+
+var promComparisons = map[string]func(a, b float64) bool{
+	"==": func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+	">":  func(a, b float64) bool { return a > b },
+	"<":  func(a, b float64) bool { return a < b },
+	">=": func(a, b float64) bool { return a >= b },
+	"<=": func(a, b float64) bool { return a <= b },
+}
+
+var promArithmetic = map[string]func(a, b float64) float64{
+	"+": func(a, b float64) float64 { return a + b },
+	"-": func(a, b float64) float64 { return a - b },
+	"*": func(a, b float64) float64 { return a * b },
+	"/": func(a, b float64) float64 { return a / b },
+	"%": math.Mod,
+	"^": math.Pow,
+}
+
+// applyPromBinary aplica um operador aritmético ou de comparação entre escalares e vetores
+func applyPromBinary(op string, lhs, rhs promResult, binary promBinary) (promResult, error) {
+	arithmetic, isArithmetic := promArithmetic[op]
+	comparison, isComparison := promComparisons[op]
+	if !isArithmetic && !isComparison {
+		return promResult{}, fmt.Errorf("operador %s não suportado pelo avaliador", op)
+	}
+	if binary.Group != "" {
+		return promResult{}, fmt.Errorf("%s não suportado pelo avaliador", binary.Group)
+	}
+
+	// combine retorna o valor resultante e se o elemento permanece no vetor
+	combine := func(a, b float64) (float64, bool) {
+		if isArithmetic {
+			return arithmetic(a, b), true
+		}
+		matches := comparison(a, b)
+		if binary.ReturnBool {
+			if matches {
+				return 1, true
+			}
+			return 0, true
+		}
+		return a, matches
+	}
+	// Comparações sem bool mantêm o elemento original, inclusive o nome da métrica
+	resultLabels := func(labels map[string]string) map[string]string {
+		if isComparison && !binary.ReturnBool {
+			return labels
+		}
+		return promDropName(labels)
+	}
+
+	switch {
+	case lhs.IsScalar && rhs.IsScalar:
+		if isComparison && !binary.ReturnBool {
+			return promResult{}, fmt.Errorf("comparação entre escalares exige bool")
+		}
+		value, _ := combine(lhs.Scalar, rhs.Scalar)
+		return promResult{IsScalar: true, Scalar: value}, nil
+
+	case rhs.IsScalar:
+		var vector []promVectorSample
+		for _, sample := range lhs.Vector {
+			if value, keep := combine(sample.Value, rhs.Scalar); keep {
+				vector = append(vector, promVectorSample{Labels: resultLabels(sample.Labels), Value: value})
+			}
+		}
+		return promResult{Vector: vector}, nil
+
+	case lhs.IsScalar:
+		var vector []promVectorSample
+		for _, sample := range rhs.Vector {
+			value, keep := combine(lhs.Scalar, sample.Value)
+			if isComparison && !binary.ReturnBool {
+				// escalar > vetor filtra o vetor e mantém o valor do elemento
+				value = sample.Value
+			}
+			if keep {
+				vector = append(vector, promVectorSample{Labels: resultLabels(sample.Labels), Value: value})
+			}
+		}
+		return promResult{Vector: vector}, nil
+	}
+
+	right := map[string]promVectorSample{}
+	for _, sample := range rhs.Vector {
+		key := promMatchKey(sample.Labels, binary.Labels, binary.On)
+		if _, duplicated := right[key]; duplicated {
+			return promResult{}, fmt.Errorf("mais de um elemento do lado direito com os labels %s", key)
+		}
+		right[key] = sample
+	}
+	var vector []promVectorSample
+	for _, sample := range lhs.Vector {
+		match, ok := right[promMatchKey(sample.Labels, binary.Labels, binary.On)]
+		if !ok {
+			continue
+		}
+		if value, keep := combine(sample.Value, match.Value); keep {
+			labels := resultLabels(sample.Labels)
+			if binary.On && isArithmetic {
+				labels = map[string]string{}
+				for _, name := range binary.Labels {
+					if v, ok := sample.Labels[name]; ok {
+						labels[name] = v
+					}
+				}
+			}
+			vector = append(vector, promVectorSample{Labels: labels, Value: value})
+		}
+	}
+	return promResult{Vector: vector}, nil
+}
+
+// promLabelsKey monta uma chave estável a partir de todos os labels
+func promLabelsKey(labels map[string]string) string {
+	var parts []string
+	for name, value := range labels {
+		parts = append(parts, name+"="+value)
+	}
+	sort.Strings(parts)
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// promMatchKey monta a assinatura usada no casamento de vetores: com on, só os labels listados
+// contam; sem on, os listados (ignoring) e __name__ são descartados
+func promMatchKey(labels map[string]string, listed []string, on bool) string {
+	selected := map[string]string{}
+	for name, value := range labels {
+		if on == containsString(listed, name) && (on || name != "__name__") {
+			selected[name] = value
+		}
+	}
+	return promLabelsKey(selected)
+}
+
+// promDropName copia os labels sem __name__, como o Prometheus faz no resultado de funções e operadores
+func promDropName(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels))
+	for name, value := range labels {
+		if name != "__name__" {
+			result[name] = value
+		}
+	}
+	return result
+}
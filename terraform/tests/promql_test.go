@@ -0,0 +1,86 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParsePromQL cobre as construções usadas nos dashboards e regras de alerta
+func TestParsePromQL(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{
+		`up`,
+		`100 - (avg by (instance) (irate(node_cpu_seconds_total{mode="idle"}[5m])) * 100)`,
+		`100 * (node_memory_MemTotal_bytes - node_memory_MemAvailable_bytes) / node_memory_MemTotal_bytes`,
+		`sum(rate(http_requests_total{status=~"5..", job!="batch"}[5m])) by (job) > bool 0.5`,
+		`topk(5, sum without (instance) (rate(container_cpu_usage_seconds_total[1h] offset 1d)))`,
+		`histogram_quantile(0.95, sum by (le) (rate(http_request_duration_seconds_bucket[5m])))`,
+		`max_over_time(rate(node_network_receive_bytes_total[1m])[30m:1m])`,
+		`a * on (node) group_left (instance) b`,
+		`-2 ^ 2 ^ 3`,
+		`sum(kube_pod_status_phase{phase="Failed"}) or vector(0)`,
+		`{__name__="up", job="api"}`,
+	}
+	for _, expr := range valid {
+		_, err := parsePromQL(expr)
+		assert.NoError(t, err, expr)
+	}
+
+	invalid := map[string]string{
+		`sum(up`:                    "parêntese não fechado",
+		`rate(http_requests_total)`: "rate sem vetor de intervalo",
+		`foo(up)`:                   "função desconhecida",
+		`up{job=api}`:               "valor de label sem aspas",
+		`up[5x]`:                    "unidade de duração inválida",
+		`up + `:                     "operando ausente",
+		`{job=""}`:                  "seletor que aceita tudo",
+		`up > bool`:                 "bool sem operando",
+		`up + bool up`:              "bool fora de comparação",
+		`(up)[5m]`:                  "range aplicado a expressão",
+	}
+	for expr, reason := range invalid {
+		_, err := parsePromQL(expr)
+		assert.Error(t, err, "%s: %s", expr, reason)
+	}
+}
+
+// TestParsePromQLPrecedence confere a associatividade e a precedência dos operadores
+func TestParsePromQLPrecedence(t *testing.T) {
+	t.Parallel()
+
+	expr, err := parsePromQL(`a + b * c`)
+	if err != nil {
+		t.Fatalf("Erro no parse: %v", err)
+	}
+	sum, ok := expr.(promBinary)
+	if assert.True(t, ok) {
+		assert.Equal(t, "+", sum.Op)
+		assert.Equal(t, "*", sum.RHS.(promBinary).Op)
+	}
+
+	expr, err = parsePromQL(`2 ^ 3 ^ 2`)
+	if err != nil {
+		t.Fatalf("Erro no parse: %v", err)
+	}
+	power := expr.(promBinary)
+	assert.Equal(t, promNumber{Value: 2}, power.LHS, "^ é associativo à direita")
+
+	expr, err = parsePromQL(`-2 ^ 2`)
+	if err != nil {
+		t.Fatalf("Erro no parse: %v", err)
+	}
+	assert.Equal(t, "-", expr.(promUnary).Op, "O unário associa menos que ^")
+
+	assert.Equal(t, []string{"node_cpu_seconds_total", "up"},
+		promMetricNames(mustParsePromQL(t, `rate(node_cpu_seconds_total[5m]) + on() group_left up`)))
+}
+
+func mustParsePromQL(t *testing.T, input string) promExpr {
+	expr, err := parsePromQL(input)
+	if err != nil {
+		t.Fatalf("Erro no parse de %q: %v", input, err)
+	}
+	return expr
+}
@@ -0,0 +1,344 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// providerMigrationFile é o arquivo, em environments/<ambiente>/, que confirma uma troca de provider.active
+const providerMigrationFile = "provider_migration.yaml"
+
+// Estratégias aceitas para os dados do banco na troca de provedor
+const (
+	migrationDumpRestore = "dump-restore"
+	migrationDiscardData = "discard"
+)
+
+var (
+	// providerGate encontra o provedor em um count que depende de local.active_provider, inclusive
+	// com outras condições (como o disaster_recovery_aws)
+	providerGate = regexp.MustCompile(`local\.active_provider\s*==\s*"([^"]+)"`)
+	// moduleRole extrai o papel do caminho do módulo (./modules/<papel>/<provedor>)
+	moduleRole = regexp.MustCompile(`"\./modules/([^/"]+)`)
+	// stateBlockAddress reduz o endereço de um recurso do estado ao bloco da raiz que o criou
+	stateBlockAddress = regexp.MustCompile(`^(module\.[A-Za-z0-9_-]+|[a-z][a-z0-9]*_[a-z0-9_]+\.[A-Za-z0-9_-]+)`)
+)
+
+// providerBlock é um módulo ou recurso da raiz criado apenas para um provedor
+type providerBlock struct {
+	ID       string
+	Provider string
+	// Role é o diretório do módulo em modules/ (network, database, kubernetes...); recursos
+	// declarados direto na raiz ficam em "outros"
+	Role string
+	// Conditional indica que o count tem outras condições além do provedor
+	Conditional bool
+}
+
+// loadProviderBlocks lê os blocos da raiz terraform/ cujo count depende de local.active_provider
+func loadProviderBlocks(dir string) (map[string]providerBlock, error) {
+	graph, err := loadModuleGraph("raiz", dir)
+	if err != nil {
+		return nil, err
+	}
+	blocks := map[string]providerBlock{}
+	for id, node := range graph.Nodes {
+		match := providerGate.FindStringSubmatch(node.Count)
+		if match == nil {
+			continue
+		}
+		block := providerBlock{ID: id, Provider: match[1], Role: "outros", Conditional: hclActiveProvider.FindString(node.Count) == ""}
+		if role := moduleRole.FindStringSubmatch(node.Attributes["source"]); node.Kind == "module" && role != nil {
+			block.Role = role[1]
+		}
+		blocks[id] = block
+	}
+	return blocks, nil
+}
+
+// migrationDatabase é um banco de dados encontrado no estado atual
+type migrationDatabase struct {
+	Address string
+	Engine  string
+	Version string
+	// StorageGB é o armazenamento alocado, 0 quando o estado não informa
+	StorageGB int
+}
+
+// providerMigration é a troca de provedor entre o estado atual e o config.yaml do ambiente
+type providerMigration struct {
+	Environment string
+	From        string
+	To          string
+	// Destroy são os recursos do estado que deixam de existir, por papel
+	Destroy map[string][]string
+	// Create são os blocos do provedor de destino, por papel
+	Create   map[string][]string
+	Database *migrationDatabase
+	// DatabaseSteps são os passos de migração dos dados, vazios quando não há banco no estado
+	DatabaseSteps []string
+}
+
+// Switch indica se o estado pertence a outro provedor
+func (m *providerMigration) Switch() bool {
+	return m.From != "" && m.From != m.To
+}
+
+// Roles lista, em ordem, os papéis com recursos destruídos ou criados
+func (m *providerMigration) Roles() []string {
+	seen := map[string]bool{}
+	var roles []string
+	for _, group := range []map[string][]string{m.Destroy, m.Create} {
+		for role := range group {
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// planProviderMigration compara os recursos do estado (`terraform show -json`) com o provider.active
+// do config.yaml, usando os blocos condicionados ao provedor na raiz
+func planProviderMigration(environment string, cfg *environmentConfig, state []byte, blocks map[string]providerBlock) (*providerMigration, error) {
+	resources, err := loadPlannedResources(state)
+	if err != nil {
+		return nil, err
+	}
+	migration := &providerMigration{
+		Environment: environment,
+		To:          cfg.Provider.Active,
+		Destroy:     map[string][]string{},
+		Create:      map[string][]string{},
+	}
+
+	providers := map[string]bool{}
+	for _, resource := range resources {
+		if block, ok := blocks[stateBlockAddress.FindString(resource.Address)]; ok {
+			providers[block.Provider] = true
+		}
+	}
+	if len(providers) > 1 {
+		var names []string
+		for provider := range providers {
+			names = append(names, provider)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%s: estado com recursos de mais de um provedor (%s); termine a migração anterior antes de planejar outra",
+			environment, strings.Join(names, ", "))
+	}
+	for provider := range providers {
+		migration.From = provider
+	}
+	if !migration.Switch() {
+		return migration, nil
+	}
+
+	for _, resource := range resources {
+		block, ok := blocks[stateBlockAddress.FindString(resource.Address)]
+		if !ok || block.Provider != migration.From {
+			continue
+		}
+		migration.Destroy[block.Role] = append(migration.Destroy[block.Role], resource.Address)
+		if migration.Database == nil && containsString(databaseResourceTypes, resource.Type) {
+			migration.Database = stateDatabase(resource)
+		}
+	}
+	for _, block := range blocks {
+		if block.Provider != migration.To {
+			continue
+		}
+		id := block.ID
+		if block.Conditional {
+			id += " (condicional)"
+		}
+		migration.Create[block.Role] = append(migration.Create[block.Role], id)
+	}
+	for _, group := range []map[string][]string{migration.Destroy, migration.Create} {
+		for _, addresses := range group {
+			sort.Strings(addresses)
+		}
+	}
+	if migration.Database != nil {
+		migration.DatabaseSteps = migration.databaseSteps(cfg)
+	}
+	return migration, nil
+}
+
+// stateDatabase lê engine, versão e armazenamento de um banco do estado
+func stateDatabase(resource plannedResource) *migrationDatabase {
+	database := &migrationDatabase{Address: resource.Address}
+	switch resource.Type {
+	case "aws_db_instance":
+		database.Engine = planString(resource.Values, "engine")
+		database.Version = planString(resource.Values, "engine_version")
+		database.StorageGB, _ = planInt(resource.Values, "allocated_storage")
+	case "digitalocean_database_cluster":
+		database.Engine = planString(resource.Values, "engine")
+		database.Version = planString(resource.Values, "version")
+		if mib, ok := planInt(resource.Values, "storage_size_mib"); ok {
+			database.StorageGB = mib / 1024
+		}
+	case "google_sql_database_instance":
+		// database_version no formato POSTGRES_14 ou MYSQL_8_0
+		engine, version, _ := strings.Cut(planString(resource.Values, "database_version"), "_")
+		database.Engine = strings.ToLower(engine)
+		database.Version = strings.ReplaceAll(version, "_", ".")
+		for _, settings := range planBlocks(resource.Values, "settings") {
+			database.StorageGB, _ = planInt(settings, "disk_size")
+		}
+	}
+	if database.Engine == "pg" {
+		database.Engine = "postgres"
+	}
+	return database
+}
+
+// databaseSteps monta os passos de migração dos dados para o banco do provedor de destino
+func (m *providerMigration) databaseSteps(cfg *environmentConfig) []string {
+	source := m.Database
+	dump, restore := "pg_dump --format=custom --no-owner", "pg_restore --no-owner --jobs=4"
+	if source.Engine == "mysql" {
+		dump, restore = "mysqldump --single-transaction --routines", "mysql"
+	}
+	size := "tamanho desconhecido no estado"
+	if source.StorageGB > 0 {
+		size = fmt.Sprintf("%d GB alocados", source.StorageGB)
+	}
+	targets := append(append([]string{}, m.Create["network"]...), m.Create["database"]...)
+	for i, target := range targets {
+		targets[i] = "-target=" + strings.TrimSuffix(target, " (condicional)")
+	}
+
+	steps := []string{
+		fmt.Sprintf("Colocar a aplicação de %s em manutenção, parando as escritas em %s", m.Environment, source.Address),
+		fmt.Sprintf("Criar só a rede e o banco em %s: terraform apply %s", m.To, strings.Join(targets, " ")),
+		fmt.Sprintf("Exportar %s %s de %s com %s (%s)", source.Engine, source.Version, source.Address, dump, size),
+		fmt.Sprintf("Restaurar no banco de %s com %s e comparar a contagem de linhas de cada tabela", m.To, restore),
+	}
+	if cfg.Database.Engine != "" && cfg.Database.Engine != source.Engine {
+		steps = append(steps, fmt.Sprintf("O engine muda de %s para %s: o dump lógico não é restaurável diretamente; planeje a conversão do schema", source.Engine, cfg.Database.Engine))
+	}
+	from, _, errFrom := parseProviderVersion(source.Version)
+	to, _, errTo := parseProviderVersion(cfg.Database.EngineVersion)
+	if errFrom == nil && errTo == nil && from[0] != to[0] {
+		if to[0] < from[0] {
+			steps = append(steps, fmt.Sprintf("A versão cai de %d para %d: %s não garante restaurar dumps de versões mais novas; teste a restauração antes do corte", from[0], to[0], restore))
+		} else {
+			steps = append(steps, fmt.Sprintf("A versão sobe de %d para %d: valide a aplicação contra o banco restaurado antes do corte", from[0], to[0]))
+		}
+	}
+	steps = append(steps,
+		"Atualizar DATABASE_URL e os segredos da aplicação com o host do novo banco e retirar a manutenção",
+		fmt.Sprintf("Executar o apply completo, que destrói os recursos de %s listados acima", m.From),
+	)
+	return steps
+}
+
+// String descreve a migração para a mensagem de falha do teste e para quem escreve o arquivo de confirmação
+func (m *providerMigration) String() string {
+	if !m.Switch() {
+		return fmt.Sprintf("%s: sem troca de provedor (%s)", m.Environment, m.To)
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s: provider.active muda de %s para %s\n", m.Environment, m.From, m.To)
+	for _, role := range m.Roles() {
+		fmt.Fprintf(&builder, "  %s:\n", role)
+		for _, address := range m.Destroy[role] {
+			fmt.Fprintf(&builder, "    - destruir %s\n", address)
+		}
+		for _, address := range m.Create[role] {
+			fmt.Fprintf(&builder, "    + criar %s\n", address)
+		}
+		if len(m.Destroy[role]) > 0 && len(m.Create[role]) == 0 {
+			fmt.Fprintf(&builder, "    ! sem equivalente em %s\n", m.To)
+		}
+	}
+	if len(m.DatabaseSteps) > 0 {
+		builder.WriteString("  migração do banco:\n")
+		for i, step := range m.DatabaseSteps {
+			fmt.Fprintf(&builder, "    %d. %s\n", i+1, step)
+		}
+	}
+	return builder.String()
+}
+
+// providerMigrationAck espelha environments/<ambiente>/provider_migration.yaml
+type providerMigrationAck struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// Destroy lista os papéis cujos recursos podem ser destruídos
+	Destroy           []string `yaml:"destroy"`
+	DatabaseMigration struct {
+		Strategy          string `yaml:"strategy"`
+		MaintenanceWindow string `yaml:"maintenance_window"`
+	} `yaml:"database_migration"`
+}
+
+// providerMigrationAckPath retorna o caminho do arquivo de confirmação do ambiente, relativo ao diretório tests/
+func providerMigrationAckPath(environment string) string {
+	return filepath.Join(filepath.Dir(environmentConfigPath(environment)), providerMigrationFile)
+}
+
+// loadProviderMigrationAck lê o arquivo de confirmação; sem o arquivo, retorna nil
+func loadProviderMigrationAck(path string) (*providerMigrationAck, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ack providerMigrationAck
+	if err := yaml.Unmarshal(content, &ack); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar %s: %v", path, err)
+	}
+	return &ack, nil
+}
+
+// Check recusa a troca de provedor que o arquivo de confirmação não cobre: origem e destino,
+// todos os papéis com recursos destruídos e, havendo banco, a estratégia para os dados
+func (m *providerMigration) Check(ack *providerMigrationAck) error {
+	if !m.Switch() {
+		return nil
+	}
+	if ack == nil {
+		return fmt.Errorf("%s: troca de %s para %s sem %s", m.Environment, m.From, m.To, providerMigrationFile)
+	}
+
+	var problems []string
+	if ack.From != m.From || ack.To != m.To {
+		problems = append(problems, fmt.Sprintf("o arquivo confirma %s -> %s, mas o estado vai de %s para %s", ack.From, ack.To, m.From, m.To))
+	}
+	for _, role := range m.Roles() {
+		if len(m.Destroy[role]) > 0 && !containsString(ack.Destroy, role) {
+			problems = append(problems, fmt.Sprintf("destroy não inclui %s (%d recursos)", role, len(m.Destroy[role])))
+		}
+	}
+	if m.Database != nil {
+		switch strategy := ack.DatabaseMigration.Strategy; strategy {
+		case migrationDumpRestore:
+			if ack.DatabaseMigration.MaintenanceWindow == "" {
+				problems = append(problems, "database_migration.maintenance_window é obrigatório com dump-restore")
+			}
+		case migrationDiscardData:
+		case "":
+			problems = append(problems, fmt.Sprintf("database_migration.strategy ausente para %s (%s ou %s)", m.Database.Address, migrationDumpRestore, migrationDiscardData))
+		default:
+			problems = append(problems, "database_migration.strategy inválida: "+strconv.Quote(strategy))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %s não cobre a migração: %s", m.Environment, providerMigrationFile, strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProviderMigration compara o estado de cada ambiente com o provider.active do config.yaml e
+// recusa a troca de provedor sem environments/<ambiente>/provider_migration.yaml. O estado vem de
+// PROVIDER_MIGRATION_STATE_DIR/<ambiente>.json (`terraform show -json`) ou, sem a variável, dos
+// exemplos em testdata/provider_migration
+func TestProviderMigration(t *testing.T) {
+	t.Parallel()
+
+	blocks, err := loadProviderBlocks("..")
+	if err != nil {
+		t.Fatalf("Erro ao ler os blocos da raiz: %v", err)
+	}
+	stateDir := os.Getenv("PROVIDER_MIGRATION_STATE_DIR")
+	if stateDir == "" {
+		stateDir = filepath.Join("testdata", "provider_migration")
+	}
+
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao carregar config.yaml de %s: %v", environment, err)
+		}
+		state, err := os.ReadFile(filepath.Join(stateDir, environment+".json"))
+		if os.IsNotExist(err) {
+			t.Logf("%s: sem estado em %s", environment, stateDir)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Erro ao ler o estado de %s: %v", environment, err)
+		}
+		migration, err := planProviderMigration(environment, cfg, state, blocks)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		ack, err := loadProviderMigrationAck(providerMigrationAckPath(environment))
+		if err != nil {
+			t.Fatalf("Erro ao ler a confirmação de %s: %v", environment, err)
+		}
+		assert.NoError(t, migration.Check(ack), migration.String())
+	}
+}
+
+// TestProviderMigrationPlanner leva o estado de exemplo de dev (DigitalOcean) para a AWS
+func TestProviderMigrationPlanner(t *testing.T) {
+	t.Parallel()
+
+	blocks, err := loadProviderBlocks("..")
+	if err != nil {
+		t.Fatalf("Erro ao ler os blocos da raiz: %v", err)
+	}
+	state, err := os.ReadFile(filepath.Join("testdata", "provider_migration", "dev.json"))
+	if err != nil {
+		t.Fatalf("Erro ao ler o estado de exemplo: %v", err)
+	}
+	cfg, err := loadEnvironmentConfig("dev")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml de dev: %v", err)
+	}
+
+	unchanged, err := planProviderMigration("dev", cfg, state, blocks)
+	if assert.NoError(t, err) {
+		assert.False(t, unchanged.Switch(), "dev continua no DigitalOcean")
+		assert.NoError(t, unchanged.Check(nil))
+	}
+
+	cfg.Provider.Active = "aws"
+	migration, err := planProviderMigration("dev", cfg, state, blocks)
+	if err != nil {
+		t.Fatalf("Erro ao planejar a migração: %v", err)
+	}
+	assert.True(t, migration.Switch())
+	assert.Equal(t, "digitalocean", migration.From)
+	assert.Equal(t, "aws", migration.To)
+
+	var destroyed []string
+	for role := range migration.Destroy {
+		destroyed = append(destroyed, role)
+	}
+	assert.ElementsMatch(t, []string{"network", "database", "monitoring", "cost_monitor"}, destroyed)
+	assert.Len(t, migration.Destroy["database"], 4)
+	assert.Contains(t, migration.Create["kubernetes"], "module.kubernetes_aws")
+	assert.Contains(t, migration.Create["disaster_recovery"], "module.disaster_recovery_aws (condicional)")
+	assert.NotContains(t, migration.String(), "sem equivalente", "todos os papéis de dev existem na AWS")
+
+	if assert.NotNil(t, migration.Database) {
+		assert.Equal(t, migrationDatabase{Address: "module.database_digitalocean[0].digitalocean_database_cluster.main",
+			Engine: "postgres", Version: "14", StorageGB: 20}, *migration.Database)
+	}
+	if assert.NotEmpty(t, migration.DatabaseSteps) {
+		assert.Contains(t, migration.DatabaseSteps[1], "-target=module.network_aws -target=module.database_aws")
+		assert.Contains(t, migration.DatabaseSteps[2], "pg_dump --format=custom --no-owner (20 GB alocados)")
+	}
+
+	assert.EqualError(t, migration.Check(nil), "dev: troca de digitalocean para aws sem provider_migration.yaml")
+	ack, err := loadProviderMigrationAck(filepath.Join("testdata", "provider_migration", "dev-to-aws.yaml"))
+	if err != nil || ack == nil {
+		t.Fatalf("Erro ao ler a confirmação de exemplo: %v", err)
+	}
+	assert.NoError(t, migration.Check(ack))
+
+	partial := *ack
+	partial.Destroy = []string{"network", "monitoring", "cost_monitor"}
+	assert.ErrorContains(t, migration.Check(&partial), "destroy não inclui database (4 recursos)")
+
+	reversed := *ack
+	reversed.From, reversed.To = "aws", "digitalocean"
+	assert.ErrorContains(t, migration.Check(&reversed), "o arquivo confirma aws -> digitalocean")
+
+	for strategy, problem := range map[string]string{
+		"":             "database_migration.strategy ausente",
+		"snapshot":     `database_migration.strategy inválida: "snapshot"`,
+		"discard":      "",
+		"dump-restore": "",
+	} {
+		changed := *ack
+		changed.DatabaseMigration.Strategy = strategy
+		if problem == "" {
+			assert.NoError(t, migration.Check(&changed), strategy)
+		} else {
+			assert.ErrorContains(t, migration.Check(&changed), problem, strategy)
+		}
+	}
+	noWindow := *ack
+	noWindow.DatabaseMigration.MaintenanceWindow = ""
+	assert.ErrorContains(t, migration.Check(&noWindow), "maintenance_window é obrigatório")
+
+	// staging na AWS tem papéis que o GCP não implementa
+	staging, err := os.ReadFile(filepath.Join("testdata", "provider_migration", "staging.json"))
+	if err != nil {
+		t.Fatalf("Erro ao ler o estado de exemplo: %v", err)
+	}
+	stagingCfg, err := loadEnvironmentConfig("staging")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml de staging: %v", err)
+	}
+	stagingCfg.Provider.Active = "gcp"
+	toGCP, err := planProviderMigration("staging", stagingCfg, staging, blocks)
+	if assert.NoError(t, err) {
+		assert.Contains(t, toGCP.String(), "  disaster_recovery:\n    - destruir module.disaster_recovery_aws[0].aws_db_instance.replica\n    ! sem equivalente em gcp\n")
+		if assert.NotNil(t, toGCP.Database) {
+			assert.Equal(t, "module.database_aws[0].aws_db_instance.default", toGCP.Database.Address)
+		}
+	}
+
+	// Estado com recursos de dois provedores indica uma migração pela metade
+	mixed := []byte(`{"values": {"root_module": {"child_modules": [
+		{"resources": [{"address": "module.network_digitalocean[0].digitalocean_vpc.main", "mode": "managed", "type": "digitalocean_vpc", "values": {}}]},
+		{"resources": [{"address": "module.network_aws[0].aws_vpc.main", "mode": "managed", "type": "aws_vpc", "values": {}}]}
+	]}}}`)
+	_, err = planProviderMigration("dev", cfg, mixed, blocks)
+	assert.EqualError(t, err, "dev: estado com recursos de mais de um provedor (aws, digitalocean); termine a migração anterior antes de planejar outra")
+}
@@ -0,0 +1,521 @@
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// providerRequirement é uma entrada de um bloco required_providers
+type providerRequirement struct {
+	// File é o caminho do arquivo relativo à raiz analisada
+	File    string
+	Name    string
+	Source  string
+	Version string
+}
+
+func (r providerRequirement) String() string {
+	version := r.Version
+	if version == "" {
+		version = "qualquer versão"
+	}
+	return fmt.Sprintf("%s (%s em %s)", version, r.Name, r.File)
+}
+
+var (
+	hclTerraformBlock        = regexp.MustCompile(`(?m)^\s*terraform\s*\{`)
+	hclRequiredProvidersHead = regexp.MustCompile(`required_providers\s*\{`)
+	hclProviderEntry         = regexp.MustCompile(`(?m)^\s*([A-Za-z0-9_-]+)\s*=\s*(\{|"([^"]*)")`)
+	hclSourceAttribute       = regexp.MustCompile(`(?m)^\s*source\s*=\s*"([^"]*)"`)
+	hclVersionAttribute      = regexp.MustCompile(`(?m)^\s*version\s*=\s*"([^"]*)"`)
+	hclConstraintsAttribute  = regexp.MustCompile(`(?m)^\s*constraints\s*=\s*"([^"]*)"`)
+	hclHashesAttribute       = regexp.MustCompile(`(?s)hashes\s*=\s*\[(.*?)\]`)
+	hclLockProvider          = regexp.MustCompile(`provider\s+"([^"]+)"\s*\{`)
+	hclQuotedString          = regexp.MustCompile(`"([^"]*)"`)
+)
+
+// stripHCLComments remove comentários (#, // e /* */) fora de strings, preservando as quebras de linha
+func stripHCLComments(src string) string {
+	var out strings.Builder
+	inString := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case inString:
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(src) {
+				i++
+				out.WriteByte(src[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '#' || (c == '/' && i+1 < len(src) && src[i+1] == '/'):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if i < len(src) {
+				out.WriteByte('\n')
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return out.String()
+			}
+			out.WriteString(strings.Repeat("\n", strings.Count(src[i:i+2+end+2], "\n")))
+			i += end + 3
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// hclBlockBody retorna o conteúdo entre a chave aberta em open e a chave que a fecha
+func hclBlockBody(src string, open int) (string, error) {
+	depth := 0
+	inString := false
+	for i := open; i < len(src); i++ {
+		switch c := src[i]; {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return src[open+1 : i], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("bloco aberto na posição %d não foi fechado", open)
+}
+
+// normalizeProviderSource completa o endereço do provider como o Terraform faz (registry.terraform.io/hashicorp/<nome>)
+func normalizeProviderSource(name, source string) string {
+	if source == "" {
+		source = name
+	}
+	parts := strings.Split(strings.ToLower(source), "/")
+	switch len(parts) {
+	case 1:
+		parts = []string{"registry.terraform.io", "hashicorp", parts[0]}
+	case 2:
+		parts = append([]string{"registry.terraform.io"}, parts...)
+	}
+	return strings.Join(parts, "/")
+}
+
+// parseRequiredProviders extrai as entradas dos blocos terraform { required_providers { ... } } de um arquivo .tf
+func parseRequiredProviders(file, content string) ([]providerRequirement, error) {
+	src := stripHCLComments(content)
+	var requirements []providerRequirement
+	for _, terraformMatch := range hclTerraformBlock.FindAllStringIndex(src, -1) {
+		terraformBody, err := hclBlockBody(src, terraformMatch[1]-1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		for _, requiredMatch := range hclRequiredProvidersHead.FindAllStringIndex(terraformBody, -1) {
+			body, err := hclBlockBody(terraformBody, requiredMatch[1]-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			for offset := 0; offset < len(body); {
+				entry := hclProviderEntry.FindStringSubmatchIndex(body[offset:])
+				if entry == nil {
+					break
+				}
+				name := body[offset+entry[2] : offset+entry[3]]
+				requirement := providerRequirement{File: file, Name: name}
+				if entry[6] >= 0 {
+					// Sintaxe antiga: nome = "restrição"
+					requirement.Version = body[offset+entry[6] : offset+entry[7]]
+					offset += entry[1]
+				} else {
+					object, err := hclBlockBody(body, offset+entry[1]-1)
+					if err != nil {
+						return nil, fmt.Errorf("%s: provider %s: %v", file, name, err)
+					}
+					if match := hclSourceAttribute.FindStringSubmatch(object); match != nil {
+						requirement.Source = match[1]
+					}
+					if match := hclVersionAttribute.FindStringSubmatch(object); match != nil {
+						requirement.Version = match[1]
+					}
+					offset += entry[1] + len(object) + 1
+				}
+				requirement.Source = normalizeProviderSource(name, requirement.Source)
+				requirements = append(requirements, requirement)
+			}
+		}
+	}
+	return requirements, nil
+}
+
+// collectProviderRequirements percorre os arquivos .tf abaixo de root, ignorando diretórios .terraform e testdata
+func collectProviderRequirements(root string) ([]providerRequirement, error) {
+	var requirements []providerRequirement
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".terraform" || (info.Name() == "testdata" && path != root) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".tf" {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		found, err := parseRequiredProviders(filepath.ToSlash(relative), string(content))
+		if err != nil {
+			return err
+		}
+		requirements = append(requirements, found...)
+		return nil
+	})
+	return requirements, err
+}
+
+// providerVersion é uma versão major.minor.patch; sufixos de pré-lançamento são ignorados
+type providerVersion [3]int
+
+func (v providerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+func (v providerVersion) Compare(other providerVersion) int {
+	for i := range v {
+		if v[i] != other[i] {
+			if v[i] < other[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseProviderVersion retorna a versão e quantos segmentos foram informados, necessário para o operador ~>
+func parseProviderVersion(value string) (providerVersion, int, error) {
+	var version providerVersion
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	if index := strings.IndexAny(value, "-+"); index >= 0 {
+		value = value[:index]
+	}
+	parts := strings.Split(value, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return version, 0, fmt.Errorf("versão inválida %q", value)
+	}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return version, 0, fmt.Errorf("versão inválida %q", value)
+		}
+		version[i] = number
+	}
+	return version, len(parts), nil
+}
+
+// versionRange é o conjunto de versões aceito por uma ou mais restrições
+type versionRange struct {
+	Min          providerVersion
+	MinInclusive bool
+	HasMax       bool
+	Max          providerVersion
+	MaxInclusive bool
+	Excluded     []providerVersion
+}
+
+var anyVersion = versionRange{MinInclusive: true}
+
+var versionConstraintPattern = regexp.MustCompile(`^\s*(~>|>=|<=|!=|=|>|<)?\s*(\S+)\s*$`)
+
+// parseVersionConstraint interpreta uma restrição do Terraform ("~> 2.36", ">= 1.0, < 2.0", ...)
+func parseVersionConstraint(constraint string) (versionRange, error) {
+	result := anyVersion
+	if strings.TrimSpace(constraint) == "" {
+		return result, nil
+	}
+	for _, part := range strings.Split(constraint, ",") {
+		match := versionConstraintPattern.FindStringSubmatch(part)
+		if match == nil {
+			return result, fmt.Errorf("restrição inválida %q", constraint)
+		}
+		version, segments, err := parseProviderVersion(match[2])
+		if err != nil {
+			return result, fmt.Errorf("restrição inválida %q: %v", constraint, err)
+		}
+
+		current := anyVersion
+		switch match[1] {
+		case "", "=":
+			current = versionRange{Min: version, MinInclusive: true, HasMax: true, Max: version, MaxInclusive: true}
+		case "!=":
+			current.Excluded = []providerVersion{version}
+		case ">=":
+			current.Min = version
+		case ">":
+			current = versionRange{Min: version}
+		case "<=":
+			current = versionRange{MinInclusive: true, HasMax: true, Max: version, MaxInclusive: true}
+		case "<":
+			current = versionRange{MinInclusive: true, HasMax: true, Max: version}
+		case "~>":
+			// ~> permite incrementar apenas o último segmento informado
+			upper := providerVersion{}
+			if segments <= 2 {
+				upper[0] = version[0] + 1
+			} else {
+				upper[0], upper[1] = version[0], version[1]+1
+			}
+			current = versionRange{Min: version, MinInclusive: true, HasMax: true, Max: upper}
+		}
+		result = result.Intersect(current)
+	}
+	return result, nil
+}
+
+// Intersect retorna as versões aceitas pelas duas faixas
+func (r versionRange) Intersect(other versionRange) versionRange {
+	result := r
+	if cmp := other.Min.Compare(r.Min); cmp > 0 || (cmp == 0 && !other.MinInclusive) {
+		result.Min, result.MinInclusive = other.Min, other.MinInclusive
+	}
+	if other.HasMax {
+		if cmp := other.Max.Compare(r.Max); !r.HasMax || cmp < 0 || (cmp == 0 && !other.MaxInclusive) {
+			result.HasMax, result.Max, result.MaxInclusive = true, other.Max, other.MaxInclusive
+		}
+	}
+	result.Excluded = append(append([]providerVersion{}, r.Excluded...), other.Excluded...)
+	return result
+}
+
+// Empty indica que nenhuma versão atende a faixa
+func (r versionRange) Empty() bool {
+	if !r.HasMax {
+		return false
+	}
+	switch cmp := r.Min.Compare(r.Max); {
+	case cmp > 0:
+		return true
+	case cmp == 0:
+		return !r.MinInclusive || !r.MaxInclusive || !r.Contains(r.Min)
+	}
+	return false
+}
+
+// Contains indica se a versão atende a faixa
+func (r versionRange) Contains(version providerVersion) bool {
+	if cmp := version.Compare(r.Min); cmp < 0 || (cmp == 0 && !r.MinInclusive) {
+		return false
+	}
+	if r.HasMax {
+		if cmp := version.Compare(r.Max); cmp > 0 || (cmp == 0 && !r.MaxInclusive) {
+			return false
+		}
+	}
+	for _, excluded := range r.Excluded {
+		if version == excluded {
+			return false
+		}
+	}
+	return true
+}
+
+// groupRequirementsBySource agrupa as restrições pelo endereço do provider, em ordem alfabética
+func groupRequirementsBySource(requirements []providerRequirement) ([]string, map[string][]providerRequirement) {
+	bySource := map[string][]providerRequirement{}
+	for _, requirement := range requirements {
+		bySource[requirement.Source] = append(bySource[requirement.Source], requirement)
+	}
+	var sources []string
+	for source := range bySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources, bySource
+}
+
+// checkProviderConflicts aponta restrições de versão sem nenhuma versão em comum e nomes
+// locais que apontam para endereços diferentes em arquivos distintos
+func checkProviderConflicts(requirements []providerRequirement) []string {
+	var problems []string
+
+	sourcesByName := map[string]map[string][]string{}
+	for _, requirement := range requirements {
+		if sourcesByName[requirement.Name] == nil {
+			sourcesByName[requirement.Name] = map[string][]string{}
+		}
+		sourcesByName[requirement.Name][requirement.Source] = append(sourcesByName[requirement.Name][requirement.Source], requirement.File)
+	}
+	for name, sources := range sourcesByName {
+		if len(sources) > 1 {
+			var details []string
+			for source, files := range sources {
+				details = append(details, fmt.Sprintf("%s em %s", source, strings.Join(files, ", ")))
+			}
+			sort.Strings(details)
+			problems = append(problems, fmt.Sprintf("provider %s aponta para endereços diferentes: %s", name, strings.Join(details, "; ")))
+		}
+	}
+
+	sources, bySource := groupRequirementsBySource(requirements)
+	for _, source := range sources {
+		combined := anyVersion
+		var details []string
+		for _, requirement := range bySource[source] {
+			constraint, err := parseVersionConstraint(requirement.Version)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s em %s: %v", source, requirement.File, err))
+				continue
+			}
+			combined = combined.Intersect(constraint)
+			details = append(details, requirement.String())
+		}
+		if combined.Empty() {
+			problems = append(problems, fmt.Sprintf("nenhuma versão de %s atende todas as restrições: %s", source, strings.Join(details, ", ")))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// providerLock é uma entrada de provider do .terraform.lock.hcl
+type providerLock struct {
+	Source      string
+	Version     string
+	Constraints string
+	Hashes      []string
+}
+
+// loadProviderLockFile lê um .terraform.lock.hcl e indexa as entradas pelo endereço do provider
+func loadProviderLockFile(path string) (map[string]providerLock, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src := stripHCLComments(string(content))
+
+	locks := map[string]providerLock{}
+	for _, match := range hclLockProvider.FindAllStringSubmatchIndex(src, -1) {
+		body, err := hclBlockBody(src, match[1]-1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		lock := providerLock{Source: strings.ToLower(src[match[2]:match[3]])}
+		if version := hclVersionAttribute.FindStringSubmatch(body); version != nil {
+			lock.Version = version[1]
+		}
+		if constraints := hclConstraintsAttribute.FindStringSubmatch(body); constraints != nil {
+			lock.Constraints = constraints[1]
+		}
+		if hashes := hclHashesAttribute.FindStringSubmatch(body); hashes != nil {
+			for _, hash := range hclQuotedString.FindAllStringSubmatch(hashes[1], -1) {
+				lock.Hashes = append(lock.Hashes, hash[1])
+			}
+		}
+		locks[lock.Source] = lock
+	}
+	return locks, nil
+}
+
+// checkProviderLock confere se cada provider exigido tem entrada no lock com uma versão que
+// atende todas as restrições, e se o lock não guarda providers que nenhum arquivo exige
+func checkProviderLock(requirements []providerRequirement, locks map[string]providerLock) []string {
+	var problems []string
+	sources, bySource := groupRequirementsBySource(requirements)
+	for _, source := range sources {
+		lock, ok := locks[source]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s não tem entrada no .terraform.lock.hcl", source))
+			continue
+		}
+		version, _, err := parseProviderVersion(lock.Version)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: versão travada %v", source, err))
+			continue
+		}
+		for _, requirement := range bySource[source] {
+			constraint, err := parseVersionConstraint(requirement.Version)
+			if err != nil {
+				continue
+			}
+			if !constraint.Contains(version) {
+				problems = append(problems, fmt.Sprintf("versão travada %s de %s não atende %s", lock.Version, source, requirement))
+			}
+		}
+		if len(lock.Hashes) == 0 {
+			problems = append(problems, fmt.Sprintf("%s não tem hashes no .terraform.lock.hcl", source))
+		}
+	}
+
+	for source := range locks {
+		if _, ok := bySource[source]; !ok {
+			problems = append(problems, fmt.Sprintf("%s está no .terraform.lock.hcl, mas nenhum required_providers o exige", source))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// checkProviderMirror confere se as versões travadas estão no espelho local (layout de
+// `terraform providers mirror`) para a plataforma informada. Pacotes compactados têm o hash
+// zh: (sha256 do .zip) comparado com o lock; diretórios descompactados só têm a presença verificada.
+func checkProviderMirror(mirror, platform string, locks map[string]providerLock) []string {
+	var problems []string
+	for _, lock := range locks {
+		providerType := lock.Source[strings.LastIndex(lock.Source, "/")+1:]
+		sourceDir := filepath.Join(append([]string{mirror}, strings.Split(lock.Source, "/")...)...)
+
+		packed := filepath.Join(sourceDir, fmt.Sprintf("terraform-provider-%s_%s_%s.zip", providerType, lock.Version, platform))
+		if content, err := os.ReadFile(packed); err == nil {
+			sum := sha256.Sum256(content)
+			hash := "zh:" + hex.EncodeToString(sum[:])
+			if !containsString(lock.Hashes, hash) {
+				problems = append(problems, fmt.Sprintf("%s %s: hash do pacote no espelho (%s) não está no .terraform.lock.hcl", lock.Source, lock.Version, hash))
+			}
+			continue
+		}
+
+		if info, err := os.Stat(filepath.Join(sourceDir, lock.Version, platform)); err == nil && info.IsDir() {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s %s para %s não está no espelho %s", lock.Source, lock.Version, platform, mirror))
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
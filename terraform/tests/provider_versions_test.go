@@ -0,0 +1,203 @@
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// Lock da configuração raiz (terraform/), gerado com `terraform providers lock`
+const providerLockFile = "../.terraform.lock.hcl"
+
+// TestProviderVersionConstraints reúne os blocos required_providers de todo o diretório terraform/
+// e falha quando restrições de um mesmo provider não têm nenhuma versão em comum
+func TestProviderVersionConstraints(t *testing.T) {
+	t.Parallel()
+
+	requirements, err := collectProviderRequirements("..")
+	if err != nil {
+		t.Fatalf("Erro ao ler required_providers: %v", err)
+	}
+	assert.NotEmpty(t, requirements, "Nenhum bloco required_providers encontrado")
+
+	sources, bySource := groupRequirementsBySource(requirements)
+	for _, source := range sources {
+		var constraints []string
+		for _, requirement := range bySource[source] {
+			constraints = append(constraints, requirement.String())
+		}
+		t.Logf("%s: %s", source, strings.Join(constraints, ", "))
+	}
+
+	for _, problem := range checkProviderConflicts(requirements) {
+		assert.Fail(t, "Restrições de provider incompatíveis", problem)
+	}
+}
+
+// TestProviderLockFile confere o .terraform.lock.hcl versionado contra as restrições da configuração
+// raiz e, com TF_PROVIDER_MIRROR definido, contra um espelho local de providers, sem acessar a rede
+func TestProviderLockFile(t *testing.T) {
+	t.Parallel()
+
+	locks, err := loadProviderLockFile(providerLockFile)
+	if os.IsNotExist(err) {
+		t.Skip("terraform/.terraform.lock.hcl não encontrado; gere com `terraform providers lock -fs-mirror=$TF_PROVIDER_MIRROR` e versione o arquivo")
+	}
+	if err != nil {
+		t.Fatalf("Erro ao ler o lock: %v", err)
+	}
+
+	requirements, err := collectProviderRequirements("..")
+	if err != nil {
+		t.Fatalf("Erro ao ler required_providers: %v", err)
+	}
+	// Cada ambiente em environments/ é uma raiz separada, com o próprio lock
+	var rootRequirements []providerRequirement
+	for _, requirement := range requirements {
+		if !strings.HasPrefix(requirement.File, "environments/") {
+			rootRequirements = append(rootRequirements, requirement)
+		}
+	}
+	for _, problem := range checkProviderLock(rootRequirements, locks) {
+		assert.Fail(t, "Lock inconsistente", problem)
+	}
+
+	mirror := os.Getenv("TF_PROVIDER_MIRROR")
+	if mirror == "" {
+		t.Log("TF_PROVIDER_MIRROR não definido, espelho local não verificado")
+		return
+	}
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+	for _, problem := range checkProviderMirror(mirror, platform, locks) {
+		assert.Fail(t, "Espelho de providers inconsistente", problem)
+	}
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Log("terraform não instalado, init com o espelho não verificado")
+		return
+	}
+
+	// init com -lockfile=readonly falha se o lock não corresponder à configuração ou ao espelho
+	terraformDir, err := files.CopyTerraformFolderToTemp("..", "provider-lock")
+	if err != nil {
+		t.Fatalf("Erro ao copiar a configuração: %v", err)
+	}
+	terraformOptions := withRetryableErrors(t, &terraform.Options{TerraformDir: terraformDir, NoColor: true})
+	if _, err := terraform.RunTerraformCommandE(t, terraformOptions, "init", "-backend=false", "-input=false", "-lockfile=readonly", "-plugin-dir="+mirror); err != nil {
+		assert.Fail(t, "terraform init com o espelho local falhou", err.Error())
+	}
+}
+
+// TestProviderVersionChecks confere a leitura de required_providers, as restrições e o lock com os arquivos de exemplo
+func TestProviderVersionChecks(t *testing.T) {
+	t.Parallel()
+
+	requirements, err := collectProviderRequirements(filepath.Join("testdata", "provider_versions", "tree"))
+	if err != nil {
+		t.Fatalf("Erro ao ler required_providers: %v", err)
+	}
+
+	var found []string
+	for _, requirement := range requirements {
+		found = append(found, requirement.Source+" "+requirement.Version)
+	}
+	// O bloco comentado de modules/legacy não conta
+	assert.ElementsMatch(t, []string{
+		"registry.terraform.io/hashicorp/aws ~> 5.0",
+		"registry.terraform.io/digitalocean/digitalocean ~> 2.36",
+		"registry.terraform.io/hashicorp/random ~> 3.5",
+		"registry.terraform.io/digitalocean/digitalocean ~> 2.0",
+		"registry.terraform.io/hashicorp/aws >= 4.0, < 5.0",
+		"registry.terraform.io/hashicorp/google ~> 4.0",
+		"registry.terraform.io/example/random ",
+	}, found)
+
+	conflicts := checkProviderConflicts(requirements)
+	assert.Len(t, conflicts, 2)
+	assert.Contains(t, strings.Join(conflicts, "\n"), "nenhuma versão de registry.terraform.io/hashicorp/aws atende todas as restrições")
+	assert.Contains(t, strings.Join(conflicts, "\n"), "provider random aponta para endereços diferentes")
+
+	locks, err := loadProviderLockFile(filepath.Join("testdata", "provider_versions", "lock.hcl"))
+	if err != nil {
+		t.Fatalf("Erro ao ler o lock: %v", err)
+	}
+	assert.Len(t, locks["registry.terraform.io/digitalocean/digitalocean"].Hashes, 2)
+
+	lockProblems := strings.Join(checkProviderLock(requirements, locks), "\n")
+	assert.Contains(t, lockProblems, "versão travada 2.34.1 de registry.terraform.io/digitalocean/digitalocean não atende ~> 2.36 (digitalocean em versions.tf)")
+	assert.Contains(t, lockProblems, "versão travada 5.31.0 de registry.terraform.io/hashicorp/aws não atende >= 4.0, < 5.0 (aws em modules/database/versions.tf)")
+	assert.Contains(t, lockProblems, "registry.terraform.io/hashicorp/google não tem entrada no .terraform.lock.hcl")
+	assert.Contains(t, lockProblems, "registry.terraform.io/hashicorp/null está no .terraform.lock.hcl, mas nenhum required_providers o exige")
+	assert.NotContains(t, lockProblems, "não atende ~> 2.0")
+	assert.NotContains(t, lockProblems, "não atende ~> 5.0")
+
+	// Operadores de restrição
+	cases := map[string]map[string]bool{
+		"~> 2.36":       {"2.36.0": true, "2.99.1": true, "3.0.0": false, "2.35.9": false},
+		"~> 2.23.0":     {"2.23.9": true, "2.24.0": false},
+		">= 1.0, < 2.0": {"1.0.0": true, "1.9.9": true, "2.0.0": false},
+		"!= 3.1.0":      {"3.1.0": false, "3.1.1": true},
+		"= 4.0.0":       {"4.0.0": true, "4.0.1": false},
+		"":              {"0.0.1": true},
+	}
+	for constraint, versions := range cases {
+		parsed, err := parseVersionConstraint(constraint)
+		if err != nil {
+			t.Fatalf("Erro ao interpretar %q: %v", constraint, err)
+		}
+		for value, expected := range versions {
+			version, _, _ := parseProviderVersion(value)
+			assert.Equal(t, expected, parsed.Contains(version), "%s em %q", value, constraint)
+		}
+	}
+	compatible, _ := parseVersionConstraint("~> 2.0")
+	narrower, _ := parseVersionConstraint("~> 2.36")
+	assert.False(t, compatible.Intersect(narrower).Empty())
+	exact, _ := parseVersionConstraint("= 2.0.0, != 2.0.0")
+	assert.True(t, exact.Empty())
+}
+
+// TestProviderMirrorCheck monta um espelho local nos dois layouts de `terraform providers mirror`
+func TestProviderMirrorCheck(t *testing.T) {
+	t.Parallel()
+
+	mirror := t.TempDir()
+	platform := "linux_amd64"
+
+	awsDir := filepath.Join(mirror, "registry.terraform.io", "hashicorp", "aws")
+	if err := os.MkdirAll(awsDir, 0755); err != nil {
+		t.Fatalf("Erro ao criar o espelho: %v", err)
+	}
+	pkg := []byte("pacote de teste")
+	if err := os.WriteFile(filepath.Join(awsDir, "terraform-provider-aws_5.31.0_linux_amd64.zip"), pkg, 0644); err != nil {
+		t.Fatalf("Erro ao criar o pacote: %v", err)
+	}
+	sum := sha256.Sum256(pkg)
+	awsHash := "zh:" + hex.EncodeToString(sum[:])
+
+	if err := os.MkdirAll(filepath.Join(mirror, "registry.terraform.io", "digitalocean", "digitalocean", "2.36.0", platform), 0755); err != nil {
+		t.Fatalf("Erro ao criar o espelho: %v", err)
+	}
+
+	locks := map[string]providerLock{
+		"registry.terraform.io/hashicorp/aws":             {Source: "registry.terraform.io/hashicorp/aws", Version: "5.31.0", Hashes: []string{awsHash}},
+		"registry.terraform.io/digitalocean/digitalocean": {Source: "registry.terraform.io/digitalocean/digitalocean", Version: "2.36.0"},
+	}
+	assert.Empty(t, checkProviderMirror(mirror, platform, locks))
+
+	locks["registry.terraform.io/hashicorp/aws"] = providerLock{Source: "registry.terraform.io/hashicorp/aws", Version: "5.31.0", Hashes: []string{"zh:outro"}}
+	locks["registry.terraform.io/hashicorp/random"] = providerLock{Source: "registry.terraform.io/hashicorp/random", Version: "3.6.0"}
+	problems := checkProviderMirror(mirror, platform, locks)
+	assert.Len(t, problems, 2)
+	assert.Contains(t, strings.Join(problems, "\n"), "registry.terraform.io/hashicorp/aws 5.31.0: hash do pacote no espelho")
+	assert.Contains(t, strings.Join(problems, "\n"), "registry.terraform.io/hashicorp/random 3.6.0 para linux_amd64 não está no espelho")
+}
@@ -0,0 +1,159 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/assert"
+)
+
+// rbacCheck descreve uma verificação de `kubectl auth can-i` com o resultado esperado
+type rbacCheck struct {
+	User        string
+	Verb        string
+	Resource    string
+	Subresource string
+	Namespace   string
+	Allowed     bool
+}
+
+func (c rbacCheck) String() string {
+	resource := c.Resource
+	if c.Subresource != "" {
+		resource += "/" + c.Subresource
+	}
+	scope := "cluster"
+	if c.Namespace != "" {
+		scope = "namespace " + c.Namespace
+	}
+	return fmt.Sprintf("%s %s %s em %s", c.User, c.Verb, resource, scope)
+}
+
+// TestRBACPermissionMatrix aplica o módulo raiz com active_provider = "local-k8s" no cluster kind de
+// kubernetes.local do ambiente dev, restrito a module.kubernetes_rbac_local, e confere, com usuários
+// impersonados, o que cada papel pode ou não fazer. O cluster chega ao módulo kubernetes/rbac apenas
+// pelo provider kubernetes.primary (kubeconfig_path e kube_context), sem KUBE_CONFIG_PATH
+func TestRBACPermissionMatrix(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("Este teste requer terraform instalado")
+	}
+	cluster := createEnvironmentKindCluster(t, "dev")
+
+	k8s.CreateNamespace(t, cluster.KubectlOptions("default"), "app")
+
+	rbacGroups := map[string]interface{}{
+		"admin":    map[string]interface{}{"users": []string{"admin-user"}, "groups": []string{}},
+		"develop":  map[string]interface{}{"users": []string{"dev-user"}, "groups": []string{}},
+		"readonly": map[string]interface{}{"users": []string{"readonly-user"}, "groups": []string{}},
+	}
+
+	dir := test_structure.CopyTerraformFolderToTemp(t, "..", ".")
+	vars := cluster.RootTerraformVars("dev")
+	vars["rbac_groups"] = rbacGroups
+	vars["rbac_namespaces"] = []string{"app"}
+
+	terraformOptions := cluster.TerraformOptions(t, dir, vars)
+	terraformOptions.EnvVars = nil
+	terraformOptions.Targets = []string{"module.kubernetes_rbac_local"}
+	terraformOptions.PluginDir = os.Getenv("TF_PROVIDER_MIRROR")
+
+	defer terraform.Destroy(t, terraformOptions)
+
+	// Fase 1: com rbac_namespaces e o padrão de rbac_restrict_to_namespaces, os ClusterRoleBindings
+	// continuam valendo para o cluster inteiro, além dos RoleBindings do namespace app
+	terraform.InitAndApply(t, terraformOptions)
+
+	assertRBACMatrix(t, cluster, []rbacCheck{
+		{User: "admin-user", Verb: "delete", Resource: "pods", Namespace: "kube-system", Allowed: true},
+		{User: "admin-user", Verb: "create", Resource: "clusterroles", Allowed: true},
+
+		{User: "dev-user", Verb: "create", Resource: "deployments", Namespace: "app", Allowed: true},
+		{User: "dev-user", Verb: "delete", Resource: "pods", Namespace: "kube-system", Allowed: true},
+		{User: "dev-user", Verb: "get", Resource: "pods", Subresource: "log", Namespace: "app", Allowed: true},
+		{User: "dev-user", Verb: "create", Resource: "clusterroles", Allowed: false},
+
+		{User: "readonly-user", Verb: "get", Resource: "pods", Namespace: "kube-system", Allowed: true},
+		{User: "readonly-user", Verb: "list", Resource: "nodes", Allowed: true},
+		{User: "readonly-user", Verb: "delete", Resource: "pods", Namespace: "app", Allowed: false},
+		{User: "readonly-user", Verb: "create", Resource: "pods", Subresource: "exec", Namespace: "app", Allowed: false},
+
+		{User: "unknown-user", Verb: "get", Resource: "pods", Namespace: "kube-system", Allowed: false},
+	})
+
+	// Fase 2: com rbac_restrict_to_namespaces, develop e readonly ficam restritos ao namespace app
+	terraformOptions.Vars["rbac_restrict_to_namespaces"] = true
+	terraform.InitAndApply(t, terraformOptions)
+
+	assertRBACMatrix(t, cluster, []rbacCheck{
+		{User: "admin-user", Verb: "delete", Resource: "pods", Namespace: "kube-system", Allowed: true},
+		{User: "admin-user", Verb: "create", Resource: "clusterroles", Allowed: true},
+
+		{User: "dev-user", Verb: "create", Resource: "deployments", Namespace: "app", Allowed: true},
+		{User: "dev-user", Verb: "delete", Resource: "pods", Namespace: "app", Allowed: true},
+		{User: "dev-user", Verb: "delete", Resource: "pods", Namespace: "kube-system", Allowed: false},
+		{User: "dev-user", Verb: "get", Resource: "secrets", Namespace: "kube-system", Allowed: false},
+		{User: "dev-user", Verb: "create", Resource: "clusterroles", Allowed: false},
+		{User: "dev-user", Verb: "create", Resource: "pods", Subresource: "exec", Namespace: "kube-system", Allowed: false},
+
+		{User: "readonly-user", Verb: "get", Resource: "pods", Namespace: "app", Allowed: true},
+		{User: "readonly-user", Verb: "list", Resource: "deployments", Namespace: "app", Allowed: true},
+		{User: "readonly-user", Verb: "delete", Resource: "pods", Namespace: "app", Allowed: false},
+		{User: "readonly-user", Verb: "create", Resource: "configmaps", Namespace: "app", Allowed: false},
+		{User: "readonly-user", Verb: "get", Resource: "pods", Namespace: "kube-system", Allowed: false},
+
+		{User: "unknown-user", Verb: "get", Resource: "pods", Namespace: "app", Allowed: false},
+	})
+
+	// Fase 3: sem namespaces a restrição não se aplica e os ClusterRoleBindings voltam
+	terraformOptions.Vars["rbac_namespaces"] = []string{}
+	terraform.InitAndApply(t, terraformOptions)
+
+	assertRBACMatrix(t, cluster, []rbacCheck{
+		{User: "dev-user", Verb: "delete", Resource: "pods", Namespace: "kube-system", Allowed: true},
+		{User: "readonly-user", Verb: "get", Resource: "pods", Namespace: "kube-system", Allowed: true},
+		{User: "unknown-user", Verb: "get", Resource: "pods", Namespace: "kube-system", Allowed: false},
+	})
+}
+
+// assertRBACMatrix executa cada verificação da matriz e compara com o resultado esperado
+func assertRBACMatrix(t *testing.T, cluster *kindCluster, checks []rbacCheck) {
+	for _, check := range checks {
+		allowed, err := kubectlCanI(t, cluster, check)
+		if err != nil {
+			t.Errorf("Erro ao verificar %s: %v", check, err)
+			continue
+		}
+		assert.Equal(t, check.Allowed, allowed, "%s", check)
+	}
+}
+
+// kubectlCanI executa `kubectl auth can-i` impersonando o usuário da verificação.
+// O kubectl retorna código de saída 1 quando a resposta é "no", por isso a saída é
+// interpretada antes do erro.
+func kubectlCanI(t *testing.T, cluster *kindCluster, check rbacCheck) (bool, error) {
+	args := []string{"auth", "can-i", check.Verb, check.Resource, "--as", check.User}
+	if check.Subresource != "" {
+		args = append(args, "--subresource", check.Subresource)
+	}
+
+	output, err := k8s.RunKubectlAndGetOutputE(t, cluster.KubectlOptions(check.Namespace), args...)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	switch strings.TrimSpace(lines[len(lines)-1]) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, fmt.Errorf("resposta inesperada do kubectl: %q", output)
+}
@@ -0,0 +1,845 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// internetSource é a origem que representa qualquer endereço público da internet
+const internetSource = "internet"
+
+// Faixas não roteáveis pela internet: uma regra cuja origem fica inteiramente dentro delas não
+// expõe o recurso
+var nonPublicCIDRs = mustParseCIDRs(
+	"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+	"fc00::/7", "fe80::/10", "::1/128",
+)
+
+// Faixas privadas de onde o Cloud SQL com private_network aceita conexões pela VPC
+var privateCIDRs = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+
+// Tipos de banco de dados gerenciado criados pelos módulos em modules/database
+var databaseResourceTypes = []string{"aws_db_instance", "digitalocean_database_cluster", "google_sql_database_instance"}
+
+func mustParseCIDRs(values ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// reachabilityRule é uma regra de entrada normalizada de qualquer provedor. Sources traz CIDRs,
+// "*" (qualquer origem), "internet" ou identificadores comparados literalmente, como tag:web ou
+// sg:<id do security group>
+type reachabilityRule struct {
+	// Firewall é o endereço do recurso de onde a regra veio
+	Firewall string
+	// Protocol é tcp, udp, icmp ou all
+	Protocol string
+	FromPort int
+	ToPort   int
+	Sources  []string
+	Deny     bool
+	// Priority ordena as regras do GCP e do Azure, e a primeira que casar decide. AWS e
+	// DigitalOcean só têm regras de liberação, todas com prioridade 0
+	Priority int
+	// Unknown indica que as origens só serão conhecidas no apply (dependem de recursos ainda não criados)
+	Unknown bool
+}
+
+// matches indica se a regra trata o protocolo e a porta, independentemente da origem
+func (r reachabilityRule) matches(protocol string, port int) bool {
+	if r.Protocol != "all" && r.Protocol != protocol {
+		return false
+	}
+	return protocol == "icmp" || (port >= r.FromPort && port <= r.ToPort)
+}
+
+// allows indica se alguma origem da regra abrange a origem consultada
+func (r reachabilityRule) allows(source string) bool {
+	for _, allowed := range r.Sources {
+		if sourceMatches(allowed, source) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r reachabilityRule) String() string {
+	action := "libera"
+	if r.Deny {
+		action = "bloqueia"
+	}
+	var ports string
+	switch {
+	case r.Protocol == "icmp":
+		ports = "icmp"
+	case r.FromPort == 0 && r.ToPort == 65535:
+		ports = r.Protocol + "/*"
+	case r.FromPort == r.ToPort:
+		ports = fmt.Sprintf("%s/%d", r.Protocol, r.FromPort)
+	default:
+		ports = fmt.Sprintf("%s/%d-%d", r.Protocol, r.FromPort, r.ToPort)
+	}
+	sources := strings.Join(r.Sources, ", ")
+	if r.Unknown {
+		sources = "origens desconhecidas no plano"
+	}
+	return fmt.Sprintf("%s: %s %s de %s", r.Firewall, action, ports, sources)
+}
+
+// reachabilityFirewall agrupa as regras de entrada de um security group, firewall ou NSG
+type reachabilityFirewall struct {
+	Address string
+	Type    string
+	Rules   []reachabilityRule
+	// TargetTags restringe o firewall aos recursos com alguma dessas tags (DigitalOcean e GCP).
+	// Vazio no GCP aplica o firewall a todas as instâncias da rede
+	TargetTags []string
+}
+
+// reachabilityTarget é um recurso protegido pelos firewalls do plano
+type reachabilityTarget struct {
+	Address string
+	Type    string
+	// Port é a porta em que o serviço escuta, quando conhecida (bancos de dados)
+	Port int
+	// Public indica se o recurso tem endereço público; sem ele, nenhuma regra o expõe à internet
+	Public    bool
+	Firewalls []string
+	// OpenWithoutFirewall indica que o provedor aceita qualquer origem quando nenhum firewall está
+	// associado, como os clusters de banco e droplets do DigitalOcean
+	OpenWithoutFirewall bool
+}
+
+// reachabilityResult é a resposta de CanReach
+type reachabilityResult struct {
+	Reachable bool
+	// Reason é a regra que decidiu ou o motivo de nenhuma ter liberado o tráfego
+	Reason string
+	// Undetermined lista as regras que tratam a porta, mas cujas origens são desconhecidas no plano
+	Undetermined []string
+}
+
+// reachabilityAnalyzer responde se uma origem alcança um recurso do plano a partir das regras de
+// aws_security_group, digitalocean_firewall, google_compute_firewall e azurerm_network_security_group
+type reachabilityAnalyzer struct {
+	Firewalls map[string]*reachabilityFirewall
+	Targets   map[string]*reachabilityTarget
+}
+
+// newReachabilityAnalyzer lê a saída de `terraform show -json` de um plano ou estado. A associação
+// entre recursos e firewalls usa os IDs quando já são conhecidos (estado) e, no plano, as referências
+// da seção configuration, como vpc_security_group_ids = [aws_security_group.db.id]
+func newReachabilityAnalyzer(content []byte) (*reachabilityAnalyzer, error) {
+	resources, err := loadPlannedResources(content)
+	if err != nil {
+		return nil, err
+	}
+	references, err := planReferences(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// linked indica se o atributo attr de from aponta para to, pelo valor de key em to ou por referência
+	linked := func(from plannedResource, attr string, to plannedResource, key string) bool {
+		if value := planString(to.Values, key); value != "" && containsString(planStrings(from.Values, attr), value) {
+			return true
+		}
+		if plannedModulePrefix(from) != plannedModulePrefix(to) {
+			return false
+		}
+		target := to.Type + "." + to.Name
+		for _, reference := range references[planIndexPattern.ReplaceAllString(from.Address, "")][attr] {
+			reference = planIndexPattern.ReplaceAllString(reference, "")
+			if reference == target || strings.HasPrefix(reference, target+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	analyzer := &reachabilityAnalyzer{
+		Firewalls: map[string]*reachabilityFirewall{},
+		Targets:   map[string]*reachabilityTarget{},
+	}
+	firewallsOfType := func(resourceType string) []plannedResource {
+		var matches []plannedResource
+		for _, resource := range resources {
+			if resource.Type == resourceType {
+				matches = append(matches, resource)
+			}
+		}
+		return matches
+	}
+
+	// Firewalls com as regras declaradas no próprio recurso
+	for _, resource := range resources {
+		firewall := &reachabilityFirewall{Address: resource.Address, Type: resource.Type}
+		switch resource.Type {
+		case "aws_security_group":
+			for _, ingress := range planBlocks(resource.Values, "ingress") {
+				firewall.Rules = append(firewall.Rules, awsIngressRule(resource.Address, ingress))
+			}
+		case "digitalocean_firewall":
+			firewall.TargetTags = planStrings(resource.Values, "tags")
+			for _, inbound := range planBlocks(resource.Values, "inbound_rule") {
+				firewall.Rules = append(firewall.Rules, digitalOceanInboundRule(resource.Address, inbound))
+			}
+		case "google_compute_firewall":
+			if direction := planString(resource.Values, "direction"); (direction != "" && direction != "INGRESS") || planBool(resource.Values, "disabled") {
+				continue
+			}
+			firewall.TargetTags = planStrings(resource.Values, "target_tags")
+			firewall.Rules = googleFirewallRules(resource.Address, resource.Values)
+		case "azurerm_network_security_group":
+			for _, rule := range planBlocks(resource.Values, "security_rule") {
+				firewall.Rules = append(firewall.Rules, azureSecurityRules(resource.Address, rule)...)
+			}
+			firewall.Rules = append(firewall.Rules, azureDefaultRules(resource.Address)...)
+		default:
+			continue
+		}
+		analyzer.Firewalls[resource.Address] = firewall
+	}
+
+	// Regras declaradas em recursos próprios
+	for _, resource := range resources {
+		switch resource.Type {
+		case "aws_security_group_rule":
+			if planString(resource.Values, "type") != "ingress" {
+				continue
+			}
+			for _, group := range firewallsOfType("aws_security_group") {
+				if linked(resource, "security_group_id", group, "id") {
+					firewall := analyzer.Firewalls[group.Address]
+					firewall.Rules = append(firewall.Rules, awsIngressRule(group.Address, resource.Values))
+				}
+			}
+		case "azurerm_network_security_rule":
+			for _, group := range firewallsOfType("azurerm_network_security_group") {
+				if linked(resource, "network_security_group_name", group, "name") {
+					firewall := analyzer.Firewalls[group.Address]
+					firewall.Rules = append(firewall.Rules, azureSecurityRules(resource.Address, resource.Values)...)
+				}
+			}
+		}
+	}
+
+	// Recursos protegidos
+	for _, resource := range resources {
+		target := &reachabilityTarget{Address: resource.Address, Type: resource.Type}
+		switch resource.Type {
+		case "aws_db_instance", "aws_instance", "aws_lb":
+			attr := "vpc_security_group_ids"
+			switch resource.Type {
+			case "aws_db_instance":
+				target.Public = planBool(resource.Values, "publicly_accessible")
+				target.Port = databasePort(resource.Values, "engine")
+			case "aws_instance":
+				target.Public = planBool(resource.Values, "associate_public_ip_address")
+			case "aws_lb":
+				target.Public = !planBool(resource.Values, "internal")
+				attr = "security_groups"
+			}
+			for _, group := range firewallsOfType("aws_security_group") {
+				if linked(resource, attr, group, "id") {
+					target.Firewalls = append(target.Firewalls, group.Address)
+				}
+			}
+		case "digitalocean_database_cluster":
+			// O cluster sempre tem um hostname público; sem trusted sources ele aceita qualquer origem
+			target.Public = true
+			target.OpenWithoutFirewall = true
+			target.Port = databasePort(resource.Values, "engine")
+			for _, databaseFirewall := range firewallsOfType("digitalocean_database_firewall") {
+				if !linked(databaseFirewall, "cluster_id", resource, "id") {
+					continue
+				}
+				firewall := &reachabilityFirewall{Address: databaseFirewall.Address, Type: databaseFirewall.Type}
+				for _, rule := range planBlocks(databaseFirewall.Values, "rule") {
+					firewall.Rules = append(firewall.Rules, digitalOceanDatabaseRule(databaseFirewall.Address, rule))
+				}
+				analyzer.Firewalls[firewall.Address] = firewall
+				target.Firewalls = append(target.Firewalls, firewall.Address)
+			}
+		case "digitalocean_droplet":
+			target.Public = true
+			target.OpenWithoutFirewall = true
+			tags := planStrings(resource.Values, "tags")
+			for _, group := range firewallsOfType("digitalocean_firewall") {
+				if tagsIntersect(tags, planStrings(group.Values, "tags")) || containsString(planStrings(group.Values, "droplet_ids"), planString(resource.Values, "id")) {
+					target.Firewalls = append(target.Firewalls, group.Address)
+				}
+			}
+		case "google_sql_database_instance":
+			// authorized_networks e a rede privada fazem o papel de firewall da instância
+			target.Port = databasePort(resource.Values, "database_version")
+			firewall := &reachabilityFirewall{Address: resource.Address, Type: resource.Type}
+			for _, settings := range planBlocks(resource.Values, "settings") {
+				for _, ipConfig := range planBlocks(settings, "ip_configuration") {
+					target.Public = planBool(ipConfig, "ipv4_enabled")
+					if target.Public {
+						for _, network := range planBlocks(ipConfig, "authorized_networks") {
+							firewall.Rules = append(firewall.Rules, reachabilityRule{
+								Firewall: resource.Address, Protocol: "tcp", FromPort: target.Port, ToPort: target.Port,
+								Sources: []string{planString(network, "value")},
+							})
+						}
+					}
+					// private_network vem de um recurso da rede e fica desconhecido no plano
+					if network, ok := ipConfig["private_network"]; !ok || network != "" {
+						firewall.Rules = append(firewall.Rules, reachabilityRule{
+							Firewall: resource.Address, Protocol: "tcp", FromPort: target.Port, ToPort: target.Port,
+							Sources: privateCIDRs,
+						})
+					}
+				}
+			}
+			analyzer.Firewalls[firewall.Address] = firewall
+			target.Firewalls = []string{firewall.Address}
+		case "google_compute_instance":
+			for _, networkInterface := range planBlocks(resource.Values, "network_interface") {
+				if len(planBlocks(networkInterface, "access_config")) > 0 {
+					target.Public = true
+				}
+			}
+			tags := planStrings(resource.Values, "tags")
+			for _, group := range firewallsOfType("google_compute_firewall") {
+				firewall, ok := analyzer.Firewalls[group.Address]
+				if ok && (len(firewall.TargetTags) == 0 || tagsIntersect(tags, firewall.TargetTags)) {
+					target.Firewalls = append(target.Firewalls, group.Address)
+				}
+			}
+		default:
+			continue
+		}
+		analyzer.Targets[resource.Address] = target
+	}
+	return analyzer, nil
+}
+
+// CanReach responde se a origem alcança o recurso na porta e protocolo informados. A origem é
+// "internet", um IP, um CIDR ou um identificador como tag:web; "internet" alcança o recurso se
+// alguma regra liberar qualquer endereço público, mesmo que de uma faixa restrita. O recurso é o endereço de um banco,
+// instância ou load balancer do plano, ou de um próprio firewall (como os NSGs do Azure, que não
+// são associados a nenhum recurso nos módulos)
+func (a *reachabilityAnalyzer) CanReach(source, target string, port int, protocol string) (reachabilityResult, error) {
+	if _, err := parseSourceCIDR(source); err != nil && source != internetSource && !strings.Contains(source, ":") {
+		return reachabilityResult{}, fmt.Errorf("origem inválida: %q", source)
+	}
+	resource, ok := a.Targets[target]
+	if !ok {
+		if _, ok := a.Firewalls[target]; !ok {
+			return reachabilityResult{}, fmt.Errorf("recurso %s não encontrado no plano", target)
+		}
+		resource = &reachabilityTarget{Address: target, Public: true, Firewalls: []string{target}}
+	}
+	protocol = normalizeProtocol(protocol)
+
+	if !resource.Public && sourceMatches(internetSource, source) {
+		return reachabilityResult{Reason: target + " não tem endereço público"}, nil
+	}
+	if len(resource.Firewalls) == 0 {
+		return reachabilityResult{
+			Reachable: resource.OpenWithoutFirewall,
+			Reason:    "nenhum firewall associado a " + target,
+		}, nil
+	}
+
+	var rules []reachabilityRule
+	for _, address := range resource.Firewalls {
+		for _, rule := range a.Firewalls[address].Rules {
+			if rule.matches(protocol, port) {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority < rules[j].Priority })
+
+	var result reachabilityResult
+	for _, rule := range rules {
+		if rule.Unknown {
+			result.Undetermined = append(result.Undetermined, rule.String())
+			continue
+		}
+		if rule.allows(source) {
+			result.Reachable = !rule.Deny
+			result.Reason = rule.String()
+			return result, nil
+		}
+	}
+	result.Reason = fmt.Sprintf("nenhuma regra libera %s/%d de %s", protocol, port, source)
+	return result, nil
+}
+
+// Databases retorna os bancos de dados do plano ordenados pelo endereço
+func (a *reachabilityAnalyzer) Databases() []*reachabilityTarget {
+	var databases []*reachabilityTarget
+	for _, target := range a.Targets {
+		if containsString(databaseResourceTypes, target.Type) {
+			databases = append(databases, target)
+		}
+	}
+	sort.Slice(databases, func(i, j int) bool { return databases[i].Address < databases[j].Address })
+	return databases
+}
+
+// WorldOpenRules aplica worldOpenRules a todas as regras de entrada do plano
+func (a *reachabilityAnalyzer) WorldOpenRules(allowedPorts ...int) []string {
+	addresses := make([]string, 0, len(a.Firewalls))
+	for address := range a.Firewalls {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	var rules []reachabilityRule
+	for _, address := range addresses {
+		rules = append(rules, a.Firewalls[address].Rules...)
+	}
+	return worldOpenRules(rules, allowedPorts...)
+}
+
+// worldOpenRules lista as regras que liberam 0.0.0.0/0, ::/0 ou qualquer origem em alguma porta
+// TCP ou UDP fora de allowedPorts, a restrição que security.feature tenta expressar
+func worldOpenRules(rules []reachabilityRule, allowedPorts ...int) []string {
+	var problems []string
+	for _, rule := range rules {
+		if rule.Deny || rule.Protocol == "icmp" {
+			continue
+		}
+		world := false
+		for _, source := range rule.Sources {
+			if source == "*" || source == internetSource || source == "0.0.0.0/0" || source == "::/0" {
+				world = true
+			}
+		}
+		allowed := 0
+		for _, port := range allowedPorts {
+			if port >= rule.FromPort && port <= rule.ToPort {
+				allowed++
+			}
+		}
+		if world && allowed < rule.ToPort-rule.FromPort+1 {
+			problems = append(problems, rule.String())
+		}
+	}
+	return problems
+}
+
+// securityConfigRules converte security.aws.security_group_rules e security.digitalocean.firewall_rules
+// do config.yaml em regras de entrada
+func securityConfigRules(cfg *environmentConfig) ([]reachabilityRule, error) {
+	var rules []reachabilityRule
+	for i, rule := range cfg.Security.AWS.SecurityGroupRules {
+		if rule.Type != "ingress" {
+			continue
+		}
+		rules = append(rules, reachabilityRule{
+			Firewall: fmt.Sprintf("security.aws.security_group_rules[%d]", i),
+			Protocol: normalizeProtocol(rule.Protocol),
+			FromPort: rule.Port,
+			ToPort:   rule.Port,
+			Sources:  rule.CIDRBlocks,
+		})
+	}
+	for i, rule := range cfg.Security.DigitalOcean.FirewallRules {
+		if rule.Type != "inbound" {
+			continue
+		}
+		// ports aceita uma lista separada por vírgulas, como "80,443"
+		for _, ports := range strings.Split(rule.Ports, ",") {
+			from, to, err := parsePortRange(strings.TrimSpace(ports))
+			if err != nil {
+				return nil, fmt.Errorf("security.digitalocean.firewall_rules[%d]: %v", i, err)
+			}
+			rules = append(rules, reachabilityRule{
+				Firewall: fmt.Sprintf("security.digitalocean.firewall_rules[%d]", i),
+				Protocol: normalizeProtocol(rule.Protocol),
+				FromPort: from,
+				ToPort:   to,
+				Sources:  rule.Sources.Addresses,
+			})
+		}
+	}
+	return rules, nil
+}
+
+// awsIngressRule converte um bloco ingress de aws_security_group ou um aws_security_group_rule
+func awsIngressRule(firewall string, values map[string]interface{}) reachabilityRule {
+	rule := reachabilityRule{Firewall: firewall, Protocol: normalizeProtocol(planString(values, "protocol"))}
+	rule.FromPort, _ = planInt(values, "from_port")
+	rule.ToPort, _ = planInt(values, "to_port")
+	if rule.Protocol == "all" || (rule.FromPort == 0 && rule.ToPort == 0) {
+		rule.FromPort, rule.ToPort = 0, 65535
+	}
+
+	known := false
+	for _, key := range []string{"cidr_blocks", "ipv6_cidr_blocks", "security_groups", "source_security_group_id", "prefix_list_ids"} {
+		if _, ok := values[key]; ok {
+			known = true
+		}
+	}
+	rule.Sources = append(planStrings(values, "cidr_blocks"), planStrings(values, "ipv6_cidr_blocks")...)
+	for _, group := range append(planStrings(values, "security_groups"), planStrings(values, "source_security_group_id")...) {
+		rule.Sources = append(rule.Sources, "sg:"+group)
+	}
+	if planBool(values, "self") {
+		rule.Sources = append(rule.Sources, "sg:"+firewall)
+		known = true
+	}
+	rule.Unknown = !known
+	return rule
+}
+
+// digitalOceanInboundRule converte um inbound_rule de digitalocean_firewall
+func digitalOceanInboundRule(firewall string, values map[string]interface{}) reachabilityRule {
+	rule := reachabilityRule{Firewall: firewall, Protocol: normalizeProtocol(planString(values, "protocol"))}
+	from, to, err := parsePortRange(planString(values, "port_range"))
+	if err != nil {
+		// Faixa inválida não libera nada
+		from, to = -1, -1
+	}
+	rule.FromPort, rule.ToPort = from, to
+
+	_, hasAddresses := values["source_addresses"]
+	_, hasTags := values["source_tags"]
+	rule.Unknown = !hasAddresses && !hasTags
+	rule.Sources = planStrings(values, "source_addresses")
+	for _, tag := range planStrings(values, "source_tags") {
+		rule.Sources = append(rule.Sources, "tag:"+tag)
+	}
+	return rule
+}
+
+// digitalOceanDatabaseRule converte um rule de digitalocean_database_firewall, que libera todas
+// as portas do cluster para a origem
+func digitalOceanDatabaseRule(firewall string, values map[string]interface{}) reachabilityRule {
+	rule := reachabilityRule{Firewall: firewall, Protocol: "tcp", FromPort: 0, ToPort: 65535}
+	value := planString(values, "value")
+	switch kind := planString(values, "type"); {
+	case value == "":
+		rule.Unknown = true
+	case kind == "ip_addr":
+		rule.Sources = []string{value}
+	default:
+		// tag, droplet, k8s e app
+		rule.Sources = []string{kind + ":" + value}
+	}
+	return rule
+}
+
+// googleFirewallRules converte os blocos allow e deny de um google_compute_firewall de entrada
+func googleFirewallRules(firewall string, values map[string]interface{}) []reachabilityRule {
+	priority, ok := planInt(values, "priority")
+	if !ok {
+		priority = 1000
+	}
+	_, hasRanges := values["source_ranges"]
+	_, hasTags := values["source_tags"]
+	sources := planStrings(values, "source_ranges")
+	for _, tag := range planStrings(values, "source_tags") {
+		sources = append(sources, "tag:"+tag)
+	}
+	for _, account := range planStrings(values, "source_service_accounts") {
+		sources = append(sources, "sa:"+account)
+	}
+
+	var rules []reachabilityRule
+	for _, action := range []string{"allow", "deny"} {
+		for _, block := range planBlocks(values, action) {
+			ports := planStrings(block, "ports")
+			if len(ports) == 0 {
+				ports = []string{""}
+			}
+			for _, portRange := range ports {
+				from, to, err := parsePortRange(portRange)
+				if err != nil {
+					continue
+				}
+				rules = append(rules, reachabilityRule{
+					Firewall: firewall,
+					Protocol: normalizeProtocol(planString(block, "protocol")),
+					FromPort: from,
+					ToPort:   to,
+					Sources:  sources,
+					Deny:     action == "deny",
+					Priority: priority,
+					Unknown:  !hasRanges && !hasTags,
+				})
+			}
+		}
+	}
+	return rules
+}
+
+// azureSecurityRules converte uma regra de entrada do NSG (bloco security_rule ou
+// azurerm_network_security_rule), com uma regra por faixa de destination_port_ranges. Regras de
+// saída são ignoradas
+func azureSecurityRules(firewall string, values map[string]interface{}) []reachabilityRule {
+	if !strings.EqualFold(planString(values, "direction"), "Inbound") {
+		return nil
+	}
+	if name := planString(values, "name"); name != "" {
+		firewall += " (" + name + ")"
+	}
+	priority, _ := planInt(values, "priority")
+
+	var sources []string
+	for _, prefix := range append(planStrings(values, "source_address_prefixes"), planStrings(values, "source_address_prefix")...) {
+		switch prefix {
+		case "*":
+			sources = append(sources, "*")
+		case "Internet":
+			sources = append(sources, internetSource)
+		default:
+			if _, err := parseSourceCIDR(prefix); err == nil {
+				sources = append(sources, prefix)
+			} else {
+				// Service tags como VirtualNetwork e AzureLoadBalancer
+				sources = append(sources, "tag:"+prefix)
+			}
+		}
+	}
+
+	var rules []reachabilityRule
+	for _, ports := range append(planStrings(values, "destination_port_ranges"), planStrings(values, "destination_port_range")...) {
+		from, to, err := parsePortRange(ports)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, reachabilityRule{
+			Firewall: firewall,
+			Protocol: normalizeProtocol(planString(values, "protocol")),
+			FromPort: from,
+			ToPort:   to,
+			Sources:  sources,
+			Deny:     strings.EqualFold(planString(values, "access"), "Deny"),
+			Priority: priority,
+			Unknown:  len(sources) == 0,
+		})
+	}
+	return rules
+}
+
+// azureDefaultRules são as regras de entrada que o Azure acrescenta a todo NSG
+func azureDefaultRules(firewall string) []reachabilityRule {
+	return []reachabilityRule{
+		{Firewall: firewall + " (AllowVnetInBound)", Protocol: "all", FromPort: 0, ToPort: 65535, Sources: []string{"tag:VirtualNetwork"}, Priority: 65000},
+		{Firewall: firewall + " (AllowAzureLoadBalancerInBound)", Protocol: "all", FromPort: 0, ToPort: 65535, Sources: []string{"tag:AzureLoadBalancer"}, Priority: 65001},
+		{Firewall: firewall + " (DenyAllInBound)", Protocol: "all", FromPort: 0, ToPort: 65535, Sources: []string{"*"}, Deny: true, Priority: 65500},
+	}
+}
+
+// sourceMatches indica se a origem da regra (allowed) abrange a origem consultada
+func sourceMatches(allowed, source string) bool {
+	if allowed == "*" || allowed == source {
+		return true
+	}
+	sourceNetwork, sourceErr := parseSourceCIDR(source)
+	if allowed == internetSource {
+		return sourceErr == nil && publicCIDR(sourceNetwork)
+	}
+	allowedNetwork, err := parseSourceCIDR(allowed)
+	if err != nil {
+		return false
+	}
+	if source == internetSource {
+		return publicCIDR(allowedNetwork)
+	}
+	return sourceErr == nil && cidrContains(allowedNetwork, sourceNetwork)
+}
+
+// parseSourceCIDR aceita CIDRs, mesmo fora do alinhamento, e IPs isolados
+func parseSourceCIDR(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		return network, err
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("%q não é um IP nem um CIDR", value)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// publicCIDR indica se a faixa contém algum endereço roteável pela internet
+func publicCIDR(network *net.IPNet) bool {
+	for _, private := range nonPublicCIDRs {
+		if cidrContains(private, network) {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePortRange interpreta "80", "1-65535", "all", "*" ou vazio (todas as portas)
+func parsePortRange(value string) (int, int, error) {
+	if value == "" || value == "all" || value == "*" {
+		return 0, 65535, nil
+	}
+	parts := strings.SplitN(value, "-", 2)
+	from, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("faixa de portas inválida: %q", value)
+	}
+	to := from
+	if len(parts) == 2 {
+		if to, err = strconv.Atoi(parts[1]); err != nil || to < from {
+			return 0, 0, fmt.Errorf("faixa de portas inválida: %q", value)
+		}
+	}
+	return from, to, nil
+}
+
+// normalizeProtocol unifica os nomes de protocolo dos provedores (-1, "*", "Tcp", "6")
+func normalizeProtocol(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "", "-1", "*", "all":
+		return "all"
+	case "6":
+		return "tcp"
+	case "17":
+		return "udp"
+	case "1":
+		return "icmp"
+	default:
+		return strings.ToLower(protocol)
+	}
+}
+
+// databasePort deduz a porta do banco pelo atributo port ou pelo engine (engine ou database_version)
+func databasePort(values map[string]interface{}, engineKey string) int {
+	if port, ok := planInt(values, "port"); ok && port > 0 {
+		return port
+	}
+	engine := strings.ToLower(planString(values, engineKey))
+	switch {
+	case engine == "pg" || strings.Contains(engine, "postgres"):
+		return 5432
+	case strings.Contains(engine, "mysql") || strings.Contains(engine, "mariadb"):
+		return 3306
+	case strings.Contains(engine, "sqlserver"):
+		return 1433
+	case strings.Contains(engine, "mongodb"):
+		return 27017
+	case strings.Contains(engine, "redis"):
+		return 6379
+	}
+	return 0
+}
+
+func tagsIntersect(a, b []string) bool {
+	for _, tag := range a {
+		if containsString(b, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// planReferences lê as referências da seção configuration do plano, indexadas pelo endereço do
+// recurso sem índices e pelo atributo (module.database_aws.aws_db_instance.default →
+// vpc_security_group_ids → [aws_security_group.db.id, aws_security_group.db])
+func planReferences(content []byte) (map[string]map[string][]string, error) {
+	var document struct {
+		Configuration struct {
+			RootModule tfJSONConfigModule `json:"root_module"`
+		} `json:"configuration"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar JSON do Terraform: %v", err)
+	}
+
+	references := map[string]map[string][]string{}
+	var walk func(prefix string, module tfJSONConfigModule)
+	walk = func(prefix string, module tfJSONConfigModule) {
+		for _, resource := range module.Resources {
+			attributes := map[string][]string{}
+			for attr, raw := range resource.Expressions {
+				var expression struct {
+					References []string `json:"references"`
+				}
+				// Blocos aninhados vêm como listas e não são usados nas associações
+				if json.Unmarshal(raw, &expression) == nil && len(expression.References) > 0 {
+					attributes[attr] = expression.References
+				}
+			}
+			references[prefix+resource.Address] = attributes
+		}
+		for name, call := range module.ModuleCalls {
+			walk(prefix+"module."+name+".", call.Module)
+		}
+	}
+	walk("", document.Configuration.RootModule)
+	return references, nil
+}
+
+// plannedModulePrefix retorna o caminho do módulo que contém o recurso, com os índices
+// (module.database_aws[0].)
+func plannedModulePrefix(resource plannedResource) string {
+	address := resource.Address
+	if i := strings.LastIndex(address, "["); i > strings.LastIndex(address, ".") {
+		address = address[:i]
+	}
+	return strings.TrimSuffix(address, resource.Type+"."+resource.Name)
+}
+
+// planBlocks retorna os itens de um bloco aninhado (lista de objetos no JSON do plano)
+func planBlocks(values map[string]interface{}, key string) []map[string]interface{} {
+	items, _ := values[key].([]interface{})
+	blocks := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if block, ok := item.(map[string]interface{}); ok {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// planStrings retorna um atributo de lista de strings, ou uma string isolada como lista de um item
+func planStrings(values map[string]interface{}, key string) []string {
+	switch value := values[key].(type) {
+	case string:
+		if value != "" {
+			return []string{value}
+		}
+	case []interface{}:
+		var items []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+func planInt(values map[string]interface{}, key string) (int, bool) {
+	switch value := values[key].(type) {
+	case float64:
+		return int(value), true
+	case string:
+		n, err := strconv.Atoi(value)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func planBool(values map[string]interface{}, key string) bool {
+	value, _ := values[key].(bool)
+	return value
+}
@@ -0,0 +1,270 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tipos de firewall lidos por newReachabilityAnalyzer, conferidos contra o código dos módulos
+var reachabilityFirewallTypes = []string{
+	"aws_security_group", "aws_security_group_rule", "digitalocean_firewall", "digitalocean_database_firewall",
+	"google_compute_firewall", "azurerm_network_security_group", "azurerm_network_security_rule",
+}
+
+var (
+	hclDynamicBlockStart = regexp.MustCompile(`^\s*dynamic\s+"([^"]+)"\s*\{`)
+	hclInterpolation     = regexp.MustCompile(`\$\{([^}]*)\}`)
+)
+
+// hclNestedBlock é um bloco aninhado de um recurso; Dynamic marca os blocos dynamic, cujo conteúdo
+// depende de variáveis do módulo
+type hclNestedBlock struct {
+	Name    string
+	Dynamic bool
+	Body    string
+}
+
+// hclNestedBlocks separa os blocos aninhados de primeiro nível de um corpo, na ordem do arquivo
+func hclNestedBlocks(body string) []hclNestedBlock {
+	var blocks []hclNestedBlock
+	var current *hclNestedBlock
+	var lines []string
+	depth := 0
+	for _, line := range strings.Split(body, "\n") {
+		if depth == 0 && current == nil {
+			if match := hclDynamicBlockStart.FindStringSubmatch(line); match != nil {
+				current = &hclNestedBlock{Name: match[1], Dynamic: true}
+			} else if match := hclNestedBlockStart.FindStringSubmatch(line); match != nil {
+				current = &hclNestedBlock{Name: match[1]}
+			}
+		}
+		if current != nil {
+			lines = append(lines, line)
+		}
+		depth += hclDepthChange(line)
+		if current != nil && depth == 0 {
+			text := strings.Join(lines, "\n")
+			if inner, err := hclBlockBody(text, strings.Index(text, "{")); err == nil {
+				current.Body = inner
+			}
+			blocks = append(blocks, *current)
+			current, lines = nil, nil
+		}
+	}
+	return blocks
+}
+
+// resolveHCLExpression avalia as expressões simples dos módulos (literais, listas e referências
+// conhecidas em vars); o segundo retorno é falso para qualquer outra expressão
+func resolveHCLExpression(expression string, vars map[string]string) (interface{}, bool) {
+	expression = strings.TrimSpace(expression)
+	switch {
+	case strings.HasPrefix(expression, "[") && strings.HasSuffix(expression, "]"):
+		items := []interface{}{}
+		for _, item := range strings.Split(expression[1:len(expression)-1], ",") {
+			if strings.TrimSpace(item) == "" {
+				continue
+			}
+			value, ok := resolveHCLExpression(item, vars)
+			if !ok {
+				return nil, false
+			}
+			items = append(items, value)
+		}
+		return items, true
+	case len(expression) >= 2 && strings.HasPrefix(expression, `"`) && strings.HasSuffix(expression, `"`):
+		resolved := true
+		value := hclInterpolation.ReplaceAllStringFunc(expression[1:len(expression)-1], func(match string) string {
+			reference := strings.TrimSpace(match[2 : len(match)-1])
+			if known, ok := vars[reference]; ok {
+				return known
+			}
+			resolved = false
+			return match
+		})
+		return value, resolved
+	case expression == "true" || expression == "false":
+		return expression == "true", true
+	}
+	if number, err := strconv.ParseFloat(expression, 64); err == nil {
+		return number, true
+	}
+	if known, ok := vars[expression]; ok {
+		return known, true
+	}
+	return nil, false
+}
+
+// compareFixtureBlock compara os atributos resolvíveis e os blocos aninhados do código com os
+// valores do plano. Blocos estáticos precisam casar com itens distintos do plano; itens sobrando
+// só são aceitos quando o código tem um bloco dynamic de mesmo nome
+func compareFixtureBlock(path, body string, values map[string]interface{}, vars map[string]string) []string {
+	var problems []string
+
+	var names []string
+	attributes := hclAttributes(body)
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		planned, found := values[name]
+		if strings.HasPrefix(name, "bloco ") || !found {
+			continue
+		}
+		expected, ok := resolveHCLExpression(hclAttributeValue(attributes[name]), vars)
+		if ok && !reflect.DeepEqual(expected, planned) {
+			problems = append(problems, fmt.Sprintf("%s.%s: plano tem %v, o módulo define %v", path, name, planned, expected))
+		}
+	}
+
+	byName := map[string][]hclNestedBlock{}
+	var blockNames []string
+	for _, block := range hclNestedBlocks(body) {
+		if _, seen := byName[block.Name]; !seen {
+			blockNames = append(blockNames, block.Name)
+		}
+		byName[block.Name] = append(byName[block.Name], block)
+	}
+	for _, name := range blockNames {
+		if _, found := values[name]; !found {
+			continue
+		}
+		planned := planBlocks(values, name)
+		used := make([]bool, len(planned))
+		dynamic := false
+		for _, block := range byName[name] {
+			if block.Dynamic {
+				dynamic = true
+				continue
+			}
+			matched := false
+			for i, item := range planned {
+				if !used[i] && len(compareFixtureBlock(path, block.Body, item, vars)) == 0 {
+					used[i], matched = true, true
+					break
+				}
+			}
+			if !matched {
+				problems = append(problems, fmt.Sprintf("%s: bloco %s do módulo sem correspondente no plano: %s", path, name, strings.Join(strings.Fields(block.Body), " ")))
+			}
+		}
+		for i, item := range planned {
+			if !used[i] && !dynamic {
+				problems = append(problems, fmt.Sprintf("%s: item de %s no plano não existe no módulo: %v", path, name, item))
+			}
+		}
+	}
+	return problems
+}
+
+// moduleResourceBodies lê os recursos de um tipo declarados nos arquivos .tf de um diretório,
+// indexados pelo nome
+func moduleResourceBodies(dir, resourceType string) (map[string]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	pattern := regexp.MustCompile(`(?m)^resource\s+"` + regexp.QuoteMeta(resourceType) + `"\s+"([^"]+)"\s*\{`)
+	bodies := map[string]string{}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		src := stripHCLComments(string(content))
+		for _, match := range pattern.FindAllStringSubmatchIndex(src, -1) {
+			body, err := hclBlockBody(src, match[1]-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			bodies[src[match[2]:match[3]]] = body
+		}
+	}
+	return bodies, nil
+}
+
+// checkReachabilityFixture confere um plano de testdata/reachability contra o código dos módulos
+// chamados por root: cada firewall e banco do plano precisa ter os mesmos atributos e regras do
+// recurso no módulo, e cada firewall sem count ou for_each de um módulo presente no plano precisa
+// estar no plano. vars traz os valores das referências usadas nas regras (var.vpc_cidr...)
+func checkReachabilityFixture(content []byte, root string, vars map[string]string) ([]string, error) {
+	resources, err := loadPlannedResources(content)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := loadModuleGraph("root", root)
+	if err != nil {
+		return nil, err
+	}
+
+	checkedTypes := append(append([]string{}, reachabilityFirewallTypes...), databaseResourceTypes...)
+	var problems []string
+	planned := map[string]map[string]bool{}
+	for _, resource := range resources {
+		if !containsString(checkedTypes, resource.Type) {
+			continue
+		}
+		prefix := strings.TrimSuffix(plannedModulePrefix(resource), ".")
+		if strings.Count(prefix, "module.") != 1 {
+			problems = append(problems, fmt.Sprintf("%s: só recursos em módulos chamados pela raiz são conferidos", resource.Address))
+			continue
+		}
+		module := prefix
+		if i := strings.Index(module, "["); i >= 0 {
+			module = module[:i]
+		}
+		node, ok := graph.Nodes[module]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: %s não existe no módulo raiz", resource.Address, module))
+			continue
+		}
+		dir := filepath.Join(root, strings.Trim(hclAttributeValue(node.Attributes["source"]), `"`))
+		if planned[dir] == nil {
+			planned[dir] = map[string]bool{}
+		}
+		planned[dir][resource.Type+"."+resource.Name] = true
+
+		bodies, err := moduleResourceBodies(dir, resource.Type)
+		if err != nil {
+			return nil, err
+		}
+		body, ok := bodies[resource.Name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: %s.%s não existe em %s", resource.Address, resource.Type, resource.Name, dir))
+			continue
+		}
+		problems = append(problems, compareFixtureBlock(resource.Address, body, resource.Values, vars)...)
+	}
+
+	var dirs []string
+	for dir := range planned {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		for _, resourceType := range reachabilityFirewallTypes {
+			bodies, err := moduleResourceBodies(dir, resourceType)
+			if err != nil {
+				return nil, err
+			}
+			for name, body := range bodies {
+				attributes := hclAttributes(body)
+				if attributes["count"] != "" || attributes["for_each"] != "" {
+					continue
+				}
+				if !planned[dir][resourceType+"."+name] {
+					problems = append(problems, fmt.Sprintf("%s.%s de %s não está no plano", resourceType, name, dir))
+				}
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
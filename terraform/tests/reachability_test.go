@@ -0,0 +1,390 @@
+package test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tipo do banco de dados criado pelo módulo raiz para cada provider.active
+var activeProviderDatabases = map[string]string{
+	"aws":          "aws_db_instance",
+	"digitalocean": "digitalocean_database_cluster",
+	"gcp":          "google_sql_database_instance",
+}
+
+// TestDatabaseReachability confere, no plano de cada ambiente, que nenhum banco de dados é
+// alcançável pela internet e que a VPC do ambiente continua chegando ao banco. Os planos vêm de
+// testdata/reachability/<ambiente>.json ou, com REACHABILITY_PLAN_DIR, da saída de
+// `terraform show -json` gerada na pipeline com as credenciais de cada provedor
+func TestDatabaseReachability(t *testing.T) {
+	t.Parallel()
+
+	dir := os.Getenv("REACHABILITY_PLAN_DIR")
+	if dir == "" {
+		dir = filepath.Join("testdata", "reachability")
+	}
+
+	for _, environment := range environments {
+		environment := environment
+		t.Run(environment, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := loadEnvironmentConfig(environment)
+			if err != nil {
+				t.Fatalf("Erro ao carregar config.yaml: %v", err)
+			}
+			content, err := os.ReadFile(filepath.Join(dir, environment+".json"))
+			if err != nil {
+				t.Fatalf("Erro ao ler o plano: %v", err)
+			}
+			analyzer, err := newReachabilityAnalyzer(content)
+			if err != nil {
+				t.Fatalf("Erro ao analisar o plano: %v", err)
+			}
+
+			_, vpc, err := net.ParseCIDR(cfg.Network.VPCCIDR)
+			if err != nil {
+				t.Fatalf("network.vpc_cidr inválido: %v", err)
+			}
+			vpcHost := append(net.IP{}, vpc.IP.To4()...)
+			vpcHost[3] += 10
+
+			databases := analyzer.Databases()
+			if !assert.NotEmpty(t, databases, "nenhum banco de dados no plano") {
+				return
+			}
+			for _, database := range databases {
+				assert.Equal(t, activeProviderDatabases[cfg.Provider.Active], database.Type, database.Address)
+				if !assert.NotZero(t, database.Port, "porta de %s", database.Address) {
+					continue
+				}
+
+				for _, source := range []string{internetSource, "0.0.0.0/0", "::/0", "203.0.113.10"} {
+					result, err := analyzer.CanReach(source, database.Address, database.Port, "tcp")
+					if assert.NoError(t, err) {
+						assert.False(t, result.Reachable, "%s alcançável de %s: %s", database.Address, source, result.Reason)
+						assert.Empty(t, result.Undetermined, "%s a partir de %s", database.Address, source)
+					}
+				}
+
+				result, err := analyzer.CanReach(vpcHost.String(), database.Address, database.Port, "tcp")
+				if assert.NoError(t, err) {
+					assert.True(t, result.Reachable, "%s inalcançável pela VPC: %s", database.Address, result.Reason)
+				}
+			}
+
+			assert.Empty(t, analyzer.WorldOpenRules(80, 443))
+		})
+	}
+}
+
+// TestReachabilityFixtures confere que os planos de testdata/reachability, escritos à mão, têm as
+// mesmas regras dos módulos chamados pelo módulo raiz: digitalocean_firewall.database,
+// aws_security_group.db, google_compute_firewall.allow_internal etc. Uma regra alterada no módulo
+// sem atualizar o plano de exemplo faz o teste falhar
+func TestReachabilityFixtures(t *testing.T) {
+	t.Parallel()
+
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao carregar config.yaml de %s: %v", environment, err)
+		}
+		content, err := os.ReadFile(filepath.Join("testdata", "reachability", environment+".json"))
+		if err != nil {
+			t.Fatalf("Erro ao ler o plano de %s: %v", environment, err)
+		}
+		// data.aws_vpc.selected é a VPC criada por module.network_aws com network.vpc_cidr
+		vars := map[string]string{
+			"var.project_name":                 "boilerplate-nestjs",
+			"var.environment":                  environment,
+			"var.vpc_cidr":                     cfg.Network.VPCCIDR,
+			"data.aws_vpc.selected.cidr_block": cfg.Network.VPCCIDR,
+		}
+		problems, err := checkReachabilityFixture(content, "..", vars)
+		if err != nil {
+			t.Fatalf("Erro ao conferir o plano de %s: %v", environment, err)
+		}
+		assert.Empty(t, problems, environment)
+
+		if environment != "dev" {
+			continue
+		}
+		// Regra do plano divergente do módulo e firewall do módulo ausente do plano
+		changed := strings.Replace(string(content), `"port_range": "5432"`, `"port_range": "5433"`, 1)
+		changed = strings.Replace(changed, `"module.network_digitalocean[0].digitalocean_firewall.web"`, `"module.network_digitalocean[0].digitalocean_firewall.web_removed"`, 1)
+		changed = strings.Replace(changed, `"name": "web"`, `"name": "web_removed"`, 1)
+		problems, err = checkReachabilityFixture([]byte(changed), "..", vars)
+		if err != nil {
+			t.Fatalf("Erro ao conferir o plano alterado: %v", err)
+		}
+		joined := strings.Join(problems, "\n")
+		assert.Contains(t, joined, `module.network_digitalocean[0].digitalocean_firewall.database: bloco inbound_rule do módulo sem correspondente no plano: protocol = "tcp" port_range = "5432" source_addresses = [var.vpc_cidr]`)
+		assert.Contains(t, joined, "module.network_digitalocean[0].digitalocean_firewall.database: item de inbound_rule no plano não existe no módulo")
+		assert.Contains(t, joined, "digitalocean_firewall.web de ../modules/network/digital-ocean não está no plano")
+		assert.Contains(t, joined, "digitalocean_firewall.web_removed não existe em ../modules/network/digital-ocean")
+	}
+}
+
+// TestReachabilityAnalyzer cobre a leitura das regras de cada provedor com planos de exemplo
+func TestReachabilityAnalyzer(t *testing.T) {
+	t.Parallel()
+
+	newAnalyzer := func(t *testing.T, plan string) *reachabilityAnalyzer {
+		analyzer, err := newReachabilityAnalyzer([]byte(plan))
+		if err != nil {
+			t.Fatalf("Erro ao analisar o plano: %v", err)
+		}
+		return analyzer
+	}
+	canReach := func(t *testing.T, analyzer *reachabilityAnalyzer, source, target string, port int, protocol string) reachabilityResult {
+		result, err := analyzer.CanReach(source, target, port, protocol)
+		if err != nil {
+			t.Fatalf("Erro ao consultar %s: %v", target, err)
+		}
+		return result
+	}
+
+	t.Run("aws", func(t *testing.T) {
+		// Estado com IDs conhecidos e um security group cujas origens só existem depois do apply
+		analyzer := newAnalyzer(t, `{"values": {"root_module": {"resources": [
+			{"address": "aws_db_instance.public", "mode": "managed", "type": "aws_db_instance", "name": "public",
+			 "values": {"engine": "mysql", "publicly_accessible": true, "vpc_security_group_ids": ["sg-1"]}},
+			{"address": "aws_db_instance.private", "mode": "managed", "type": "aws_db_instance", "name": "private",
+			 "values": {"engine": "postgres", "port": 5433, "publicly_accessible": false, "vpc_security_group_ids": ["sg-1", "sg-2"]}},
+			{"address": "aws_security_group.open", "mode": "managed", "type": "aws_security_group", "name": "open",
+			 "values": {"id": "sg-1", "ingress": [
+				{"from_port": 3306, "to_port": 3306, "protocol": "tcp", "cidr_blocks": ["0.0.0.0/0"], "security_groups": []},
+				{"from_port": 5433, "to_port": 5433, "protocol": "tcp", "cidr_blocks": [], "security_groups": ["sg-app"]}]}},
+			{"address": "aws_security_group.pending", "mode": "managed", "type": "aws_security_group", "name": "pending",
+			 "values": {"id": "sg-2", "ingress": [{"from_port": 5433, "to_port": 5433, "protocol": "tcp"}]}},
+			{"address": "aws_security_group_rule.admin", "mode": "managed", "type": "aws_security_group_rule", "name": "admin",
+			 "values": {"type": "ingress", "security_group_id": "sg-1", "from_port": 22, "to_port": 22, "protocol": "tcp", "cidr_blocks": ["203.0.113.0/24"]}}
+		]}}}`)
+
+		assert.Equal(t, 3306, analyzer.Targets["aws_db_instance.public"].Port)
+		assert.Equal(t, 5433, analyzer.Targets["aws_db_instance.private"].Port)
+
+		result := canReach(t, analyzer, internetSource, "aws_db_instance.public", 3306, "tcp")
+		assert.True(t, result.Reachable)
+		assert.Equal(t, "aws_security_group.open: libera tcp/3306 de 0.0.0.0/0", result.Reason)
+		assert.False(t, canReach(t, analyzer, internetSource, "aws_db_instance.public", 3306, "udp").Reachable)
+
+		assert.True(t, canReach(t, analyzer, "203.0.113.7", "aws_db_instance.public", 22, "tcp").Reachable)
+		assert.False(t, canReach(t, analyzer, "198.51.100.1", "aws_db_instance.public", 22, "tcp").Reachable)
+		assert.False(t, canReach(t, analyzer, "203.0.0.0/16", "aws_db_instance.public", 22, "tcp").Reachable, "faixa maior que a liberada")
+
+		result = canReach(t, analyzer, internetSource, "aws_db_instance.private", 5433, "tcp")
+		assert.False(t, result.Reachable)
+		assert.Equal(t, "aws_db_instance.private não tem endereço público", result.Reason)
+		assert.Empty(t, result.Undetermined)
+
+		assert.True(t, canReach(t, analyzer, "sg:sg-app", "aws_db_instance.private", 5433, "tcp").Reachable)
+		result = canReach(t, analyzer, "10.0.1.5", "aws_db_instance.private", 5433, "tcp")
+		assert.False(t, result.Reachable)
+		assert.Equal(t, []string{"aws_security_group.pending: libera tcp/5433 de origens desconhecidas no plano"}, result.Undetermined)
+
+		assert.Equal(t, []string{"aws_security_group.open: libera tcp/3306 de 0.0.0.0/0"}, analyzer.WorldOpenRules(80, 443))
+	})
+
+	t.Run("digitalocean", func(t *testing.T) {
+		// Associações por referência, como no plano antes da criação dos recursos
+		analyzer := newAnalyzer(t, `{
+			"planned_values": {"root_module": {"child_modules": [{"resources": [
+				{"address": "module.db[0].digitalocean_database_cluster.main", "mode": "managed", "type": "digitalocean_database_cluster", "name": "main",
+				 "values": {"engine": "pg"}},
+				{"address": "module.db[0].digitalocean_database_cluster.cache", "mode": "managed", "type": "digitalocean_database_cluster", "name": "cache",
+				 "values": {"engine": "redis"}},
+				{"address": "module.db[0].digitalocean_database_firewall.main", "mode": "managed", "type": "digitalocean_database_firewall", "name": "main",
+				 "values": {"rule": [{"type": "ip_addr", "value": "10.0.0.0/16"}, {"type": "tag", "value": "web"}]}},
+				{"address": "module.db[1].digitalocean_database_cluster.main", "mode": "managed", "type": "digitalocean_database_cluster", "name": "main",
+				 "values": {"engine": "pg"}},
+				{"address": "module.db[0].digitalocean_firewall.web", "mode": "managed", "type": "digitalocean_firewall", "name": "web",
+				 "values": {"tags": ["web"], "inbound_rule": [
+					{"protocol": "tcp", "port_range": "80", "source_addresses": ["0.0.0.0/0", "::/0"]},
+					{"protocol": "tcp", "port_range": "all", "source_tags": ["bastion"]},
+					{"protocol": "icmp", "source_addresses": ["10.0.0.0/16"]}]}},
+				{"address": "module.db[0].digitalocean_droplet.app", "mode": "managed", "type": "digitalocean_droplet", "name": "app",
+				 "values": {"tags": ["nestjs", "web"]}},
+				{"address": "module.db[0].digitalocean_droplet.worker", "mode": "managed", "type": "digitalocean_droplet", "name": "worker",
+				 "values": {"tags": ["worker"]}}
+			]}]}},
+			"configuration": {"root_module": {"module_calls": {"db": {"module": {"resources": [
+				{"address": "digitalocean_database_firewall.main", "expressions": {"cluster_id": {"references": ["digitalocean_database_cluster.main.id", "digitalocean_database_cluster.main"]}}}
+			]}}}}}
+		}`)
+
+		main := "module.db[0].digitalocean_database_cluster.main"
+		assert.Equal(t, []string{"module.db[0].digitalocean_database_firewall.main"}, analyzer.Targets[main].Firewalls)
+		assert.False(t, canReach(t, analyzer, internetSource, main, 5432, "tcp").Reachable)
+		assert.True(t, canReach(t, analyzer, "10.0.3.4", main, 5432, "tcp").Reachable)
+		assert.True(t, canReach(t, analyzer, "tag:web", main, 5432, "tcp").Reachable)
+
+		// A referência vale só para a instância do mesmo módulo
+		result := canReach(t, analyzer, internetSource, "module.db[1].digitalocean_database_cluster.main", 5432, "tcp")
+		assert.True(t, result.Reachable)
+		assert.Equal(t, "nenhum firewall associado a module.db[1].digitalocean_database_cluster.main", result.Reason)
+		assert.True(t, canReach(t, analyzer, internetSource, "module.db[0].digitalocean_database_cluster.cache", 6379, "tcp").Reachable)
+
+		app := "module.db[0].digitalocean_droplet.app"
+		assert.True(t, canReach(t, analyzer, "::/0", app, 80, "tcp").Reachable)
+		assert.False(t, canReach(t, analyzer, internetSource, app, 22, "tcp").Reachable)
+		assert.True(t, canReach(t, analyzer, "tag:bastion", app, 22, "tcp").Reachable)
+		assert.True(t, canReach(t, analyzer, "10.0.0.9", app, 0, "icmp").Reachable)
+		assert.True(t, canReach(t, analyzer, internetSource, "module.db[0].digitalocean_droplet.worker", 22, "tcp").Reachable)
+
+		databases := analyzer.Databases()
+		if assert.Len(t, databases, 3) {
+			assert.Equal(t, "module.db[0].digitalocean_database_cluster.cache", databases[0].Address)
+		}
+	})
+
+	t.Run("gcp", func(t *testing.T) {
+		analyzer := newAnalyzer(t, `{"planned_values": {"root_module": {"resources": [
+			{"address": "google_compute_instance.web", "mode": "managed", "type": "google_compute_instance", "name": "web",
+			 "values": {"tags": ["web"], "network_interface": [{"access_config": [{}]}]}},
+			{"address": "google_compute_instance.internal", "mode": "managed", "type": "google_compute_instance", "name": "internal",
+			 "values": {"tags": ["web"], "network_interface": [{"access_config": []}]}},
+			{"address": "google_compute_firewall.deny_ssh", "mode": "managed", "type": "google_compute_firewall", "name": "deny_ssh",
+			 "values": {"direction": "INGRESS", "priority": 900, "source_ranges": ["0.0.0.0/0"], "target_tags": ["web"],
+			            "deny": [{"protocol": "tcp", "ports": ["22"]}]}},
+			{"address": "google_compute_firewall.web", "mode": "managed", "type": "google_compute_firewall", "name": "web",
+			 "values": {"direction": "INGRESS", "source_ranges": ["0.0.0.0/0"], "target_tags": ["web"],
+			            "allow": [{"protocol": "tcp", "ports": ["22", "8000-8080"]}]}},
+			{"address": "google_compute_firewall.db", "mode": "managed", "type": "google_compute_firewall", "name": "db",
+			 "values": {"source_ranges": ["0.0.0.0/0"], "target_tags": ["db"], "allow": [{"protocol": "tcp", "ports": ["5432"]}]}},
+			{"address": "google_compute_firewall.egress", "mode": "managed", "type": "google_compute_firewall", "name": "egress",
+			 "values": {"direction": "EGRESS", "destination_ranges": ["0.0.0.0/0"], "allow": [{"protocol": "all"}]}},
+			{"address": "google_sql_database_instance.public", "mode": "managed", "type": "google_sql_database_instance", "name": "public",
+			 "values": {"database_version": "MYSQL_8_0", "settings": [{"ip_configuration": [
+				{"ipv4_enabled": true, "private_network": "", "authorized_networks": [{"name": "todos", "value": "0.0.0.0/0"}]}]}]}},
+			{"address": "google_sql_database_instance.private", "mode": "managed", "type": "google_sql_database_instance", "name": "private",
+			 "values": {"database_version": "POSTGRES_14", "settings": [{"ip_configuration": [
+				{"ipv4_enabled": false, "authorized_networks": [{"name": "todos", "value": "0.0.0.0/0"}]}]}]}}
+		]}}}`)
+
+		web := "google_compute_instance.web"
+		assert.Equal(t, []string{"google_compute_firewall.deny_ssh", "google_compute_firewall.web"}, analyzer.Targets[web].Firewalls)
+		result := canReach(t, analyzer, internetSource, web, 22, "tcp")
+		assert.False(t, result.Reachable)
+		assert.Equal(t, "google_compute_firewall.deny_ssh: bloqueia tcp/22 de 0.0.0.0/0", result.Reason)
+		assert.True(t, canReach(t, analyzer, internetSource, web, 8080, "tcp").Reachable)
+		assert.False(t, canReach(t, analyzer, internetSource, web, 5432, "tcp").Reachable)
+		assert.False(t, canReach(t, analyzer, internetSource, "google_compute_instance.internal", 8080, "tcp").Reachable)
+
+		assert.True(t, canReach(t, analyzer, internetSource, "google_sql_database_instance.public", 3306, "tcp").Reachable)
+		assert.False(t, canReach(t, analyzer, "tag:web", "google_sql_database_instance.public", 3306, "tcp").Reachable, "sem rede privada")
+		assert.False(t, canReach(t, analyzer, internetSource, "google_sql_database_instance.private", 5432, "tcp").Reachable)
+		assert.True(t, canReach(t, analyzer, "10.1.0.2", "google_sql_database_instance.private", 5432, "tcp").Reachable)
+
+		assert.Equal(t, []string{
+			"google_compute_firewall.db: libera tcp/5432 de 0.0.0.0/0",
+			"google_compute_firewall.web: libera tcp/22 de 0.0.0.0/0",
+			"google_compute_firewall.web: libera tcp/8000-8080 de 0.0.0.0/0",
+			"google_sql_database_instance.public: libera tcp/3306 de 0.0.0.0/0",
+		}, analyzer.WorldOpenRules(80, 443))
+	})
+
+	t.Run("azure", func(t *testing.T) {
+		// Regras avulsas ligadas ao NSG por referência, como em modules/load_balancing/azure
+		analyzer := newAnalyzer(t, `{
+			"planned_values": {"root_module": {"resources": [
+				{"address": "azurerm_network_security_group.main[0]", "mode": "managed", "type": "azurerm_network_security_group", "name": "main", "index": 0,
+				 "values": {"name": "parity-nsg", "security_rule": [
+					{"name": "deny-rdp", "priority": 90, "direction": "Inbound", "access": "Deny", "protocol": "*",
+					 "destination_port_range": "3389", "source_address_prefix": "Internet"}]}},
+				{"address": "azurerm_network_security_rule.http[0]", "mode": "managed", "type": "azurerm_network_security_rule", "name": "http", "index": 0,
+				 "values": {"name": "allow-http", "priority": 100, "direction": "Inbound", "access": "Allow", "protocol": "Tcp",
+				            "destination_port_range": "80", "source_address_prefixes": ["0.0.0.0/0"]}},
+				{"address": "azurerm_network_security_rule.ssh[0]", "mode": "managed", "type": "azurerm_network_security_rule", "name": "ssh", "index": 0,
+				 "values": {"name": "allow-ssh", "priority": 102, "direction": "Inbound", "access": "Allow", "protocol": "Tcp",
+				            "destination_port_ranges": ["50001", "50002-50003"], "source_address_prefixes": ["198.51.100.0/24"]}},
+				{"address": "azurerm_network_security_rule.rdp", "mode": "managed", "type": "azurerm_network_security_rule", "name": "rdp",
+				 "values": {"name": "allow-rdp", "priority": 200, "direction": "Inbound", "access": "Allow", "protocol": "*",
+				            "destination_port_range": "3389", "source_address_prefix": "*", "network_security_group_name": "parity-nsg"}}
+			]}},
+			"configuration": {"root_module": {"resources": [
+				{"address": "azurerm_network_security_rule.http", "expressions": {"network_security_group_name": {"references": ["azurerm_network_security_group.main[0].name", "azurerm_network_security_group.main[0]", "azurerm_network_security_group.main"]}}},
+				{"address": "azurerm_network_security_rule.ssh", "expressions": {"network_security_group_name": {"references": ["azurerm_network_security_group.main[0].name", "azurerm_network_security_group.main[0]", "azurerm_network_security_group.main"]}}}
+			]}}
+		}`)
+
+		nsg := "azurerm_network_security_group.main[0]"
+		result := canReach(t, analyzer, internetSource, nsg, 80, "tcp")
+		assert.True(t, result.Reachable)
+		assert.Equal(t, "azurerm_network_security_rule.http[0] (allow-http): libera tcp/80 de 0.0.0.0/0", result.Reason)
+
+		// "internet" casa com a faixa de administração, que é pública; um IP fora dela cai na regra padrão
+		assert.True(t, canReach(t, analyzer, internetSource, nsg, 50002, "tcp").Reachable)
+		assert.True(t, canReach(t, analyzer, "198.51.100.20", nsg, 50003, "tcp").Reachable)
+		result = canReach(t, analyzer, "203.0.113.50", nsg, 50002, "tcp")
+		assert.False(t, result.Reachable)
+		assert.Equal(t, "azurerm_network_security_group.main[0] (DenyAllInBound): bloqueia all/* de *", result.Reason)
+		assert.True(t, canReach(t, analyzer, "tag:VirtualNetwork", nsg, 5432, "tcp").Reachable)
+
+		// A regra de prioridade menor vence
+		assert.False(t, canReach(t, analyzer, internetSource, nsg, 3389, "tcp").Reachable)
+		assert.True(t, canReach(t, analyzer, "10.0.0.4", nsg, 3389, "tcp").Reachable)
+
+		assert.Equal(t, []string{
+			"azurerm_network_security_rule.rdp (allow-rdp): libera all/3389 de *",
+		}, analyzer.WorldOpenRules(80, 443))
+	})
+
+	t.Run("consultas inválidas", func(t *testing.T) {
+		analyzer := newAnalyzer(t, `{"planned_values": {"root_module": {}}}`)
+		_, err := analyzer.CanReach(internetSource, "aws_db_instance.main", 5432, "tcp")
+		assert.EqualError(t, err, "recurso aws_db_instance.main não encontrado no plano")
+		_, err = analyzer.CanReach("qualquer", "aws_db_instance.main", 5432, "tcp")
+		assert.EqualError(t, err, `origem inválida: "qualquer"`)
+	})
+}
+
+// TestSecurityConfigWorldOpenPorts confere as regras de security no config.yaml de cada ambiente:
+// 0.0.0.0/0 e ::/0 só podem chegar às portas 80 e 443
+func TestSecurityConfigWorldOpenPorts(t *testing.T) {
+	t.Parallel()
+
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao carregar config.yaml de %s: %v", environment, err)
+		}
+		rules, err := securityConfigRules(cfg)
+		if err != nil {
+			t.Fatalf("Erro nas regras de security de %s: %v", environment, err)
+		}
+		assert.Empty(t, worldOpenRules(rules, 80, 443), environment)
+	}
+
+	cfg, err := loadEnvironmentConfig("dev")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	rules, err := securityConfigRules(cfg)
+	if err != nil {
+		t.Fatalf("Erro nas regras de security: %v", err)
+	}
+	// Duas regras da AWS e a regra "80,443" do DigitalOcean separada por porta
+	assert.Len(t, rules, 4)
+
+	cfg.Security.AWS.SecurityGroupRules[0].Port = 22
+	cfg.Security.DigitalOcean.FirewallRules[0].Ports = "80,8000-8080"
+	rules, err = securityConfigRules(cfg)
+	if err != nil {
+		t.Fatalf("Erro nas regras de security: %v", err)
+	}
+	assert.Equal(t, []string{
+		"security.aws.security_group_rules[0]: libera tcp/22 de 0.0.0.0/0",
+		"security.digitalocean.firewall_rules[0]: libera tcp/8000-8080 de 0.0.0.0/0, ::/0",
+	}, worldOpenRules(rules, 80, 443))
+
+	cfg.Security.DigitalOcean.FirewallRules[0].Ports = "80,https"
+	_, err = securityConfigRules(cfg)
+	assert.EqualError(t, err, `security.digitalocean.firewall_rules[0]: faixa de portas inválida: "https"`)
+}
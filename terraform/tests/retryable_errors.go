@@ -0,0 +1,237 @@
+package test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// retryableError é um erro transitório de um provedor: Pattern é a expressão regular que o Terratest
+// procura na saída do Terraform para decidir se repete o comando
+type retryableError struct {
+	Pattern     string
+	Description string
+}
+
+// retryPolicy define quantas vezes e com que intervalo os comandos são repetidos para um provedor
+type retryPolicy struct {
+	MaxRetries         int
+	TimeBetweenRetries time.Duration
+	Errors             []retryableError
+}
+
+// retryableErrorCatalog complementa terraform.DefaultRetryableTerraformErrors com os erros
+// transitórios de cada provedor, pelas chaves usadas em provider.active (mais azure e local-k8s)
+var retryableErrorCatalog = map[string]retryPolicy{
+	"aws": {
+		MaxRetries:         5,
+		TimeBetweenRetries: 10 * time.Second,
+		Errors: []retryableError{
+			{`RequestLimitExceeded`, "Limite de requisições da API do EC2 excedido"},
+			{`ThrottlingException|Throttling: Rate exceeded`, "Limite de requisições da API da AWS excedido"},
+			{`DependencyViolation`, "Recurso ainda usado por outro que está sendo removido (subnets, security groups, VPC)"},
+			{`InvalidDBSubnetGroupStateFault|InvalidDBInstanceState`, "Instância ou subnet group do RDS em transição de estado"},
+		},
+	},
+	"gcp": {
+		MaxRetries:         6,
+		TimeBetweenRetries: 15 * time.Second,
+		Errors: []retryableError{
+			{`operationInProgress|another operation (was already|is) in progress`, "Outra operação em andamento no mesmo recurso (Cloud SQL, redes)"},
+			{`resourceNotReady`, "Recurso ainda não está pronto para ser usado"},
+			{`Error 429|rateLimitExceeded|RATE_LIMIT_EXCEEDED`, "Cota de requisições da API do Google excedida"},
+			{`Cluster is currently being (created|deleted|updated|repaired)`, "Cluster GKE em outra operação"},
+		},
+	},
+	"digitalocean": {
+		// O limite de requisições da DigitalOcean é por minuto
+		MaxRetries:         5,
+		TimeBetweenRetries: 30 * time.Second,
+		Errors: []retryableError{
+			{`api\.digitalocean\.com\S*: 429 `, "Limite de requisições da API da DigitalOcean excedido"},
+			{`api\.digitalocean\.com\S*: 5(00|02|03|04) `, "Erro temporário da API da DigitalOcean"},
+			{`(?i)can ?not delete VPC with members`, "VPC ainda tem recursos sendo removidos"},
+		},
+	},
+	"azure": {
+		MaxRetries:         6,
+		TimeBetweenRetries: 20 * time.Second,
+		Errors: []retryableError{
+			{`AnotherOperationInProgress`, "Outra operação em andamento no recurso ou em um dependente"},
+			{`Code="RetryableError"`, "Erro que a própria API do Azure marca como repetível"},
+			{`StatusCode=429|Code="TooManyRequests"`, "Limite de requisições do Azure Resource Manager excedido"},
+		},
+	},
+	"local-k8s": {
+		MaxRetries:         4,
+		TimeBetweenRetries: 10 * time.Second,
+		Errors: []retryableError{
+			{`dial tcp 127\.0\.0\.1:\d+: connect: connection refused`, "API server do kind ainda subindo"},
+			{`the server is currently unable to handle the request`, "API server do kind reiniciando ou sobrecarregado"},
+			{`etcdserver: (request timed out|leader changed)`, "etcd do kind sem resposta"},
+			{`Internal error occurred: failed calling webhook`, "Webhook de admissão ainda não está pronto"},
+		},
+	},
+	"local": {
+		MaxRetries:         3,
+		TimeBetweenRetries: 5 * time.Second,
+		Errors: []retryableError{
+			{`port is already allocated|address already in use`, "Porta do host ainda presa a um container removido"},
+			{`toomanyrequests: You have reached your pull rate limit`, "Limite de downloads do Docker Hub excedido"},
+			{`(?i)TLS handshake timeout|net/http: request canceled while waiting for connection`, "Falha temporária de rede ao baixar imagens"},
+		},
+	},
+}
+
+// retryProvidersFor descobre os provedores usados por um diretório do Terraform: a variável
+// active_provider, um segmento do caminho (modules/network/aws, fixtures/gcp...) ou o
+// provider.active do config.yaml em environments/<ambiente>. O contexto de um cluster kind em
+// KUBE_CTX indica local-k8s. Sem pistas, retorna todos
+func retryProvidersFor(options *terraform.Options) []string {
+	if active, ok := options.Vars["active_provider"].(string); ok {
+		if _, known := retryableErrorCatalog[active]; known {
+			return []string{active}
+		}
+	}
+
+	// Contexto de um cluster kind, exportado por kindCluster.TerraformEnvVars
+	if strings.HasPrefix(options.EnvVars["KUBE_CTX"], "kind-") {
+		return []string{"local-k8s"}
+	}
+
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(options.TerraformDir)), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		for _, segment := range []string{segments[i], strings.ReplaceAll(segments[i], "-", "")} {
+			if _, known := retryableErrorCatalog[segment]; known {
+				return []string{segment}
+			}
+		}
+		if i > 0 && segments[i-1] == "environments" {
+			if cfg, err := loadEnvironmentConfig(segments[i]); err == nil {
+				if _, known := retryableErrorCatalog[cfg.Provider.Active]; known {
+					return []string{cfg.Provider.Active}
+				}
+			}
+		}
+	}
+
+	var providers []string
+	for provider := range retryableErrorCatalog {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// withRetryableErrors aplica os erros repetíveis padrão do Terratest e os do catálogo para os
+// provedores do diretório testado, com o maior número de tentativas e intervalo entre eles
+func withRetryableErrors(t *testing.T, originalOptions *terraform.Options) *terraform.Options {
+	options := terraform.WithDefaultRetryableErrors(t, originalOptions)
+	if options.RetryableTerraformErrors == nil {
+		options.RetryableTerraformErrors = map[string]string{}
+	}
+	for _, provider := range retryProvidersFor(originalOptions) {
+		policy := retryableErrorCatalog[provider]
+		for _, retryable := range policy.Errors {
+			options.RetryableTerraformErrors[retryable.Pattern] = retryable.Description
+		}
+		if policy.MaxRetries > options.MaxRetries {
+			options.MaxRetries = policy.MaxRetries
+		}
+		if policy.TimeBetweenRetries > options.TimeBetweenRetries {
+			options.TimeBetweenRetries = policy.TimeBetweenRetries
+		}
+	}
+	return options
+}
+
+// matchRetryableError retorna o erro do catálogo que corresponde à saída, como o Terratest faria
+func matchRetryableError(provider, output string) (retryableError, bool) {
+	for _, retryable := range retryableErrorCatalog[provider].Errors {
+		if regexp.MustCompile(retryable.Pattern).MatchString(output) {
+			return retryable, true
+		}
+	}
+	return retryableError{}, false
+}
+
+// terratestTerraformPackage é o pacote do Terratest que define terraform.Options
+const terratestTerraformPackage = "github.com/gruntwork-io/terratest/modules/terraform"
+
+// unwrappedTerraformOptions analisa um arquivo Go e retorna a posição de cada literal
+// terraform.Options que não é argumento direto (com ou sem &) de withRetryableErrors. Opções
+// montadas em uma variável e passadas depois também são apontadas; comentários e strings não
+func unwrappedTerraformOptions(filename string, src []byte) ([]string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := ""
+	for _, spec := range file.Imports {
+		if path, _ := strconv.Unquote(spec.Path.Value); path == terratestTerraformPackage {
+			pkg = "terraform"
+			if spec.Name != nil {
+				pkg = spec.Name.Name
+			}
+		}
+	}
+	if pkg == "" || pkg == "_" {
+		return nil, nil
+	}
+
+	wrapped := map[*ast.CompositeLit]bool{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if name, ok := call.Fun.(*ast.Ident); !ok || name.Name != "withRetryableErrors" {
+			return true
+		}
+		for _, arg := range call.Args {
+			for {
+				if paren, ok := arg.(*ast.ParenExpr); ok {
+					arg = paren.X
+				} else if unary, ok := arg.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+					arg = unary.X
+				} else {
+					break
+				}
+			}
+			if literal, ok := arg.(*ast.CompositeLit); ok {
+				wrapped[literal] = true
+			}
+		}
+		return true
+	})
+
+	var positions []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		literal, ok := node.(*ast.CompositeLit)
+		if !ok || wrapped[literal] {
+			return true
+		}
+		selector, ok := literal.Type.(*ast.SelectorExpr)
+		if !ok || selector.Sel.Name != "Options" {
+			return true
+		}
+		if ident, ok := selector.X.(*ast.Ident); ok && ident.Name == pkg {
+			position := fset.Position(literal.Pos())
+			positions = append(positions, fmt.Sprintf("%s:%d", position.Filename, position.Line))
+		}
+		return true
+	})
+	return positions, nil
+}
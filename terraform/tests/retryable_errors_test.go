@@ -0,0 +1,236 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// Mensagens reais dos providers e do Docker, como aparecem na saída do Terraform, e o erro do
+// catálogo que deve reconhecê-las
+var retryableErrorSamples = []struct {
+	provider    string
+	description string
+	output      string
+}{
+	{"aws", "Limite de requisições da API do EC2 excedido",
+		`Error: creating EC2 Instance: RequestLimitExceeded: Request limit exceeded.
+	status code: 503, request id: 2b1f5c2e-8d3e-4b8a-9f1a-0c6a6f1d2e3f`},
+	{"aws", "Limite de requisições da API da AWS excedido",
+		`Error: reading EKS Cluster (test-eks-abc123): operation error EKS: DescribeCluster, https response error StatusCode: 400, RequestID: 8c2d..., api error ThrottlingException: Rate exceeded`},
+	{"aws", "Limite de requisições da API da AWS excedido",
+		`Error: error creating IAM Role (test-role): Throttling: Rate exceeded
+	status code: 400, request id: 5a4d3c2b-1a2b-3c4d-5e6f-7a8b9c0d1e2f`},
+	{"aws", "Recurso ainda usado por outro que está sendo removido (subnets, security groups, VPC)",
+		`Error: deleting EC2 Subnet (subnet-0a1b2c3d4e5f67890): DependencyViolation: The subnet 'subnet-0a1b2c3d4e5f67890' has dependencies and cannot be deleted.
+	status code: 400, request id: 0f9e8d7c-6b5a-4c3d-2e1f-0a9b8c7d6e5f`},
+	{"aws", "Recurso ainda usado por outro que está sendo removido (subnets, security groups, VPC)",
+		`Error: deleting Security Group (sg-0123456789abcdef0): DependencyViolation: resource sg-0123456789abcdef0 has a dependent object`},
+	{"aws", "Instância ou subnet group do RDS em transição de estado",
+		`Error: deleting RDS DB Subnet Group (test-db-subnets): InvalidDBSubnetGroupStateFault: Cannot delete the subnet group 'test-db-subnets' because at least one database instance: test-db is still using it.`},
+
+	{"gcp", "Outra operação em andamento no mesmo recurso (Cloud SQL, redes)",
+		`Error: Error, failed to create instance test-db-abc123: googleapi: Error 409: Operation failed because another operation was already in progress., operationInProgress`},
+	{"gcp", "Recurso ainda não está pronto para ser usado",
+		`Error: Error creating Subnetwork: googleapi: Error 400: The resource 'projects/test-project/global/networks/test-vpc' is not ready, resourceNotReady`},
+	{"gcp", "Cota de requisições da API do Google excedida",
+		`Error: Error reading ContainerNodePool: googleapi: Error 429: Quota exceeded for quota metric 'Read requests' and limit 'Read requests per minute' of service 'container.googleapis.com', rateLimitExceeded`},
+	{"gcp", "Cluster GKE em outra operação",
+		`Error: googleapi: Error 400: Cluster is currently being created, deleted, updated or repaired and cannot be updated., failedPrecondition`},
+
+	{"digitalocean", "Limite de requisições da API da DigitalOcean excedido",
+		`Error: Error creating droplet: POST https://api.digitalocean.com/v2/droplets: 429 (request "3f2a1b0c-9d8e-4f7a-b6c5-d4e3f2a1b0c9") Too many requests`},
+	{"digitalocean", "Erro temporário da API da DigitalOcean",
+		`Error: Error retrieving Kubernetes cluster: GET https://api.digitalocean.com/v2/kubernetes/clusters/5c6d7e8f-1a2b-3c4d-5e6f-7a8b9c0d1e2f: 503 (request "a1b2c3d4") Service Unavailable`},
+	{"digitalocean", "VPC ainda tem recursos sendo removidos",
+		`Error: Error deleting VPC: DELETE https://api.digitalocean.com/v2/vpcs/7d8e9f0a-1b2c-3d4e-5f6a-7b8c9d0e1f2a: 403 (request "b2c3d4e5") Can not delete VPC with members`},
+
+	{"azure", "Outra operação em andamento no recurso ou em um dependente",
+		`Error: creating/updating Virtual Network: (Name "test-vnet" / Resource Group "test-rg"): network.VirtualNetworksClient#CreateOrUpdate: Failure sending request: StatusCode=0 -- Original Error: Code="AnotherOperationInProgress" Message="Another operation on this or dependent resource is in progress. To retrieve status of the operation use uri: https://management.azure.com/subscriptions/.../operations/..." Details=[]`},
+	{"azure", "Erro que a própria API do Azure marca como repetível",
+		`Error: waiting for creation of Public IP: Code="RetryableError" Message="A retryable error occurred."`},
+	{"azure", "Limite de requisições do Azure Resource Manager excedido",
+		`Error: retrieving Resource Group "test-rg": resources.GroupsClient#Get: Failure responding to request: StatusCode=429 -- Original Error: autorest/azure: Service returned an error. Status=429 Code="TooManyRequests" Message="The request is being throttled."`},
+
+	{"local-k8s", "API server do kind ainda subindo",
+		`Error: Get "https://127.0.0.1:40123/api/v1/namespaces/monitoring": dial tcp 127.0.0.1:40123: connect: connection refused`},
+	{"local-k8s", "API server do kind reiniciando ou sobrecarregado",
+		`Error: configmaps "grafana-datasources" is forbidden: the server is currently unable to handle the request (get configmaps grafana-datasources)`},
+	{"local-k8s", "etcd do kind sem resposta",
+		`Error: Internal error occurred: etcdserver: request timed out`},
+	{"local-k8s", "Webhook de admissão ainda não está pronto",
+		`Error: Internal error occurred: failed calling webhook "validate.nginx.ingress.kubernetes.io": failed to call webhook: Post "https://ingress-nginx-controller-admission.ingress-nginx.svc:443/networking/v1/ingresses?timeout=10s": dial tcp 10.96.12.34:443: connect: connection refused`},
+
+	{"local", "Porta do host ainda presa a um container removido",
+		`Error: Unable to start container: Error response from daemon: driver failed programming external connectivity on endpoint test-local-app (4f5e6d7c8b9a): Bind for 0.0.0.0:32000 failed: port is already allocated`},
+	{"local", "Porta do host ainda presa a um container removido",
+		`Error: Unable to start container: Error response from daemon: driver failed programming external connectivity on endpoint test-local-db (9a8b7c6d5e4f): Error starting userland proxy: listen tcp4 0.0.0.0:32001: bind: address already in use`},
+	{"local", "Limite de downloads do Docker Hub excedido",
+		`Error: Unable to pull image postgres:14: error pulling image postgres:14: Error response from daemon: toomanyrequests: You have reached your pull rate limit. You may increase the limit by authenticating and upgrading: https://www.docker.com/increase-rate-limit`},
+	{"local", "Falha temporária de rede ao baixar imagens",
+		`Error: Unable to read Docker image into resource: unable to pull image redis:7: Error response from daemon: Get "https://registry-1.docker.io/v2/": net/http: TLS handshake timeout`},
+}
+
+// Erros permanentes que não devem ser repetidos
+var permanentErrorSamples = []struct {
+	provider string
+	output   string
+}{
+	{"aws", `Error: creating EC2 VPC: InvalidParameterValue: Value (10.0.0.0/33) for parameter cidrBlock is invalid. This is not a valid CIDR block.`},
+	{"aws", `Error: Invalid reference: A reference to a resource type must be followed by at least one attribute access`},
+	{"gcp", `Error: Error creating Network: googleapi: Error 403: Compute Engine API has not been used in project 123456 before or it is disabled., accessNotConfigured`},
+	{"digitalocean", `Error: Error creating droplet: POST https://api.digitalocean.com/v2/droplets: 422 (request "c3d4e5f6") s-1vcpu-1gb is not a valid size`},
+	{"digitalocean", `Error: Error creating droplet: POST https://api.digitalocean.com/v2/droplets: 401 (request "d4e5f6a7") Unable to authenticate you`},
+	{"azure", `Error: building account: could not acquire access token to parse claims: clientCredentialsToken: received HTTP status 401`},
+	{"local-k8s", `Error: namespaces "monitoring" already exists`},
+	{"local-k8s", `Error: Kubernetes cluster unreachable: invalid configuration: no configuration has been provided, try setting KUBERNETES_MASTER environment variable`},
+	{"local-k8s", `Error: cannot re-use a name that is still in use`},
+	{"local", `Error: Unable to create container: Error response from daemon: Conflict. The container name "/test-local-app" is already in use by container "4f5e6d7c8b9a"`},
+}
+
+// TestRetryableErrorCatalog confere que cada padrão do catálogo reconhece mensagens reais do seu
+// provedor e não reconhece erros permanentes, que devem falhar na primeira tentativa
+func TestRetryableErrorCatalog(t *testing.T) {
+	t.Parallel()
+
+	covered := map[string]bool{}
+	for _, sample := range retryableErrorSamples {
+		matched, ok := matchRetryableError(sample.provider, sample.output)
+		if assert.True(t, ok, "nenhum padrão de %s reconhece: %s", sample.provider, sample.output) {
+			assert.Equal(t, sample.description, matched.Description, sample.output)
+			covered[sample.provider+": "+matched.Description] = true
+		}
+	}
+
+	for provider, policy := range retryableErrorCatalog {
+		assert.Greater(t, policy.MaxRetries, 0, provider)
+		assert.GreaterOrEqual(t, policy.TimeBetweenRetries, time.Second, provider)
+		for _, retryable := range policy.Errors {
+			_, err := regexp.Compile(retryable.Pattern)
+			assert.NoError(t, err, "%s: %s", provider, retryable.Pattern)
+			assert.True(t, covered[provider+": "+retryable.Description], "%s: padrão %q sem mensagem de exemplo", provider, retryable.Pattern)
+		}
+	}
+
+	for _, sample := range permanentErrorSamples {
+		for provider := range retryableErrorCatalog {
+			matched, ok := matchRetryableError(provider, sample.output)
+			assert.False(t, ok, "%s (%s) reconheceu um erro permanente: %s", provider, matched.Description, sample.output)
+		}
+	}
+}
+
+// TestWithRetryableErrors confere os provedores deduzidos do diretório testado e a política aplicada
+func TestWithRetryableErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		dir       string
+		vars      map[string]interface{}
+		envVars   map[string]string
+		providers []string
+	}{
+		{"../modules/network/aws", nil, nil, []string{"aws"}},
+		{"../modules/load_balancing/digital-ocean", nil, nil, []string{"digitalocean"}},
+		{"../environments/dev/digital-ocean", nil, nil, []string{"digitalocean"}},
+		{"../examples/load_balancing/azure", nil, nil, []string{"azure"}},
+		{"/tmp/terratest123/tests/fixtures/gcp", nil, nil, []string{"gcp"}},
+		{"../environments/staging", nil, nil, []string{"aws"}},
+		{"../environments/prod", nil, nil, []string{"gcp"}},
+		{"../modules/local", nil, nil, []string{"local"}},
+		{"../", map[string]interface{}{"active_provider": "local"}, nil, []string{"local"}},
+		{"../", map[string]interface{}{"active_provider": "local-k8s"}, nil, []string{"local-k8s"}},
+		{"/tmp/TestRBACPermissionMatrix123/001", nil, map[string]string{"KUBE_CTX": "kind-rbac-abc123"}, []string{"local-k8s"}},
+		{"../", nil, nil, []string{"aws", "azure", "digitalocean", "gcp", "local", "local-k8s"}},
+	}
+	for _, tc := range testCases {
+		options := &terraform.Options{TerraformDir: tc.dir, Vars: tc.vars, EnvVars: tc.envVars}
+		assert.Equal(t, tc.providers, retryProvidersFor(options), tc.dir)
+	}
+
+	original := &terraform.Options{TerraformDir: "../modules/network/digital-ocean", NoColor: true}
+	options := withRetryableErrors(t, original)
+	policy := retryableErrorCatalog["digitalocean"]
+	assert.Equal(t, policy.MaxRetries, options.MaxRetries)
+	assert.Equal(t, policy.TimeBetweenRetries, options.TimeBetweenRetries)
+	for pattern := range terraform.DefaultRetryableTerraformErrors {
+		assert.Contains(t, options.RetryableTerraformErrors, pattern, "padrão do Terratest")
+	}
+	for _, retryable := range policy.Errors {
+		assert.Equal(t, retryable.Description, options.RetryableTerraformErrors[retryable.Pattern])
+	}
+	assert.NotContains(t, options.RetryableTerraformErrors, retryableErrorCatalog["aws"].Errors[0].Pattern)
+	assert.Nil(t, original.RetryableTerraformErrors, "as opções originais não devem ser alteradas")
+}
+
+// TestRetryableErrorCatalogCallSites falha quando algum teste monta terraform.Options sem passar
+// por withRetryableErrors, o que deixaria os comandos sem o catálogo de erros repetíveis
+func TestRetryableErrorCatalogCallSites(t *testing.T) {
+	t.Parallel()
+
+	sources, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Erro ao listar os arquivos do pacote: %v", err)
+	}
+	for _, source := range sources {
+		// Os testes do próprio catálogo comparam as opções antes e depois de withRetryableErrors
+		if source == "retryable_errors_test.go" {
+			continue
+		}
+		content, err := os.ReadFile(source)
+		if err != nil {
+			t.Fatalf("Erro ao ler %s: %v", source, err)
+		}
+		positions, err := unwrappedTerraformOptions(source, content)
+		if err != nil {
+			t.Fatalf("Erro ao analisar %s: %v", source, err)
+		}
+		for _, position := range positions {
+			assert.Fail(t, "terraform.Options sem withRetryableErrors", position)
+		}
+	}
+}
+
+// TestUnwrappedTerraformOptions confere a análise usada por TestRetryableErrorCatalogCallSites
+func TestUnwrappedTerraformOptions(t *testing.T) {
+	t.Parallel()
+
+	src := `package test
+
+import (
+	tf "github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// Comentário com &tf.Options{} não conta
+const exemplo = "&tf.Options{}"
+
+func exemplos(t *testing.T) {
+	direto := withRetryableErrors(t, &tf.Options{TerraformDir: "."})
+	multilinha := withRetryableErrors(t,
+		&tf.Options{
+			TerraformDir: ".",
+		},
+	)
+	depois := &tf.Options{TerraformDir: "."}
+	withRetryableErrors(t, depois)
+	valor := tf.Options{
+		TerraformDir: ".",
+	}
+	outro := helper(&tf.Options{})
+}
+`
+	positions, err := unwrappedTerraformOptions("exemplo.go", []byte(src))
+	if err != nil {
+		t.Fatalf("Erro ao analisar o exemplo: %v", err)
+	}
+	assert.Equal(t, []string{"exemplo.go:17", "exemplo.go:19", "exemplo.go:22"}, positions)
+
+	positions, err = unwrappedTerraformOptions("outro.go", []byte("package test\n\nvar x = terraform.Options{}\n"))
+	assert.NoError(t, err)
+	assert.Empty(t, positions, "sem o import do Terratest, terraform.Options é outro tipo")
+}
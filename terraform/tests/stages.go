@@ -0,0 +1,82 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+)
+
+// Etapas dos testes que criam infraestrutura real. Cada etapa é pulada quando SKIP_<etapa> está
+// definida (por exemplo SKIP_deploy=true), e as etapas seguintes reaproveitam as opções e saídas
+// gravadas em <diretório do Terraform>/.test-data pelas execuções anteriores
+const (
+	stageSetup    = "setup"
+	stageDeploy   = "deploy"
+	stageValidate = "validate"
+	stageTeardown = "teardown"
+)
+
+// Nome do arquivo em .test-data com as saídas do Terraform gravadas pela etapa deploy
+const stageOutputsName = "TerraformOutputs"
+
+// stageOutputs são as saídas de `terraform output -json` gravadas pela etapa deploy
+type stageOutputs map[string]interface{}
+
+// String retorna a saída como texto, ou vazio se ela não existir
+func (o stageOutputs) String(key string) string {
+	value, ok := o[key]
+	if !ok || value == nil {
+		return ""
+	}
+	if text, ok := value.(string); ok {
+		return text
+	}
+	return fmt.Sprint(value)
+}
+
+// Map retorna uma saída do tipo map com os valores convertidos para texto
+func (o stageOutputs) Map(key string) map[string]string {
+	values, _ := o[key].(map[string]interface{})
+	result := make(map[string]string, len(values))
+	for name, value := range values {
+		result[name] = fmt.Sprint(value)
+	}
+	return result
+}
+
+// loadStageTerraformOptions lê as opções gravadas pela etapa setup, com o catálogo de erros
+// repetíveis reaplicado, e falha com uma mensagem explicando como gerá-las quando ainda não existem
+func loadStageTerraformOptions(t *testing.T, workingDir string) *terraform.Options {
+	if !test_structure.IsTestDataPresent(t, test_structure.FormatTestDataPath(workingDir, "TerraformOptions.json")) {
+		t.Fatalf("Nenhuma opção do Terraform gravada em %s; rode o teste uma vez sem SKIP_%s", workingDir, stageSetup)
+	}
+	return withRetryableErrors(t, test_structure.LoadTerraformOptions(t, workingDir))
+}
+
+// saveStageOutputs grava todas as saídas do Terraform para a etapa validate
+func saveStageOutputs(t *testing.T, workingDir string, terraformOptions *terraform.Options) {
+	storeStageOutputs(t, workingDir, terraform.OutputAll(t, terraformOptions))
+}
+
+func storeStageOutputs(t *testing.T, workingDir string, outputs map[string]interface{}) {
+	content, err := json.Marshal(outputs)
+	if err != nil {
+		t.Fatalf("Erro ao serializar as saídas do Terraform: %v", err)
+	}
+	test_structure.SaveString(t, workingDir, stageOutputsName, string(content))
+}
+
+// loadStageOutputs lê as saídas gravadas pela etapa deploy
+func loadStageOutputs(t *testing.T, workingDir string) stageOutputs {
+	if !test_structure.IsTestDataPresent(t, test_structure.FormatTestDataPath(workingDir, stageOutputsName+".json")) {
+		t.Fatalf("Nenhuma saída do Terraform gravada em %s; rode o teste uma vez sem SKIP_%s", workingDir, stageDeploy)
+	}
+	var outputs stageOutputs
+	if err := json.Unmarshal([]byte(test_structure.LoadString(t, workingDir, stageOutputsName)), &outputs); err != nil {
+		t.Fatalf("Erro ao ler as saídas do Terraform gravadas em %s: %v", workingDir, err)
+	}
+	return outputs
+}
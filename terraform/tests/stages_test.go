@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStageData confere que opções e saídas gravadas por uma execução são lidas pelas etapas
+// seguintes, como acontece ao rodar com SKIP_setup e SKIP_deploy
+func TestStageData(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	test_structure.SaveTerraformOptions(t, workingDir, withRetryableErrors(t, &terraform.Options{
+		TerraformDir: workingDir,
+		Vars:         map[string]interface{}{"project_name": "test-nestjs-abc123", "active_provider": "aws"},
+	}))
+	storeStageOutputs(t, workingDir, map[string]interface{}{
+		"vpc_id":         "vpc-0123456789",
+		"node_count":     float64(3),
+		"cluster_status": map[string]interface{}{"status": "running", "nodes": float64(3)},
+		"optional":       nil,
+	})
+
+	terraformOptions := loadStageTerraformOptions(t, workingDir)
+	assert.Equal(t, "test-nestjs-abc123", terraformOptions.Vars["project_name"])
+	assert.Equal(t, retryableErrorCatalog["aws"].MaxRetries, terraformOptions.MaxRetries, "opções lidas mantêm o catálogo de erros repetíveis")
+	assert.Contains(t, terraformOptions.RetryableTerraformErrors, retryableErrorCatalog["aws"].Errors[0].Pattern)
+
+	outputs := loadStageOutputs(t, workingDir)
+	assert.Equal(t, "vpc-0123456789", outputs.String("vpc_id"))
+	assert.Equal(t, "3", outputs.String("node_count"))
+	assert.Equal(t, "", outputs.String("optional"))
+	assert.Equal(t, "", outputs.String("inexistente"))
+	assert.Equal(t, map[string]string{"status": "running", "nodes": "3"}, outputs.Map("cluster_status"))
+	assert.Empty(t, outputs.Map("vpc_id"))
+
+	test_structure.CleanupTestDataFolder(t, workingDir)
+	assert.False(t, test_structure.IsTestDataPresent(t, test_structure.FormatTestDataPath(workingDir, stageOutputsName+".json")))
+}
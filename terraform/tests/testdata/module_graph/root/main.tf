@@ -0,0 +1,64 @@
+locals {
+  active_provider = "aws"
+  vpc_id          = local.active_provider == "aws" ? module.network_aws[0].vpc_id : ""
+}
+
+module "network_aws" {
+  source = "./modules/network/aws"
+  count  = local.active_provider == "aws" ? 1 : 0
+}
+
+module "network_gcp" {
+  source = "./modules/network/gcp"
+  count  = local.active_provider == "gcp" ? 1 : 0
+}
+
+# Guardado pela mesma condição do count de network_aws
+module "database_aws" {
+  source = "./modules/database/aws"
+  count  = local.active_provider == "aws" ? 1 : 0
+
+  subnet_ids = module.network_aws[0].private_subnet_ids
+  vpc_id     = local.vpc_id
+
+  # Redundante: local.vpc_id já depende de module.network_aws
+  depends_on = [module.network_aws]
+}
+
+module "kubernetes_aws" {
+  source = "./modules/kubernetes/aws"
+
+  subnet_ids = length(module.network_aws) > 0 ? module.network_aws[0].private_subnet_ids : []
+  # Sem guarda: falha quando active_provider não é aws
+  vpc_id = module.network_aws[0].vpc_id
+
+  depends_on = [module.database_aws]
+}
+
+module "monitoring_aws" {
+  source = "./modules/monitoring/aws"
+
+  cluster_name = module.kubernetes_aws.cluster_name
+
+  # Redundante: kubernetes_aws já depende de database_aws
+  depends_on = [module.kubernetes_aws, module.database_aws]
+}
+
+# Ciclo: o módulo recebe o id do recurso, que espera pelo módulo
+resource "null_resource" "bootstrap" {
+  triggers = {
+    endpoint = try(module.network_gcp[0].endpoint, "")
+  }
+
+  depends_on = [module.dns]
+}
+
+module "dns" {
+  source = "./modules/dns"
+
+  bootstrap_id = null_resource.bootstrap.id
+}
+
+output "database_endpoint" {
+  value = module.database_aws[0].endpoint
+}
@@ -0,0 +1,22 @@
+# terraform {
+#   required_providers {
+#     kubernetes = {
+#       source  = "hashicorp/kubernetes"
+#       version = "~> 2.36"
+#     }
+#   }
+# }
+
+terraform {
+  required_providers {
+    google = "~> 4.0"
+    # Endereço diferente do usado na raiz para o mesmo nome local
+    random = {
+      source = "example/random"
+    }
+  }
+}
+
+resource "random_id" "suffix" {
+  byte_length = 4
+}
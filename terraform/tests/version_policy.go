@@ -0,0 +1,183 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// versionCalendarPath é o calendário de suporte mantido no repositório, relativo ao diretório tests/
+const versionCalendarPath = "version_calendar.yaml"
+
+// Situações de uma versão em relação ao calendário
+const (
+	versionSupported   = "suportada"
+	versionEndingSoon  = "perto do fim do suporte"
+	versionExpired     = "fora do suporte"
+	versionUnavailable = "indisponível"
+)
+
+// versionSupport é a janela de suporte de uma versão em um provedor
+type versionSupport struct {
+	AvailableFrom time.Time `yaml:"available_from"`
+	EndOfSupport  time.Time `yaml:"end_of_support"`
+}
+
+// providerVersionCalendar são as versões de um componente oferecidas por um provedor
+type providerVersionCalendar struct {
+	Service  string                    `yaml:"service"`
+	Source   string                    `yaml:"source"`
+	Versions map[string]versionSupport `yaml:"versions"`
+}
+
+// versionCalendar espelha version_calendar.yaml: componente (kubernetes ou o engine do banco) →
+// provedor (provider.active) → versões
+type versionCalendar struct {
+	WarningDays int `yaml:"warning_days"`
+	// ReviewedAt é a data da última revisão, usada como data de avaliação padrão
+	ReviewedAt time.Time                                     `yaml:"reviewed_at"`
+	Components map[string]map[string]providerVersionCalendar `yaml:"components"`
+}
+
+// versionCheck é o resultado da política para uma versão configurada
+type versionCheck struct {
+	Component string
+	Provider  string
+	Version   string
+	Status    string
+	// DaysLeft é o número de dias até o fim do suporte, negativo depois dele
+	DaysLeft int
+	Detail   string
+}
+
+// Failed indica se a versão reprova a política (fora do suporte ou indisponível no provedor)
+func (c versionCheck) Failed() bool {
+	return c.Status == versionExpired || c.Status == versionUnavailable
+}
+
+func (c versionCheck) String() string {
+	return fmt.Sprintf("%s %s em %s: %s (%s)", c.Component, c.Version, c.Provider, c.Status, c.Detail)
+}
+
+// loadVersionCalendar lê e valida o calendário de suporte
+func loadVersionCalendar(path string) (*versionCalendar, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var calendar versionCalendar
+	if err := yaml.Unmarshal(content, &calendar); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar %s: %v", path, err)
+	}
+	if calendar.WarningDays <= 0 {
+		return nil, fmt.Errorf("%s: warning_days deve ser positivo", path)
+	}
+	if calendar.ReviewedAt.IsZero() {
+		return nil, fmt.Errorf("%s: reviewed_at é obrigatório", path)
+	}
+	for component, providers := range calendar.Components {
+		for provider, entry := range providers {
+			for version, support := range entry.Versions {
+				if support.EndOfSupport.IsZero() {
+					return nil, fmt.Errorf("%s: %s %s em %s sem end_of_support", path, component, version, provider)
+				}
+				if !support.AvailableFrom.IsZero() && !support.AvailableFrom.Before(support.EndOfSupport) {
+					return nil, fmt.Errorf("%s: %s %s em %s termina antes de ser oferecida", path, component, version, provider)
+				}
+			}
+		}
+	}
+	return &calendar, nil
+}
+
+// versionKey reduz a versão configurada à chave do calendário: minor do Kubernetes
+// (1.26.3-do.0 → 1.26) e major dos bancos (14.7 → 14)
+func versionKey(component, version string) string {
+	parsed, _, err := parseProviderVersion(version)
+	if err != nil {
+		return version
+	}
+	if component == "kubernetes" {
+		return fmt.Sprintf("%d.%d", parsed[0], parsed[1])
+	}
+	return strconv.Itoa(parsed[0])
+}
+
+// Check classifica a versão do componente no provedor na data informada
+func (c *versionCalendar) Check(component, provider, version string, today time.Time) versionCheck {
+	check := versionCheck{Component: component, Provider: provider, Version: version}
+	entry, ok := c.Components[component][provider]
+	if !ok {
+		check.Status = versionUnavailable
+		check.Detail = "provedor sem calendário de suporte para o componente"
+		return check
+	}
+	support, ok := entry.Versions[versionKey(component, version)]
+	if !ok {
+		check.Status = versionUnavailable
+		check.Detail = fmt.Sprintf("versão não oferecida pelo %s (disponíveis: %s)", entry.Service, strings.Join(entry.Available(today), ", "))
+		return check
+	}
+
+	day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	check.DaysLeft = int(support.EndOfSupport.Sub(day).Hours() / 24)
+	switch {
+	case !support.AvailableFrom.IsZero() && day.Before(support.AvailableFrom):
+		check.Status = versionUnavailable
+		check.Detail = fmt.Sprintf("oferecida pelo %s a partir de %s", entry.Service, support.AvailableFrom.Format("2006-01-02"))
+	case check.DaysLeft < 0:
+		check.Status = versionExpired
+		check.Detail = fmt.Sprintf("suporte do %s terminou em %s", entry.Service, support.EndOfSupport.Format("2006-01-02"))
+	case check.DaysLeft <= c.WarningDays:
+		check.Status = versionEndingSoon
+		check.Detail = fmt.Sprintf("suporte do %s termina em %s, daqui a %d dias", entry.Service, support.EndOfSupport.Format("2006-01-02"), check.DaysLeft)
+	default:
+		check.Status = versionSupported
+		check.Detail = fmt.Sprintf("suporte do %s até %s", entry.Service, support.EndOfSupport.Format("2006-01-02"))
+	}
+	return check
+}
+
+// versionPolicyFindings separa os resultados em falhas e avisos. Versões perto do fim do suporte são
+// sempre avisos; fora do suporte ou indisponíveis só reprovam com strict, e sem ele viram avisos
+func versionPolicyFindings(checks []versionCheck, strict bool) (failures, warnings []string) {
+	for _, check := range checks {
+		switch {
+		case check.Failed() && strict:
+			failures = append(failures, check.String())
+		case check.Failed() || check.Status == versionEndingSoon:
+			warnings = append(warnings, check.String())
+		}
+	}
+	return failures, warnings
+}
+
+// CheckEnvironment aplica a política a kubernetes.version e database.engine_version do ambiente,
+// no provedor de provider.active
+func (c *versionCalendar) CheckEnvironment(cfg *environmentConfig, today time.Time) []versionCheck {
+	return []versionCheck{
+		c.Check("kubernetes", cfg.Provider.Active, cfg.Kubernetes.Version, today),
+		c.Check(cfg.Database.Engine, cfg.Provider.Active, cfg.Database.EngineVersion, today),
+	}
+}
+
+// Available lista, em ordem, as versões oferecidas e ainda suportadas na data informada
+func (p providerVersionCalendar) Available(today time.Time) []string {
+	var versions []string
+	for version, support := range p.Versions {
+		if (support.AvailableFrom.IsZero() || !today.Before(support.AvailableFrom)) && today.Before(support.EndOfSupport) {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		a, _, _ := parseProviderVersion(versions[i])
+		b, _, _ := parseProviderVersion(versions[j])
+		return a.Compare(b) < 0
+	})
+	return versions
+}
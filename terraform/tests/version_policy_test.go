@@ -0,0 +1,165 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTestVersionCalendar(t *testing.T) *versionCalendar {
+	calendar, err := loadVersionCalendar(versionCalendarPath)
+	if err != nil {
+		t.Fatalf("Erro ao carregar o calendário de suporte: %v", err)
+	}
+	return calendar
+}
+
+func calendarDate(t *testing.T, value string) time.Time {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatalf("Data inválida %q: %v", value, err)
+	}
+	return date
+}
+
+// TestVersionPolicy confere kubernetes.version e database.engine_version de cada ambiente contra o
+// calendário de suporte do provider.active, na data reviewed_at do calendário ou em
+// VERSION_POLICY_DATE (AAAA-MM-DD). Versões perto do fim do suporte geram avisos. Versões fora do
+// suporte ou não oferecidas pelo provedor também são avisos, e só reprovam o teste com
+// VERSION_POLICY_STRICT=1
+func TestVersionPolicy(t *testing.T) {
+	t.Parallel()
+
+	calendar := loadTestVersionCalendar(t)
+	date := calendar.ReviewedAt
+	if value := os.Getenv("VERSION_POLICY_DATE"); value != "" {
+		date = calendarDate(t, value)
+	}
+	strict := os.Getenv("VERSION_POLICY_STRICT") == "1"
+	t.Logf("Avaliando em %s (estrito: %v)", date.Format("2006-01-02"), strict)
+
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao carregar config.yaml de %s: %v", environment, err)
+		}
+		failures, warnings := versionPolicyFindings(calendar.CheckEnvironment(cfg, date), strict)
+		for _, warning := range warnings {
+			t.Logf("AVISO %s: %s", environment, warning)
+		}
+		for _, failure := range failures {
+			assert.Fail(t, "Versão fora da política", "%s: %s", environment, failure)
+		}
+	}
+}
+
+// TestVersionPolicyCheck cobre a classificação com datas fixas do calendário
+func TestVersionPolicyCheck(t *testing.T) {
+	t.Parallel()
+
+	calendar := loadTestVersionCalendar(t)
+	assert.Equal(t, 90, calendar.WarningDays)
+
+	// Cada provedor usado pelos ambientes tem calendário para o Kubernetes e para o banco
+	for _, environment := range environments {
+		cfg, err := loadEnvironmentConfig(environment)
+		if err != nil {
+			t.Fatalf("Erro ao carregar config.yaml de %s: %v", environment, err)
+		}
+		assert.Contains(t, calendar.Components["kubernetes"], cfg.Provider.Active, environment)
+		assert.Contains(t, calendar.Components[cfg.Database.Engine], cfg.Provider.Active, environment)
+	}
+
+	cases := []struct {
+		component, provider, version, date string
+		status                             string
+		daysLeft                           int
+		detail                             string
+	}{
+		{"kubernetes", "aws", "1.26", "2024-03-12", versionSupported, 91, "suporte do Amazon EKS até 2024-06-11"},
+		{"kubernetes", "aws", "1.26", "2024-03-13", versionEndingSoon, 90, "suporte do Amazon EKS termina em 2024-06-11, daqui a 90 dias"},
+		{"kubernetes", "aws", "1.26.12", "2024-06-11", versionEndingSoon, 0, "suporte do Amazon EKS termina em 2024-06-11, daqui a 0 dias"},
+		{"kubernetes", "aws", "v1.26", "2024-06-12", versionExpired, -1, "suporte do Amazon EKS terminou em 2024-06-11"},
+		{"kubernetes", "digitalocean", "1.26", "2024-01-01", versionUnavailable, 0, "versão não oferecida pelo DigitalOcean Kubernetes (disponíveis: 1.27, 1.28)"},
+		{"kubernetes", "digitalocean", "1.28.2-do.0", "2024-01-01", versionSupported, 301, "suporte do DigitalOcean Kubernetes até 2024-10-28"},
+		{"kubernetes", "gcp", "1.35", "2026-01-01", versionUnavailable, 455, "oferecida pelo Google Kubernetes Engine a partir de 2026-02-10"},
+		{"postgres", "gcp", "14.7", "2026-10-19", versionEndingSoon, 24, "suporte do Cloud SQL for PostgreSQL termina em 2026-11-12, daqui a 24 dias"},
+		{"postgres", "aws", "14", "2026-10-19", versionSupported, 132, "suporte do Amazon RDS for PostgreSQL até 2027-02-28"},
+		{"postgres", "digitalocean", "12", "2024-01-01", versionUnavailable, 0, "versão não oferecida pelo DigitalOcean Managed PostgreSQL (disponíveis: 13, 14, 15, 16, 17)"},
+		{"mysql", "aws", "8.0", "2024-01-01", versionUnavailable, 0, "provedor sem calendário de suporte para o componente"},
+	}
+	for _, c := range cases {
+		check := calendar.Check(c.component, c.provider, c.version, calendarDate(t, c.date))
+		assert.Equal(t, c.status, check.Status, "%s %s em %s", c.component, c.version, c.provider)
+		assert.Equal(t, c.daysLeft, check.DaysLeft, "%s %s em %s", c.component, c.version, c.provider)
+		assert.Equal(t, c.detail, check.Detail, "%s %s em %s", c.component, c.version, c.provider)
+		assert.Equal(t, c.status == versionExpired || c.status == versionUnavailable, check.Failed())
+	}
+
+	cfg, err := loadEnvironmentConfig("staging")
+	if err != nil {
+		t.Fatalf("Erro ao carregar config.yaml: %v", err)
+	}
+	cfg.Kubernetes.Version = "1.26"
+	checks := calendar.CheckEnvironment(cfg, calendarDate(t, "2025-01-01"))
+	if assert.Len(t, checks, 2) {
+		assert.Equal(t, "kubernetes 1.26 em aws: fora do suporte (suporte do Amazon EKS terminou em 2024-06-11)", checks[0].String())
+		assert.Equal(t, "postgres", checks[1].Component)
+		assert.Equal(t, versionSupported, checks[1].Status)
+	}
+	// Perto do fim do suporte é sempre aviso; fora do suporte só reprova no modo estrito
+	checks = []versionCheck{
+		calendar.Check("kubernetes", "aws", "1.26", calendarDate(t, "2025-01-01")),
+		calendar.Check("postgres", "gcp", "14", calendarDate(t, "2026-10-19")),
+		calendar.Check("postgres", "aws", "14", calendarDate(t, "2026-10-19")),
+	}
+	failures, warnings := versionPolicyFindings(checks, false)
+	assert.Empty(t, failures)
+	assert.Equal(t, []string{checks[0].String(), checks[1].String()}, warnings)
+
+	failures, warnings = versionPolicyFindings(checks, true)
+	assert.Equal(t, []string{checks[0].String()}, failures)
+	assert.Equal(t, []string{checks[1].String()}, warnings)
+}
+
+// TestVersionCalendarValidation confere a validação do arquivo do calendário
+func TestVersionCalendarValidation(t *testing.T) {
+	t.Parallel()
+
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "calendar.yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Erro ao gravar o calendário: %v", err)
+		}
+		return path
+	}
+
+	_, err := loadVersionCalendar(write("components: {}\n"))
+	assert.ErrorContains(t, err, "warning_days deve ser positivo")
+
+	_, err = loadVersionCalendar(write("warning_days: 30\ncomponents: {}\n"))
+	assert.ErrorContains(t, err, "reviewed_at é obrigatório")
+
+	_, err = loadVersionCalendar(write(`warning_days: 30
+reviewed_at: 2024-06-01
+components:
+  kubernetes:
+    aws:
+      versions:
+        "1.30": { available_from: 2024-05-23 }
+`))
+	assert.ErrorContains(t, err, "kubernetes 1.30 em aws sem end_of_support")
+
+	_, err = loadVersionCalendar(write(`warning_days: 30
+reviewed_at: 2024-06-01
+components:
+  kubernetes:
+    aws:
+      versions:
+        "1.30": { available_from: 2025-07-23, end_of_support: 2024-05-23 }
+`))
+	assert.ErrorContains(t, err, "kubernetes 1.30 em aws termina antes de ser oferecida")
+}
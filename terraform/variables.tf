@@ -24,4 +24,58 @@ variable "tags" {
   description = "Tags adicionais para os recursos"
   type        = map(string)
   default     = {}
-}
\ No newline at end of file
+}
+
+variable "active_provider" {
+  description = "Sobrepõe provider.active do config.yaml (aws, gcp, digitalocean, local ou local-k8s)"
+  type        = string
+  default     = null
+
+  validation {
+    condition     = var.active_provider == null || contains(["aws", "gcp", "digitalocean", "local", "local-k8s"], coalesce(var.active_provider, "aws"))
+    error_message = "O valor da variável active_provider deve ser aws, gcp, digitalocean, local ou local-k8s."
+  }
+}
+
+variable "kubeconfig_path" {
+  description = "Kubeconfig do cluster usado quando active_provider é local-k8s (cluster kind criado pelos testes)"
+  type        = string
+  default     = null
+}
+
+variable "kube_context" {
+  description = "Contexto do kubeconfig usado quando active_provider é local-k8s"
+  type        = string
+  default     = null
+}
+
+variable "grafana_chart_path" {
+  description = "Chart do Grafana já baixado (helm pull grafana/grafana --untar), para aplicar local-k8s sem acesso ao repositório"
+  type        = string
+  default     = null
+}
+
+variable "rbac_groups" {
+  description = "Usuários e grupos de cada papel RBAC (admin, develop e readonly) aplicados quando active_provider é local-k8s"
+  type = map(object({
+    users  = list(string)
+    groups = list(string)
+  }))
+  default = {
+    admin    = { users = [], groups = [] }
+    develop  = { users = [], groups = [] }
+    readonly = { users = [], groups = [] }
+  }
+}
+
+variable "rbac_namespaces" {
+  description = "Namespaces com Roles e RoleBindings próprios quando active_provider é local-k8s"
+  type        = list(string)
+  default     = []
+}
+
+variable "rbac_restrict_to_namespaces" {
+  description = "Restringe develop e readonly aos rbac_namespaces quando active_provider é local-k8s"
+  type        = bool
+  default     = false
+}